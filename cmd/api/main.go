@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"log"
 	"os"
@@ -12,6 +11,8 @@ import (
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/network/standard"
 	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/config"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
 	"github.com/vergabe-agent/vergabe-backend/internal/handler"
 	"github.com/vergabe-agent/vergabe-backend/internal/middleware"
 	"github.com/vergabe-agent/vergabe-backend/internal/service"
@@ -21,7 +22,7 @@ import (
 )
 
 func main() {
-	if err := loadEnvFiles(".env", "cmd/api/.env"); err != nil {
+	if err := config.LoadEnvFiles(".env", "cmd/api/.env"); err != nil {
 		log.Fatalf("Failed to load .env file: %v", err)
 	}
 
@@ -37,6 +38,20 @@ func main() {
 
 	openRouterEmbeddingModel := strings.TrimSpace(os.Getenv("OPENROUTER_EMBEDDING_MODEL"))
 
+	// Prompt-Registry: Umgebung für prompt_assignments, optional Prompt-Dateien
+	appEnv := strings.TrimSpace(os.Getenv("APP_ENV"))
+	promptDir := strings.TrimSpace(os.Getenv("PROMPT_DIR"))
+
+	// Einladungen: SMTP (optional) und Frontend-Adresse für den Einladungslink
+	mailer := service.NewMailer(service.MailerConfig{
+		Host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		Port:     strings.TrimSpace(os.Getenv("SMTP_PORT")),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	})
+	appURL := strings.TrimSpace(os.Getenv("APP_URL"))
+
 	// Supabase Storage Config
 	supabaseURL := strings.TrimSpace(os.Getenv("SUPABASE_URL"))
 	supabaseServiceKey := strings.TrimSpace(os.Getenv("SUPABASE_SERVICE_KEY"))
@@ -80,18 +95,47 @@ func main() {
 	sqlDB.SetConnMaxLifetime(time.Hour)
 	defer sqlDB.Close()
 
+	// NUTS-Schwerpunkte für die Geo-Distanz von Ausschreibungen ohne Koordinaten (Migration 023)
+	if err := service.SyncNUTSCentroids(context.Background(), db); err != nil {
+		log.Printf("⚠️ NUTS centroids not synced: %v", err)
+	}
+
 	// 3. Services
-	ingestionSvc, err := service.NewIngestionService(db, novitaAPIKey, embeddingCfg)
+	// Verbrauch (OCR, Embeddings, Chat) je Firma und Kontingente je Tarif
+	usageSvc := service.NewUsageService(db)
+	embeddingCfg.Usage = usageSvc
+
+	ingestionSvc, err := service.NewIngestionService(db, novitaAPIKey, embeddingCfg, usageSvc)
 	if err != nil {
 		log.Fatalf("Ingestion Service Init failed: %v", err)
 	}
 	matchingSvc := service.NewMatchingService(db)
+	feedbackSvc := service.NewFeedbackService(db)
+
+	// Storage Service (optional - still works without it)
+	var storageSvc *service.SupabaseStorageService
+	if supabaseURL != "" && supabaseServiceKey != "" {
+		storageSvc = service.NewSupabaseStorageService(supabaseURL, supabaseServiceKey)
+		log.Println("✅ Supabase Storage enabled")
+	} else {
+		log.Println("⚠️ Supabase Storage disabled (missing SUPABASE_URL or SUPABASE_SERVICE_KEY)")
+	}
 
-	companySvc, err := service.NewCompanyService(db, embeddingCfg)
+	companySvc, err := service.NewCompanyService(db, embeddingCfg, storageSvc)
 	if err != nil {
 		log.Fatalf("Company Service Init failed: %v", err)
 	}
 
+	referenceSvc, err := service.NewReferenceService(db, embeddingCfg, usageSvc)
+	if err != nil {
+		log.Fatalf("Reference Service Init failed: %v", err)
+	}
+
+	promptRegistry := service.NewPromptRegistry(db, service.PromptRegistryConfig{
+		Environment: appEnv,
+		Dir:         promptDir,
+	})
+
 	complianceAgent, err := agent.NewComplianceAgent(context.Background(), agent.ComplianceAgentConfig{
 		APIKey:      openRouterKey,
 		Model:       openRouterModel,
@@ -99,39 +143,153 @@ func main() {
 		AppName:     openRouterAppName,
 		AppURL:      openRouterAppURL,
 		Temperature: 0.2,
+		Prompts:     promptRegistry,
+		Usage:       usageSvc,
 	})
 	if err != nil {
 		log.Fatalf("Compliance Agent Init failed: %v", err)
 	}
 
-	complianceSvc := service.NewComplianceService(complianceAgent, db)
+	complianceSvc := service.NewComplianceService(complianceAgent, db, usageSvc)
+
+	checklistAgent, err := agent.NewChecklistAgent(context.Background(), agent.ComplianceAgentConfig{
+		APIKey:      openRouterKey,
+		Model:       openRouterModel,
+		BaseURL:     openRouterBaseURL,
+		AppName:     openRouterAppName,
+		AppURL:      openRouterAppURL,
+		Temperature: 0.2,
+		Prompts:     promptRegistry,
+		Usage:       usageSvc,
+	})
+	if err != nil {
+		log.Fatalf("Checklist Agent Init failed: %v", err)
+	}
+	checklistSvc := service.NewChecklistService(checklistAgent, db, usageSvc)
+
+	qaAgent, err := agent.NewTenderQAAgent(context.Background(), agent.ComplianceAgentConfig{
+		APIKey:      openRouterKey,
+		Model:       openRouterModel,
+		BaseURL:     openRouterBaseURL,
+		AppName:     openRouterAppName,
+		AppURL:      openRouterAppURL,
+		Temperature: 0,
+		Prompts:     promptRegistry,
+		Usage:       usageSvc,
+	})
+	if err != nil {
+		log.Fatalf("Tender QA Agent Init failed: %v", err)
+	}
+	qaSvc := service.NewTenderQAService(qaAgent, db, usageSvc)
+
+	bidderQuestionAgent, err := agent.NewBidderQuestionAgent(context.Background(), agent.ComplianceAgentConfig{
+		APIKey:      openRouterKey,
+		Model:       openRouterModel,
+		BaseURL:     openRouterBaseURL,
+		AppName:     openRouterAppName,
+		AppURL:      openRouterAppURL,
+		Temperature: 0.2,
+		Prompts:     promptRegistry,
+		Usage:       usageSvc,
+	})
+	if err != nil {
+		log.Fatalf("Bidder Question Agent Init failed: %v", err)
+	}
+	bidderQuestionSvc := service.NewBidderQuestionService(bidderQuestionAgent, db, usageSvc)
+
+	// Fließtext fürs Angebot: etwas mehr Spielraum bei der Formulierung
+	bidDraftAgent, err := agent.NewBidDraftAgent(context.Background(), agent.ComplianceAgentConfig{
+		APIKey:      openRouterKey,
+		Model:       openRouterModel,
+		BaseURL:     openRouterBaseURL,
+		AppName:     openRouterAppName,
+		AppURL:      openRouterAppURL,
+		Temperature: 0.4,
+		Prompts:     promptRegistry,
+		Usage:       usageSvc,
+	})
+	if err != nil {
+		log.Fatalf("Bid Draft Agent Init failed: %v", err)
+	}
+	bidDraftSvc := service.NewBidDraftService(bidDraftAgent, db, usageSvc)
+
+	// Lebensläufe: Extraktion streng deterministisch, Teamvorschlag mit etwas Spielraum
+	cvAgent, err := agent.NewCVExtractionAgent(context.Background(), agent.ComplianceAgentConfig{
+		APIKey:      openRouterKey,
+		Model:       openRouterModel,
+		BaseURL:     openRouterBaseURL,
+		AppName:     openRouterAppName,
+		AppURL:      openRouterAppURL,
+		Temperature: 0,
+		Prompts:     promptRegistry,
+		Usage:       usageSvc,
+	})
+	if err != nil {
+		log.Fatalf("CV Extraction Agent Init failed: %v", err)
+	}
+	staffingAgent, err := agent.NewStaffingAgent(context.Background(), agent.ComplianceAgentConfig{
+		APIKey:      openRouterKey,
+		Model:       openRouterModel,
+		BaseURL:     openRouterBaseURL,
+		AppName:     openRouterAppName,
+		AppURL:      openRouterAppURL,
+		Temperature: 0.2,
+		Prompts:     promptRegistry,
+		Usage:       usageSvc,
+	})
+	if err != nil {
+		log.Fatalf("Staffing Agent Init failed: %v", err)
+	}
 
 	// Handler registrieren
 	complianceHandler := handler.NewComplianceHandler(complianceSvc)
+	checklistHandler := handler.NewChecklistHandler(checklistSvc)
+	qaHandler := handler.NewTenderQAHandler(qaSvc)
+	bidderQuestionHandler := handler.NewBidderQuestionHandler(bidderQuestionSvc)
+	bidDraftHandler := handler.NewBidDraftHandler(bidDraftSvc)
 
 	// 4. Handlers
 	ingestHandler := handler.NewIngestionHandler(ingestionSvc)
 	feedHandler := handler.NewFeedHandler(matchingSvc)
+	feedbackHandler := handler.NewFeedbackHandler(feedbackSvc)
+	partnerHandler := handler.NewPartnerHandler(matchingSvc)
 	companyHandler := handler.NewCompanyHandler(companySvc)
+	usageHandler := handler.NewUsageHandler(usageSvc)
+	referenceHandler := handler.NewReferenceHandler(referenceSvc)
 
-	// Storage Service (optional - still works without it)
-	var storageSvc *service.SupabaseStorageService
-	if supabaseURL != "" && supabaseServiceKey != "" {
-		storageSvc = service.NewSupabaseStorageService(supabaseURL, supabaseServiceKey)
-		log.Println("✅ Supabase Storage enabled")
-	} else {
-		log.Println("⚠️ Supabase Storage disabled (missing SUPABASE_URL or SUPABASE_SERVICE_KEY)")
+	// Mitglieder, Rollen und Einladungen
+	if mailer == nil {
+		log.Println("⚠️ Invitation emails disabled (missing SMTP_HOST or SMTP_FROM)")
+	}
+	membershipSvc := service.NewMembershipService(db, mailer, appURL)
+	membershipHandler := handler.NewMembershipHandler(membershipSvc)
+	viewer := handler.RequireRole(membershipSvc, domain.RoleViewer)
+	bidManager := handler.RequireRole(membershipSvc, domain.RoleBidManager)
+	admin := handler.RequireRole(membershipSvc, domain.RoleAdmin)
+	owner := handler.RequireRole(membershipSvc, domain.RoleOwner)
+
+	// Nachweise mit Ablaufdatum; Erinnerungen per Mail (mehrmals täglich geprüft)
+	certificateSvc := service.NewCertificateService(db, storageSvc, mailer)
+	certificateHandler := handler.NewCertificateHandler(certificateSvc)
+	if mailer == nil {
+		log.Println("⚠️ Certificate expiry reminders disabled (missing SMTP_HOST or SMTP_FROM)")
 	}
+	certificateSvc.StartExpiryReminders(context.Background(), 6*time.Hour)
 
 	// OCR Service for PDF attachments
-	ocrSvc := service.NewOCRService(novitaAPIKey)
-	tenderHandler := handler.NewTenderHandler(db, storageSvc, ocrSvc)
+	ocrSvc := service.NewOCRService(novitaAPIKey, usageSvc)
+	eligibilitySvc := service.NewEligibilityService(db)
+	tenderHandler := handler.NewTenderHandler(db, storageSvc, ocrSvc, eligibilitySvc, complianceSvc, usageSvc, membershipSvc)
+	formFillingHandler := handler.NewFormFillingHandler(service.NewFormFillingService(db, storageSvc))
+	cvHandler := handler.NewCVHandler(service.NewCVService(db, storageSvc, ocrSvc, cvAgent, staffingAgent, usageSvc))
 
 	// 5. Server
 	h := server.Default(
 		server.WithHostPorts(":8080"),
 		server.WithMaxRequestBodySize(50*1024*1024), // 50MB
 		server.WithTransport(standard.NewTransporter),
+		// Verbindungsabbruch beendet den Request-Context (SSE-Streams, laufende Modellaufrufe)
+		server.WithSenseClientDisconnection(true),
 	)
 
 	// Public routes
@@ -143,78 +301,93 @@ func main() {
 	api := h.Group("/api/v1")
 	api.Use(middleware.AuthMiddleware())
 
-	api.POST("/ingest", ingestHandler.UploadFile)
-	api.GET("/feed", feedHandler.GetFeed)
-	api.POST("/analyze/:tenderId", complianceHandler.Analyze)
+	api.POST("/ingest", bidManager, ingestHandler.UploadFile)
+	api.GET("/feed", viewer, feedHandler.GetFeed)
+	api.GET("/feed/feedback", viewer, feedbackHandler.List)
+	api.POST("/feed/:tenderId/feedback", bidManager, feedbackHandler.Submit)
+	api.DELETE("/feed/:tenderId/feedback", bidManager, feedbackHandler.Delete)
+	api.POST("/analyze/:tenderId", bidManager, complianceHandler.Analyze)
+	api.POST("/analyze/:tenderId/stream", bidManager, complianceHandler.AnalyzeStream)
+	api.GET("/compliance-checks/:checkId", viewer, complianceHandler.GetCheck)
 	api.POST("/companies", companyHandler.Create)
+	api.GET("/companies/me", viewer, companyHandler.Get)
+	api.PATCH("/companies/me", admin, companyHandler.Update)
+	api.DELETE("/companies/me", owner, companyHandler.Delete)
+	api.GET("/companies/me/members", viewer, membershipHandler.ListMembers)
+	api.PATCH("/companies/me/members/:userId", admin, membershipHandler.UpdateMember)
+	api.DELETE("/companies/me/members/:userId", viewer, membershipHandler.RemoveMember)
+	api.POST("/companies/me/transfer-ownership", owner, membershipHandler.TransferOwnership)
+	api.GET("/companies/me/invitations", admin, membershipHandler.ListInvitations)
+	api.POST("/companies/me/invitations", admin, membershipHandler.Invite)
+	api.DELETE("/companies/me/invitations/:invitationId", admin, membershipHandler.RevokeInvitation)
+	api.POST("/invitations/accept", membershipHandler.AcceptInvitation)
+	api.GET("/companies/me/certificates", viewer, certificateHandler.List)
+	api.POST("/companies/me/certificates", bidManager, certificateHandler.Create)
+	api.PATCH("/companies/me/certificates/:certificateId", bidManager, certificateHandler.Update)
+	api.DELETE("/companies/me/certificates/:certificateId", bidManager, certificateHandler.Delete)
+	api.PUT("/companies/me/certificates/:certificateId/file", bidManager, certificateHandler.UploadFile)
+	api.GET("/companies/me/certificates/:certificateId/file", viewer, certificateHandler.DownloadFile)
+	api.GET("/companies/me/references", viewer, referenceHandler.List)
+	api.POST("/companies/me/references", bidManager, referenceHandler.Create)
+	api.PATCH("/companies/me/references/:referenceId", bidManager, referenceHandler.Update)
+	api.DELETE("/companies/me/references/:referenceId", bidManager, referenceHandler.Delete)
+	api.GET("/companies/me/cvs", viewer, cvHandler.List)
+	api.POST("/companies/me/cvs", bidManager, cvHandler.Upload)
+	api.PATCH("/companies/me/cvs/:cvId", bidManager, cvHandler.Update)
+	api.DELETE("/companies/me/cvs/:cvId", bidManager, cvHandler.Delete)
+	api.POST("/companies/me/cvs/:cvId/extract", bidManager, cvHandler.Reextract)
+	api.GET("/companies/me/cvs/:cvId/file", viewer, cvHandler.DownloadFile)
+	api.GET("/usage", viewer, usageHandler.Get)
 
 	// Tender routes
-	api.GET("/tenders", tenderHandler.ListTenders)
-	api.GET("/tenders/:tenderId/attachments", tenderHandler.GetTenderAttachments)
-	api.POST("/tenders/:tenderId/attachments", tenderHandler.UploadAttachment)
-	api.DELETE("/attachments/:attachmentId", tenderHandler.DeleteAttachment)
+	api.GET("/tenders", viewer, tenderHandler.ListTenders)
+	api.GET("/tenders/:tenderId/attachments", viewer, tenderHandler.GetTenderAttachments)
+	api.GET("/tenders/:tenderId/partners", viewer, partnerHandler.FindPartners)
+	api.GET("/tenders/:tenderId/references", viewer, referenceHandler.BestForTender)
+	api.POST("/tenders/:tenderId/team-proposal", bidManager, cvHandler.ProposeTeam)
+	api.GET("/tenders/:tenderId/compliance-checks", viewer, complianceHandler.History)
+	api.POST("/tenders/:tenderId/attachments", bidManager, tenderHandler.UploadAttachment)
+	api.DELETE("/attachments/:attachmentId", bidManager, tenderHandler.DeleteAttachment)
+
+	// Abgabe-Checkliste
+	api.GET("/tenders/:tenderId/checklist", viewer, checklistHandler.Get)
+	api.POST("/tenders/:tenderId/checklist", bidManager, checklistHandler.Generate)
+	api.POST("/tenders/:tenderId/checklist/items", bidManager, checklistHandler.AddItem)
+	api.PATCH("/checklist-items/:itemId", bidManager, checklistHandler.UpdateItem)
+	api.DELETE("/checklist-items/:itemId", bidManager, checklistHandler.DeleteItem)
+
+	// Fragen zu einer Ausschreibung (RAG über Beschreibung und Anhänge)
+	api.GET("/tenders/:tenderId/chat", viewer, qaHandler.History)
+	api.POST("/tenders/:tenderId/chat", viewer, qaHandler.Ask)
+	api.DELETE("/tenders/:tenderId/chat", viewer, qaHandler.Clear)
+
+	// Bieterfragen an die Vergabestelle
+	api.GET("/tenders/:tenderId/bidder-questions", viewer, bidderQuestionHandler.List)
+	api.POST("/tenders/:tenderId/bidder-questions", bidManager, bidderQuestionHandler.Generate)
+	api.POST("/tenders/:tenderId/bidder-questions/items", bidManager, bidderQuestionHandler.Add)
+	api.GET("/tenders/:tenderId/bidder-questions/export", viewer, bidderQuestionHandler.Export)
+	api.PUT("/tenders/:tenderId/question-deadline", bidManager, bidderQuestionHandler.SetDeadline)
+	api.DELETE("/tenders/:tenderId/question-deadline", bidManager, bidderQuestionHandler.ResetDeadline)
+	api.PATCH("/bidder-questions/:questionId", bidManager, bidderQuestionHandler.Update)
+	api.DELETE("/bidder-questions/:questionId", bidManager, bidderQuestionHandler.Delete)
+
+	// Bewerbung und Angebotskonzept (versioniert)
+	api.GET("/tenders/:tenderId/application", viewer, bidDraftHandler.GetApplication)
+	api.POST("/tenders/:tenderId/application", bidManager, bidDraftHandler.OpenApplication)
+	api.PATCH("/applications/:applicationId", bidManager, bidDraftHandler.UpdateApplication)
+	api.POST("/applications/:applicationId/drafts", bidManager, bidDraftHandler.GenerateDraft)
+	api.GET("/bid-drafts/:draftId", viewer, bidDraftHandler.GetDraft)
+	api.POST("/bid-drafts/:draftId", bidManager, bidDraftHandler.SaveDraft)
+	api.GET("/bid-drafts/:draftId/export", viewer, bidDraftHandler.ExportDraft)
+
+	// Standardformulare (Eigenerklärungen) aus dem Firmenprofil vorausfüllen
+	api.GET("/tenders/:tenderId/forms", viewer, formFillingHandler.Detect)
+	api.POST("/tenders/:tenderId/forms/fill", bidManager, formFillingHandler.Fill)
+	api.GET("/tenders/:tenderId/forms/filled", viewer, formFillingHandler.ListFilled)
+	api.DELETE("/filled-forms/:attachmentId", bidManager, formFillingHandler.DeleteFilled)
 
 	log.Println("🚀 Server running on :8080")
 	if err := h.Run(); err != nil {
 		log.Fatalf("Server stopped: %v", err)
 	}
 }
-
-func loadEnvFiles(paths ...string) error {
-	for _, path := range paths {
-		if path == "" {
-			continue
-		}
-
-		if err := parseEnvFile(path); err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return err
-		}
-	}
-
-	return nil
-}
-
-func parseEnvFile(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		line = strings.TrimPrefix(line, "export ")
-		line = strings.TrimPrefix(line, "$env:")
-
-		kv := strings.SplitN(line, "=", 2)
-		if len(kv) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(kv[0])
-		if key == "" {
-			continue
-		}
-
-		value := strings.TrimSpace(kv[1])
-		value = strings.Trim(value, `"'`)
-
-		if _, exists := os.LookupEnv(key); exists {
-			continue
-		}
-
-		if err := os.Setenv(key, value); err != nil {
-			return err
-		}
-	}
-
-	return scanner.Err()
-}
@@ -0,0 +1,101 @@
+// Command backfill-requirements extrahiert die Mindestanforderungen (Umsatz, Beschäftigte,
+// Zertifikate) für bestehende Ausschreibungen nach. Neue Ausschreibungen erhalten sie bei
+// Ingestion und nach OCR von Anhängen; ältere Datensätze bleiben ohne diesen Lauf leer und
+// werden vom Eignungs-Vorfilter nicht erfasst.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/config"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+func main() {
+	batch := flag.Int("batch", 500, "Ausschreibungen je Abfrage")
+	all := flag.Bool("all", false, "Auch Ausschreibungen mit abgelaufener Angebotsfrist verarbeiten")
+	flag.Parse()
+
+	if err := run(*batch, *all); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(batch int, all bool) error {
+	if batch <= 0 {
+		return fmt.Errorf("batch must be positive")
+	}
+	if err := config.LoadEnvFiles(".env", "cmd/api/.env"); err != nil {
+		return fmt.Errorf("load .env file: %w", err)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return fmt.Errorf("missing required environment variable: DATABASE_URL")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return fmt.Errorf("db connection: %w", err)
+	}
+
+	ctx := context.Background()
+	eligibility := service.NewEligibilityService(db)
+
+	var (
+		lastID              uuid.UUID
+		processed, withReqs int
+		failed              int
+	)
+	start := time.Now()
+	for {
+		// Keyset-Paginierung über die ID, damit Updates die Reihenfolge nicht verschieben
+		query := db.WithContext(ctx).Model(&domain.Tender{}).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(batch)
+		if !all {
+			query = query.Where("deadline IS NULL OR deadline > now()")
+		}
+
+		var ids []uuid.UUID
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("load tenders: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			req, err := eligibility.RefreshTenderRequirements(ctx, id)
+			if err != nil {
+				log.Printf("Tender %s: %v", id, err)
+				failed++
+				continue
+			}
+			processed++
+			if req.MinAnnualRevenue > 0 || req.MinEmployeeCount > 0 || len(req.RequiredCertificates) > 0 {
+				withReqs++
+			}
+		}
+		lastID = ids[len(ids)-1]
+		log.Printf("%d Ausschreibungen verarbeitet (%d mit Anforderungen, %d Fehler)", processed, withReqs, failed)
+	}
+
+	fmt.Printf("Fertig nach %s: %d Ausschreibungen, %d mit Mindestanforderungen, %d Fehler\n",
+		time.Since(start).Round(time.Second), processed, withReqs, failed)
+	return nil
+}
@@ -0,0 +1,334 @@
+// Command bench-matching misst die Latenz des Matchings mit synthetischen Daten.
+//
+// In einem eigenen Schema (Default: bench_matching) werden Ausschreibungen und Firmen mit
+// geclusterten Embeddings, CPV-Codes, NUTS-Codes und Koordinaten erzeugt und die Indizes aus
+// migrations/005_matching_ann_indexes.sql angelegt. Danach läuft FindMatchesForCompany
+// einmal mit ANN-Kandidatenstufe und einmal als exakter Vollscan; ausgegeben werden
+// p50/p95/p99 sowie der Recall der ANN-Ergebnisse gegenüber dem Vollscan.
+//
+// Voraussetzung: Migrationen 005 (public.nuts_prefixes) und 023 (public.nuts_centroid) sind
+// eingespielt und pgvector >= 0.8.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/config"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+var schemaNameRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Eine Auswahl deutscher NUTS-3-Regionen für die synthetischen Daten
+const benchNutsCodes = `ARRAY['DE111','DE113','DE212','DE21H','DE222','DE232','DE254','DE300','DE402','DE501',
+	'DE600','DE712','DE731','DE929','DEA11','DEA12','DEA22','DEA23','DEA52','DEB34',
+	'DEC01','DED21','DED52','DEE01','DEF02','DEG01','DE144','DE125','DE711','DE27E']`
+
+type options struct {
+	tenders, companies, topics int
+	runs, exactRuns, limit     int
+	schema                     string
+	keep                       bool
+}
+
+func main() {
+	var opts options
+	flag.IntVar(&opts.tenders, "tenders", 100000, "Anzahl synthetischer Ausschreibungen")
+	flag.IntVar(&opts.companies, "companies", 20, "Anzahl synthetischer Firmen (Anfragen rotieren über alle)")
+	flag.IntVar(&opts.topics, "topics", 50, "Anzahl Themen-Cluster für Embeddings und CPV-Codes")
+	flag.IntVar(&opts.runs, "runs", 200, "Feed-Anfragen mit ANN-Kandidatenstufe")
+	flag.IntVar(&opts.exactRuns, "exact-runs", 20, "Feed-Anfragen als exakter Vollscan (Referenz)")
+	flag.IntVar(&opts.limit, "limit", 20, "Matches pro Anfrage")
+	flag.StringVar(&opts.schema, "schema", "bench_matching", "Schema für die synthetischen Daten")
+	flag.BoolVar(&opts.keep, "keep", false, "Schema nach dem Lauf behalten (nächster Lauf nutzt die Daten wieder)")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run gibt Fehler zurück statt abzubrechen, damit das Aufräumen des Schemas immer läuft
+func run(opts options) error {
+	if !schemaNameRe.MatchString(opts.schema) || opts.schema == "public" {
+		return fmt.Errorf("invalid schema name: %q", opts.schema)
+	}
+
+	if err := config.LoadEnvFiles(".env", "cmd/api/.env"); err != nil {
+		return fmt.Errorf("load .env file: %w", err)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return fmt.Errorf("missing required environment variable: DATABASE_URL")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return fmt.Errorf("db connection: %w", err)
+	}
+
+	// Eine Verbindung, damit search_path für alle Queries gilt
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("db handle: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+
+	if !opts.keep {
+		defer func() {
+			if err := db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", opts.schema)).Error; err != nil {
+				log.Printf("Cleanup failed: %v", err)
+			}
+		}()
+	}
+
+	var existing int64
+	if err := db.Raw(fmt.Sprintf("SELECT count(*) FROM %s.tenders", opts.schema)).Scan(&existing).Error; err != nil || existing != int64(opts.tenders) {
+		if err := generate(ctx, db, opts.schema, opts.tenders, opts.companies, opts.topics); err != nil {
+			return fmt.Errorf("generate synthetic data: %w", err)
+		}
+	} else {
+		log.Printf("Reusing %d tenders in schema %s", existing, opts.schema)
+	}
+
+	if err := db.Exec(fmt.Sprintf("SET search_path TO %s, public, extensions", opts.schema)).Error; err != nil {
+		return fmt.Errorf("set search_path: %w", err)
+	}
+
+	var companyIDs []uuid.UUID
+	if err := db.Model(&domain.Company{}).Order("id").Pluck("id", &companyIDs).Error; err != nil {
+		return fmt.Errorf("load synthetic companies: %w", err)
+	}
+	if len(companyIDs) == 0 {
+		return fmt.Errorf("no synthetic companies in schema %s", opts.schema)
+	}
+
+	svc := service.NewMatchingService(db)
+	annOpts := service.MatchOptions{Limit: opts.limit}
+	exactOpts := service.MatchOptions{Limit: opts.limit, ExactScan: true}
+
+	// Aufwärmen (Buffer Cache, Query-Pläne)
+	for _, id := range companyIDs[:min(5, len(companyIDs))] {
+		if _, err := svc.FindMatchesForCompany(ctx, id, annOpts); err != nil {
+			return fmt.Errorf("warm-up: %w", err)
+		}
+	}
+
+	annLatencies := make([]time.Duration, 0, opts.runs)
+	annResults := make(map[uuid.UUID][]domain.Match)
+	for i := 0; i < opts.runs; i++ {
+		companyID := companyIDs[i%len(companyIDs)]
+		start := time.Now()
+		matches, err := svc.FindMatchesForCompany(ctx, companyID, annOpts)
+		if err != nil {
+			return fmt.Errorf("ANN run: %w", err)
+		}
+		annLatencies = append(annLatencies, time.Since(start))
+		annResults[companyID] = matches
+	}
+
+	exactLatencies := make([]time.Duration, 0, opts.exactRuns)
+	var recallSum float64
+	recallCount := 0
+	for i := 0; i < opts.exactRuns; i++ {
+		companyID := companyIDs[i%len(companyIDs)]
+		start := time.Now()
+		matches, err := svc.FindMatchesForCompany(ctx, companyID, exactOpts)
+		if err != nil {
+			return fmt.Errorf("exact run: %w", err)
+		}
+		exactLatencies = append(exactLatencies, time.Since(start))
+
+		if ann, ok := annResults[companyID]; ok && i < len(companyIDs) {
+			recallSum += recall(ann, matches)
+			recallCount++
+		}
+	}
+
+	fmt.Printf("\n%d tenders, %d companies, limit %d\n\n", opts.tenders, len(companyIDs), opts.limit)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODE\tRUNS\tP50\tP95\tP99\tMEAN")
+	printLatencies(w, "ann+prefilter", annLatencies)
+	printLatencies(w, "exact scan", exactLatencies)
+	w.Flush()
+
+	if recallCount > 0 {
+		fmt.Printf("\nrecall@%d (ANN vs. exact): %.3f over %d companies\n", opts.limit, recallSum/float64(recallCount), recallCount)
+	}
+	return nil
+}
+
+// generate legt Schema, Tabellen, synthetische Daten und die Matching-Indizes an
+func generate(ctx context.Context, db *gorm.DB, schema string, tenders, companies, topics int) error {
+	log.Printf("Generating %d tenders and %d companies in schema %s ...", tenders, companies, schema)
+
+	steps := []string{
+		fmt.Sprintf("DROP SCHEMA IF EXISTS %[1]s CASCADE", schema),
+		fmt.Sprintf("CREATE SCHEMA %[1]s", schema),
+		fmt.Sprintf("CREATE TABLE %[1]s.tenders (LIKE public.tenders INCLUDING DEFAULTS)", schema),
+		fmt.Sprintf("CREATE TABLE %[1]s.companies (LIKE public.companies INCLUDING DEFAULTS)", schema),
+		// Themen-Cluster: je ein zufälliger Schwerpunkt im Embedding-Raum und ein CPV-Code
+		fmt.Sprintf(`CREATE TABLE %[1]s.bench_topics AS
+			SELECT
+				g AS id,
+				(30000000 + g * 100000)::text AS cpv,
+				(SELECT array_agg(random() - 0.5) FROM generate_series(1, 1536) WHERE g > 0)::vector(1536) AS embedding
+			FROM generate_series(1, %[2]d) AS g`, schema, topics),
+	}
+	for _, stmt := range steps {
+		if err := db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return fmt.Errorf("%s: %w", firstLine(stmt), err)
+		}
+	}
+
+	// In Batches, damit der Fortschritt sichtbar bleibt
+	const batchSize = 10000
+	for offset := 0; offset < tenders; offset += batchSize {
+		end := min(offset+batchSize, tenders)
+		stmt := fmt.Sprintf(`INSERT INTO %[1]s.tenders
+			(id, external_id, source_portal, title, description_full, cpv_codes, nutscodes,
+			 deadline, deadline_at, latitude, longitude, location_geom, requirement_embedding, processing_status, created_at)
+			SELECT
+				gen_random_uuid(),
+				'bench-' || g.i,
+				'bench',
+				'Synthetische Ausschreibung ' || g.i,
+				'Synthetische Leistungsbeschreibung zu Thema ' || tp.id,
+				ARRAY[tp.cpv, (30000000 + (1 + floor(random() * %[4]d)::int) * 100000)::text],
+				ARRAY[(%[5]s)[1 + floor(random() * 30)::int]],
+				d.deadline,
+				d.deadline,
+				p.lat,
+				p.lng,
+				ST_SetSRID(ST_MakePoint(p.lng, p.lat), 4326),
+				tp.embedding + (SELECT array_agg((random() - 0.5) * 0.6) FROM generate_series(1, 1536) WHERE g.i > 0)::vector(1536),
+				'completed',
+				now()
+			FROM generate_series(%[2]d, %[3]d) AS g(i)
+			JOIN %[1]s.bench_topics tp ON tp.id = 1 + (g.i %% %[4]d)
+			CROSS JOIN LATERAL (SELECT now() + ((random() * 120 - 20) * interval '1 day') AS deadline WHERE g.i > 0) d
+			CROSS JOIN LATERAL (SELECT 47.5 + random() * 7 AS lat, 6 + random() * 9 AS lng WHERE g.i > 0) p`,
+			schema, offset+1, end, topics, benchNutsCodes)
+		if err := db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return fmt.Errorf("insert tenders: %w", err)
+		}
+		log.Printf("  %d/%d tenders", end, tenders)
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %[1]s.companies
+		(id, auth_user_id, name, industry_tags, service_radius_km, latitude, longitude, location_geom,
+		 profile_embedding, settings, onboarding_completed, employee_count, annual_revenue)
+		SELECT
+			gen_random_uuid(),
+			gen_random_uuid(),
+			'Bench Firma ' || g.i,
+			ARRAY[tp.cpv],
+			100,
+			p.lat,
+			p.lng,
+			ST_SetSRID(ST_MakePoint(p.lng, p.lat), 4326),
+			tp.embedding + (SELECT array_agg((random() - 0.5) * 0.6) FROM generate_series(1, 1536) WHERE g.i > 0)::vector(1536),
+			jsonb_build_object('regions', ARRAY[(%[3]s)[1 + floor(random() * 30)::int]]),
+			true,
+			50,
+			5000000
+		FROM generate_series(1, %[2]d) AS g(i)
+		JOIN %[1]s.bench_topics tp ON tp.id = 1 + (g.i %% %[4]d)
+		CROSS JOIN LATERAL (SELECT 47.5 + random() * 7 AS lat, 6 + random() * 9 AS lng WHERE g.i > 0) p`,
+		schema, companies, benchNutsCodes, topics)
+	if err := db.WithContext(ctx).Exec(stmt).Error; err != nil {
+		return fmt.Errorf("insert companies: %w", err)
+	}
+
+	// Dieselben Indizes wie migrations/005_matching_ann_indexes.sql, erst nach dem Laden gebaut
+	indexes := []string{
+		"SET maintenance_work_mem = '1GB'",
+		fmt.Sprintf(`CREATE INDEX ON %[1]s.tenders USING hnsw (requirement_embedding vector_cosine_ops) WITH (m = 16, ef_construction = 64)`, schema),
+		fmt.Sprintf(`CREATE INDEX ON %[1]s.tenders USING gin (cpv_codes)`, schema),
+		fmt.Sprintf(`CREATE INDEX ON %[1]s.tenders USING gin (public.nuts_prefixes(nutscodes))`, schema),
+		fmt.Sprintf(`CREATE INDEX ON %[1]s.tenders USING gist ((location_geom::geography))`, schema),
+		fmt.Sprintf(`CREATE INDEX ON %[1]s.tenders (deadline)`, schema),
+		fmt.Sprintf(`CREATE UNIQUE INDEX ON %[1]s.tenders (id)`, schema),
+		fmt.Sprintf(`CREATE UNIQUE INDEX ON %[1]s.companies (id)`, schema),
+		fmt.Sprintf(`ANALYZE %[1]s.tenders`, schema),
+		fmt.Sprintf(`ANALYZE %[1]s.companies`, schema),
+		"RESET maintenance_work_mem",
+	}
+	for _, stmt := range indexes {
+		start := time.Now()
+		if err := db.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return fmt.Errorf("%s: %w", firstLine(stmt), err)
+		}
+		log.Printf("  %s (%s)", firstLine(stmt), time.Since(start).Round(time.Millisecond))
+	}
+
+	return nil
+}
+
+// recall ist der Anteil der exakten Top-Treffer, die auch die ANN-Stufe gefunden hat
+func recall(ann, exact []domain.Match) float64 {
+	if len(exact) == 0 {
+		return 1
+	}
+	found := make(map[uuid.UUID]bool, len(ann))
+	for _, m := range ann {
+		found[m.TenderID] = true
+	}
+	hits := 0
+	for _, m := range exact {
+		if found[m.TenderID] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(exact))
+}
+
+func printLatencies(w *tabwriter.Writer, mode string, latencies []time.Duration) {
+	if len(latencies) == 0 {
+		return
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", mode, len(sorted),
+		percentile(sorted, 0.50), percentile(sorted, 0.95), percentile(sorted, 0.99),
+		(total / time.Duration(len(sorted))).Round(time.Microsecond*100))
+}
+
+// percentile erwartet eine aufsteigend sortierte Liste (Nearest-Rank-Methode)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	idx = max(0, min(idx, len(sorted)-1))
+	return sorted[idx].Round(time.Microsecond * 100)
+}
+
+func firstLine(stmt string) string {
+	line, _, _ := strings.Cut(stmt, "\n")
+	return line
+}
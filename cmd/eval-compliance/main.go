@@ -0,0 +1,291 @@
+// Command eval-compliance bewertet den Compliance-Agent offline gegen einen Golden-Datensatz.
+//
+// Jeder Fall unter <dataset>/cases enthält Unterlagen, Firmenprofil und die erwarteten
+// Anforderungen und Blocker. Ausgegeben werden Precision und Recall je Kategorie, die
+// Genauigkeit von Status und Machbarkeit sowie der Vergleich mit der letzten Baseline;
+// liegt eine Kennzahl darunter, endet der Lauf mit Exit-Code 1.
+//
+// Im Modus replay (Standard) kommen die Modellantworten aus <dataset>/recordings, der Lauf
+// braucht also kein Netzwerk. Ändern sich Prompt, Modell oder Eingabe, fehlen passende
+// Aufzeichnungen; -mode record ruft dann das Modell über OpenRouter auf und zeichnet neu auf.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloudwego/eino/components/model"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/config"
+)
+
+func main() {
+	dataset := flag.String("dataset", "eval/compliance", "Verzeichnis mit cases/, recordings/ und baseline.json")
+	mode := flag.String("mode", modeReplay, "replay (Aufzeichnungen, ohne Netzwerk) | record (Modell aufrufen und aufzeichnen) | live (Modell ohne Aufzeichnung)")
+	modelName := flag.String("model", agent.DefaultModel, "Modell (OpenRouter-ID)")
+	temperature := flag.Float64("temperature", 0.2, "Temperatur (nur record/live)")
+	promptDir := flag.String("prompt-dir", "", "Prompt-Dateien <agent>/<version>/<name>.txt wie PROMPT_DIR (Standard: mitgelieferte Prompts)")
+	promptVersion := flag.String("prompt-version", "", "Prompt-Version aus -prompt-dir oder den mitgelieferten Prompts (Standard: v1)")
+	baselinePath := flag.String("baseline", "", "Baseline-Datei (Standard: <dataset>/baseline.json)")
+	updateBaseline := flag.Bool("update-baseline", false, "Ergebnis als neue Baseline speichern")
+	tolerance := flag.Float64("tolerance", 0.01, "Erlaubter Rückgang je Kennzahl gegenüber der Baseline")
+	caseFlag := flag.String("case", "", "Nur diesen Fall auswerten (ID)")
+	reportPath := flag.String("report", "", "Ergebnis zusätzlich als JSON schreiben")
+	flag.Parse()
+
+	if *mode != modeReplay && *mode != modeRecord && *mode != modeLive {
+		log.Fatalf("Invalid mode: %q", *mode)
+	}
+	if *baselinePath == "" {
+		*baselinePath = filepath.Join(*dataset, "baseline.json")
+	}
+
+	ctx := context.Background()
+	cfg := agent.ComplianceAgentConfig{
+		Model:       *modelName,
+		Temperature: float32(*temperature),
+	}
+	if *promptDir != "" || *promptVersion != "" {
+		cfg.Prompts = &filePromptSource{dir: *promptDir, version: *promptVersion}
+	}
+
+	var live model.ToolCallingChatModel
+	if *mode != modeReplay {
+		if err := config.LoadEnvFiles(".env", "cmd/api/.env"); err != nil {
+			log.Fatalf("Failed to load .env file: %v", err)
+		}
+		liveCfg := cfg
+		liveCfg.APIKey = strings.TrimSpace(os.Getenv("OPENROUTER_API_KEY"))
+		liveCfg.BaseURL = strings.TrimSpace(os.Getenv("OPENROUTER_BASE_URL"))
+		liveCfg.AppName = os.Getenv("OPENROUTER_APP_NAME")
+		liveCfg.AppURL = os.Getenv("OPENROUTER_APP_URL")
+		var err error
+		if live, _, err = agent.NewOpenRouterChatModel(ctx, liveCfg); err != nil {
+			log.Fatalf("Chat model init failed: %v", err)
+		}
+	}
+
+	cases, err := loadCases(filepath.Join(*dataset, "cases"), *caseFlag)
+	if err != nil {
+		log.Fatalf("Load cases failed: %v", err)
+	}
+	if len(cases) == 0 {
+		log.Fatalf("No cases found in %s", filepath.Join(*dataset, "cases"))
+	}
+
+	// Prompt-Version und Modell stehen vor dem ersten Aufruf fest
+	probe, err := agent.NewComplianceAgentWithModel(ctx, &replayModel{tape: &cassette{}}, cfg)
+	if err != nil {
+		log.Fatalf("Agent init failed: %v", err)
+	}
+	_, run, err := probe.Prepare(ctx)
+	if err != nil {
+		log.Fatalf("Load prompts failed: %v", err)
+	}
+
+	report := newEvalReport(run, *mode)
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CASE\tFOUND\tMISSED\tUNEXPECTED\tWRONG STATUS\tERROR\n")
+	for _, c := range cases {
+		result := evaluateCase(ctx, c, report, cfg, *mode, live, filepath.Join(*dataset, "recordings", c.ID+".json"))
+		if result.Error != "" {
+			failed++
+		}
+		report.Cases = append(report.Cases, result)
+		if result.Error != "" {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t%s\n", c.ID, result.Error)
+			continue
+		}
+		found := len(c.Expected.Requirements) + len(c.Expected.Blockers) - len(result.Missed)
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n", c.ID, found, len(result.Missed), len(result.Unexpected), len(result.WrongState))
+	}
+	w.Flush()
+	report.finish()
+
+	for _, result := range report.Cases {
+		for _, line := range result.Missed {
+			fmt.Printf("  %s fehlt: %s\n", result.ID, line)
+		}
+		for _, line := range result.Unexpected {
+			fmt.Printf("  %s zusätzlich: %s\n", result.ID, line)
+		}
+		for _, line := range result.WrongState {
+			fmt.Printf("  %s Status: %s\n", result.ID, line)
+		}
+	}
+
+	baseline, err := loadReport(*baselinePath)
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatalf("Load baseline failed: %v", err)
+	}
+
+	fmt.Printf("\nPrompt-Version %s, Modell %s (%s)\n", run.PromptVersion, run.ModelID, *mode)
+	if baseline != nil {
+		fmt.Printf("Baseline: Prompt-Version %s, Modell %s vom %s\n", baseline.PromptVersion, baseline.ModelID, baseline.CreatedAt.Format("2006-01-02"))
+	}
+	printMetrics(report, baseline)
+
+	if *reportPath != "" {
+		if err := writeReport(*reportPath, report); err != nil {
+			log.Fatalf("Write report failed: %v", err)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d von %d Fällen fehlgeschlagen.\n", failed, len(cases))
+		os.Exit(1)
+	}
+
+	if *updateBaseline {
+		if *caseFlag != "" {
+			log.Fatal("Refusing to update baseline from a single case")
+		}
+		if err := writeReport(*baselinePath, report); err != nil {
+			log.Fatalf("Write baseline failed: %v", err)
+		}
+		fmt.Printf("\nBaseline aktualisiert: %s\n", *baselinePath)
+		return
+	}
+
+	if baseline == nil {
+		fmt.Println("\nKeine Baseline vorhanden; mit -update-baseline anlegen.")
+		return
+	}
+	if *caseFlag != "" {
+		// Einzelne Fälle sind mit der Baseline über alle Fälle nicht vergleichbar
+		return
+	}
+	if regressed := regressions(report, baseline, *tolerance); len(regressed) > 0 {
+		fmt.Println("\nRegression gegenüber der Baseline:")
+		for _, line := range regressed {
+			fmt.Println("  " + line)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("\nKeine Regression gegenüber der Baseline.")
+}
+
+// evaluateCase führt einen Fall aus und wertet ihn aus; Fehler landen im Ergebnis
+func evaluateCase(ctx context.Context, c evalCase, report *evalReport, cfg agent.ComplianceAgentConfig, mode string, live model.ToolCallingChatModel, recording string) caseResult {
+	var chatModel model.ToolCallingChatModel
+	var tape *cassette
+	switch mode {
+	case modeReplay:
+		var err error
+		if tape, err = loadCassette(recording); err != nil {
+			return caseResult{ID: c.ID, Error: err.Error()}
+		}
+		chatModel = &replayModel{tape: tape}
+	case modeRecord:
+		// Neu aufzeichnen: alte Antworten passen nach Prompt- oder Modellwechsel nicht mehr
+		tape = &cassette{path: recording, entries: map[string]json.RawMessage{}}
+		chatModel = &replayModel{inner: live, tape: tape}
+	default:
+		chatModel = live
+	}
+
+	complianceAgent, err := agent.NewComplianceAgentWithModel(ctx, chatModel, cfg)
+	if err != nil {
+		return caseResult{ID: c.ID, Error: err.Error()}
+	}
+
+	var assessment *agent.ComplianceAssessment
+	if len(c.Sections) > 0 {
+		result, err := complianceAgent.AssessSections(ctx, agent.ComplianceGraphInput{Sections: c.Sections, Profile: c.Profile})
+		if err != nil {
+			return caseResult{ID: c.ID, Error: err.Error()}
+		}
+		if len(result.FailedSections) > 0 {
+			return caseResult{ID: c.ID, Error: fmt.Sprintf("section %q failed: %s", result.FailedSections[0].Label, result.FailedSections[0].Error)}
+		}
+		assessment = &result.Assessment
+	} else {
+		if assessment, err = complianceAgent.Assess(ctx, agent.ComplianceInput{OCRText: c.TenderText, Profile: c.Profile}); err != nil {
+			return caseResult{ID: c.ID, Error: err.Error()}
+		}
+	}
+
+	if mode == modeRecord {
+		if err := tape.save(); err != nil {
+			return caseResult{ID: c.ID, Error: fmt.Sprintf("save recording: %v", err)}
+		}
+	}
+	return report.score(c, assessment)
+}
+
+// printMetrics gibt die Kennzahlen mit Baseline und Differenz aus
+func printMetrics(report, baseline *evalReport) {
+	previous := make(map[string]float64)
+	if baseline != nil {
+		for _, m := range baseline.metrics() {
+			previous[m.name] = m.value
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nCATEGORY\tTP\tFP\tFN\tPRECISION\tRECALL\n")
+	for _, c := range evalCategories {
+		s := report.Categories[c]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.3f\t%.3f\n", c, s.TP, s.FP, s.FN, s.Precision, s.Recall)
+	}
+	w.Flush()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nMETRIC\tCURRENT\tBASELINE\tDELTA\n")
+	for _, m := range report.metrics() {
+		before, ok := previous[m.name]
+		if !ok {
+			fmt.Fprintf(w, "%s\t%.3f\t-\t-\n", m.name, m.value)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%+.3f\n", m.name, m.value, before, m.value-before)
+	}
+	w.Flush()
+}
+
+// filePromptSource liefert eine Prompt-Version aus Dateien oder den mitgelieferten Prompts,
+// um eine neue Version vor dem Eintrag in die Registry zu bewerten
+type filePromptSource struct {
+	dir     string
+	version string
+}
+
+func (s *filePromptSource) PromptConfig(ctx context.Context, agentName string) (*agent.PromptConfig, error) {
+	version := s.version
+	if version == "" {
+		version = agent.DefaultPromptVersion
+	}
+	if s.dir == "" {
+		prompts, err := agent.BuiltinPrompts(agentName, version)
+		if err != nil {
+			return nil, err
+		}
+		return &agent.PromptConfig{Version: version, Prompts: prompts}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, agentName, version, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no prompts in %s", filepath.Join(s.dir, agentName, version))
+	}
+	prompts := make(map[string]string, len(files))
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		prompts[strings.TrimSuffix(filepath.Base(file), ".txt")] = strings.TrimSpace(string(text))
+	}
+	return &agent.PromptConfig{Version: version, Prompts: prompts}, nil
+}
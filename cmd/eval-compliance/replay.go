@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Modi für Modellaufrufe
+const (
+	modeReplay = "replay" // nur Aufzeichnungen, kein Netzwerk
+	modeRecord = "record" // echtes Modell, Antworten werden neu aufgezeichnet
+	modeLive   = "live"   // echtes Modell ohne Aufzeichnung
+)
+
+// cassette enthält die aufgezeichneten Antworten eines Falls, Schlüssel ist callKey
+type cassette struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]json.RawMessage
+}
+
+// loadCassette liest die Aufzeichnung; fehlt die Datei, ist sie leer
+func loadCassette(path string) (*cassette, error) {
+	c := &cassette{path: path, entries: map[string]json.RawMessage{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *cassette) get(key string) (*schema.Message, bool, error) {
+	c.mu.Lock()
+	raw, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+	// Jede Wiedergabe bekommt eine eigene Kopie, der Agent hängt Nachrichten an
+	var msg schema.Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, false, fmt.Errorf("parse recorded response: %w", err)
+	}
+	return &msg, true, nil
+}
+
+func (c *cassette) put(key string, msg *schema.Message) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = raw
+	return nil
+}
+
+// save schreibt die Aufzeichnung eingerückt (encoding/json sortiert die Schlüssel), damit
+// Diffs lesbar bleiben
+func (c *cassette) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// replayModel spielt Antworten aus der Aufzeichnung ab (inner == nil) oder ruft das echte
+// Modell auf und zeichnet die Antworten auf
+type replayModel struct {
+	inner model.ToolCallingChatModel
+	tape  *cassette
+	tools []*schema.ToolInfo
+}
+
+func (m *replayModel) Generate(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	key, err := callKey(in, m.tools, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.inner == nil {
+		msg, ok, err := m.tape.get(key)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("no recorded response for call %s (re-record with -mode record)", key[:12])
+		}
+		return msg, nil
+	}
+
+	msg, err := m.inner.Generate(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if msg != nil {
+		if err := m.tape.put(key, msg); err != nil {
+			return nil, fmt.Errorf("record response: %w", err)
+		}
+	}
+	return msg, nil
+}
+
+// Stream liefert die Antwort in einem Stück; die Auswertung setzt keinen Listener, der
+// Agent streamt also ohnehin nicht
+func (m *replayModel) Stream(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *replayModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	bound := &replayModel{tape: m.tape, tools: tools}
+	if m.inner != nil {
+		inner, err := m.inner.WithTools(tools)
+		if err != nil {
+			return nil, err
+		}
+		bound.inner = inner
+	}
+	return bound, nil
+}
+
+// callKey identifiziert einen Aufruf über Verlauf, gebundene Tools und Modell. Ändern sich
+// Prompt, Eingabe oder Modell, passt die Aufzeichnung nicht mehr.
+func callKey(in []*schema.Message, tools []*schema.ToolInfo, opts []model.Option) (string, error) {
+	type call struct {
+		Role       schema.RoleType   `json:"role"`
+		Content    string            `json:"content"`
+		ToolCalls  []schema.ToolCall `json:"tool_calls,omitempty"`
+		ToolCallID string            `json:"tool_call_id,omitempty"`
+	}
+	calls := make([]call, len(in))
+	for i, msg := range in {
+		calls[i] = call{Role: msg.Role, Content: msg.Content, ToolCalls: msg.ToolCalls, ToolCallID: msg.ToolCallID}
+	}
+	toolNames := make([]string, len(tools))
+	for i, t := range tools {
+		toolNames[i] = t.Name
+	}
+	modelName := ""
+	if o := model.GetCommonOptions(&model.Options{}, opts...); o.Model != nil {
+		modelName = *o.Model
+	}
+
+	payload, err := json.Marshal(struct {
+		Model    string   `json:"model"`
+		Tools    []string `json:"tools"`
+		Messages []call   `json:"messages"`
+	}{modelName, toolNames, calls})
+	if err != nil {
+		return "", fmt.Errorf("marshal call: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+)
+
+// categoryBlocker fasst die Blocker als eigene Kategorie neben den Anforderungen
+const categoryBlocker = "blocker"
+
+var evalCategories = []string{
+	agent.RequirementCategoryEignung,
+	agent.RequirementCategoryAusschluss,
+	agent.RequirementCategoryNachweis,
+	agent.RequirementCategoryTechnical,
+	categoryBlocker,
+}
+
+// evalCase ist ein Fall des Golden-Datensatzes: Unterlagen, Firmenprofil und Erwartung.
+// Mit Sections läuft der Map-Reduce-Graph, sonst ein einzelner Prompt über TenderText.
+type evalCase struct {
+	ID          string                  `json:"id"`
+	Description string                  `json:"description,omitempty"`
+	TenderText  string                  `json:"tender_text,omitempty"`
+	Sections    []agent.DocumentSection `json:"sections,omitempty"`
+	Profile     agent.CompanyProfile    `json:"profile"`
+	Expected    expectation             `json:"expected"`
+}
+
+type expectation struct {
+	IsFeasible   *bool                 `json:"is_feasible,omitempty"`
+	Requirements []expectedRequirement `json:"requirements"`
+	Blockers     []expectedBlocker     `json:"blockers"`
+}
+
+// expectedRequirement gilt als gefunden, wenn eine Anforderung gleicher Kategorie alle
+// Stichworte in Titel oder Zitat enthält (ohne Stichworte: die Wörter des Titels)
+type expectedRequirement struct {
+	Title    string   `json:"title"`
+	Category string   `json:"category"`
+	Status   string   `json:"status,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+type expectedBlocker struct {
+	Kind     string   `json:"kind"`
+	Keywords []string `json:"keywords"`
+}
+
+// loadCases liest alle *.json aus dir, sortiert nach ID
+func loadCases(dir, only string) ([]evalCase, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []evalCase
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var c evalCase
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		if c.ID == "" {
+			c.ID = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+		if only != "" && c.ID != only {
+			continue
+		}
+		cases = append(cases, c)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].ID < cases[j].ID })
+	return cases, nil
+}
+
+// categoryScore zählt Treffer je Kategorie. Precision und Recall sind 1, wenn es nichts
+// vorherzusagen bzw. nichts zu finden gab.
+type categoryScore struct {
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	FN        int     `json:"fn"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+func (s *categoryScore) finish() {
+	s.Precision, s.Recall = 1, 1
+	if s.TP+s.FP > 0 {
+		s.Precision = float64(s.TP) / float64(s.TP+s.FP)
+	}
+	if s.TP+s.FN > 0 {
+		s.Recall = float64(s.TP) / float64(s.TP+s.FN)
+	}
+}
+
+// caseResult hält fest, was in einem Fall gefehlt hat oder zu viel war
+type caseResult struct {
+	ID         string   `json:"id"`
+	Error      string   `json:"error,omitempty"`
+	Missed     []string `json:"missed,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	WrongState []string `json:"wrong_status,omitempty"`
+	Feasible   *bool    `json:"is_feasible,omitempty"`
+}
+
+// evalReport ist das Ergebnis eines Laufs und zugleich das Format der Baseline
+type evalReport struct {
+	CreatedAt     time.Time                 `json:"created_at"`
+	PromptVersion string                    `json:"prompt_version"`
+	ModelID       string                    `json:"model_id"`
+	Mode          string                    `json:"mode"`
+	Categories    map[string]*categoryScore `json:"categories"`
+	// StatusAccuracy: Anteil gefundener Anforderungen mit erwartetem Status
+	StatusAccuracy float64 `json:"status_accuracy"`
+	// FeasibilityAccuracy: Anteil der Fälle mit richtiger Machbarkeit
+	FeasibilityAccuracy float64      `json:"feasibility_accuracy"`
+	Cases               []caseResult `json:"cases"`
+
+	statusTotal, statusCorrect           int
+	feasibilityTotal, feasibilityCorrect int
+}
+
+func newEvalReport(run agent.RunInfo, mode string) *evalReport {
+	r := &evalReport{
+		CreatedAt:     time.Now().UTC(),
+		PromptVersion: run.PromptVersion,
+		ModelID:       run.ModelID,
+		Mode:          mode,
+		Categories:    map[string]*categoryScore{},
+	}
+	for _, c := range evalCategories {
+		r.Categories[c] = &categoryScore{}
+	}
+	return r
+}
+
+// score vergleicht das Ergebnis eines Falls mit der Erwartung
+func (r *evalReport) score(c evalCase, got *agent.ComplianceAssessment) caseResult {
+	result := caseResult{ID: c.ID}
+
+	for _, category := range evalCategories[:4] {
+		var expected []expectedRequirement
+		for _, e := range c.Expected.Requirements {
+			if e.Category == category {
+				expected = append(expected, e)
+			}
+		}
+		var predicted []agent.RequirementAssessment
+		for _, p := range got.Requirements {
+			if p.Category == category {
+				predicted = append(predicted, p)
+			}
+		}
+
+		score := r.Categories[category]
+		used := make([]bool, len(predicted))
+		for _, e := range expected {
+			keywords := e.Keywords
+			if len(keywords) == 0 {
+				keywords = strings.Fields(e.Title)
+			}
+			match := -1
+			for i, p := range predicted {
+				if !used[i] && containsAll(p.Title+" "+p.SourceQuote, keywords) {
+					match = i
+					break
+				}
+			}
+			if match < 0 {
+				score.FN++
+				result.Missed = append(result.Missed, category+": "+e.Title)
+				continue
+			}
+			used[match] = true
+			score.TP++
+
+			if e.Status != "" {
+				r.statusTotal++
+				if predicted[match].Status == e.Status {
+					r.statusCorrect++
+				} else {
+					result.WrongState = append(result.WrongState,
+						fmt.Sprintf("%s: %s (erwartet %s)", e.Title, predicted[match].Status, e.Status))
+				}
+			}
+		}
+		for i, p := range predicted {
+			if !used[i] {
+				score.FP++
+				result.Unexpected = append(result.Unexpected, category+": "+p.Title)
+			}
+		}
+	}
+
+	blockers := r.Categories[categoryBlocker]
+	used := make([]bool, len(got.Blockers))
+	for _, e := range c.Expected.Blockers {
+		match := -1
+		for i, b := range got.Blockers {
+			if !used[i] && b.Kind == e.Kind && containsAll(b.Description, e.Keywords) {
+				match = i
+				break
+			}
+		}
+		if match < 0 {
+			blockers.FN++
+			result.Missed = append(result.Missed, categoryBlocker+": "+strings.Join(e.Keywords, " "))
+			continue
+		}
+		used[match] = true
+		blockers.TP++
+	}
+	for i, b := range got.Blockers {
+		if !used[i] {
+			blockers.FP++
+			result.Unexpected = append(result.Unexpected, categoryBlocker+": "+b.Description)
+		}
+	}
+
+	if c.Expected.IsFeasible != nil {
+		feasible := got.IsFeasible
+		result.Feasible = &feasible
+		r.feasibilityTotal++
+		if feasible == *c.Expected.IsFeasible {
+			r.feasibilityCorrect++
+		}
+	}
+	return result
+}
+
+func (r *evalReport) finish() {
+	for _, s := range r.Categories {
+		s.finish()
+	}
+	r.StatusAccuracy, r.FeasibilityAccuracy = 1, 1
+	if r.statusTotal > 0 {
+		r.StatusAccuracy = float64(r.statusCorrect) / float64(r.statusTotal)
+	}
+	if r.feasibilityTotal > 0 {
+		r.FeasibilityAccuracy = float64(r.feasibilityCorrect) / float64(r.feasibilityTotal)
+	}
+}
+
+// metrics liefert alle Kennzahlen in fester Reihenfolge für Tabelle und Baseline-Vergleich
+func (r *evalReport) metrics() []metric {
+	var out []metric
+	for _, c := range evalCategories {
+		s := r.Categories[c]
+		if s == nil {
+			continue
+		}
+		out = append(out, metric{c + " precision", s.Precision}, metric{c + " recall", s.Recall})
+	}
+	return append(out, metric{"status accuracy", r.StatusAccuracy}, metric{"feasibility accuracy", r.FeasibilityAccuracy})
+}
+
+type metric struct {
+	name  string
+	value float64
+}
+
+// regressions nennt alle Kennzahlen, die um mehr als tolerance unter der Baseline liegen
+func regressions(current, baseline *evalReport, tolerance float64) []string {
+	previous := make(map[string]float64)
+	for _, m := range baseline.metrics() {
+		previous[m.name] = m.value
+	}
+
+	var out []string
+	for _, m := range current.metrics() {
+		before, ok := previous[m.name]
+		if ok && m.value < before-tolerance {
+			out = append(out, fmt.Sprintf("%s: %.3f -> %.3f", m.name, before, m.value))
+		}
+	}
+	return out
+}
+
+func loadReport(path string) (*evalReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r evalReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+func writeReport(path string, r *evalReport) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// containsAll prüft Stichworte ohne Beachtung von Groß-/Kleinschreibung
+func containsAll(text string, keywords []string) bool {
+	text = strings.ToLower(text)
+	for _, k := range keywords {
+		if !strings.Contains(text, strings.ToLower(strings.TrimSpace(k))) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,199 @@
+// Command eval-matching misst offline, ob das Feedback-Re-Ranking die Matches verbessert.
+//
+// Pro Firma wird das Feedback zeitlich gesplittet: die älteren Bewertungen dienen als
+// Lernsignal, die neuesten als Holdout. Für die Holdout-Ausschreibungen wird einmal ohne
+// und einmal mit Feedback gerankt und precision@k (Anteil gemochter Ausschreibungen in
+// den Top-k) verglichen.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/config"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+func main() {
+	k := flag.Int("k", 10, "Cutoff für precision@k")
+	holdout := flag.Float64("holdout", 0.2, "Anteil des neuesten Feedbacks pro Firma, der als Testmenge dient")
+	minFeedback := flag.Int("min-feedback", 5, "Firmen mit weniger Feedback werden übersprungen")
+	companyFlag := flag.String("company", "", "Nur diese Firma auswerten (UUID)")
+	flag.Parse()
+
+	if err := config.LoadEnvFiles(".env", "cmd/api/.env"); err != nil {
+		log.Fatalf("Failed to load .env file: %v", err)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("Missing required environment variable: DATABASE_URL")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		log.Fatalf("DB connection failed: %v", err)
+	}
+
+	ctx := context.Background()
+	matchingSvc := service.NewMatchingService(db)
+
+	var companyIDs []uuid.UUID
+	query := db.WithContext(ctx).Model(&domain.MatchFeedback{}).
+		Group("company_id").
+		Having("COUNT(*) >= ?", *minFeedback)
+	if *companyFlag != "" {
+		id, err := uuid.Parse(*companyFlag)
+		if err != nil {
+			log.Fatalf("Invalid company ID: %v", err)
+		}
+		query = query.Where("company_id = ?", id)
+	}
+	if err := query.Pluck("company_id", &companyIDs).Error; err != nil {
+		log.Fatalf("Load companies failed: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "COMPANY\tTRAIN\tTEST\tP@%d BEFORE\tP@%d AFTER\tDELTA\n", *k, *k)
+
+	var sumBefore, sumAfter float64
+	evaluated := 0
+	for _, companyID := range companyIDs {
+		result, err := evaluateCompany(ctx, db, matchingSvc, companyID, *k, *holdout)
+		if err != nil {
+			log.Printf("Skipping company %s: %v", companyID, err)
+			continue
+		}
+		if result == nil {
+			continue
+		}
+
+		evaluated++
+		sumBefore += result.before
+		sumAfter += result.after
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.3f\t%.3f\t%+.3f\n",
+			companyID, result.train, result.test, result.before, result.after, result.after-result.before)
+	}
+
+	if evaluated == 0 {
+		w.Flush()
+		fmt.Println("Keine Firma mit ausreichend Feedback gefunden.")
+		return
+	}
+
+	meanBefore := sumBefore / float64(evaluated)
+	meanAfter := sumAfter / float64(evaluated)
+	fmt.Fprintf(w, "MEAN (%d companies)\t\t\t%.3f\t%.3f\t%+.3f\n", evaluated, meanBefore, meanAfter, meanAfter-meanBefore)
+	w.Flush()
+}
+
+type companyResult struct {
+	train  int
+	test   int
+	before float64
+	after  float64
+}
+
+func evaluateCompany(ctx context.Context, db *gorm.DB, svc *service.MatchingService, companyID uuid.UUID, k int, holdout float64) (*companyResult, error) {
+	var feedback []domain.MatchFeedback
+	if err := db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Order("created_at ASC").
+		Find(&feedback).Error; err != nil {
+		return nil, fmt.Errorf("load feedback: %w", err)
+	}
+
+	testSize := int(math.Ceil(float64(len(feedback)) * holdout))
+	if testSize < 1 || testSize >= len(feedback) {
+		return nil, nil
+	}
+	split := len(feedback) - testSize
+	train, test := feedback[:split], feedback[split:]
+
+	liked := make(map[uuid.UUID]bool)
+	testIDs := make([]uuid.UUID, 0, len(test))
+	for _, f := range test {
+		testIDs = append(testIDs, f.TenderID)
+		if f.Vote == domain.FeedbackVoteUp {
+			liked[f.TenderID] = true
+		}
+	}
+	if len(liked) == 0 {
+		// Ohne positive Beispiele ist precision@k nicht aussagekräftig
+		return nil, nil
+	}
+
+	opts := service.MatchOptions{
+		Limit:             len(testIDs),
+		TenderIDs:         testIDs,
+		IncludeExpired:    true,
+		IncludeIneligible: true,
+	}
+
+	before, err := svc.FindMatchesForCompany(ctx, companyID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("rank without feedback: %w", err)
+	}
+
+	opts.UseFeedback = true
+	opts.FeedbackBefore = test[0].CreatedAt
+	after, err := svc.FindMatchesForCompany(ctx, companyID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("rank with feedback: %w", err)
+	}
+
+	return &companyResult{
+		train:  len(train),
+		test:   len(test),
+		before: precisionAtK(rankedIDs(before), liked, k, len(testIDs)),
+		after:  precisionAtK(rankedIDs(after), liked, k, len(testIDs)),
+	}, nil
+}
+
+// rankedIDs sortiert nach Score, unabhängig von der Radius-Priorisierung des Feeds
+func rankedIDs(matches []domain.Match) []uuid.UUID {
+	sorted := append([]domain.Match(nil), matches...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Score > sorted[j].Score })
+
+	ids := make([]uuid.UUID, len(sorted))
+	for i, m := range sorted {
+		ids[i] = m.TenderID
+	}
+	return ids
+}
+
+// precisionAtK teilt durch min(k, Testmenge), damit kleine Testmengen nicht bestraft werden
+func precisionAtK(ranked []uuid.UUID, relevant map[uuid.UUID]bool, k, candidates int) float64 {
+	cutoff := k
+	if candidates < cutoff {
+		cutoff = candidates
+	}
+	if cutoff == 0 {
+		return 0
+	}
+
+	hits := 0
+	for i, id := range ranked {
+		if i >= cutoff {
+			break
+		}
+		if relevant[id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(cutoff)
+}
@@ -0,0 +1,360 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// BidDraftWordsPerPage ist die Schätzung für eine DIN-A4-Seite Fließtext (11 pt)
+const BidDraftWordsPerPage = 400
+
+// maxBidOutlineSections begrenzt die Gliederung, damit ein Entwurf nicht ausufert
+const maxBidOutlineSections = 15
+
+// BidOutlineSection ist ein Abschnitt der Gliederung des Konzeptteils
+type BidOutlineSection struct {
+	Heading        string `json:"heading" jsonschema:"description=Überschrift des Abschnitts (bei vorgegebener Gliederung wörtlich übernehmen)"`
+	Requirements   string `json:"requirements" jsonschema:"description=Was der Abschnitt laut Unterlagen behandeln muss (Leistungsbeschreibung, Wertungskriterium)"`
+	AwardCriterion string `json:"award_criterion,omitempty" jsonschema:"description=Zugehöriges Zuschlags- bzw. Wertungskriterium mit Gewichtung (leer, wenn keines)"`
+	PageLimit      int    `json:"page_limit,omitempty" jsonschema:"description=Vorgegebene Höchstzahl an Seiten (0, wenn keine Vorgabe)"`
+}
+
+// bidOutline ist das Ziel-Struct für die Gliederung
+type bidOutline struct {
+	Sections []BidOutlineSection `json:"sections" jsonschema:"description=Abschnitte des Konzeptteils in der geforderten Reihenfolge"`
+}
+
+func (o *bidOutline) validate() error {
+	var problems []string
+	if len(o.Sections) == 0 {
+		problems = append(problems, "sections is empty")
+	}
+	if len(o.Sections) > maxBidOutlineSections {
+		problems = append(problems, fmt.Sprintf("more than %d sections", maxBidOutlineSections))
+	}
+	for i, s := range o.Sections {
+		if strings.TrimSpace(s.Heading) == "" {
+			problems = append(problems, fmt.Sprintf("sections[%d].heading is empty", i))
+		}
+		if s.PageLimit < 0 {
+			problems = append(problems, fmt.Sprintf("sections[%d].page_limit is negative", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid outline: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// bidSectionText ist das Ziel-Struct für einen ausformulierten Abschnitt
+type bidSectionText struct {
+	Content        string   `json:"content" jsonschema:"description=Text des Abschnitts in Markdown, ohne die Abschnittsüberschrift"`
+	UsedReferences []string `json:"used_references,omitempty" jsonschema:"description=Namen der im Text genannten Referenzprojekte, exakt wie im Unternehmensprofil"`
+	UsedCVs        []string `json:"used_cvs,omitempty" jsonschema:"description=Namen der im Text genannten Personen bzw. Lebensläufe, exakt wie im Unternehmensprofil"`
+}
+
+func (t *bidSectionText) validate() error {
+	if strings.TrimSpace(t.Content) == "" {
+		return errors.New("content is empty")
+	}
+	return nil
+}
+
+// BidDraftInput enthält alles, was der Entwurf braucht
+type BidDraftInput struct {
+	TenderTitle   string
+	AwardCriteria string
+	// Outline ist die vorgegebene Gliederung; leer -> wird aus den Unterlagen abgeleitet
+	Outline []BidOutlineSection
+	// Passages sind die relevanten Textstellen (Leistungsbeschreibung, Wertung)
+	Passages []Passage
+	Profile  CompanyProfile
+}
+
+// BidDraftSection ist ein ausformulierter Abschnitt des Entwurfs
+type BidDraftSection struct {
+	Heading        string  `json:"heading"`
+	Requirements   string  `json:"requirements,omitempty"`
+	AwardCriterion string  `json:"award_criterion,omitempty"`
+	PageLimit      int     `json:"page_limit,omitempty"`
+	Content        string  `json:"content"`
+	WordCount      int     `json:"word_count"`
+	EstimatedPages float64 `json:"estimated_pages"`
+	// OverPageLimit: auch nach dem Kürzen länger als erlaubt
+	OverPageLimit bool     `json:"over_page_limit,omitempty"`
+	References    []string `json:"references,omitempty"`
+	CVs           []string `json:"cvs,omitempty"`
+}
+
+// BidDraftResult ist der Entwurf in Gliederungsreihenfolge. Fehlgeschlagene Abschnitte
+// bleiben mit leerem Text enthalten und stehen zusätzlich in FailedSections.
+type BidDraftResult struct {
+	Sections       []BidDraftSection `json:"sections"`
+	OutlineDerived bool              `json:"outline_derived"`
+	FailedSections []FailedSection   `json:"failed_sections,omitempty"`
+	Run            RunInfo           `json:"-"`
+}
+
+// BidDraftAgent entwirft den Konzeptteil eines Angebots
+type BidDraftAgent struct {
+	outline        *structuredCall[bidOutline]
+	write          *structuredCall[bidSectionText]
+	prompts        *promptResolver
+	maxConcurrency int
+}
+
+// NewBidDraftAgent nutzt dieselbe Modellkonfiguration wie der Compliance-Agent
+func NewBidDraftAgent(ctx context.Context, cfg ComplianceAgentConfig) (*BidDraftAgent, error) {
+	chatModel, modelName, err := newOpenRouterChatModel(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Model = modelName
+	return newBidDraftAgent(chatModel, cfg)
+}
+
+func newBidDraftAgent(chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*BidDraftAgent, error) {
+	callCfg := structuredCallConfig{
+		ForceToolChoice: !cfg.DisableForcedToolChoice,
+		MaxRepairs:      cfg.MaxRepairAttempts,
+	}
+	if callCfg.MaxRepairs == 0 {
+		callCfg.MaxRepairs = defaultMaxRepairAttempts
+	}
+
+	outline, err := newStructuredCall[bidOutline](chatModel,
+		"submit_bid_outline", "Reicht die Gliederung des Konzeptteils ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Der Abschnittstext bekommt das Firmenprofil als Tool-Kontext
+	writeCfg := callCfg
+	writeCfg.ExtraTools = []*schema.ToolInfo{companyProfileTool()}
+	write, err := newStructuredCall[bidSectionText](chatModel,
+		"submit_bid_section", "Reicht den ausformulierten Abschnitt ein.", writeCfg)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := newPromptResolver(AgentBidDraft, cfg, &bidOutline{}, &bidSectionText{}, &CompanyProfile{})
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrency := cfg.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+	return &BidDraftAgent{outline: outline, write: write, prompts: prompts, maxConcurrency: maxConcurrency}, nil
+}
+
+// Generate leitet bei Bedarf die Gliederung ab und schreibt dann jeden Abschnitt einzeln
+// (parallel, begrenzt durch MaxConcurrency). Abschnitte über der Seitenbegrenzung werden
+// einmal zum Kürzen zurückgegeben. Ein Fehler kommt nur, wenn die Gliederung oder alle
+// Abschnitte fehlschlagen.
+func (a *BidDraftAgent) Generate(ctx context.Context, input BidDraftInput) (*BidDraftResult, error) {
+	if a == nil || a.outline == nil || a.write == nil {
+		return nil, errors.New("bid draft agent is not initialized")
+	}
+
+	ctx, run, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenderContext := bidTenderContext(input)
+	result := &BidDraftResult{Run: run.info}
+
+	outline := input.Outline
+	if len(outline) == 0 {
+		derived, err := a.outline.invoke(ctx, []*schema.Message{
+			schema.SystemMessage(run.prompts["outline"]),
+			schema.UserMessage(tenderContext),
+		})
+		if err != nil {
+			return nil, err
+		}
+		outline = derived.Sections
+		result.OutlineDerived = true
+	}
+
+	profileMessages, err := profileContextMessages(input.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	result.Sections = make([]BidDraftSection, len(outline))
+	sem := make(chan struct{}, a.maxConcurrency)
+
+	for i, section := range outline {
+		result.Sections[i] = BidDraftSection{
+			Heading:        strings.TrimSpace(section.Heading),
+			Requirements:   strings.TrimSpace(section.Requirements),
+			AwardCriterion: strings.TrimSpace(section.AwardCriterion),
+			PageLimit:      section.PageLimit,
+		}
+
+		wg.Add(1)
+		go func(i int, section BidOutlineSection) {
+			defer wg.Done()
+
+			messages := []*schema.Message{
+				schema.SystemMessage(run.prompts["section"]),
+				schema.UserMessage(tenderContext),
+			}
+			messages = append(messages, profileMessages...)
+			messages = append(messages, schema.UserMessage(sectionAssignment(section)))
+
+			var text bidSectionText
+			var err error
+			select {
+			case sem <- struct{}{}:
+				text, err = a.writeSection(ctx, messages, section.PageLimit)
+				<-sem
+			case <-ctx.Done():
+				err = &TransportError{Tool: a.write.tool.Name, Err: ctx.Err()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.FailedSections = append(result.FailedSections, FailedSection{SectionID: i, Label: section.Heading, Error: err.Error()})
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			out := &result.Sections[i]
+			out.Content = strings.TrimSpace(text.Content)
+			out.Measure()
+			out.References = knownNames(text.UsedReferences, referenceNames(input.Profile.References))
+			out.CVs = knownNames(text.UsedCVs, documentNames(input.Profile.EmployeeCVs))
+		}(i, section)
+	}
+	wg.Wait()
+	sort.Slice(result.FailedSections, func(i, j int) bool {
+		return result.FailedSections[i].SectionID < result.FailedSections[j].SectionID
+	})
+
+	if len(result.FailedSections) == len(outline) {
+		return nil, fmt.Errorf("all %d sections failed: %w", len(outline), firstErr)
+	}
+	return result, nil
+}
+
+// writeSection schreibt einen Abschnitt und fordert bei überschrittener Seitenbegrenzung
+// einmal eine gekürzte Fassung an
+func (a *BidDraftAgent) writeSection(ctx context.Context, messages []*schema.Message, pageLimit int) (bidSectionText, error) {
+	text, err := a.write.invoke(ctx, messages)
+	if err != nil || pageLimit <= 0 {
+		return text, err
+	}
+
+	words := len(strings.Fields(text.Content))
+	maxWords := pageLimit * BidDraftWordsPerPage
+	if words <= maxWords {
+		return text, nil
+	}
+
+	shorten := fmt.Sprintf("Der folgende Entwurf hat %d Wörter, erlaubt sind höchstens %d (%d Seiten). Kürze ihn auf höchstens %d Wörter, ohne Bezüge auf Anforderungen, Referenzen oder Personal zu verlieren:\n\n%s",
+		words, maxWords, pageLimit, maxWords, text.Content)
+	shorter, err := a.write.invoke(ctx, append(messages, schema.UserMessage(shorten)))
+	if err != nil {
+		// Der lange Entwurf ist besser als keiner; OverPageLimit markiert ihn
+		return text, nil
+	}
+	return shorter, nil
+}
+
+// bidTenderContext fasst Titel, Zuschlagskriterien und Textstellen für den Prompt zusammen
+func bidTenderContext(input BidDraftInput) string {
+	var b strings.Builder
+	if title := strings.TrimSpace(input.TenderTitle); title != "" {
+		fmt.Fprintf(&b, "AUSSCHREIBUNG: %s\n\n", title)
+	}
+	if criteria := strings.TrimSpace(input.AwardCriteria); criteria != "" {
+		fmt.Fprintf(&b, "ZUSCHLAGSKRITERIEN:\n%s\n\n", criteria)
+	}
+	if len(input.Passages) > 0 {
+		b.WriteString("TEXTSTELLEN AUS DEN UNTERLAGEN:\n")
+		b.WriteString(formatPassages(input.Passages))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// sectionAssignment beschreibt den zu schreibenden Abschnitt
+func sectionAssignment(section BidOutlineSection) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ABSCHNITT: %s\n", strings.TrimSpace(section.Heading))
+	if r := strings.TrimSpace(section.Requirements); r != "" {
+		fmt.Fprintf(&b, "INHALT: %s\n", r)
+	}
+	if c := strings.TrimSpace(section.AwardCriterion); c != "" {
+		fmt.Fprintf(&b, "WERTUNGSKRITERIUM: %s\n", c)
+	}
+	if section.PageLimit > 0 {
+		fmt.Fprintf(&b, "UMFANG: höchstens %d Seiten (ca. %d Wörter)\n", section.PageLimit, section.PageLimit*BidDraftWordsPerPage)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Measure berechnet Wortzahl, geschätzte Seiten (eine Nachkommastelle) und ob die
+// Seitenbegrenzung überschritten ist
+func (s *BidDraftSection) Measure() {
+	s.WordCount = len(strings.Fields(s.Content))
+	s.EstimatedPages = math.Round(float64(s.WordCount)/BidDraftWordsPerPage*10) / 10
+	s.OverPageLimit = s.PageLimit > 0 && s.EstimatedPages > float64(s.PageLimit)
+}
+
+// knownNames behält nur Namen, die im Profil vorkommen (in der Schreibweise des Profils)
+func knownNames(used, known []string) []string {
+	byKey := make(map[string]string, len(known))
+	for _, name := range known {
+		byKey[normalizeText(name)] = name
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range used {
+		key := normalizeText(name)
+		if profileName, ok := byKey[key]; ok && !seen[key] {
+			seen[key] = true
+			names = append(names, profileName)
+		}
+	}
+	return names
+}
+
+func referenceNames(refs []ProjectReference) []string {
+	names := make([]string, 0, len(refs))
+	for _, r := range refs {
+		if r.Name != "" {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+func documentNames(docs []ProfileDocument) []string {
+	names := make([]string, 0, len(docs))
+	for _, d := range docs {
+		if d.Name != "" {
+			names = append(names, d.Name)
+		}
+	}
+	return names
+}
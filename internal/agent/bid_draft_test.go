@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func newTestBidDraftAgent(t *testing.T, fake *scriptedModel) *BidDraftAgent {
+	t.Helper()
+	a, err := newBidDraftAgent(fake, ComplianceAgentConfig{MaxConcurrency: 2})
+	if err != nil {
+		t.Fatalf("newBidDraftAgent: %v", err)
+	}
+	return a
+}
+
+func bidDraftInput() BidDraftInput {
+	return BidDraftInput{
+		TenderTitle:   "Sanierung Grundschule Nord",
+		AwardCriteria: "Preis 60 %, Konzept 40 %",
+		Passages: []Passage{
+			{Document: "Leistungsbeschreibung.pdf", Page: 3, Text: "Das Konzept umfasst höchstens 4 Seiten."},
+		},
+		Profile: CompanyProfile{
+			Name:        "Muster Bau GmbH",
+			References:  []ProjectReference{{Name: "Schule Süd", Year: 2023}},
+			EmployeeCVs: []ProfileDocument{{Name: "Anna Beispiel"}},
+		},
+	}
+}
+
+func TestBidDraftDerivesOutlineAndWritesSections(t *testing.T) {
+	fake := newScriptedModel()
+	fake.state.reply = func(call recordedCall) scriptedReply {
+		if call.tools[0].Name == "submit_bid_outline" {
+			return toolCallReply("submit_bid_outline", `{"sections": [
+				{"heading": "Vorgehen", "requirements": "Bauablauf im laufenden Schulbetrieb", "award_criterion": "Konzept 40 %", "page_limit": 2},
+				{"heading": "Personal", "requirements": "Schlüsselpersonal"}
+			]}`)
+		}
+		if strings.Contains(call.messages[len(call.messages)-1].Content, "ABSCHNITT: Personal") {
+			return toolCallReply("submit_bid_section", `{"content": "Die Bauleitung übernimmt Anna Beispiel.", "used_cvs": ["anna beispiel", "Max Erfunden"]}`)
+		}
+		return toolCallReply("submit_bid_section", `{"content": "Wir bauen in Abschnitten wie bei der Schule Süd.", "used_references": ["Schule Süd", "Rathaus Ost"]}`)
+	}
+	a := newTestBidDraftAgent(t, fake)
+
+	result, err := a.Generate(context.Background(), bidDraftInput())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !result.OutlineDerived || len(result.Sections) != 2 {
+		t.Fatalf("expected derived outline with 2 sections, got %+v", result)
+	}
+
+	method := result.Sections[0]
+	if method.Heading != "Vorgehen" || method.PageLimit != 2 || method.WordCount != 9 || method.OverPageLimit {
+		t.Errorf("unexpected section: %+v", method)
+	}
+	if len(method.References) != 1 || method.References[0] != "Schule Süd" {
+		t.Errorf("expected only known references, got %v", method.References)
+	}
+	if staff := result.Sections[1]; len(staff.CVs) != 1 || staff.CVs[0] != "Anna Beispiel" {
+		t.Errorf("expected CV name as in profile, got %v", staff.CVs)
+	}
+
+	// Abschnitt mit Seitenbegrenzung und Firmenprofil als Tool-Kontext
+	var assignment string
+	for _, call := range fake.callsFor("submit_bid_section") {
+		last := call.messages[len(call.messages)-1].Content
+		if strings.Contains(last, "ABSCHNITT: Vorgehen") {
+			assignment = last
+			if call.messages[2].Role != schema.Assistant || call.messages[3].Role != schema.Tool {
+				t.Errorf("expected company profile as tool context")
+			}
+		}
+	}
+	if !strings.Contains(assignment, "UMFANG: höchstens 2 Seiten (ca. 800 Wörter)") {
+		t.Errorf("unexpected assignment: %q", assignment)
+	}
+}
+
+func TestBidDraftShortensSectionOverPageLimit(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("Wort ", BidDraftWordsPerPage+50))
+	fake := newScriptedModel(
+		toolCallReply("submit_bid_section", `{"content": "`+long+`"}`),
+		toolCallReply("submit_bid_section", `{"content": "Kurze Fassung."}`),
+	)
+	a := newTestBidDraftAgent(t, fake)
+
+	input := bidDraftInput()
+	input.Outline = []BidOutlineSection{{Heading: "Konzept", PageLimit: 1}}
+	result, err := a.Generate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.OutlineDerived {
+		t.Errorf("expected given outline to be used")
+	}
+	if n := len(fake.callsFor("submit_bid_outline")); n != 0 {
+		t.Errorf("expected no outline call, got %d", n)
+	}
+
+	section := result.Sections[0]
+	if section.Content != "Kurze Fassung." || section.OverPageLimit {
+		t.Fatalf("expected shortened section, got %d words", section.WordCount)
+	}
+	calls := fake.callsFor("submit_bid_section")
+	if len(calls) != 2 || !strings.Contains(calls[1].messages[len(calls[1].messages)-1].Content, "höchstens 400") {
+		t.Errorf("expected one shortening request")
+	}
+}
+
+func TestBidDraftKeepsPartialResults(t *testing.T) {
+	unavailable := errors.New("503 service unavailable")
+	fake := newScriptedModel()
+	fake.state.reply = func(call recordedCall) scriptedReply {
+		if strings.Contains(call.messages[len(call.messages)-1].Content, "ABSCHNITT: Qualität") {
+			return scriptedReply{err: unavailable}
+		}
+		return toolCallReply("submit_bid_section", `{"content": "Text"}`)
+	}
+	a := newTestBidDraftAgent(t, fake)
+
+	input := bidDraftInput()
+	input.Outline = []BidOutlineSection{{Heading: "Vorgehen"}, {Heading: "Qualität"}}
+	result, err := a.Generate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(result.Sections) != 2 || result.Sections[1].Content != "" {
+		t.Fatalf("expected failed section to stay with empty content, got %+v", result.Sections)
+	}
+	if len(result.FailedSections) != 1 || result.FailedSections[0].SectionID != 1 || result.FailedSections[0].Label != "Qualität" {
+		t.Errorf("unexpected failed sections: %+v", result.FailedSections)
+	}
+
+	input.Outline = input.Outline[1:]
+	_, err = a.Generate(context.Background(), input)
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || !errors.Is(err, unavailable) {
+		t.Fatalf("expected wrapped *TransportError when all sections fail, got %v", err)
+	}
+}
@@ -0,0 +1,308 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Europe/Berlin auch in Containern ohne Zeitzonen-Datenbank
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Arten einer Bieterfrage
+const (
+	BidderQuestionKindContradiction = "widerspruch"
+	BidderQuestionKindAmbiguity     = "unklarheit"
+	BidderQuestionKindGap           = "luecke"
+)
+
+// Formate, in denen das Modell die Frist für Bieterfragen angibt
+const (
+	questionDeadlineDateTimeLayout = "2006-01-02T15:04"
+	questionDeadlineDateLayout     = "2006-01-02"
+)
+
+// BidderQuestionDraft ist ein Vorschlag für eine Bieterfrage an die Vergabestelle
+type BidderQuestionDraft struct {
+	Subject        string `json:"subject" jsonschema:"description=Kurzer Betreff, z. B. 'Widerspruch Ausführungsfrist'"`
+	Question       string `json:"question" jsonschema:"description=Ausformulierte, sachliche Frage an die Vergabestelle (Sie-Form, ohne Firmennamen)"`
+	Reason         string `json:"reason" jsonschema:"description=Warum die Frage nötig ist (interner Hinweis, wird nicht eingereicht)"`
+	Kind           string `json:"kind" jsonschema:"enum=widerspruch,enum=unklarheit,enum=luecke,description=widerspruch = Angaben widersprechen sich; unklarheit = mehrdeutige Angabe; luecke = für das Angebot nötige Angabe fehlt"`
+	SourceQuote    string `json:"source_quote" jsonschema:"description=Wörtliches Zitat der betroffenen Textstelle (nicht umformulieren)"`
+	SourceDocument string `json:"source_document,omitempty" jsonschema:"description=Name des Dokuments laut [DOKUMENT: ...]-Markierung (leer, wenn nicht erkennbar)"`
+	SourcePage     int    `json:"source_page,omitempty" jsonschema:"description=Seitenzahl laut [SEITE: ...]-Markierung (0, wenn nicht erkennbar)"`
+}
+
+// bidderQuestionProposal ist das Ziel-Struct je Abschnitt
+type bidderQuestionProposal struct {
+	QuestionDeadline string                `json:"question_deadline,omitempty" jsonschema:"description=Frist für Bieterfragen laut Unterlagen als YYYY-MM-DDTHH:MM oder YYYY-MM-DD (leer, wenn im Abschnitt nicht genannt)"`
+	DeadlineQuote    string                `json:"deadline_quote,omitempty" jsonschema:"description=Wörtliches Zitat der Fristangabe (leer, wenn keine Frist genannt)"`
+	Questions        []BidderQuestionDraft `json:"questions" jsonschema:"description=Vorgeschlagene Bieterfragen (leer, wenn keine nötig)"`
+}
+
+func (p *bidderQuestionProposal) validate() error {
+	var problems []string
+	if p.QuestionDeadline != "" {
+		if _, _, ok := parseQuestionDeadline(p.QuestionDeadline); !ok {
+			problems = append(problems, fmt.Sprintf("question_deadline %q is not YYYY-MM-DDTHH:MM or YYYY-MM-DD", p.QuestionDeadline))
+		}
+	}
+	for i, q := range p.Questions {
+		if strings.TrimSpace(q.Question) == "" {
+			problems = append(problems, fmt.Sprintf("questions[%d].question is empty", i))
+		}
+		if strings.TrimSpace(q.SourceQuote) == "" {
+			problems = append(problems, fmt.Sprintf("questions[%d].source_quote is empty", i))
+		}
+		if q.SourcePage < 0 {
+			problems = append(problems, fmt.Sprintf("questions[%d].source_page is negative", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid proposal: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseQuestionDeadline liest die Frist; hasTime ist false bei reinen Datumsangaben
+func parseQuestionDeadline(value string) (t time.Time, hasTime bool, ok bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(questionDeadlineDateTimeLayout, value); err == nil {
+		return t, true, true
+	}
+	if t, err := time.Parse(questionDeadlineDateLayout, value); err == nil {
+		return t, false, true
+	}
+	return time.Time{}, false, false
+}
+
+// ComplianceFinding ist ein Befund aus dem Compliance-Check als Hinweis für Bieterfragen
+type ComplianceFinding struct {
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+	SourceQuote string `json:"source_quote,omitempty"`
+	Evidence    string `json:"evidence,omitempty"`
+}
+
+// BidderQuestionInput sind die Unterlagen (abschnittsweise) und die Befunde des letzten Checks
+type BidderQuestionInput struct {
+	Sections []DocumentSection
+	Findings []ComplianceFinding
+}
+
+// BidderQuestionResult enthält die zusammengeführten Vorschläge und die gefundene Frist
+type BidderQuestionResult struct {
+	Questions []BidderQuestionDraft `json:"questions"`
+	// QuestionDeadline ist die früheste genannte Frist (nil, wenn keine gefunden);
+	// reine Datumsangaben gelten bis Tagesende
+	QuestionDeadline *time.Time      `json:"question_deadline,omitempty"`
+	DeadlineQuote    string          `json:"deadline_quote,omitempty"`
+	SectionsTotal    int             `json:"sections_total"`
+	FailedSections   []FailedSection `json:"failed_sections,omitempty"`
+	Run              RunInfo         `json:"-"`
+}
+
+// BidderQuestionAgent schlägt Bieterfragen vor
+type BidderQuestionAgent struct {
+	submit         *structuredCall[bidderQuestionProposal]
+	prompts        *promptResolver
+	maxConcurrency int
+	location       *time.Location
+}
+
+// NewBidderQuestionAgent nutzt dieselbe Modellkonfiguration wie der Compliance-Agent
+func NewBidderQuestionAgent(ctx context.Context, cfg ComplianceAgentConfig) (*BidderQuestionAgent, error) {
+	chatModel, _, err := newOpenRouterChatModel(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newBidderQuestionAgent(chatModel, cfg)
+}
+
+func newBidderQuestionAgent(chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*BidderQuestionAgent, error) {
+	callCfg := structuredCallConfig{
+		ForceToolChoice: !cfg.DisableForcedToolChoice,
+		MaxRepairs:      cfg.MaxRepairAttempts,
+	}
+	if callCfg.MaxRepairs == 0 {
+		callCfg.MaxRepairs = defaultMaxRepairAttempts
+	}
+
+	submit, err := newStructuredCall[bidderQuestionProposal](chatModel,
+		"submit_bidder_questions", "Reicht die vorgeschlagenen Bieterfragen und die Frist für Bieterfragen ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := newPromptResolver(AgentBidderQuestions, cfg, &bidderQuestionProposal{})
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrency := cfg.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+
+	// Fristen in deutschen Unterlagen sind Ortszeit
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		return nil, fmt.Errorf("load time zone: %w", err)
+	}
+	return &BidderQuestionAgent{submit: submit, prompts: prompts, maxConcurrency: maxConcurrency, location: location}, nil
+}
+
+// Propose wertet jeden Abschnitt einzeln aus (parallel, begrenzt durch MaxConcurrency) und
+// führt doppelte Fragen zusammen. Schlagen einzelne Abschnitte fehl, enthält das Ergebnis
+// die übrigen; ein Fehler kommt nur, wenn alle fehlschlagen.
+func (a *BidderQuestionAgent) Propose(ctx context.Context, input BidderQuestionInput) (*BidderQuestionResult, error) {
+	if a == nil || a.submit == nil {
+		return nil, errors.New("bidder question agent is not initialized")
+	}
+	ctx, run, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &BidderQuestionResult{Questions: []BidderQuestionDraft{}, SectionsTotal: len(input.Sections), Run: run.info}
+	if len(input.Sections) == 0 {
+		return result, nil
+	}
+
+	findings := formatFindings(input.Findings)
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	proposals := make([]*bidderQuestionProposal, len(input.Sections))
+	sem := make(chan struct{}, a.maxConcurrency)
+
+	for i, section := range input.Sections {
+		wg.Add(1)
+		go func(i int, section DocumentSection) {
+			defer wg.Done()
+
+			messages := []*schema.Message{schema.SystemMessage(run.prompts["system"])}
+			if findings != "" {
+				messages = append(messages, schema.UserMessage(findings))
+			}
+			messages = append(messages, schema.UserMessage(section.Text))
+
+			var proposal bidderQuestionProposal
+			var err error
+			select {
+			case sem <- struct{}{}:
+				proposal, err = a.submit.invoke(ctx, messages)
+				<-sem
+			case <-ctx.Done():
+				err = &TransportError{Tool: a.submit.tool.Name, Err: ctx.Err()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.FailedSections = append(result.FailedSections, FailedSection{SectionID: section.ID, Label: section.Label, Error: err.Error()})
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			proposals[i] = &proposal
+		}(i, section)
+	}
+	wg.Wait()
+	sort.Slice(result.FailedSections, func(i, j int) bool {
+		return result.FailedSections[i].SectionID < result.FailedSections[j].SectionID
+	})
+
+	if len(result.FailedSections) == len(input.Sections) {
+		return nil, fmt.Errorf("all %d sections failed: %w", len(input.Sections), firstErr)
+	}
+
+	var all []BidderQuestionDraft
+	for _, p := range proposals {
+		if p == nil {
+			continue
+		}
+		all = append(all, p.Questions...)
+
+		// Mehrere Fristangaben -> die früheste gilt
+		if deadline, ok := a.deadline(p.QuestionDeadline); ok {
+			if result.QuestionDeadline == nil || deadline.Before(*result.QuestionDeadline) {
+				result.QuestionDeadline = &deadline
+				result.DeadlineQuote = strings.TrimSpace(p.DeadlineQuote)
+			}
+		}
+	}
+	result.Questions = mergeBidderQuestions(all)
+	return result, nil
+}
+
+// deadline rechnet die Fristangabe in Ortszeit um; reine Datumsangaben gelten bis 23:59 Uhr
+func (a *BidderQuestionAgent) deadline(value string) (time.Time, bool) {
+	if strings.TrimSpace(value) == "" {
+		return time.Time{}, false
+	}
+	t, hasTime, ok := parseQuestionDeadline(value)
+	if !ok {
+		return time.Time{}, false
+	}
+	hour, minute := 23, 59
+	if hasTime {
+		hour, minute = t.Hour(), t.Minute()
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, a.location), true
+}
+
+// formatFindings listet die offenen Befunde des Compliance-Checks für den Prompt
+func formatFindings(findings []ComplianceFinding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("BEFUNDE DER COMPLIANCE-PRÜFUNG (offene oder nicht erfüllte Anforderungen):\n")
+	for _, f := range findings {
+		fmt.Fprintf(&b, "- %s (%s)", f.Title, f.Status)
+		if f.SourceQuote != "" {
+			fmt.Fprintf(&b, ": „%s“", f.SourceQuote)
+		}
+		if f.Evidence != "" {
+			fmt.Fprintf(&b, " – %s", f.Evidence)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// mergeBidderQuestions führt Fragen zur selben Textstelle bzw. mit fast gleichem Wortlaut zusammen
+func mergeBidderQuestions(questions []BidderQuestionDraft) []BidderQuestionDraft {
+	merged := []BidderQuestionDraft{}
+	var questionTokens []map[string]bool
+
+	for _, q := range questions {
+		tokens := wordSet(q.Question)
+		duplicate := -1
+		for i, m := range merged {
+			sameQuote := normalizeText(m.SourceQuote) == normalizeText(q.SourceQuote)
+			if sameQuote || jaccard(tokens, questionTokens[i]) >= duplicateQuoteSimilarity {
+				duplicate = i
+				break
+			}
+		}
+
+		if duplicate < 0 {
+			merged = append(merged, q)
+			questionTokens = append(questionTokens, tokens)
+			continue
+		}
+		if merged[duplicate].SourcePage == 0 && q.SourcePage > 0 {
+			merged[duplicate].SourceDocument, merged[duplicate].SourcePage = q.SourceDocument, q.SourcePage
+		}
+	}
+	return merged
+}
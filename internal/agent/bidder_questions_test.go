@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestBidderQuestionAgent(t *testing.T, fake *scriptedModel) *BidderQuestionAgent {
+	t.Helper()
+	a, err := newBidderQuestionAgent(fake, ComplianceAgentConfig{MaxConcurrency: 2})
+	if err != nil {
+		t.Fatalf("newBidderQuestionAgent: %v", err)
+	}
+	return a
+}
+
+func TestBidderQuestionsMergeSectionsAndUseEarliestDeadline(t *testing.T) {
+	fake := newScriptedModel()
+	fake.state.reply = func(call recordedCall) scriptedReply {
+		section := call.messages[len(call.messages)-1].Content
+		switch {
+		case strings.Contains(section, "kaputt"):
+			return scriptedReply{err: errors.New("timeout")}
+		case strings.Contains(section, "Teil A"):
+			return toolCallReply("submit_bidder_questions", `{"question_deadline": "2026-03-10T12:00", "deadline_quote": "Fragen bis 10.03. 12 Uhr", "questions": [
+				{"subject": "Ausführungsfrist", "question": "Welche Ausführungsfrist gilt?", "reason": "Fristen widersprechen sich", "kind": "widerspruch", "source_quote": "Ausführung bis 30.06."}
+			]}`)
+		default:
+			return toolCallReply("submit_bidder_questions", `{"question_deadline": "2026-03-05", "deadline_quote": "Bieterfragen bis 05.03.", "questions": [
+				{"subject": "Frist", "question": "Gilt die Ausführungsfrist 30.06. oder 31.07.?", "reason": "Fristen widersprechen sich", "kind": "widerspruch", "source_quote": "ausführung bis 30.06.", "source_document": "Leistungsbeschreibung.pdf", "source_page": 4},
+				{"subject": "Mengen", "question": "Wie viele Stellplätze sind vorzuhalten?", "reason": "Für die Kalkulation nötig", "kind": "luecke", "source_quote": "ausreichend Stellplätze"}
+			]}`)
+		}
+	}
+	a := newTestBidderQuestionAgent(t, fake)
+
+	result, err := a.Propose(context.Background(), BidderQuestionInput{Sections: []DocumentSection{
+		{ID: 0, Label: "Teil A", Text: "Teil A"},
+		{ID: 1, Label: "Teil B", Text: "Teil B kaputt"},
+		{ID: 2, Label: "Teil C", Text: "Teil C"},
+	}})
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+
+	if len(result.FailedSections) != 1 || result.FailedSections[0].SectionID != 1 {
+		t.Fatalf("expected section 1 to fail, got %+v", result.FailedSections)
+	}
+	if len(result.Questions) != 2 {
+		t.Fatalf("expected 2 merged questions, got %+v", result.Questions)
+	}
+	if q := result.Questions[0]; q.SourceDocument != "Leistungsbeschreibung.pdf" || q.SourcePage != 4 {
+		t.Errorf("expected source reference to be filled in, got %+v", q)
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	want := time.Date(2026, 3, 5, 23, 59, 0, 0, berlin)
+	if result.QuestionDeadline == nil || !result.QuestionDeadline.Equal(want) {
+		t.Fatalf("expected earliest deadline %v, got %v", want, result.QuestionDeadline)
+	}
+	if result.DeadlineQuote != "Bieterfragen bis 05.03." {
+		t.Errorf("unexpected deadline quote %q", result.DeadlineQuote)
+	}
+}
+
+func TestBidderQuestionsIncludeFindings(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_bidder_questions", `{"questions": []}`))
+	a := newTestBidderQuestionAgent(t, fake)
+
+	result, err := a.Propose(context.Background(), BidderQuestionInput{
+		Sections: []DocumentSection{{ID: 0, Label: "Unterlagen", Text: "..."}},
+		Findings: []ComplianceFinding{{Title: "Referenzen", Status: "unklar", SourceQuote: "vergleichbare Referenzen"}},
+	})
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+	if result.QuestionDeadline != nil {
+		t.Errorf("expected no deadline, got %v", result.QuestionDeadline)
+	}
+
+	msgs := fake.callsFor("submit_bidder_questions")[0].messages
+	if len(msgs) != 3 || !strings.Contains(msgs[1].Content, "- Referenzen (unklar): „vergleichbare Referenzen“") {
+		t.Fatalf("expected findings message before the section, got %d messages", len(msgs))
+	}
+}
+
+func TestBidderQuestionsRepairInvalidDeadline(t *testing.T) {
+	fake := newScriptedModel(
+		toolCallReply("submit_bidder_questions", `{"question_deadline": "10.03.2026", "questions": []}`),
+		toolCallReply("submit_bidder_questions", `{"question_deadline": "2026-03-10T10:00", "questions": []}`),
+	)
+	a := newTestBidderQuestionAgent(t, fake)
+
+	result, err := a.Propose(context.Background(), BidderQuestionInput{Sections: []DocumentSection{{ID: 0, Label: "Unterlagen", Text: "..."}}})
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+	if result.QuestionDeadline == nil || result.QuestionDeadline.Hour() != 10 {
+		t.Fatalf("expected repaired deadline, got %v", result.QuestionDeadline)
+	}
+	if n := len(fake.callsFor("submit_bidder_questions")); n != 2 {
+		t.Errorf("expected one repair, got %d calls", n)
+	}
+}
@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Kategorien eines Checklisteneintrags
+const (
+	ChecklistCategoryFormular        = "formular"
+	ChecklistCategoryEigenerklaerung = "eigenerklaerung"
+	ChecklistCategoryNachweis        = "nachweis"
+	ChecklistCategoryPreisblatt      = "preisblatt"
+	ChecklistCategoryKonzept         = "konzept"
+	ChecklistCategorySonstiges       = "sonstiges"
+)
+
+// Zeitpunkt, zu dem ein Dokument vorzulegen ist
+const (
+	SubmissionWithBid   = "mit_angebot"
+	SubmissionOnRequest = "auf_verlangen"
+)
+
+// ChecklistItem ist ein einzureichendes Dokument laut Vergabeunterlagen
+type ChecklistItem struct {
+	Title            string `json:"title" jsonschema:"description=Bezeichnung des Dokuments, z. B. 'Eigenerklärung zur Eignung (Formblatt 124)'"`
+	Category         string `json:"category" jsonschema:"enum=formular,enum=eigenerklaerung,enum=nachweis,enum=preisblatt,enum=konzept,enum=sonstiges,description=formular = auszufüllendes Formblatt; eigenerklaerung = Eigenerklärung; nachweis = Bescheinigung/Zertifikat/Referenz eines Dritten; preisblatt = Preisblatt/Leistungsverzeichnis mit Preisen; konzept = Konzept/Erläuterung des Bieters; sonstiges = alles andere"`
+	DueForm          string `json:"due_form,omitempty" jsonschema:"description=Geforderte Form, z. B. 'unterschrieben', 'Kopie', 'beglaubigt', 'in Textform über die Vergabeplattform' (leer, wenn nicht angegeben)"`
+	SubmissionTiming string `json:"submission_timing" jsonschema:"enum=mit_angebot,enum=auf_verlangen,description=mit_angebot = mit dem Angebot einzureichen; auf_verlangen = erst auf gesondertes Verlangen der Vergabestelle"`
+	PageLimit        int    `json:"page_limit,omitempty" jsonschema:"description=Maximale Seitenzahl (z. B. für Konzepte), 0 wenn keine Vorgabe"`
+	SourceQuote      string `json:"source_quote" jsonschema:"description=Wörtliches Zitat aus den Unterlagen, das das Dokument fordert (nicht umformulieren)"`
+	SourceDocument   string `json:"source_document,omitempty" jsonschema:"description=Name des Dokuments laut [DOKUMENT: ...]-Markierung (leer, wenn nicht erkennbar)"`
+	SourcePage       int    `json:"source_page,omitempty" jsonschema:"description=Seitenzahl laut [SEITE: ...]-Markierung (0, wenn nicht erkennbar)"`
+}
+
+// SubmissionChecklist ist das Ziel-Struct des Checklisten-Tools
+type SubmissionChecklist struct {
+	Items []ChecklistItem `json:"items" jsonschema:"description=Alle einzureichenden Unterlagen in diesem Abschnitt (leer, wenn keine)"`
+}
+
+// validate prüft die Einträge inhaltlich über das JSON-Schema hinaus
+func (c *SubmissionChecklist) validate() error {
+	var problems []string
+	for i, item := range c.Items {
+		if strings.TrimSpace(item.Title) == "" {
+			problems = append(problems, fmt.Sprintf("items[%d].title is empty", i))
+		}
+		if strings.TrimSpace(item.SourceQuote) == "" {
+			problems = append(problems, fmt.Sprintf("items[%d].source_quote is empty", i))
+		}
+		if item.SourcePage < 0 {
+			problems = append(problems, fmt.Sprintf("items[%d].source_page is negative", i))
+		}
+		if item.PageLimit < 0 {
+			problems = append(problems, fmt.Sprintf("items[%d].page_limit is negative", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid checklist: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ChecklistResult ist die zusammengeführte Checkliste über alle Abschnitte
+type ChecklistResult struct {
+	Items          []ChecklistItem `json:"items"`
+	SectionsTotal  int             `json:"sections_total"`
+	FailedSections []FailedSection `json:"failed_sections,omitempty"`
+	Run            RunInfo         `json:"-"`
+}
+
+// ChecklistAgent erstellt die Abgabe-Checkliste aus den Vergabeunterlagen
+type ChecklistAgent struct {
+	submit         *structuredCall[SubmissionChecklist]
+	prompts        *promptResolver
+	maxConcurrency int
+}
+
+// NewChecklistAgent nutzt dieselbe Modellkonfiguration wie der Compliance-Agent
+func NewChecklistAgent(ctx context.Context, cfg ComplianceAgentConfig) (*ChecklistAgent, error) {
+	chatModel, _, err := newOpenRouterChatModel(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newChecklistAgent(chatModel, cfg)
+}
+
+func newChecklistAgent(chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*ChecklistAgent, error) {
+	callCfg := structuredCallConfig{
+		ForceToolChoice: !cfg.DisableForcedToolChoice,
+		MaxRepairs:      cfg.MaxRepairAttempts,
+	}
+	if callCfg.MaxRepairs == 0 {
+		callCfg.MaxRepairs = defaultMaxRepairAttempts
+	}
+
+	submit, err := newStructuredCall[SubmissionChecklist](chatModel,
+		"submit_checklist", "Reicht die Liste der einzureichenden Unterlagen ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := newPromptResolver(AgentChecklist, cfg, &SubmissionChecklist{})
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrency := cfg.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+	return &ChecklistAgent{submit: submit, prompts: prompts, maxConcurrency: maxConcurrency}, nil
+}
+
+// Generate erstellt die Checkliste. Jeder Abschnitt wird einzeln ausgewertet (parallel, begrenzt
+// durch MaxConcurrency), doppelte Einträge werden zusammengeführt. Schlagen einzelne Abschnitte
+// fehl, enthält das Ergebnis die übrigen; ein Fehler kommt nur, wenn alle fehlschlagen.
+func (a *ChecklistAgent) Generate(ctx context.Context, sections []DocumentSection) (*ChecklistResult, error) {
+	if a == nil || a.submit == nil {
+		return nil, errors.New("checklist agent is not initialized")
+	}
+	ctx, run, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return &ChecklistResult{Items: []ChecklistItem{}, Run: run.info}, nil
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	extracted := make([][]ChecklistItem, len(sections))
+	result := &ChecklistResult{SectionsTotal: len(sections), Run: run.info}
+	sem := make(chan struct{}, a.maxConcurrency)
+
+	for i, section := range sections {
+		wg.Add(1)
+		go func(i int, section DocumentSection) {
+			defer wg.Done()
+
+			var checklist SubmissionChecklist
+			var err error
+			select {
+			case sem <- struct{}{}:
+				checklist, err = a.submit.invoke(ctx, []*schema.Message{
+					schema.SystemMessage(run.prompts["system"]),
+					schema.UserMessage(section.Text),
+				})
+				<-sem
+			case <-ctx.Done():
+				err = &TransportError{Tool: a.submit.tool.Name, Err: ctx.Err()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.FailedSections = append(result.FailedSections, FailedSection{SectionID: section.ID, Label: section.Label, Error: err.Error()})
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			extracted[i] = checklist.Items
+		}(i, section)
+	}
+	wg.Wait()
+	sort.Slice(result.FailedSections, func(i, j int) bool {
+		return result.FailedSections[i].SectionID < result.FailedSections[j].SectionID
+	})
+
+	if len(result.FailedSections) == len(sections) {
+		return nil, fmt.Errorf("all %d sections failed: %w", len(sections), firstErr)
+	}
+
+	var all []ChecklistItem
+	for _, items := range extracted {
+		all = append(all, items...)
+	}
+	result.Items = mergeChecklistItems(all)
+	return result, nil
+}
+
+// mergeChecklistItems führt Einträge mit gleichem Titel zusammen. Fordert eine Fundstelle das
+// Dokument mit dem Angebot, gilt das für den Eintrag; fehlende Angaben werden ergänzt.
+func mergeChecklistItems(items []ChecklistItem) []ChecklistItem {
+	merged := []ChecklistItem{}
+	index := make(map[string]int)
+
+	for _, item := range items {
+		key := normalizeText(item.Title)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, item)
+			continue
+		}
+
+		m := &merged[i]
+		if item.SubmissionTiming == SubmissionWithBid {
+			m.SubmissionTiming = SubmissionWithBid
+		}
+		if m.DueForm == "" {
+			m.DueForm = item.DueForm
+		}
+		// Bei abweichenden Seitenvorgaben gilt die strengere
+		if item.PageLimit > 0 && (m.PageLimit == 0 || item.PageLimit < m.PageLimit) {
+			m.PageLimit = item.PageLimit
+		}
+		if m.SourcePage == 0 && item.SourcePage > 0 {
+			m.SourceDocument, m.SourcePage = item.SourceDocument, item.SourcePage
+		}
+	}
+	return merged
+}
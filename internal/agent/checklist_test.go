@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func newTestChecklistAgent(t *testing.T, fake *scriptedModel) *ChecklistAgent {
+	t.Helper()
+	a, err := newChecklistAgent(fake, ComplianceAgentConfig{MaxConcurrency: 2})
+	if err != nil {
+		t.Fatalf("newChecklistAgent: %v", err)
+	}
+	return a
+}
+
+func TestChecklistMergesSectionsAndKeepsPartialResults(t *testing.T) {
+	fake := newScriptedModel()
+	fake.state.reply = func(call recordedCall) scriptedReply {
+		section := call.messages[1].Content
+		switch {
+		case strings.Contains(section, "kaputt"):
+			return scriptedReply{err: errors.New("timeout")}
+		case strings.Contains(section, "Teil A"):
+			return toolCallReply("submit_checklist", `{"items": [
+				{"title": "Eigenerklärung zur Eignung", "category": "eigenerklaerung", "due_form": "unterschrieben", "submission_timing": "auf_verlangen", "source_quote": "Eigenerklärung auf Verlangen"},
+				{"title": "Konzept Baustelleneinrichtung", "category": "konzept", "submission_timing": "mit_angebot", "page_limit": 10, "source_quote": "Konzept max. 10 Seiten"}
+			]}`)
+		default:
+			return toolCallReply("submit_checklist", `{"items": [
+				{"title": "eigenerklärung  zur Eignung", "category": "eigenerklaerung", "submission_timing": "mit_angebot", "source_quote": "mit dem Angebot einzureichen", "source_document": "Aufforderung.pdf", "source_page": 3},
+				{"title": "Konzept Baustelleneinrichtung", "category": "konzept", "submission_timing": "mit_angebot", "page_limit": 5, "source_quote": "höchstens 5 Seiten"}
+			]}`)
+		}
+	}
+	a := newTestChecklistAgent(t, fake)
+
+	result, err := a.Generate(context.Background(), []DocumentSection{
+		{ID: 0, Label: "Teil A", Text: "Teil A"},
+		{ID: 1, Label: "Teil B", Text: "Teil B kaputt"},
+		{ID: 2, Label: "Teil C", Text: "Teil C"},
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if len(result.FailedSections) != 1 || result.FailedSections[0].SectionID != 1 {
+		t.Fatalf("expected section 1 to fail, got %+v", result.FailedSections)
+	}
+	if len(result.Items) != 2 {
+		t.Fatalf("expected 2 merged items, got %+v", result.Items)
+	}
+
+	declaration := result.Items[0]
+	if declaration.SubmissionTiming != SubmissionWithBid {
+		t.Errorf("expected mit_angebot to win, got %q", declaration.SubmissionTiming)
+	}
+	if declaration.DueForm != "unterschrieben" || declaration.SourcePage != 3 || declaration.SourceDocument != "Aufforderung.pdf" {
+		t.Errorf("expected missing details to be filled in, got %+v", declaration)
+	}
+	if concept := result.Items[1]; concept.PageLimit != 5 {
+		t.Errorf("expected stricter page limit 5, got %d", concept.PageLimit)
+	}
+
+	calls := fake.callsFor("submit_checklist")
+	if tc := calls[0].options.ToolChoice; tc == nil || *tc != schema.ToolChoiceForced {
+		t.Errorf("expected forced tool choice, got %v", tc)
+	}
+}
+
+func TestChecklistFailsWhenAllSectionsFail(t *testing.T) {
+	unavailable := errors.New("503 service unavailable")
+	fake := newScriptedModel(scriptedReply{err: unavailable})
+	a := newTestChecklistAgent(t, fake)
+
+	_, err := a.Generate(context.Background(), []DocumentSection{{ID: 0, Label: "Unterlagen", Text: "..."}})
+
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) || !errors.Is(err, unavailable) {
+		t.Fatalf("expected wrapped *TransportError, got %v", err)
+	}
+}
+
+func TestChecklistRepairsMissingSourceQuote(t *testing.T) {
+	fake := newScriptedModel(
+		toolCallReply("submit_checklist", `{"items": [{"title": "Preisblatt", "category": "preisblatt", "submission_timing": "mit_angebot", "source_quote": " "}]}`),
+		toolCallReply("submit_checklist", `{"items": [{"title": "Preisblatt", "category": "preisblatt", "submission_timing": "mit_angebot", "source_quote": "Das Preisblatt ist auszufüllen."}]}`),
+	)
+	a := newTestChecklistAgent(t, fake)
+
+	result, err := a.Generate(context.Background(), []DocumentSection{{ID: 0, Label: "Unterlagen", Text: "..."}})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(result.Items) != 1 || result.Items[0].SourceQuote == "" {
+		t.Fatalf("unexpected items: %+v", result.Items)
+	}
+	if n := len(fake.callsFor("submit_checklist")); n != 2 {
+		t.Errorf("expected one repair, got %d calls", n)
+	}
+}
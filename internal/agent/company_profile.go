@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// companyProfileToolName ist das Tool, über das dem Modell das Firmenprofil als
+// strukturierter Tool-Kontext übergeben wird (synthetischer Aufruf + Ergebnis).
+const (
+	companyProfileToolName   = "get_company_profile"
+	companyProfileToolCallID = "call_company_profile"
+)
+
+// CompanyProfile ist das Unternehmensdossier für die Compliance-Prüfung
+type CompanyProfile struct {
+	AsOf            string   `json:"stichtag"`
+	Name            string   `json:"name"`
+	LegalForm       string   `json:"legal_form,omitempty"`
+	FoundingYear    int      `json:"founding_year,omitempty"`
+	YearsInBusiness int      `json:"years_in_business,omitempty"`
+	EmployeeCount   int      `json:"employee_count,omitempty"`
+	AnnualRevenue   float64  `json:"annual_revenue_eur,omitempty"`
+	IsAVPQ          bool     `json:"is_avpq"`
+	City            string   `json:"city,omitempty"`
+	Zip             string   `json:"zip,omitempty"`
+	Country         string   `json:"country,omitempty"`
+	ServiceRadiusKM int      `json:"service_radius_km,omitempty"`
+	IndustryTags    []string `json:"cpv_codes,omitempty"`
+	Summary         string   `json:"summary,omitempty"`
+
+	Certificates       []string            `json:"certificates"`
+	CertificateRecords []CertificateRecord `json:"certificate_records,omitempty"`
+	References         []ProjectReference  `json:"project_references"`
+	EmployeeCVs        []ProfileDocument   `json:"employee_cvs"`
+	FinancialDocuments []ProfileDocument   `json:"financial_documents"`
+	UploadedDocuments  []ProfileDocument   `json:"uploaded_documents"`
+}
+
+// CertificateRecord ist ein hinterlegter Nachweis mit Gültigkeit (Daten als YYYY-MM-DD)
+type CertificateRecord struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Issuer      string `json:"issuer,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+	ValidFrom   string `json:"valid_from,omitempty"`
+	ValidUntil  string `json:"valid_until,omitempty"` // leer = unbefristet
+	Status      string `json:"status"`                // valid | expiring | expired
+	HasDocument bool   `json:"has_document"`
+}
+
+// ProjectReference ist ein Referenzprojekt des Unternehmens
+type ProjectReference struct {
+	Name     string   `json:"name"`
+	Client   string   `json:"client,omitempty"`
+	Year     int      `json:"year,omitempty"`
+	YearsAgo *int     `json:"years_ago,omitempty"`
+	Budget   string   `json:"budget,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+
+	// Nur bei strukturierten Referenzen
+	Description           string   `json:"description,omitempty"`
+	ClientType            string   `json:"client_type,omitempty"` // public | private
+	Period                string   `json:"period,omitempty"`      // z. B. "03/2022 – 11/2023"
+	CPVCodes              []string `json:"cpv_codes,omitempty"`
+	Location              string   `json:"location,omitempty"`
+	ReleasedForDisclosure *bool    `json:"released_for_disclosure,omitempty"`
+}
+
+// ProfileDocument ist ein hinterlegtes Dokument (Lebenslauf, Finanzunterlage, Upload)
+type ProfileDocument struct {
+	Name    string            `json:"name"`
+	Type    string            `json:"type,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// companyProfileTool beschreibt das Profil-Tool. Es muss gebunden sein, damit Anbieter
+// den synthetischen Aufruf im Verlauf akzeptieren.
+func companyProfileTool() *schema.ToolInfo {
+	return &schema.ToolInfo{
+		Name: companyProfileToolName,
+		Desc: "Liefert das vollständige Unternehmensprofil. Das Ergebnis liegt bereits im Verlauf vor; nicht erneut aufrufen.",
+	}
+}
+
+// profileContextMessages liefert das Firmenprofil als Tool-Aufruf samt Ergebnis
+func profileContextMessages(profile CompanyProfile) ([]*schema.Message, error) {
+	payload, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("marshal company profile: %w", err)
+	}
+
+	return []*schema.Message{
+		schema.AssistantMessage("", []schema.ToolCall{{
+			ID:   companyProfileToolCallID,
+			Type: "function",
+			Function: schema.FunctionCall{
+				Name:      companyProfileToolName,
+				Arguments: "{}",
+			},
+		}}),
+		schema.ToolMessage(string(payload), companyProfileToolCallID, schema.WithToolName(companyProfileToolName)),
+	}, nil
+}
@@ -2,29 +2,70 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 
-	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
-	"github.com/eino-contrib/jsonschema"
 
 	openai "github.com/cloudwego/eino-ext/components/model/openai"
 	orclient "github.com/vergabe-agent/vergabe-backend/internal/openrouter"
+	"github.com/vergabe-agent/vergabe-backend/internal/usage"
 )
 
-// ComplianceInput enthält OCR-Text und Profil.
+// ComplianceInput enthält OCR-Text und Firmenprofil.
 type ComplianceInput struct {
-	OCRText        string `json:"ocr_text"`
-	ProfileSummary string `json:"profile_summary"`
+	OCRText string         `json:"ocr_text"`
+	Profile CompanyProfile `json:"profile"`
+}
+
+// Kategorien einer Anforderung
+const (
+	RequirementCategoryEignung    = "eignung"
+	RequirementCategoryAusschluss = "ausschluss"
+	RequirementCategoryNachweis   = "nachweis"
+	RequirementCategoryTechnical  = "technical"
+)
+
+// Erfüllungsstatus einer Anforderung gegenüber dem Firmenprofil
+const (
+	RequirementStatusFulfilled    = "fulfilled"
+	RequirementStatusNotFulfilled = "not_fulfilled"
+	RequirementStatusUnknown      = "unknown"
+)
+
+// Arten von Blockern
+const (
+	BlockerKindMissingDocument = "missing_document"
+	BlockerKindCriticalIssue   = "critical_issue"
+)
+
+// RequirementAssessment ist eine einzelne Anforderung aus den Vergabeunterlagen samt Bewertung.
+type RequirementAssessment struct {
+	Title          string `json:"title" jsonschema:"description=Kurzbezeichnung der Anforderung"`
+	Category       string `json:"category" jsonschema:"enum=eignung,enum=ausschluss,enum=nachweis,enum=technical,description=eignung = Eignungskriterium; ausschluss = Ausschlussgrund; nachweis = geforderter Nachweis/Dokument; technical = technische Anforderung"`
+	SourceQuote    string `json:"source_quote" jsonschema:"description=Wörtliches Zitat der Anforderung aus den Unterlagen (nicht umformulieren)"`
+	SourceDocument string `json:"source_document,omitempty" jsonschema:"description=Name des Dokuments laut [DOKUMENT: ...]-Markierung (leer, wenn nicht erkennbar)"`
+	SourcePage     int    `json:"source_page,omitempty" jsonschema:"description=Seitenzahl laut [SEITE: ...]-Markierung (0, wenn nicht erkennbar)"`
+	Status         string `json:"status" jsonschema:"enum=fulfilled,enum=not_fulfilled,enum=unknown,description=Erfüllt das Unternehmen die Anforderung laut Profil? unknown, wenn das Profil keine Aussage erlaubt"`
+	Evidence       string `json:"evidence,omitempty" jsonschema:"description=Verwendete Angaben aus dem Unternehmensprofil (leer, wenn keine)"`
+	IsKnockout     bool   `json:"is_knockout" jsonschema:"description=Führt die Nichterfüllung zwingend zum Ausschluss?"`
+}
+
+// ComplianceBlocker ist ein Grund, der einer Bewerbung im Weg steht.
+type ComplianceBlocker struct {
+	Kind        string `json:"kind" jsonschema:"enum=missing_document,enum=critical_issue,description=missing_document = fehlender Nachweis; critical_issue = K.O.-Kriterium oder sonstiges kritisches Problem"`
+	Description string `json:"description" jsonschema:"description=Kurze Beschreibung des Blockers"`
 }
 
 // ComplianceAssessment ist das Ziel-Struct.
 type ComplianceAssessment struct {
-	IsFeasible bool     `json:"is_feasible" jsonschema:"description=Ist die Bewerbung machbar?"`
-	Blockers   []string `json:"blockers" jsonschema:"description=Liste der fehlenden Dokumente oder K.O.-Kriterien."`
+	IsFeasible   bool                    `json:"is_feasible" jsonschema:"description=Ist die Bewerbung machbar?"`
+	Requirements []RequirementAssessment `json:"requirements" jsonschema:"description=Alle einzelnen Anforderungen aus den Unterlagen mit Bewertung"`
+	Blockers     []ComplianceBlocker     `json:"blockers,omitempty" jsonschema:"description=Fehlende Dokumente und K.O.-Kriterien, die einer Bewerbung entgegenstehen"`
 }
 
 type ComplianceAgentConfig struct {
@@ -34,20 +75,56 @@ type ComplianceAgentConfig struct {
 	Temperature float32
 	AppName     string
 	AppURL      string
+	// MaxConcurrency begrenzt parallele Modellaufrufe in der Map-Stufe (Standard: 4)
+	MaxConcurrency int
+	// DisableForcedToolChoice für Anbieter, die tool_choice nicht unterstützen
+	DisableForcedToolChoice bool
+	// MaxRepairAttempts begrenzt Nachfragen bei ungültigen Antworten (Standard: 2, <0 = keine)
+	MaxRepairAttempts int
+	// Prompts liefert Prompt-Version, Modell und Temperatur je Lauf (nil = mitgelieferte Prompts)
+	Prompts PromptSource
+	// Usage erfasst Tokens, Latenz und Kosten aller Modellaufrufe (nil = keine Erfassung)
+	Usage usage.Recorder
 }
 
 type ComplianceAgent struct {
-	chain compose.Runnable[ComplianceInput, ComplianceAssessment]
+	chain   compose.Runnable[ComplianceInput, ComplianceAssessment]
+	graph   compose.Runnable[ComplianceGraphInput, *ComplianceGraphResult]
+	prompts *promptResolver
 }
 
 func NewComplianceAgent(ctx context.Context, cfg ComplianceAgentConfig) (*ComplianceAgent, error) {
+	chatModel, modelName, err := newOpenRouterChatModel(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Model = modelName
+	return newComplianceAgent(ctx, chatModel, cfg)
+}
+
+// NewComplianceAgentWithModel baut den Agent auf einem vorhandenen ChatModel auf, z. B. einem
+// aufzeichnenden oder wiedergebenden Modell für cmd/eval-compliance. cfg.Model ist dann nur
+// die Modellkennung für Prompt-Version und Modelloption.
+func NewComplianceAgentWithModel(ctx context.Context, chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*ComplianceAgent, error) {
+	return newComplianceAgent(ctx, chatModel, cfg)
+}
+
+// NewOpenRouterChatModel erstellt das ChatModel über OpenRouter und liefert den Modellnamen
+func NewOpenRouterChatModel(ctx context.Context, cfg ComplianceAgentConfig) (model.ToolCallingChatModel, string, error) {
+	return newOpenRouterChatModel(ctx, cfg)
+}
+
+// newOpenRouterChatModel erstellt das ChatModel über OpenRouter und liefert den Modellnamen
+// (Tools werden je Aufruf-Typ über WithTools gebunden)
+func newOpenRouterChatModel(ctx context.Context, cfg ComplianceAgentConfig) (model.ToolCallingChatModel, string, error) {
 	if strings.TrimSpace(cfg.APIKey) == "" {
-		return nil, errors.New("missing OpenRouter API key")
+		return nil, "", errors.New("missing OpenRouter API key")
 	}
 
 	modelName := strings.TrimSpace(cfg.Model)
 	if modelName == "" {
-		modelName = "openai/gpt-4o"
+		modelName = DefaultModel
 	}
 	temp := cfg.Temperature
 
@@ -58,18 +135,6 @@ func NewComplianceAgent(ctx context.Context, cfg ComplianceAgentConfig) (*Compli
 
 	httpClient := orclient.NewHTTPClient(cfg.AppURL, cfg.AppName)
 
-	// 1. Tool Definition (JSON Schema via Reflection)
-	reflector := jsonschema.Reflector{ExpandedStruct: true}
-	jsonSchema := reflector.Reflect(&ComplianceAssessment{})
-
-	toolName := "submit_compliance_check"
-	toolInfo := &schema.ToolInfo{
-		Name:        toolName,
-		Desc:        "Reicht das Ergebnis der Compliance-Prüfung ein.",
-		ParamsOneOf: schema.NewParamsOneOfByJSONSchema(jsonSchema),
-	}
-
-	// 2. ChatModel erstellen (OHNE Tools in der Config)
 	chatModel, err := openai.NewChatModel(ctx, &openai.ChatModelConfig{
 		APIKey:      cfg.APIKey,
 		Model:       modelName,
@@ -78,79 +143,140 @@ func NewComplianceAgent(ctx context.Context, cfg ComplianceAgentConfig) (*Compli
 		HTTPClient:  httpClient,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("init chat model: %w", err)
+		return nil, "", fmt.Errorf("init chat model: %w", err)
 	}
+	return usage.WrapChatModel(chatModel, cfg.Usage, modelName), modelName, nil
+}
 
-	// 3. Tools Binden (In-Place Fix!)
-	// Wir gehen davon aus, dass BindTools in deiner Version das Model direkt modifiziert.
-	// Falls deine Version doch (Runnable, error) zurückgibt, wird der Compiler hier meckern.
-	// In dem Fall nutzen wir das Interface ToolBindable manuell.
-	// Aber probieren wir erst den In-Place Fix:
-	err = chatModel.BindTools([]*schema.ToolInfo{toolInfo})
+// newComplianceAgent baut Chain und Graph auf einem beliebigen ChatModel auf (auch für Tests)
+func newComplianceAgent(ctx context.Context, chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*ComplianceAgent, error) {
+	callCfg := structuredCallConfig{
+		ForceToolChoice: !cfg.DisableForcedToolChoice,
+		MaxRepairs:      cfg.MaxRepairAttempts,
+		ExtraTools:      []*schema.ToolInfo{companyProfileTool()},
+	}
+	if callCfg.MaxRepairs == 0 {
+		callCfg.MaxRepairs = defaultMaxRepairAttempts
+	}
+
+	prompts, err := newPromptResolver(AgentCompliance, cfg,
+		&ComplianceAssessment{}, &sectionExtraction{}, &requirementVerdicts{}, &CompanyProfile{})
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Tool (JSON Schema via Reflection) + Reparaturschleife
+	submit, err := newStructuredCall[ComplianceAssessment](chatModel,
+		"submit_compliance_check", "Reicht das Ergebnis der Compliance-Prüfung ein.", callCfg)
 	if err != nil {
-		return nil, fmt.Errorf("bind tools failed: %w", err)
+		return nil, err
 	}
 
-	// 4. Prompt Template
-	template := prompt.FromMessages(
-		schema.FString,
-		&schema.Message{
-			Role:    schema.System,
-			Content: "Du bist ein strenger Vergabeprüfer. Analysiere das Profil. DU MUSST das Tool 'submit_compliance_check' nutzen, um das Ergebnis zu melden. Antworte NICHT mit Text.",
-		},
-		&schema.Message{
-			Role:    schema.User,
-			Content: "OCR_TEXT:\n{ocr_text}\n\nUNTERNEHMENSPROFIL:\n{profile}",
-		},
-	)
+	// Gestreamt, sobald ein Listener im Kontext hängt: jede Anforderung wird gemeldet,
+	// sobald ihr JSON-Objekt in den Tool-Argumenten vollständig ist
+	submit.streamKey = "requirements"
+	submit.streamItems = func(ctx context.Context) func(json.RawMessage) {
+		fn := requirementListener(ctx)
+		if fn == nil {
+			return nil
+		}
+		return func(raw json.RawMessage) {
+			var r RequirementAssessment
+			if json.Unmarshal(raw, &r) == nil {
+				fn(r)
+			}
+		}
+	}
 
-	// 5. Parser Konfiguration
-	parserConfig := &schema.MessageJSONParseConfig{
-		ParseFrom: schema.MessageParseFromToolCall,
+	// 2. Map-Reduce-Graph für lange Unterlagen
+	graph, err := buildComplianceGraph(ctx, chatModel, prompts, cfg.MaxConcurrency, callCfg)
+	if err != nil {
+		return nil, fmt.Errorf("compile compliance graph: %w", err)
 	}
-	parser := schema.NewMessageJSONParser[ComplianceAssessment](parserConfig)
 
-	// 6. Chain zusammenbauen
+	// 3. Chain zusammenbauen
 	c := compose.NewChain[ComplianceInput, ComplianceAssessment]()
 
-	// Node 1: Input -> Map
-	c.AppendLambda(compose.InvokableLambda(func(ctx context.Context, input ComplianceInput) (map[string]any, error) {
-		return map[string]any{
-			"ocr_text": strings.TrimSpace(input.OCRText),
-			"profile":  strings.TrimSpace(input.ProfileSummary),
-		}, nil
+	// Node 1: Input -> Messages (Firmenprofil als Tool-Kontext)
+	c.AppendLambda(compose.InvokableLambda(func(ctx context.Context, input ComplianceInput) ([]*schema.Message, error) {
+		profileMessages, err := profileContextMessages(input.Profile)
+		if err != nil {
+			return nil, err
+		}
+		messages := []*schema.Message{
+			schema.SystemMessage(prompts.prompt(ctx, "system")),
+			schema.UserMessage("OCR_TEXT:\n" + strings.TrimSpace(input.OCRText)),
+		}
+		return append(messages, profileMessages...), nil
 	}))
 
-	// Node 2: Map -> Prompt -> Messages
-	c.AppendChatTemplate(template)
-
-	// Node 3: Messages -> Model (Tools sind bereits gebunden)
-	c.AppendChatModel(chatModel)
-
-	// Node 4: Message -> Parser -> Struct
-	// FIX: InvokableLambda statt InvokableGraph (kompatibler)
-	c.AppendLambda(compose.InvokableLambda(func(ctx context.Context, input *schema.Message) (ComplianceAssessment, error) {
-		return parser.Parse(ctx, input)
-	}))
+	// Node 2: Messages -> Model -> validiertes Struct
+	c.AppendLambda(compose.InvokableLambda(submit.invoke))
 
 	runnable, err := c.Compile(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("compile compliance chain: %w", err)
 	}
 
-	return &ComplianceAgent{chain: runnable}, nil
+	return &ComplianceAgent{chain: runnable, graph: graph, prompts: prompts}, nil
 }
 
+// Prepare legt Prompt-Version und Modell für alle folgenden Aufrufe mit dem zurückgegebenen
+// Kontext fest. So passt der Cache-Schlüssel zum Lauf, auch wenn die Registry zwischendurch
+// umgestellt wird. Assess und AssessSections legen den Lauf sonst selbst fest.
+func (a *ComplianceAgent) Prepare(ctx context.Context) (context.Context, RunInfo, error) {
+	if a == nil || a.prompts == nil {
+		return ctx, RunInfo{}, errors.New("compliance agent is not initialized")
+	}
+	ctx, run, err := a.prompts.pin(ctx)
+	if err != nil {
+		return ctx, RunInfo{}, err
+	}
+	return ctx, run.info, nil
+}
+
+// Assess prüft die Unterlagen in einem Prompt. Fehler sind *TransportError (Anbieter nicht
+// erreichbar) oder *ModelError (keine gültige Antwort nach allen Reparaturversuchen).
 func (a *ComplianceAgent) Assess(ctx context.Context, input ComplianceInput) (*ComplianceAssessment, error) {
 	if a == nil || a.chain == nil {
 		return nil, errors.New("compliance agent is not initialized")
 	}
 
+	ctx, _, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reportProgress(ctx, ComplianceProgress{Stage: ComplianceStageAssess, Total: 1})
+
 	result, err := a.chain.Invoke(ctx, input)
 	if err != nil {
-		// Detailliertes Error-Logging, falls das Model kein ToolCall macht
 		return nil, fmt.Errorf("agent error: %w", err)
 	}
 
+	reportProgress(ctx, ComplianceProgress{Stage: ComplianceStageAssess, Completed: 1, Total: 1})
 	return &result, nil
 }
+
+// validate prüft die Bewertung inhaltlich über das JSON-Schema hinaus
+func (a *ComplianceAssessment) validate() error {
+	var problems []string
+	for i, r := range a.Requirements {
+		if strings.TrimSpace(r.Title) == "" {
+			problems = append(problems, fmt.Sprintf("requirements[%d].title is empty", i))
+		}
+		if strings.TrimSpace(r.SourceQuote) == "" {
+			problems = append(problems, fmt.Sprintf("requirements[%d].source_quote is empty", i))
+		}
+		if r.SourcePage < 0 {
+			problems = append(problems, fmt.Sprintf("requirements[%d].source_page is negative", i))
+		}
+		if a.IsFeasible && r.IsKnockout && r.Status == RequirementStatusNotFulfilled {
+			problems = append(problems, fmt.Sprintf("is_feasible is true although knockout requirement %d is not fulfilled", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid assessment: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,462 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+const defaultMaxConcurrency = 4
+
+// Stufen des Map-Reduce-Graphen (documents meldet der Service vor dem Agent-Lauf)
+const (
+	ComplianceStageDocuments = "documents"
+	ComplianceStageMap       = "map"
+	ComplianceStageReduce    = "reduce"
+	ComplianceStageAssess    = "assess"
+)
+
+// Ab dieser Wortüberschneidung gelten zwei Zitate als dieselbe Anforderung
+const duplicateQuoteSimilarity = 0.8
+
+// DocumentSection ist ein Abschnitt der Vergabeunterlagen für die Map-Stufe.
+// Text enthält bereits die [DOKUMENT: ...]/[SEITE: ...]-Markierungen.
+type DocumentSection struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	Text  string `json:"text"`
+}
+
+// ComplianceGraphInput ist die Eingabe des Map-Reduce-Graphen
+type ComplianceGraphInput struct {
+	Sections []DocumentSection
+	Profile  CompanyProfile
+}
+
+// ExtractedRequirement ist eine Anforderung aus der Map-Stufe (noch ohne Bewertung)
+type ExtractedRequirement struct {
+	Title          string `json:"title" jsonschema:"description=Kurzbezeichnung der Anforderung"`
+	Category       string `json:"category" jsonschema:"enum=eignung,enum=ausschluss,enum=nachweis,enum=technical,description=eignung = Eignungskriterium; ausschluss = Ausschlussgrund; nachweis = geforderter Nachweis/Dokument; technical = technische Anforderung"`
+	SourceQuote    string `json:"source_quote" jsonschema:"description=Wörtliches Zitat der Anforderung aus dem Abschnitt (nicht umformulieren)"`
+	SourceDocument string `json:"source_document,omitempty" jsonschema:"description=Name des Dokuments laut [DOKUMENT: ...]-Markierung"`
+	SourcePage     int    `json:"source_page,omitempty" jsonschema:"description=Seitenzahl laut [SEITE: ...]-Markierung (0, wenn nicht erkennbar)"`
+	IsKnockout     bool   `json:"is_knockout" jsonschema:"description=Führt die Nichterfüllung zwingend zum Ausschluss?"`
+}
+
+type sectionExtraction struct {
+	Requirements []ExtractedRequirement `json:"requirements" jsonschema:"description=Alle Anforderungen an Bieter in diesem Abschnitt (leer, wenn keine)"`
+}
+
+func (e *sectionExtraction) validate() error {
+	for i, r := range e.Requirements {
+		if strings.TrimSpace(r.SourceQuote) == "" {
+			return fmt.Errorf("requirements[%d].source_quote is empty", i)
+		}
+	}
+	return nil
+}
+
+// requirementVerdict ist die Bewertung einer zusammengeführten Anforderung in der Assess-Stufe
+type requirementVerdict struct {
+	ID       int    `json:"id" jsonschema:"description=ID der Anforderung aus der Liste"`
+	Status   string `json:"status" jsonschema:"enum=fulfilled,enum=not_fulfilled,enum=unknown,description=Erfüllt das Unternehmen die Anforderung laut Profil? unknown, wenn das Profil keine Aussage erlaubt"`
+	Evidence string `json:"evidence,omitempty" jsonschema:"description=Verwendete Angaben aus dem Unternehmensprofil (leer, wenn keine)"`
+}
+
+type requirementVerdicts struct {
+	IsFeasible bool                 `json:"is_feasible" jsonschema:"description=Ist die Bewerbung machbar?"`
+	Verdicts   []requirementVerdict `json:"verdicts" jsonschema:"description=Eine Bewertung pro Anforderung"`
+	Blockers   []ComplianceBlocker  `json:"blockers,omitempty" jsonschema:"description=Fehlende Dokumente und K.O.-Kriterien, die einer Bewerbung entgegenstehen"`
+}
+
+// FailedSection ist ein Abschnitt, dessen Extraktion fehlgeschlagen ist
+type FailedSection struct {
+	SectionID int    `json:"section_id"`
+	Label     string `json:"label"`
+	Error     string `json:"error"`
+}
+
+// ComplianceGraphResult ist das Ergebnis des Graphen. Bei fehlgeschlagenen Abschnitten ist
+// die Bewertung unvollständig; FailedSections nennt die betroffenen Abschnitte.
+type ComplianceGraphResult struct {
+	Assessment     ComplianceAssessment `json:"assessment"`
+	SectionsTotal  int                  `json:"sections_total"`
+	FailedSections []FailedSection      `json:"failed_sections,omitempty"`
+}
+
+// ComplianceProgress ist ein Fortschrittsereignis des Graphen
+type ComplianceProgress struct {
+	Stage     string `json:"stage"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+	Section   string `json:"section,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ComplianceProgressFunc empfängt Fortschrittsereignisse (wird nebenläufig aufgerufen)
+type ComplianceProgressFunc func(ComplianceProgress)
+
+type progressKey struct{}
+
+// WithComplianceProgress hängt einen Fortschritts-Callback an den Kontext
+func WithComplianceProgress(ctx context.Context, fn ComplianceProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportComplianceProgress meldet ein Fortschrittsereignis an den Callback im Kontext (falls vorhanden)
+func ReportComplianceProgress(ctx context.Context, p ComplianceProgress) {
+	reportProgress(ctx, p)
+}
+
+func reportProgress(ctx context.Context, p ComplianceProgress) {
+	if fn, ok := ctx.Value(progressKey{}).(ComplianceProgressFunc); ok && fn != nil {
+		fn(p)
+	}
+}
+
+// ComplianceRequirementFunc empfängt Anforderungen, sobald sie extrahiert sind. Die Meldungen
+// sind vorläufig (im Map-Reduce-Modus noch ohne Status und vor dem Zusammenführen);
+// maßgeblich ist das Endergebnis.
+type ComplianceRequirementFunc func(RequirementAssessment)
+
+type requirementKey struct{}
+
+// WithComplianceRequirements hängt einen Callback für extrahierte Anforderungen an den Kontext
+func WithComplianceRequirements(ctx context.Context, fn ComplianceRequirementFunc) context.Context {
+	return context.WithValue(ctx, requirementKey{}, fn)
+}
+
+func requirementListener(ctx context.Context) ComplianceRequirementFunc {
+	fn, _ := ctx.Value(requirementKey{}).(ComplianceRequirementFunc)
+	return fn
+}
+
+// mapOutput / reduceOutput transportieren die Zwischenergebnisse zwischen den Knoten
+type mapOutput struct {
+	profile       CompanyProfile
+	extracted     [][]ExtractedRequirement
+	sectionsTotal int
+	failed        []FailedSection
+}
+
+type reduceOutput struct {
+	profile       CompanyProfile
+	requirements  []ExtractedRequirement
+	sectionsTotal int
+	failed        []FailedSection
+}
+
+// buildComplianceGraph baut den Graphen map -> reduce -> assess
+func buildComplianceGraph(ctx context.Context, chatModel model.ToolCallingChatModel, prompts *promptResolver, maxConcurrency int, callCfg structuredCallConfig) (compose.Runnable[ComplianceGraphInput, *ComplianceGraphResult], error) {
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+
+	extractCfg := callCfg
+	extractCfg.ExtraTools = nil
+	extract, err := newStructuredCall[sectionExtraction](chatModel,
+		"submit_requirements", "Reicht die Anforderungen eines Abschnitts ein.", extractCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	assess, err := newStructuredCall[requirementVerdicts](chatModel,
+		"submit_requirement_verdicts", "Reicht die Bewertung aller Anforderungen ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	g := compose.NewGraph[ComplianceGraphInput, *ComplianceGraphResult]()
+
+	// Map: Anforderungen je Abschnitt extrahieren, parallel mit begrenzter Nebenläufigkeit
+	err = g.AddLambdaNode(ComplianceStageMap, compose.InvokableLambda(func(ctx context.Context, input ComplianceGraphInput) (*mapOutput, error) {
+		total := len(input.Sections)
+		out := &mapOutput{
+			profile:       input.Profile,
+			extracted:     make([][]ExtractedRequirement, total),
+			sectionsTotal: total,
+		}
+		reportProgress(ctx, ComplianceProgress{Stage: ComplianceStageMap, Total: total})
+
+		var (
+			mu        sync.Mutex
+			wg        sync.WaitGroup
+			completed int
+			firstErr  error
+		)
+		sem := make(chan struct{}, maxConcurrency)
+
+		for i, section := range input.Sections {
+			wg.Add(1)
+			go func(i int, section DocumentSection) {
+				defer wg.Done()
+
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					mu.Lock()
+					out.failed = append(out.failed, FailedSection{SectionID: section.ID, Label: section.Label, Error: ctx.Err().Error()})
+					if firstErr == nil {
+						firstErr = &TransportError{Tool: "submit_requirements", Err: ctx.Err()}
+					}
+					mu.Unlock()
+					return
+				}
+
+				extraction, err := extract.invoke(ctx, []*schema.Message{
+					schema.SystemMessage(prompts.prompt(ctx, "extraction")),
+					schema.UserMessage(section.Text),
+				})
+
+				mu.Lock()
+				defer mu.Unlock()
+				completed++
+				progress := ComplianceProgress{Stage: ComplianceStageMap, Completed: completed, Total: total, Section: section.Label}
+				if err != nil {
+					out.failed = append(out.failed, FailedSection{SectionID: section.ID, Label: section.Label, Error: err.Error()})
+					progress.Error = err.Error()
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					out.extracted[i] = extraction.Requirements
+					if fn := requirementListener(ctx); fn != nil {
+						for _, r := range extraction.Requirements {
+							fn(RequirementAssessment{
+								Title:          r.Title,
+								Category:       r.Category,
+								SourceQuote:    r.SourceQuote,
+								SourceDocument: r.SourceDocument,
+								SourcePage:     r.SourcePage,
+								IsKnockout:     r.IsKnockout,
+							})
+						}
+					}
+				}
+				reportProgress(ctx, progress)
+			}(i, section)
+		}
+		wg.Wait()
+		sort.Slice(out.failed, func(i, j int) bool { return out.failed[i].SectionID < out.failed[j].SectionID })
+
+		if total > 0 && len(out.failed) == total {
+			return nil, fmt.Errorf("all %d sections failed: %w", total, firstErr)
+		}
+		return out, nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	// Reduce: Duplikate über Abschnitte hinweg zusammenführen
+	err = g.AddLambdaNode(ComplianceStageReduce, compose.InvokableLambda(func(ctx context.Context, in *mapOutput) (*reduceOutput, error) {
+		var all []ExtractedRequirement
+		for _, reqs := range in.extracted {
+			all = append(all, reqs...)
+		}
+		merged := mergeRequirements(all)
+		reportProgress(ctx, ComplianceProgress{Stage: ComplianceStageReduce, Completed: len(merged), Total: len(all)})
+
+		return &reduceOutput{
+			profile:       in.profile,
+			requirements:  merged,
+			sectionsTotal: in.sectionsTotal,
+			failed:        in.failed,
+		}, nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	// Assess: zusammengeführte Anforderungen gegen das Firmenprofil bewerten
+	err = g.AddLambdaNode(ComplianceStageAssess, compose.InvokableLambda(func(ctx context.Context, in *reduceOutput) (*ComplianceGraphResult, error) {
+		reportProgress(ctx, ComplianceProgress{Stage: ComplianceStageAssess, Total: 1})
+
+		result := &ComplianceGraphResult{
+			SectionsTotal:  in.sectionsTotal,
+			FailedSections: in.failed,
+		}
+
+		var verdicts requirementVerdicts
+		if len(in.requirements) > 0 {
+			list, err := json.Marshal(indexedRequirements(in.requirements))
+			if err != nil {
+				return nil, fmt.Errorf("marshal requirements: %w", err)
+			}
+
+			profileMessages, err := profileContextMessages(in.profile)
+			if err != nil {
+				return nil, err
+			}
+			messages := []*schema.Message{
+				schema.SystemMessage(prompts.prompt(ctx, "verdict")),
+				schema.UserMessage(fmt.Sprintf("ANFORDERUNGEN:\n%s", list)),
+			}
+			verdicts, err = assess.invoke(ctx, append(messages, profileMessages...))
+			if err != nil {
+				return nil, fmt.Errorf("assess requirements: %w", err)
+			}
+		} else {
+			// Keine Anforderungen gefunden -> nichts, was gegen eine Bewerbung spricht
+			verdicts.IsFeasible = true
+		}
+
+		result.Assessment = applyVerdicts(in.requirements, verdicts)
+		reportProgress(ctx, ComplianceProgress{Stage: ComplianceStageAssess, Completed: 1, Total: 1})
+		return result, nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, edge := range [][2]string{
+		{compose.START, ComplianceStageMap},
+		{ComplianceStageMap, ComplianceStageReduce},
+		{ComplianceStageReduce, ComplianceStageAssess},
+		{ComplianceStageAssess, compose.END},
+	} {
+		if err := g.AddEdge(edge[0], edge[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.Compile(ctx)
+}
+
+// AssessSections prüft lange Vergabeunterlagen abschnittsweise (Map-Reduce).
+// Fortschritt wird über WithComplianceProgress gemeldet.
+func (a *ComplianceAgent) AssessSections(ctx context.Context, input ComplianceGraphInput) (*ComplianceGraphResult, error) {
+	if a == nil || a.graph == nil {
+		return nil, errors.New("compliance agent is not initialized")
+	}
+	ctx, _, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.graph.Invoke(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("agent error: %w", err)
+	}
+	return result, nil
+}
+
+type indexedRequirement struct {
+	ID int `json:"id"`
+	ExtractedRequirement
+}
+
+func indexedRequirements(reqs []ExtractedRequirement) []indexedRequirement {
+	list := make([]indexedRequirement, len(reqs))
+	for i, r := range reqs {
+		list[i] = indexedRequirement{ID: i, ExtractedRequirement: r}
+	}
+	return list
+}
+
+// applyVerdicts verbindet die extrahierten Anforderungen mit den Bewertungen.
+// Zitate und Fundstellen stammen aus der Map-Stufe und bleiben unverändert.
+func applyVerdicts(reqs []ExtractedRequirement, verdicts requirementVerdicts) ComplianceAssessment {
+	byID := make(map[int]requirementVerdict, len(verdicts.Verdicts))
+	for _, v := range verdicts.Verdicts {
+		byID[v.ID] = v
+	}
+
+	assessment := ComplianceAssessment{
+		IsFeasible:   verdicts.IsFeasible,
+		Requirements: make([]RequirementAssessment, len(reqs)),
+		Blockers:     verdicts.Blockers,
+	}
+	for i, r := range reqs {
+		status := RequirementStatusUnknown
+		var evidence string
+		if v, ok := byID[i]; ok {
+			status, evidence = v.Status, v.Evidence
+		}
+		assessment.Requirements[i] = RequirementAssessment{
+			Title:          r.Title,
+			Category:       r.Category,
+			SourceQuote:    r.SourceQuote,
+			SourceDocument: r.SourceDocument,
+			SourcePage:     r.SourcePage,
+			Status:         status,
+			Evidence:       evidence,
+			IsKnockout:     r.IsKnockout,
+		}
+	}
+	return assessment
+}
+
+// mergeRequirements entfernt Duplikate (nahezu gleiches Zitat). Gleiche Titel allein reichen
+// nicht, etwa "Referenzen" verschiedener Lose; sie entscheiden nur zwischen mehreren ähnlichen
+// Zitaten. Die erste Fundstelle bleibt erhalten, K.O. gewinnt.
+func mergeRequirements(reqs []ExtractedRequirement) []ExtractedRequirement {
+	var merged []ExtractedRequirement
+	var quoteTokens []map[string]bool
+
+	for _, r := range reqs {
+		tokens := wordSet(r.SourceQuote)
+		duplicate, bestSimilarity, bestSameTitle := -1, 0.0, false
+		for i, m := range merged {
+			similarity := jaccard(tokens, quoteTokens[i])
+			if similarity < duplicateQuoteSimilarity {
+				continue
+			}
+			sameTitle := r.Title != "" && m.Category == r.Category && normalizeText(m.Title) == normalizeText(r.Title)
+			if duplicate < 0 || (sameTitle && !bestSameTitle) || (sameTitle == bestSameTitle && similarity > bestSimilarity) {
+				duplicate, bestSimilarity, bestSameTitle = i, similarity, sameTitle
+			}
+		}
+
+		if duplicate < 0 {
+			merged = append(merged, r)
+			quoteTokens = append(quoteTokens, tokens)
+			continue
+		}
+		if r.IsKnockout {
+			merged[duplicate].IsKnockout = true
+		}
+		if merged[duplicate].SourcePage == 0 && r.SourcePage > 0 {
+			merged[duplicate].SourceDocument, merged[duplicate].SourcePage = r.SourceDocument, r.SourcePage
+		}
+	}
+	return merged
+}
+
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		set[w] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for w := range a {
+		if b[w] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
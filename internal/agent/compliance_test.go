@@ -0,0 +1,608 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// scriptedModel ist ein Fake-ChatModel, das vorgegebene Antworten der Reihe nach liefert
+// und alle Aufrufe protokolliert. WithTools teilt Skript und Protokoll.
+type scriptedModel struct {
+	state *scriptState
+	tools []*schema.ToolInfo
+}
+
+type scriptState struct {
+	mu      sync.Mutex
+	replies []scriptedReply
+	// reply wird statt replies genutzt, wenn gesetzt (z. B. für parallele Aufrufe)
+	reply func(call recordedCall) scriptedReply
+	calls []recordedCall
+}
+
+type scriptedReply struct {
+	msg *schema.Message
+	err error
+}
+
+type recordedCall struct {
+	messages []*schema.Message
+	tools    []*schema.ToolInfo
+	options  *model.Options
+}
+
+func newScriptedModel(replies ...scriptedReply) *scriptedModel {
+	return &scriptedModel{state: &scriptState{replies: replies}}
+}
+
+func (m *scriptedModel) Generate(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	call := recordedCall{
+		messages: append([]*schema.Message(nil), in...),
+		tools:    m.tools,
+		options:  model.GetCommonOptions(&model.Options{}, opts...),
+	}
+	m.state.calls = append(m.state.calls, call)
+
+	if m.state.reply != nil {
+		r := m.state.reply(call)
+		return r.msg, r.err
+	}
+	if len(m.state.replies) == 0 {
+		return nil, errors.New("script exhausted")
+	}
+	r := m.state.replies[0]
+	m.state.replies = m.state.replies[1:]
+	return r.msg, r.err
+}
+
+// Stream liefert die Skriptantwort in kleinen Stücken, wie ein Anbieter Tool-Argumente streamt
+func (m *scriptedModel) Stream(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := []*schema.Message{{Role: schema.Assistant, Content: msg.Content}}
+	for i, call := range msg.ToolCalls {
+		index := i
+		args := call.Function.Arguments
+		first := true
+		for len(args) > 0 || first {
+			n := min(7, len(args))
+			delta := schema.ToolCall{Index: &index, Function: schema.FunctionCall{Arguments: args[:n]}}
+			if first {
+				delta.ID, delta.Type, delta.Function.Name = call.ID, call.Type, call.Function.Name
+				first = false
+			}
+			chunks = append(chunks, &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{delta}})
+			args = args[n:]
+		}
+	}
+	return schema.StreamReaderFromArray(chunks), nil
+}
+
+func (m *scriptedModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return &scriptedModel{state: m.state, tools: tools}, nil
+}
+
+func (m *scriptedModel) callsFor(tool string) []recordedCall {
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	var calls []recordedCall
+	for _, c := range m.state.calls {
+		if len(c.tools) > 0 && c.tools[0].Name == tool {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func toolCallReply(name, args string) scriptedReply {
+	return scriptedReply{msg: schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call_" + name,
+		Type:     "function",
+		Function: schema.FunctionCall{Name: name, Arguments: args},
+	}})}
+}
+
+func textReply(content string) scriptedReply {
+	return scriptedReply{msg: schema.AssistantMessage(content, nil)}
+}
+
+const validAssessment = `{
+	"is_feasible": false,
+	"requirements": [{
+		"title": "ISO 9001",
+		"category": "nachweis",
+		"source_quote": "Der Bieter hat ein Zertifikat nach DIN EN ISO 9001 vorzulegen.",
+		"source_document": "Bewerbungsbedingungen.pdf",
+		"source_page": 4,
+		"status": "not_fulfilled",
+		"is_knockout": true
+	}],
+	"blockers": [{"kind": "missing_document", "description": "ISO 9001 Zertifikat"}]
+}`
+
+func newTestAgent(t *testing.T, fake *scriptedModel, cfg ComplianceAgentConfig) *ComplianceAgent {
+	t.Helper()
+	a, err := newComplianceAgent(context.Background(), fake, cfg)
+	if err != nil {
+		t.Fatalf("newComplianceAgent: %v", err)
+	}
+	return a
+}
+
+func testInput() ComplianceInput {
+	return ComplianceInput{
+		OCRText: "[DOKUMENT: Bewerbungsbedingungen.pdf]\n[SEITE: 4]\nDer Bieter hat ein Zertifikat nach DIN EN ISO 9001 vorzulegen.",
+		Profile: CompanyProfile{Name: "Muster GmbH", AsOf: "2026-01-01"},
+	}
+}
+
+func TestAssessParsesToolCallWithForcedToolChoice(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_compliance_check", validAssessment))
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	got, err := a.Assess(context.Background(), testInput())
+	if err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	if got.IsFeasible || len(got.Requirements) != 1 || got.Requirements[0].SourcePage != 4 {
+		t.Fatalf("unexpected assessment: %+v", got)
+	}
+
+	calls := fake.callsFor("submit_compliance_check")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 call, got %d", len(calls))
+	}
+	if tc := calls[0].options.ToolChoice; tc == nil || *tc != schema.ToolChoiceForced {
+		t.Errorf("expected forced tool choice, got %v", tc)
+	}
+
+	// Firmenprofil als synthetischer Tool-Aufruf samt Ergebnis
+	msgs := calls[0].messages
+	last := msgs[len(msgs)-1]
+	if last.Role != schema.Tool || last.ToolCallID != companyProfileToolCallID || !strings.Contains(last.Content, "Muster GmbH") {
+		t.Errorf("expected company profile tool result as last message, got %+v", last)
+	}
+	if prev := msgs[len(msgs)-2]; len(prev.ToolCalls) != 1 || prev.ToolCalls[0].Function.Name != companyProfileToolName {
+		t.Errorf("expected synthetic get_company_profile call, got %+v", prev)
+	}
+}
+
+func TestAssessStreamsRequirements(t *testing.T) {
+	args := `{"is_feasible": true, "requirements": [
+		{"title": "ISO 9001", "category": "nachweis", "source_quote": "Zertifikat nach ISO 9001 {Anlage 3}", "status": "fulfilled", "is_knockout": true},
+		{"title": "Umsatz", "category": "eignung", "source_quote": "Mindestumsatz 1 Mio. EUR", "status": "unknown", "is_knockout": false}
+	]}`
+	fake := newScriptedModel(toolCallReply("submit_compliance_check", args))
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	var streamed []RequirementAssessment
+	ctx := WithComplianceRequirements(context.Background(), func(r RequirementAssessment) {
+		streamed = append(streamed, r)
+	})
+
+	got, err := a.Assess(ctx, testInput())
+	if err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	if len(got.Requirements) != 2 || !got.IsFeasible {
+		t.Fatalf("unexpected assessment: %+v", got)
+	}
+	if len(streamed) != 2 || streamed[0].Title != "ISO 9001" || streamed[1].Title != "Umsatz" {
+		t.Fatalf("expected both requirements streamed in order, got %+v", streamed)
+	}
+}
+
+func TestArrayItemScannerIncremental(t *testing.T) {
+	full := `{"note": "\"requirements\": [1]", "requirements": [{"a": "}"}, {"b": [1, {"c": 2}]}], "x": 1}`
+	scanner := &arrayItemScanner{key: "requirements"}
+
+	var items []string
+	for i := 1; i <= len(full); i++ {
+		scanner.scan(full[:i], func(raw json.RawMessage) { items = append(items, string(raw)) })
+	}
+
+	want := []string{`{"a": "}"}`, `{"b": [1, {"c": 2}]}`}
+	if len(items) != len(want) {
+		t.Fatalf("expected %d items, got %q", len(want), items)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d: got %q, want %q", i, items[i], want[i])
+		}
+	}
+}
+
+func TestAssessWithoutForcedToolChoice(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_compliance_check", validAssessment))
+	a := newTestAgent(t, fake, ComplianceAgentConfig{DisableForcedToolChoice: true})
+
+	if _, err := a.Assess(context.Background(), testInput()); err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	if tc := fake.callsFor("submit_compliance_check")[0].options.ToolChoice; tc != nil {
+		t.Errorf("expected no tool choice, got %v", *tc)
+	}
+}
+
+func TestAssessFallsBackToJSONInContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"code fence", "Hier ist das Ergebnis:\n```json\n" + validAssessment + "\n```"},
+		{"tool call as text", `{"name": "submit_compliance_check", "arguments": ` + validAssessment + `}`},
+		{"stringified arguments", `{"name": "submit_compliance_check", "arguments": "{\"is_feasible\": true, \"requirements\": []}"}`},
+		{"braces in prose", "Vorab {Hinweis} zum Ergebnis: " + validAssessment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newScriptedModel(textReply(tt.content))
+			a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+			if _, err := a.Assess(context.Background(), testInput()); err != nil {
+				t.Fatalf("Assess: %v", err)
+			}
+			if n := len(fake.callsFor("submit_compliance_check")); n != 1 {
+				t.Errorf("expected no repair round, got %d calls", n)
+			}
+		})
+	}
+}
+
+func TestAssessRepairsTextAnswer(t *testing.T) {
+	fake := newScriptedModel(
+		textReply("Die Bewerbung ist machbar."),
+		toolCallReply("submit_compliance_check", validAssessment),
+	)
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	if _, err := a.Assess(context.Background(), testInput()); err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+
+	calls := fake.callsFor("submit_compliance_check")
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	msgs := calls[1].messages
+	repair := msgs[len(msgs)-1]
+	if repair.Role != schema.User || !strings.Contains(repair.Content, "submit_compliance_check") {
+		t.Errorf("expected repair prompt naming the tool, got %+v", repair)
+	}
+	if answer := msgs[len(msgs)-2]; answer.Role != schema.Assistant || answer.Content != "Die Bewerbung ist machbar." {
+		t.Errorf("expected failed answer in history, got %+v", answer)
+	}
+}
+
+func TestAssessRepairsSchemaViolation(t *testing.T) {
+	invalid := strings.Replace(validAssessment, `"status": "not_fulfilled"`, `"status": "vielleicht"`, 1)
+	fake := newScriptedModel(
+		toolCallReply("submit_compliance_check", invalid),
+		toolCallReply("submit_compliance_check", validAssessment),
+	)
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	if _, err := a.Assess(context.Background(), testInput()); err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+
+	msgs := fake.callsFor("submit_compliance_check")[1].messages
+	toolResult := msgs[len(msgs)-1]
+	if toolResult.Role != schema.Tool || toolResult.ToolCallID != "call_submit_compliance_check" {
+		t.Fatalf("expected tool result for the failed call, got %+v", toolResult)
+	}
+	if !strings.Contains(toolResult.Content, "requirements[0].status") {
+		t.Errorf("expected parse error in repair message, got %q", toolResult.Content)
+	}
+}
+
+func TestAssessRepairsMissingRequiredField(t *testing.T) {
+	fake := newScriptedModel(
+		toolCallReply("submit_compliance_check", `{"requirements": []}`),
+		toolCallReply("submit_compliance_check", validAssessment),
+	)
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	if _, err := a.Assess(context.Background(), testInput()); err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	msgs := fake.callsFor("submit_compliance_check")[1].messages
+	if !strings.Contains(msgs[len(msgs)-1].Content, "is_feasible: required field missing") {
+		t.Errorf("expected missing field in repair message, got %q", msgs[len(msgs)-1].Content)
+	}
+}
+
+func TestAssessRepairsInconsistentFeasibility(t *testing.T) {
+	inconsistent := strings.Replace(validAssessment, `"is_feasible": false`, `"is_feasible": true`, 1)
+	fake := newScriptedModel(
+		toolCallReply("submit_compliance_check", inconsistent),
+		toolCallReply("submit_compliance_check", validAssessment),
+	)
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	got, err := a.Assess(context.Background(), testInput())
+	if err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	if got.IsFeasible {
+		t.Errorf("expected repaired assessment to be infeasible")
+	}
+}
+
+func TestAssessAnswersEveryToolCallInRepair(t *testing.T) {
+	fake := newScriptedModel(
+		toolCallReply(companyProfileToolName, "{}"),
+		toolCallReply("submit_compliance_check", validAssessment),
+	)
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	if _, err := a.Assess(context.Background(), testInput()); err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	msgs := fake.callsFor("submit_compliance_check")[1].messages
+	last := msgs[len(msgs)-1]
+	if last.Role != schema.Tool || last.ToolCallID != "call_"+companyProfileToolName {
+		t.Errorf("expected tool result for get_company_profile call, got %+v", last)
+	}
+}
+
+func TestAssessReturnsModelErrorAfterRepairs(t *testing.T) {
+	fake := newScriptedModel(
+		textReply("Ich kann das nicht beurteilen."),
+		textReply("Leider nein."),
+		textReply("Immer noch nicht."),
+	)
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	_, err := a.Assess(context.Background(), testInput())
+
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) {
+		t.Fatalf("expected *ModelError, got %T: %v", err, err)
+	}
+	if modelErr.Attempts != 3 || modelErr.Content != "Immer noch nicht." {
+		t.Errorf("unexpected model error: %+v", modelErr)
+	}
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		t.Errorf("model failure must not be a transport error")
+	}
+	if n := len(fake.callsFor("submit_compliance_check")); n != 3 {
+		t.Errorf("expected 3 calls, got %d", n)
+	}
+}
+
+func TestAssessRespectsMaxRepairAttempts(t *testing.T) {
+	fake := newScriptedModel(textReply("nein"), textReply("nein"))
+	a := newTestAgent(t, fake, ComplianceAgentConfig{MaxRepairAttempts: -1})
+
+	_, err := a.Assess(context.Background(), testInput())
+
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) || modelErr.Attempts != 1 {
+		t.Fatalf("expected *ModelError after a single attempt, got %v", err)
+	}
+}
+
+func TestAssessReturnsTransportError(t *testing.T) {
+	unavailable := errors.New("502 bad gateway")
+	fake := newScriptedModel(scriptedReply{err: unavailable})
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	_, err := a.Assess(context.Background(), testInput())
+
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		t.Fatalf("expected *TransportError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, unavailable) {
+		t.Errorf("expected original error to be wrapped")
+	}
+	var modelErr *ModelError
+	if errors.As(err, &modelErr) {
+		t.Errorf("transport failure must not be a model error")
+	}
+	if n := len(fake.callsFor("submit_compliance_check")); n != 1 {
+		t.Errorf("transport errors must not trigger repairs, got %d calls", n)
+	}
+}
+
+func TestAssessSectionsKeepsPartialResults(t *testing.T) {
+	fake := newScriptedModel()
+	fake.state.reply = func(call recordedCall) scriptedReply {
+		switch call.tools[0].Name {
+		case "submit_requirements":
+			section := call.messages[1].Content
+			if strings.Contains(section, "kaputt") {
+				return scriptedReply{err: errors.New("timeout")}
+			}
+			return toolCallReply("submit_requirements", `{"requirements": [
+				{"title": "ISO 9001", "category": "nachweis", "source_quote": "Zertifikat nach DIN EN ISO 9001 vorzulegen", "source_page": 4, "is_knockout": false},
+				{"title": "Referenzen", "category": "eignung", "source_quote": "drei Referenzen der letzten fünf Jahre", "is_knockout": `+map[bool]string{true: "true", false: "false"}[strings.Contains(section, "K.O.")]+`}
+			]}`)
+		default:
+			return toolCallReply("submit_requirement_verdicts", `{"is_feasible": true, "verdicts": [{"id": 0, "status": "fulfilled", "evidence": "ISO 9001 im Profil"}]}`)
+		}
+	}
+
+	a := newTestAgent(t, fake, ComplianceAgentConfig{MaxConcurrency: 2})
+
+	var mu sync.Mutex
+	var stages []string
+	ctx := WithComplianceProgress(context.Background(), func(p ComplianceProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		stages = append(stages, p.Stage)
+	})
+
+	result, err := a.AssessSections(ctx, ComplianceGraphInput{
+		Sections: []DocumentSection{
+			{ID: 0, Label: "Teil A", Text: "Abschnitt A"},
+			{ID: 1, Label: "Teil B", Text: "Abschnitt B kaputt"},
+			{ID: 2, Label: "Teil C", Text: "Abschnitt C K.O."},
+		},
+		Profile: CompanyProfile{Name: "Muster GmbH"},
+	})
+	if err != nil {
+		t.Fatalf("AssessSections: %v", err)
+	}
+
+	if result.SectionsTotal != 3 || len(result.FailedSections) != 1 || result.FailedSections[0].Label != "Teil B" {
+		t.Fatalf("unexpected failed sections: %+v", result.FailedSections)
+	}
+
+	reqs := result.Assessment.Requirements
+	if len(reqs) != 2 {
+		t.Fatalf("expected duplicates to be merged into 2 requirements, got %+v", reqs)
+	}
+	if reqs[0].Status != RequirementStatusFulfilled || reqs[0].Evidence == "" {
+		t.Errorf("expected verdict to be applied, got %+v", reqs[0])
+	}
+	if reqs[1].Status != RequirementStatusUnknown || !reqs[1].IsKnockout {
+		t.Errorf("expected missing verdict as unknown and knockout merged, got %+v", reqs[1])
+	}
+
+	if stages[0] != ComplianceStageMap || stages[len(stages)-1] != ComplianceStageAssess {
+		t.Errorf("unexpected progress stages: %v", stages)
+	}
+}
+
+func TestAssessSectionsFailsWhenAllSectionsFail(t *testing.T) {
+	fake := newScriptedModel()
+	fake.state.reply = func(call recordedCall) scriptedReply {
+		return scriptedReply{err: errors.New("connection reset")}
+	}
+	a := newTestAgent(t, fake, ComplianceAgentConfig{})
+
+	_, err := a.AssessSections(context.Background(), ComplianceGraphInput{
+		Sections: []DocumentSection{{ID: 0, Label: "Teil A", Text: "A"}, {ID: 1, Label: "Teil B", Text: "B"}},
+	})
+
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		t.Fatalf("expected *TransportError, got %T: %v", err, err)
+	}
+}
+
+func TestMergeRequirements(t *testing.T) {
+	req := func(title, category, quote string, page int, knockout bool) ExtractedRequirement {
+		return ExtractedRequirement{Title: title, Category: category, SourceQuote: quote, SourceDocument: "Vergabeunterlagen.pdf", SourcePage: page, IsKnockout: knockout}
+	}
+
+	tests := []struct {
+		name       string
+		reqs       []ExtractedRequirement
+		wantQuotes []string
+		check      func(t *testing.T, merged []ExtractedRequirement)
+	}{
+		{
+			name: "same title from different lots stays separate",
+			reqs: []ExtractedRequirement{
+				req("Referenzen", "eignung", "Los 1: drei Referenzen über Elektroinstallationen in Schulgebäuden", 3, false),
+				req("Referenzen", "eignung", "Los 2: zwei Referenzen über Heizungsanlagen ab 500 kW", 7, false),
+			},
+			wantQuotes: []string{
+				"Los 1: drei Referenzen über Elektroinstallationen in Schulgebäuden",
+				"Los 2: zwei Referenzen über Heizungsanlagen ab 500 kW",
+			},
+		},
+		{
+			name: "same quote with different titles is merged",
+			reqs: []ExtractedRequirement{
+				req("Referenzen", "eignung", "drei Referenzen der letzten fünf Jahre", 0, false),
+				req("Referenzprojekte", "nachweis", "Drei Referenzen der letzten fünf Jahre.", 4, true),
+			},
+			wantQuotes: []string{"drei Referenzen der letzten fünf Jahre"},
+			check: func(t *testing.T, merged []ExtractedRequirement) {
+				if !merged[0].IsKnockout || merged[0].SourcePage != 4 {
+					t.Errorf("expected knockout and page to be merged, got %+v", merged[0])
+				}
+			},
+		},
+		{
+			name: "title breaks ties between similar quotes",
+			reqs: []ExtractedRequirement{
+				req("Umsatz", "eignung", "Nachweis des Umsatzes der letzten drei Geschäftsjahre für Bauleistungen gesamt netto vorlegen", 2, false),
+				req("Umsatz Los 2", "nachweis", "Nachweis des Umsatzes der letzten drei Geschäftsjahre für Bauleistungen (Los 2) vorlegen", 5, false),
+				// gleich ähnlich zu beiden Zitaten, der Titel entscheidet
+				req("Umsatz Los 2", "nachweis", "Nachweis des Umsatzes der letzten drei Geschäftsjahre für Bauleistungen netto (Los) vorlegen", 6, true),
+			},
+			wantQuotes: []string{
+				"Nachweis des Umsatzes der letzten drei Geschäftsjahre für Bauleistungen gesamt netto vorlegen",
+				"Nachweis des Umsatzes der letzten drei Geschäftsjahre für Bauleistungen (Los 2) vorlegen",
+			},
+			check: func(t *testing.T, merged []ExtractedRequirement) {
+				if merged[0].IsKnockout || !merged[1].IsKnockout {
+					t.Errorf("expected duplicate to merge into the requirement with the same title, got %+v", merged)
+				}
+			},
+		},
+		{
+			name: "first occurrence is kept",
+			reqs: []ExtractedRequirement{
+				req("Haftpflicht", "nachweis", "Nachweis einer Betriebshaftpflichtversicherung", 2, false),
+				req("Betriebshaftpflicht", "nachweis", "Nachweis einer Betriebshaftpflichtversicherung", 9, false),
+			},
+			wantQuotes: []string{"Nachweis einer Betriebshaftpflichtversicherung"},
+			check: func(t *testing.T, merged []ExtractedRequirement) {
+				if merged[0].Title != "Haftpflicht" || merged[0].SourcePage != 2 {
+					t.Errorf("expected first occurrence, got %+v", merged[0])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeRequirements(tt.reqs)
+			var quotes []string
+			for _, r := range merged {
+				quotes = append(quotes, r.SourceQuote)
+			}
+			if !slices.Equal(quotes, tt.wantQuotes) {
+				t.Fatalf("merged quotes = %q, want %q", quotes, tt.wantQuotes)
+			}
+			if tt.check != nil {
+				tt.check(t, merged)
+			}
+		})
+	}
+}
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		ok      bool
+	}{
+		{"plain", `{"a": 1}`, `{"a": 1}`, true},
+		{"braces in strings", `Text {"a": "x}y{"} Ende`, `{"a": "x}y{"}`, true},
+		{"escaped quote", `{"a": "sagt \"}\""}`, `{"a": "sagt \"}\""}`, true},
+		{"skips invalid", `{kein json} dann {"b": 2}`, `{"b": 2}`, true},
+		{"no json", "keine Daten", "", false},
+		{"unterminated", `{"a": 1`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := extractJSONObject(tt.content, "submit")
+			if ok != tt.ok || string(got) != tt.want {
+				t.Errorf("extractJSONObject(%q) = %q, %v; want %q, %v", tt.content, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Obergrenze für den Lebenslauf-Text im Prompt (ein Lebenslauf hat selten mehr als 10 Seiten)
+const cvMaxInputChars = 60_000
+
+// CVDegree ist ein Ausbildungs- oder Studienabschluss
+type CVDegree struct {
+	Degree      string `json:"degree" jsonschema:"description=Abschluss wie angegeben, z. B. 'Dipl.-Ing. (FH)', 'M.Sc.', 'Meister', 'Geselle'"`
+	Field       string `json:"field,omitempty" jsonschema:"description=Fachrichtung, z. B. 'Elektrotechnik' (leer, wenn nicht genannt)"`
+	Institution string `json:"institution,omitempty" jsonschema:"description=Hochschule, Kammer oder Schule (leer, wenn nicht genannt)"`
+	Year        int    `json:"year,omitempty" jsonschema:"description=Abschlussjahr (0, wenn nicht genannt)"`
+}
+
+// CVProjectRole ist eine Projektrolle aus dem Lebenslauf
+type CVProjectRole struct {
+	Project     string `json:"project" jsonschema:"description=Projekt oder Auftrag"`
+	Role        string `json:"role" jsonschema:"description=Funktion im Projekt, z. B. 'Projektleiter', 'Bauleiter', 'Fachplaner TGA'"`
+	Client      string `json:"client,omitempty" jsonschema:"description=Auftraggeber (leer, wenn nicht genannt)"`
+	FromYear    int    `json:"from_year,omitempty" jsonschema:"description=Beginn (Jahr, 0 wenn nicht genannt)"`
+	ToYear      int    `json:"to_year,omitempty" jsonschema:"description=Ende (Jahr, 0 wenn laufend oder nicht genannt)"`
+	Description string `json:"description,omitempty" jsonschema:"description=Kurzbeschreibung der Leistung, höchstens zwei Sätze"`
+}
+
+// CVProfile ist das Ziel-Struct der Lebenslauf-Extraktion
+type CVProfile struct {
+	FullName          string          `json:"full_name" jsonschema:"description=Vor- und Nachname der Person"`
+	Position          string          `json:"position,omitempty" jsonschema:"description=Aktuelle Funktion im Unternehmen, z. B. 'Projektleiter' (leer, wenn nicht erkennbar)"`
+	YearsOfExperience int             `json:"years_of_experience" jsonschema:"description=Berufserfahrung in Jahren seit dem ersten Berufsabschluss bis zum Stichtag (0, wenn nicht erkennbar)"`
+	Degrees           []CVDegree      `json:"degrees,omitempty" jsonschema:"description=Ausbildungs- und Studienabschlüsse (leer, wenn keine genannt)"`
+	Certifications    []string        `json:"certifications,omitempty" jsonschema:"description=Persönliche Qualifikationen und Bescheinigungen, z. B. 'SiGeKo nach RAB 30', 'Sachkunde Asbest TRGS 519', 'PMP'"`
+	Skills            []string        `json:"skills,omitempty" jsonschema:"description=Fachkenntnisse und Software als kurze Begriffe, z. B. 'KNX', 'AutoCAD', 'HOAI Leistungsphasen 1-9'"`
+	Languages         []string        `json:"languages,omitempty" jsonschema:"description=Sprachen mit Niveau, z. B. 'Deutsch (Muttersprache)', 'Englisch (C1)'"`
+	ProjectRoles      []CVProjectRole `json:"project_roles,omitempty" jsonschema:"description=Projekte mit Funktion der Person, neueste zuerst"`
+	Summary           string          `json:"summary,omitempty" jsonschema:"description=Kurzprofil in zwei bis drei Sätzen, sachlich und ohne Wertung"`
+}
+
+func (p *CVProfile) validate() error {
+	var problems []string
+	if strings.TrimSpace(p.FullName) == "" {
+		problems = append(problems, "full_name is empty")
+	}
+	if p.YearsOfExperience < 0 || p.YearsOfExperience > 60 {
+		problems = append(problems, fmt.Sprintf("years_of_experience %d is out of range", p.YearsOfExperience))
+	}
+	for i, d := range p.Degrees {
+		if strings.TrimSpace(d.Degree) == "" {
+			problems = append(problems, fmt.Sprintf("degrees[%d].degree is empty", i))
+		}
+	}
+	for i, r := range p.ProjectRoles {
+		if strings.TrimSpace(r.Project) == "" || strings.TrimSpace(r.Role) == "" {
+			problems = append(problems, fmt.Sprintf("project_roles[%d] needs project and role", i))
+		}
+		if r.FromYear > 0 && r.ToYear > 0 && r.ToYear < r.FromYear {
+			problems = append(problems, fmt.Sprintf("project_roles[%d].to_year is before from_year", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid cv profile: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CVExtractionResult ist der strukturierte Lebenslauf; Truncated meldet gekürzten Text
+type CVExtractionResult struct {
+	Profile   CVProfile `json:"profile"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Run       RunInfo   `json:"-"`
+}
+
+// CVExtractionAgent liest Abschlüsse, Qualifikationen und Projektrollen aus Lebensläufen
+type CVExtractionAgent struct {
+	submit  *structuredCall[CVProfile]
+	prompts *promptResolver
+}
+
+// NewCVExtractionAgent nutzt dieselbe Modellkonfiguration wie der Compliance-Agent
+func NewCVExtractionAgent(ctx context.Context, cfg ComplianceAgentConfig) (*CVExtractionAgent, error) {
+	chatModel, _, err := newOpenRouterChatModel(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newCVExtractionAgent(chatModel, cfg)
+}
+
+func newCVExtractionAgent(chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*CVExtractionAgent, error) {
+	callCfg := structuredCallConfig{
+		ForceToolChoice: !cfg.DisableForcedToolChoice,
+		MaxRepairs:      cfg.MaxRepairAttempts,
+	}
+	if callCfg.MaxRepairs == 0 {
+		callCfg.MaxRepairs = defaultMaxRepairAttempts
+	}
+
+	submit, err := newStructuredCall[CVProfile](chatModel,
+		"submit_cv", "Reicht die strukturierten Angaben aus dem Lebenslauf ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := newPromptResolver(AgentCVExtraction, cfg, &CVProfile{})
+	if err != nil {
+		return nil, err
+	}
+	return &CVExtractionAgent{submit: submit, prompts: prompts}, nil
+}
+
+// Extract strukturiert einen Lebenslauf. asOf (YYYY-MM-DD) ist der Stichtag für die
+// Berufsjahre. Listen sind nie nil, leere Einträge und Dubletten werden entfernt.
+func (a *CVExtractionAgent) Extract(ctx context.Context, text, asOf string) (*CVExtractionResult, error) {
+	if a == nil || a.submit == nil {
+		return nil, errors.New("cv extraction agent is not initialized")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("cv text is empty")
+	}
+	ctx, run, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CVExtractionResult{Run: run.info}
+	if len(text) > cvMaxInputChars {
+		text = truncate(text, cvMaxInputChars)
+		result.Truncated = true
+	}
+
+	profile, err := a.submit.invoke(ctx, []*schema.Message{
+		schema.SystemMessage(run.prompts["system"]),
+		schema.UserMessage(fmt.Sprintf("Stichtag: %s\n\n%s", asOf, text)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profile.FullName = strings.TrimSpace(profile.FullName)
+	profile.Position = strings.TrimSpace(profile.Position)
+	profile.Summary = strings.TrimSpace(profile.Summary)
+	profile.Certifications = uniqueTerms(profile.Certifications)
+	profile.Skills = uniqueTerms(profile.Skills)
+	profile.Languages = uniqueTerms(profile.Languages)
+	if profile.Degrees == nil {
+		profile.Degrees = []CVDegree{}
+	}
+	if profile.ProjectRoles == nil {
+		profile.ProjectRoles = []CVProjectRole{}
+	}
+	result.Profile = profile
+	return result, nil
+}
+
+// uniqueTerms entfernt leere Einträge und Dubletten (Groß-/Kleinschreibung egal)
+func uniqueTerms(terms []string) []string {
+	out := []string{}
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		key := normalizeText(term)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, term)
+	}
+	return out
+}
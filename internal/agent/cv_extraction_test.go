@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newTestCVExtractionAgent(t *testing.T, fake *scriptedModel) *CVExtractionAgent {
+	t.Helper()
+	a, err := newCVExtractionAgent(fake, ComplianceAgentConfig{})
+	if err != nil {
+		t.Fatalf("newCVExtractionAgent: %v", err)
+	}
+	return a
+}
+
+func TestCVExtractionNormalizesProfile(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_cv", `{
+		"full_name": " Anna Weber ",
+		"position": "Projektleiterin",
+		"years_of_experience": 12,
+		"degrees": [{"degree": "Dipl.-Ing. (FH)", "field": "Elektrotechnik", "year": 2012}],
+		"certifications": ["SiGeKo nach RAB 30", "sigeko nach RAB 30", " "],
+		"skills": ["KNX", "AutoCAD"],
+		"project_roles": [{"project": "Neubau Feuerwache", "role": "Projektleiterin", "from_year": 2021, "to_year": 2023}]
+	}`))
+	a := newTestCVExtractionAgent(t, fake)
+
+	result, err := a.Extract(context.Background(), "Lebenslauf Anna Weber ...", "2026-10-19")
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	p := result.Profile
+	if p.FullName != "Anna Weber" || p.YearsOfExperience != 12 {
+		t.Errorf("unexpected profile: %+v", p)
+	}
+	if len(p.Certifications) != 1 || p.Certifications[0] != "SiGeKo nach RAB 30" {
+		t.Errorf("expected deduplicated certifications, got %q", p.Certifications)
+	}
+	if p.Languages == nil {
+		t.Error("expected empty languages instead of nil")
+	}
+	if result.Truncated {
+		t.Error("short text must not be truncated")
+	}
+
+	calls := fake.callsFor("submit_cv")
+	if !strings.Contains(calls[0].messages[1].Content, "Stichtag: 2026-10-19") {
+		t.Errorf("expected reference date in prompt, got %q", calls[0].messages[1].Content)
+	}
+}
+
+func TestCVExtractionRepairsInvalidProfile(t *testing.T) {
+	fake := newScriptedModel(
+		toolCallReply("submit_cv", `{"full_name": "", "years_of_experience": 80}`),
+		toolCallReply("submit_cv", `{"full_name": "Jonas Brandt", "years_of_experience": 8}`),
+	)
+	a := newTestCVExtractionAgent(t, fake)
+
+	result, err := a.Extract(context.Background(), strings.Repeat("Berufserfahrung ", cvMaxInputChars/8), "2026-10-19")
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if result.Profile.FullName != "Jonas Brandt" || !result.Truncated {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if n := len(fake.callsFor("submit_cv")); n != 2 {
+		t.Errorf("expected one repair, got %d calls", n)
+	}
+}
+
+func TestCVExtractionRejectsEmptyText(t *testing.T) {
+	a := newTestCVExtractionAgent(t, newScriptedModel())
+	if _, err := a.Extract(context.Background(), "  ", "2026-10-19"); err == nil {
+		t.Fatal("expected error for empty text")
+	}
+}
@@ -0,0 +1,245 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/eino-contrib/jsonschema"
+)
+
+// Mitgelieferte Prompts liegen unter prompts/<agent>/<version>/<name>.txt
+//
+//go:embed prompts
+var builtinPrompts embed.FS
+
+// Agents mit versionierten Prompts
+const (
+	AgentCompliance      = "compliance"
+	AgentChecklist       = "checklist"
+	AgentTenderQA        = "tender_qa"
+	AgentBidderQuestions = "bidder_questions"
+	AgentBidDraft        = "bid_draft"
+	AgentCVExtraction    = "cv_extraction"
+	AgentStaffing        = "staffing"
+)
+
+// DefaultPromptVersion ist die Version, die ohne Registry-Eintrag gilt
+const DefaultPromptVersion = "v1"
+
+// DefaultModel gilt, wenn weder Konfiguration noch Registry ein Modell vorgeben
+const DefaultModel = "openai/gpt-4o"
+
+// PromptConfig ist die aktive Konfiguration eines Agents: Prompt-Version mit Texten sowie
+// optional Modell und Temperatur (leer bzw. nil = Werte aus ComplianceAgentConfig)
+type PromptConfig struct {
+	Version     string
+	Prompts     map[string]string
+	Model       string
+	Temperature *float32
+}
+
+// PromptSource liefert die aktive Konfiguration je Agent (z. B. die Prompt-Registry).
+// nil als Ergebnis heißt: mitgelieferte Standard-Prompts verwenden.
+type PromptSource interface {
+	PromptConfig(ctx context.Context, agentName string) (*PromptConfig, error)
+}
+
+// RunInfo hält fest, mit welchen Prompts und welchem Modell ein Ergebnis entstanden ist.
+// PromptVersion ist "<version>@<hash>"; der Hash läuft über Prompts und Tool-Schemas,
+// damit auch geänderte Texte unter gleicher Versionsnummer auffallen.
+type RunInfo struct {
+	PromptVersion string `json:"prompt_version"`
+	ModelID       string `json:"model_id"`
+}
+
+// BuiltinPrompts liefert die mitgelieferten Prompts eines Agents in einer Version
+func BuiltinPrompts(agentName, version string) (map[string]string, error) {
+	dir := path.Join("prompts", agentName, version)
+	entries, err := fs.ReadDir(builtinPrompts, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt version %s/%s", agentName, version)
+	}
+
+	prompts := make(map[string]string, len(entries))
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".txt")
+		if e.IsDir() || !ok {
+			continue
+		}
+		text, err := builtinPrompts.ReadFile(path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		prompts[name] = strings.TrimSpace(string(text))
+	}
+	return prompts, nil
+}
+
+// PromptNames sind die Prompts, die eine Version des Agents vollständig enthalten muss
+func PromptNames(agentName string) []string {
+	prompts, err := BuiltinPrompts(agentName, DefaultPromptVersion)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(prompts))
+	for name := range prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// agentRun ist die für einen Lauf festgelegte Konfiguration
+type agentRun struct {
+	agent   string
+	info    RunInfo
+	prompts map[string]string
+	options []model.Option
+}
+
+type agentRunKey struct{}
+
+// runOptions liefert Modell und Temperatur des festgelegten Laufs für jeden Modellaufruf
+func runOptions(ctx context.Context) []model.Option {
+	run, _ := ctx.Value(agentRunKey{}).(*agentRun)
+	if run == nil {
+		return nil
+	}
+	return run.options
+}
+
+// promptResolver bestimmt je Lauf Prompts, Modell und Temperatur eines Agents
+type promptResolver struct {
+	agent       string
+	source      PromptSource
+	model       string
+	temperature float32
+	builtin     *PromptConfig
+	schemaHash  []byte
+}
+
+// newPromptResolver liest die Standard-Prompts des Agents; schemas sind die Tool-Structs,
+// die in die Prompt-Version einfließen
+func newPromptResolver(agentName string, cfg ComplianceAgentConfig, schemas ...any) (*promptResolver, error) {
+	prompts, err := BuiltinPrompts(agentName, DefaultPromptVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	modelName := strings.TrimSpace(cfg.Model)
+	if modelName == "" {
+		modelName = DefaultModel
+	}
+
+	reflector := jsonschema.Reflector{ExpandedStruct: true}
+	h := sha256.New()
+	for _, v := range schemas {
+		schemaJSON, err := json.Marshal(reflector.Reflect(v))
+		if err != nil {
+			return nil, fmt.Errorf("reflect tool schema: %w", err)
+		}
+		h.Write(schemaJSON)
+		h.Write([]byte{0})
+	}
+
+	return &promptResolver{
+		agent:       agentName,
+		source:      cfg.Prompts,
+		model:       modelName,
+		temperature: cfg.Temperature,
+		builtin:     &PromptConfig{Version: DefaultPromptVersion, Prompts: prompts},
+		schemaHash:  h.Sum(nil),
+	}, nil
+}
+
+// pin legt die Konfiguration für alle folgenden Aufrufe mit dem zurückgegebenen Kontext
+// fest. Ist für diesen Agent schon ein Lauf festgelegt, bleibt er bestehen.
+func (r *promptResolver) pin(ctx context.Context) (context.Context, *agentRun, error) {
+	if run, ok := ctx.Value(agentRunKey{}).(*agentRun); ok && run.agent == r.agent {
+		return ctx, run, nil
+	}
+
+	cfg := r.builtin
+	if r.source != nil {
+		loaded, err := r.source.PromptConfig(ctx, r.agent)
+		if err != nil {
+			return ctx, nil, fmt.Errorf("load prompt config for %s: %w", r.agent, err)
+		}
+		if loaded != nil {
+			if err := r.check(loaded); err != nil {
+				return ctx, nil, err
+			}
+			cfg = loaded
+		}
+	}
+
+	modelName := r.model
+	if m := strings.TrimSpace(cfg.Model); m != "" {
+		modelName = m
+	}
+	temperature := r.temperature
+	if cfg.Temperature != nil {
+		temperature = *cfg.Temperature
+	}
+
+	run := &agentRun{
+		agent:   r.agent,
+		info:    RunInfo{PromptVersion: cfg.Version + "@" + r.hash(cfg.Prompts), ModelID: modelName},
+		prompts: cfg.Prompts,
+		options: []model.Option{model.WithModel(modelName), model.WithTemperature(temperature)},
+	}
+	return context.WithValue(ctx, agentRunKey{}, run), run, nil
+}
+
+// prompt liefert einen Prompt des festgelegten Laufs (ohne Lauf: mitgelieferte Version)
+func (r *promptResolver) prompt(ctx context.Context, name string) string {
+	if run, ok := ctx.Value(agentRunKey{}).(*agentRun); ok && run.agent == r.agent {
+		return run.prompts[name]
+	}
+	return r.builtin.Prompts[name]
+}
+
+// check stellt sicher, dass eine Version alle Prompts des Agents enthält
+func (r *promptResolver) check(cfg *PromptConfig) error {
+	if strings.TrimSpace(cfg.Version) == "" {
+		return fmt.Errorf("prompt config for %s has no version", r.agent)
+	}
+	var missing []string
+	for name := range r.builtin.Prompts {
+		if strings.TrimSpace(cfg.Prompts[name]) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("prompt version %s/%s is missing: %s", r.agent, cfg.Version, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (r *promptResolver) hash(prompts map[string]string) string {
+	names := make([]string, 0, len(prompts))
+	for name := range r.builtin.Prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(prompts[name]))
+		h.Write([]byte{0})
+	}
+	h.Write(r.schemaHash)
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePromptSource liefert je Agent eine feste Konfiguration und zählt die Abfragen
+type fakePromptSource struct {
+	mu      sync.Mutex
+	configs map[string]*PromptConfig
+	calls   int
+}
+
+func (s *fakePromptSource) PromptConfig(ctx context.Context, agentName string) (*PromptConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return s.configs[agentName], nil
+}
+
+func (s *fakePromptSource) set(agentName string, cfg *PromptConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs[agentName] = cfg
+}
+
+func TestBuiltinPromptsAreComplete(t *testing.T) {
+	want := map[string][]string{
+		AgentCompliance:      {"extraction", "system", "verdict"},
+		AgentChecklist:       {"system"},
+		AgentTenderQA:        {"system"},
+		AgentBidderQuestions: {"system"},
+		AgentBidDraft:        {"outline", "section"},
+		AgentCVExtraction:    {"system"},
+		AgentStaffing:        {"requirements", "team"},
+	}
+	for agentName, names := range want {
+		if got := PromptNames(agentName); strings.Join(got, ",") != strings.Join(names, ",") {
+			t.Errorf("%s: expected prompts %v, got %v", agentName, names, got)
+		}
+		prompts, err := BuiltinPrompts(agentName, DefaultPromptVersion)
+		if err != nil {
+			t.Fatalf("%s: %v", agentName, err)
+		}
+		for _, name := range names {
+			if strings.TrimSpace(prompts[name]) == "" {
+				t.Errorf("%s/%s is empty", agentName, name)
+			}
+		}
+	}
+	if _, err := BuiltinPrompts(AgentChecklist, "v999"); err == nil {
+		t.Error("expected error for unknown version")
+	}
+}
+
+func TestPromptSourceSelectsPromptsAndModel(t *testing.T) {
+	temperature := float32(0.7)
+	source := &fakePromptSource{configs: map[string]*PromptConfig{
+		AgentChecklist: {
+			Version:     "v2",
+			Prompts:     map[string]string{"system": "Neuer Checklisten-Prompt"},
+			Model:       "anthropic/claude-sonnet-4",
+			Temperature: &temperature,
+		},
+	}}
+	fake := newScriptedModel(toolCallReply("submit_checklist", `{"items": []}`))
+	a, err := newChecklistAgent(fake, ComplianceAgentConfig{Model: "openai/gpt-4o", Temperature: 0.2, Prompts: source})
+	if err != nil {
+		t.Fatalf("newChecklistAgent: %v", err)
+	}
+
+	result, err := a.Generate(context.Background(), []DocumentSection{{ID: 0, Label: "Teil A", Text: "Teil A"}})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !strings.HasPrefix(result.Run.PromptVersion, "v2@") || result.Run.ModelID != "anthropic/claude-sonnet-4" {
+		t.Errorf("unexpected run info: %+v", result.Run)
+	}
+
+	call := fake.state.calls[0]
+	if call.messages[0].Content != "Neuer Checklisten-Prompt" {
+		t.Errorf("expected registry prompt, got %q", call.messages[0].Content)
+	}
+	if call.options.Model == nil || *call.options.Model != "anthropic/claude-sonnet-4" {
+		t.Errorf("expected model override, got %v", call.options.Model)
+	}
+	if call.options.Temperature == nil || *call.options.Temperature != 0.7 {
+		t.Errorf("expected temperature override, got %v", call.options.Temperature)
+	}
+}
+
+func TestPromptVersionFallsBackToConfig(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_checklist", `{"items": []}`))
+	a, err := newChecklistAgent(fake, ComplianceAgentConfig{Model: "openai/gpt-4o-mini", Temperature: 0.2})
+	if err != nil {
+		t.Fatalf("newChecklistAgent: %v", err)
+	}
+
+	result, err := a.Generate(context.Background(), []DocumentSection{{ID: 0, Label: "Teil A", Text: "Teil A"}})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !strings.HasPrefix(result.Run.PromptVersion, DefaultPromptVersion+"@") || result.Run.ModelID != "openai/gpt-4o-mini" {
+		t.Errorf("unexpected run info: %+v", result.Run)
+	}
+	if call := fake.state.calls[0]; call.options.Temperature == nil || *call.options.Temperature != 0.2 {
+		t.Errorf("expected configured temperature, got %v", call.options.Temperature)
+	}
+
+	// Gleiche Versionsnummer mit anderem Text ergibt eine andere Prompt-Version
+	source := &fakePromptSource{configs: map[string]*PromptConfig{
+		AgentChecklist: {Version: DefaultPromptVersion, Prompts: map[string]string{"system": "geändert"}},
+	}}
+	changed, err := newChecklistAgent(newScriptedModel(toolCallReply("submit_checklist", `{"items": []}`)),
+		ComplianceAgentConfig{Model: "openai/gpt-4o-mini", Prompts: source})
+	if err != nil {
+		t.Fatalf("newChecklistAgent: %v", err)
+	}
+	other, err := changed.Generate(context.Background(), []DocumentSection{{ID: 0, Label: "Teil A", Text: "Teil A"}})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if other.Run.PromptVersion == result.Run.PromptVersion {
+		t.Errorf("expected different prompt version for changed text, both %q", other.Run.PromptVersion)
+	}
+}
+
+func TestIncompletePromptVersionIsRejected(t *testing.T) {
+	source := &fakePromptSource{configs: map[string]*PromptConfig{
+		AgentBidDraft: {Version: "v2", Prompts: map[string]string{"outline": "nur Gliederung"}},
+	}}
+	fake := newScriptedModel()
+	a, err := newBidDraftAgent(fake, ComplianceAgentConfig{Prompts: source})
+	if err != nil {
+		t.Fatalf("newBidDraftAgent: %v", err)
+	}
+
+	_, err = a.Generate(context.Background(), BidDraftInput{TenderTitle: "Neubau Kita"})
+	if err == nil || !strings.Contains(err.Error(), "section") {
+		t.Fatalf("expected missing section prompt error, got %v", err)
+	}
+	if len(fake.state.calls) != 0 {
+		t.Errorf("expected no model call, got %d", len(fake.state.calls))
+	}
+}
+
+func TestPrepareKeepsRunForSections(t *testing.T) {
+	source := &fakePromptSource{configs: map[string]*PromptConfig{}}
+	fake := newScriptedModel()
+	fake.state.reply = func(call recordedCall) scriptedReply {
+		if len(call.tools) > 0 && call.tools[0].Name == "submit_requirements" {
+			return toolCallReply("submit_requirements", `{"requirements": []}`)
+		}
+		return toolCallReply("submit_requirement_verdicts", `{"is_feasible": true, "verdicts": []}`)
+	}
+	a := newTestAgent(t, fake, ComplianceAgentConfig{Model: "openai/gpt-4o", Prompts: source})
+
+	ctx, run, err := a.Prepare(context.Background())
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+
+	// Umstellung in der Registry während des Laufs
+	source.set(AgentCompliance, &PromptConfig{
+		Version: "v2",
+		Prompts: map[string]string{"system": "s", "extraction": "neu", "verdict": "v"},
+		Model:   "other/model",
+	})
+
+	if _, err := a.AssessSections(ctx, ComplianceGraphInput{Sections: []DocumentSection{{ID: 0, Label: "Teil A", Text: "Teil A"}}}); err != nil {
+		t.Fatalf("AssessSections: %v", err)
+	}
+	if source.calls != 1 {
+		t.Errorf("expected one registry lookup, got %d", source.calls)
+	}
+	call := fake.callsFor("submit_requirements")[0]
+	if call.messages[0].Content == "neu" || *call.options.Model != run.ModelID {
+		t.Errorf("expected pinned run %+v, got prompt %q and model %v", run, call.messages[0].Content, *call.options.Model)
+	}
+}
@@ -0,0 +1,438 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Besetzungsstand einer Personalanforderung
+const (
+	StaffingFulfilled = "fulfilled" // alle Positionen besetzt, Qualifikationen belegt
+	StaffingGaps      = "gaps"      // besetzt, aber Qualifikationen fehlen
+	StaffingUnfilled  = "unfilled"  // mindestens eine Position ohne Vorschlag
+)
+
+// PersonnelRequirement ist eine Anforderung an namentlich zu benennendes Personal
+type PersonnelRequirement struct {
+	Role                   string   `json:"role" jsonschema:"description=Funktion, z. B. 'Projektleitung', 'Bauleitung Elektro', 'SiGeKo'"`
+	Count                  int      `json:"count" jsonschema:"description=Anzahl geforderter Personen (mindestens 1)"`
+	RequiredDegrees        []string `json:"required_degrees,omitempty" jsonschema:"description=Geforderte Abschlüsse, z. B. 'Dipl.-Ing. oder M.Sc. Elektrotechnik', 'Meister im Elektrotechniker-Handwerk' (leer, wenn keine)"`
+	RequiredQualifications []string `json:"required_qualifications,omitempty" jsonschema:"description=Geforderte Qualifikationen, Bescheinigungen und Kenntnisse, z. B. 'SiGeKo nach RAB 30', 'verhandlungssicheres Deutsch' (leer, wenn keine)"`
+	MinYearsExperience     int      `json:"min_years_experience,omitempty" jsonschema:"description=Mindestberufserfahrung in Jahren (0, wenn keine Vorgabe)"`
+	MinProjectReferences   int      `json:"min_project_references,omitempty" jsonschema:"description=Anzahl geforderter Referenzprojekte der Person in dieser Funktion (0, wenn keine Vorgabe)"`
+	Mandatory              bool     `json:"mandatory" jsonschema:"description=true = Mindest- bzw. Eignungsanforderung; false = wird nur gewertet"`
+	SourceQuote            string   `json:"source_quote" jsonschema:"description=Wörtliches Zitat der Anforderung (nicht umformulieren)"`
+	SourceDocument         string   `json:"source_document,omitempty" jsonschema:"description=Name des Dokuments laut [DOKUMENT: ...]-Markierung (leer, wenn nicht erkennbar)"`
+	SourcePage             int      `json:"source_page,omitempty" jsonschema:"description=Seitenzahl laut [SEITE: ...]-Markierung (0, wenn nicht erkennbar)"`
+}
+
+// personnelRequirements ist das Ziel-Struct je Abschnitt
+type personnelRequirements struct {
+	Requirements []PersonnelRequirement `json:"requirements" jsonschema:"description=Personalanforderungen in diesem Abschnitt (leer, wenn keine)"`
+}
+
+func (r *personnelRequirements) validate() error {
+	var problems []string
+	for i, req := range r.Requirements {
+		if strings.TrimSpace(req.Role) == "" {
+			problems = append(problems, fmt.Sprintf("requirements[%d].role is empty", i))
+		}
+		if strings.TrimSpace(req.SourceQuote) == "" {
+			problems = append(problems, fmt.Sprintf("requirements[%d].source_quote is empty", i))
+		}
+		if req.Count < 0 || req.MinYearsExperience < 0 || req.MinProjectReferences < 0 || req.SourcePage < 0 {
+			problems = append(problems, fmt.Sprintf("requirements[%d] has negative numbers", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid requirements: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// teamAssignment ist ein Besetzungsvorschlag des Modells
+type teamAssignment struct {
+	Requirement           int      `json:"requirement" jsonschema:"description=Nummer der Anforderung laut Liste"`
+	CandidateID           string   `json:"candidate_id" jsonschema:"description=ID des Lebenslaufs, exakt wie angegeben"`
+	Rationale             string   `json:"rationale" jsonschema:"description=Warum die Person passt, ein bis zwei Sätze mit Bezug auf den Lebenslauf"`
+	MissingQualifications []string `json:"missing_qualifications,omitempty" jsonschema:"description=Geforderte Abschlüsse, Qualifikationen oder Referenzen, die der Lebenslauf nicht belegt (leer, wenn alles belegt)"`
+}
+
+// teamProposal ist das Ziel-Struct der Teamzusammenstellung
+type teamProposal struct {
+	Assignments []teamAssignment `json:"assignments" jsonschema:"description=Vorgeschlagene Personen je Anforderung (Anforderungen ohne passende Person auslassen)"`
+}
+
+func (p *teamProposal) validate() error {
+	var problems []string
+	for i, a := range p.Assignments {
+		if a.Requirement < 1 {
+			problems = append(problems, fmt.Sprintf("assignments[%d].requirement must be >= 1", i))
+		}
+		if strings.TrimSpace(a.CandidateID) == "" {
+			problems = append(problems, fmt.Sprintf("assignments[%d].candidate_id is empty", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid team: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// StaffingCandidate ist ein Mitarbeiter mit strukturiertem Lebenslauf
+type StaffingCandidate struct {
+	ID      string
+	Profile CVProfile
+}
+
+// StaffingInput sind die Unterlagen (abschnittsweise) und die Lebensläufe der Firma
+type StaffingInput struct {
+	Sections   []DocumentSection
+	Candidates []StaffingCandidate
+}
+
+// StaffedPerson ist eine für eine Funktion vorgeschlagene Person
+type StaffedPerson struct {
+	CandidateID           string   `json:"candidate_id"`
+	Name                  string   `json:"name"`
+	YearsOfExperience     int      `json:"years_of_experience"`
+	Rationale             string   `json:"rationale,omitempty"`
+	MissingQualifications []string `json:"missing_qualifications"`
+	// Weitere Funktionen, für die dieselbe Person vorgeschlagen ist
+	OtherRoles []string `json:"other_roles,omitempty"`
+}
+
+// StaffedRole ist eine Personalanforderung mit Besetzungsvorschlag
+type StaffedRole struct {
+	Requirement   PersonnelRequirement `json:"requirement"`
+	Proposed      []StaffedPerson      `json:"proposed"`
+	OpenPositions int                  `json:"open_positions"`
+	Status        string               `json:"status"` // fulfilled | gaps | unfilled
+}
+
+// TeamProposalResult ist der Teamvorschlag in der Reihenfolge der Anforderungen
+type TeamProposalResult struct {
+	Roles          []StaffedRole   `json:"roles"`
+	SectionsTotal  int             `json:"sections_total"`
+	FailedSections []FailedSection `json:"failed_sections,omitempty"`
+	Run            RunInfo         `json:"-"`
+}
+
+// StaffingAgent ermittelt das geforderte Schlüsselpersonal und schlägt ein Team vor
+type StaffingAgent struct {
+	requirements   *structuredCall[personnelRequirements]
+	team           *structuredCall[teamProposal]
+	prompts        *promptResolver
+	maxConcurrency int
+}
+
+// NewStaffingAgent nutzt dieselbe Modellkonfiguration wie der Compliance-Agent
+func NewStaffingAgent(ctx context.Context, cfg ComplianceAgentConfig) (*StaffingAgent, error) {
+	chatModel, _, err := newOpenRouterChatModel(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newStaffingAgent(chatModel, cfg)
+}
+
+func newStaffingAgent(chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*StaffingAgent, error) {
+	callCfg := structuredCallConfig{
+		ForceToolChoice: !cfg.DisableForcedToolChoice,
+		MaxRepairs:      cfg.MaxRepairAttempts,
+	}
+	if callCfg.MaxRepairs == 0 {
+		callCfg.MaxRepairs = defaultMaxRepairAttempts
+	}
+
+	requirements, err := newStructuredCall[personnelRequirements](chatModel,
+		"submit_personnel_requirements", "Reicht die Anforderungen an das zu benennende Personal ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+	team, err := newStructuredCall[teamProposal](chatModel,
+		"submit_team", "Reicht die vorgeschlagene Besetzung je Anforderung ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := newPromptResolver(AgentStaffing, cfg, &personnelRequirements{}, &teamProposal{})
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrency := cfg.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+	return &StaffingAgent{requirements: requirements, team: team, prompts: prompts, maxConcurrency: maxConcurrency}, nil
+}
+
+// Propose liest die Personalanforderungen abschnittsweise (parallel, begrenzt durch
+// MaxConcurrency), führt gleiche Funktionen zusammen und lässt dann in einem Aufruf die
+// Lebensläufe zuordnen. Unbekannte IDs werden verworfen, die Mindestberufserfahrung wird
+// gegen den Lebenslauf nachgeprüft. Ein Fehler kommt nur, wenn alle Abschnitte oder die
+// Zuordnung fehlschlagen.
+func (a *StaffingAgent) Propose(ctx context.Context, input StaffingInput) (*TeamProposalResult, error) {
+	if a == nil || a.requirements == nil || a.team == nil {
+		return nil, errors.New("staffing agent is not initialized")
+	}
+	ctx, run, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &TeamProposalResult{Roles: []StaffedRole{}, SectionsTotal: len(input.Sections), Run: run.info}
+	if len(input.Sections) == 0 {
+		return result, nil
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	extracted := make([][]PersonnelRequirement, len(input.Sections))
+	sem := make(chan struct{}, a.maxConcurrency)
+
+	for i, section := range input.Sections {
+		wg.Add(1)
+		go func(i int, section DocumentSection) {
+			defer wg.Done()
+
+			var reqs personnelRequirements
+			var err error
+			select {
+			case sem <- struct{}{}:
+				reqs, err = a.requirements.invoke(ctx, []*schema.Message{
+					schema.SystemMessage(run.prompts["requirements"]),
+					schema.UserMessage(section.Text),
+				})
+				<-sem
+			case <-ctx.Done():
+				err = &TransportError{Tool: a.requirements.tool.Name, Err: ctx.Err()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.FailedSections = append(result.FailedSections, FailedSection{SectionID: section.ID, Label: section.Label, Error: err.Error()})
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			extracted[i] = reqs.Requirements
+		}(i, section)
+	}
+	wg.Wait()
+	sort.Slice(result.FailedSections, func(i, j int) bool {
+		return result.FailedSections[i].SectionID < result.FailedSections[j].SectionID
+	})
+
+	if len(result.FailedSections) == len(input.Sections) {
+		return nil, fmt.Errorf("all %d sections failed: %w", len(input.Sections), firstErr)
+	}
+
+	var all []PersonnelRequirement
+	for _, reqs := range extracted {
+		all = append(all, reqs...)
+	}
+	requirements := mergePersonnelRequirements(all)
+	if len(requirements) == 0 {
+		return result, nil
+	}
+
+	var assignments []teamAssignment
+	if len(input.Candidates) > 0 {
+		userMessage, err := staffingAssignment(requirements, input.Candidates)
+		if err != nil {
+			return nil, err
+		}
+		proposal, err := a.team.invoke(ctx, []*schema.Message{
+			schema.SystemMessage(run.prompts["team"]),
+			schema.UserMessage(userMessage),
+		})
+		if err != nil {
+			return nil, err
+		}
+		assignments = proposal.Assignments
+	}
+
+	result.Roles = staffRoles(requirements, input.Candidates, assignments)
+	return result, nil
+}
+
+// mergePersonnelRequirements führt Anforderungen an dieselbe Funktion zusammen. Es gilt
+// jeweils die strengere Vorgabe; Abschlüsse und Qualifikationen werden vereinigt.
+func mergePersonnelRequirements(reqs []PersonnelRequirement) []PersonnelRequirement {
+	merged := []PersonnelRequirement{}
+	index := make(map[string]int)
+
+	for _, req := range reqs {
+		req.Role = strings.TrimSpace(req.Role)
+		if req.Count < 1 {
+			req.Count = 1
+		}
+		req.RequiredDegrees = uniqueTerms(req.RequiredDegrees)
+		req.RequiredQualifications = uniqueTerms(req.RequiredQualifications)
+
+		key := normalizeText(req.Role)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, req)
+			continue
+		}
+
+		m := &merged[i]
+		m.Count = max(m.Count, req.Count)
+		m.MinYearsExperience = max(m.MinYearsExperience, req.MinYearsExperience)
+		m.MinProjectReferences = max(m.MinProjectReferences, req.MinProjectReferences)
+		m.Mandatory = m.Mandatory || req.Mandatory
+		m.RequiredDegrees = uniqueTerms(append(m.RequiredDegrees, req.RequiredDegrees...))
+		m.RequiredQualifications = uniqueTerms(append(m.RequiredQualifications, req.RequiredQualifications...))
+		if m.SourcePage == 0 && req.SourcePage > 0 {
+			m.SourceDocument, m.SourcePage = req.SourceDocument, req.SourcePage
+		}
+	}
+	return merged
+}
+
+// staffingAssignment listet die nummerierten Anforderungen und die Lebensläufe als JSON
+func staffingAssignment(reqs []PersonnelRequirement, candidates []StaffingCandidate) (string, error) {
+	var b strings.Builder
+	b.WriteString("ANFORDERUNGEN:\n")
+	for i, req := range reqs {
+		fmt.Fprintf(&b, "[%d] %s – %d Person(en)", i+1, req.Role, req.Count)
+		if req.Mandatory {
+			b.WriteString(", verbindlich")
+		}
+		if len(req.RequiredDegrees) > 0 {
+			fmt.Fprintf(&b, "; Abschluss: %s", strings.Join(req.RequiredDegrees, " / "))
+		}
+		if len(req.RequiredQualifications) > 0 {
+			fmt.Fprintf(&b, "; Qualifikationen: %s", strings.Join(req.RequiredQualifications, ", "))
+		}
+		if req.MinYearsExperience > 0 {
+			fmt.Fprintf(&b, "; mindestens %d Jahre Berufserfahrung", req.MinYearsExperience)
+		}
+		if req.MinProjectReferences > 0 {
+			fmt.Fprintf(&b, "; %d Referenzprojekte in dieser Funktion", req.MinProjectReferences)
+		}
+		b.WriteString("\n")
+	}
+
+	type candidateJSON struct {
+		ID string `json:"id"`
+		CVProfile
+	}
+	list := make([]candidateJSON, 0, len(candidates))
+	for _, c := range candidates {
+		list = append(list, candidateJSON{ID: c.ID, CVProfile: c.Profile})
+	}
+	payload, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("marshal candidates: %w", err)
+	}
+
+	b.WriteString("\nLEBENSLÄUFE:\n")
+	b.Write(payload)
+	return b.String(), nil
+}
+
+// staffRoles übernimmt die Vorschläge je Anforderung: unbekannte IDs, doppelte Nennungen
+// und Personen über der geforderten Anzahl fallen weg; fehlende Berufsjahre werden ergänzt.
+func staffRoles(reqs []PersonnelRequirement, candidates []StaffingCandidate, assignments []teamAssignment) []StaffedRole {
+	byID := make(map[string]CVProfile, len(candidates))
+	for _, c := range candidates {
+		byID[c.ID] = c.Profile
+	}
+
+	roles := make([]StaffedRole, len(reqs))
+	for i, req := range reqs {
+		roles[i] = StaffedRole{Requirement: req, Proposed: []StaffedPerson{}}
+	}
+
+	rolesByCandidate := make(map[string][]int)
+	for _, a := range assignments {
+		idx := a.Requirement - 1
+		id := strings.TrimSpace(a.CandidateID)
+		profile, known := byID[id]
+		if idx < 0 || idx >= len(roles) || !known {
+			continue
+		}
+		role := &roles[idx]
+		if len(role.Proposed) >= role.Requirement.Count || containsCandidate(role.Proposed, id) {
+			continue
+		}
+
+		missing := uniqueTerms(a.MissingQualifications)
+		if minYears := role.Requirement.MinYearsExperience; minYears > 0 && profile.YearsOfExperience < minYears && !mentionsExperience(missing) {
+			missing = append(missing, fmt.Sprintf("Mindestens %d Jahre Berufserfahrung (Lebenslauf: %d)", minYears, profile.YearsOfExperience))
+		}
+		role.Proposed = append(role.Proposed, StaffedPerson{
+			CandidateID:           id,
+			Name:                  profile.FullName,
+			YearsOfExperience:     profile.YearsOfExperience,
+			Rationale:             strings.TrimSpace(a.Rationale),
+			MissingQualifications: missing,
+		})
+		rolesByCandidate[id] = append(rolesByCandidate[id], idx)
+	}
+
+	for i := range roles {
+		role := &roles[i]
+		for j := range role.Proposed {
+			person := &role.Proposed[j]
+			for _, other := range rolesByCandidate[person.CandidateID] {
+				if other != i {
+					person.OtherRoles = append(person.OtherRoles, roles[other].Requirement.Role)
+				}
+			}
+		}
+
+		role.OpenPositions = role.Requirement.Count - len(role.Proposed)
+		role.Status = StaffingFulfilled
+		switch {
+		case role.OpenPositions > 0:
+			role.Status = StaffingUnfilled
+		case hasMissingQualifications(role.Proposed):
+			role.Status = StaffingGaps
+		}
+	}
+	return roles
+}
+
+func containsCandidate(people []StaffedPerson, id string) bool {
+	for _, p := range people {
+		if p.CandidateID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMissingQualifications(people []StaffedPerson) bool {
+	for _, p := range people {
+		if len(p.MissingQualifications) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionsExperience erkennt, ob das Modell fehlende Berufsjahre schon selbst genannt hat
+func mentionsExperience(missing []string) bool {
+	for _, m := range missing {
+		if strings.Contains(strings.ToLower(m), "berufserfahrung") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func newTestStaffingAgent(t *testing.T, fake *scriptedModel) *StaffingAgent {
+	t.Helper()
+	a, err := newStaffingAgent(fake, ComplianceAgentConfig{MaxConcurrency: 2})
+	if err != nil {
+		t.Fatalf("newStaffingAgent: %v", err)
+	}
+	return a
+}
+
+func staffingCandidates() []StaffingCandidate {
+	return []StaffingCandidate{
+		{ID: "cv-1", Profile: CVProfile{FullName: "Anna Weber", YearsOfExperience: 12}},
+		{ID: "cv-2", Profile: CVProfile{FullName: "Jonas Brandt", YearsOfExperience: 3}},
+	}
+}
+
+func TestStaffingWithoutCVsLeavesRolesUnfilled(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_personnel_requirements", `{"requirements": [
+		{"role": "SiGeKo", "count": 1, "mandatory": true, "source_quote": "SiGeKo nach RAB 30 ist zu benennen"}
+	]}`))
+	a := newTestStaffingAgent(t, fake)
+
+	result, err := a.Propose(context.Background(), StaffingInput{Sections: []DocumentSection{{ID: 0, Label: "Unterlagen", Text: "..."}}})
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+	if len(result.Roles) != 1 || result.Roles[0].Status != StaffingUnfilled || result.Roles[0].OpenPositions != 1 {
+		t.Fatalf("unexpected roles: %+v", result.Roles)
+	}
+	if n := len(fake.callsFor("submit_team")); n != 0 {
+		t.Errorf("expected no team call without CVs, got %d", n)
+	}
+}
+
+func TestStaffRoles(t *testing.T) {
+	lead := PersonnelRequirement{Role: "Projektleitung", Count: 1, MinYearsExperience: 5}
+	site := PersonnelRequirement{Role: "Bauleitung", Count: 2}
+
+	tests := []struct {
+		name         string
+		req          PersonnelRequirement
+		assignments  []teamAssignment
+		wantStatus   string
+		wantOpen     int
+		wantProposed []string
+		// fehlende Qualifikationen der ersten vorgeschlagenen Person
+		wantMissing []string
+	}{
+		{
+			name:         "experienced candidate fulfills role",
+			req:          lead,
+			assignments:  []teamAssignment{{Requirement: 1, CandidateID: "cv-1", Rationale: " Leitete drei Neubauten "}},
+			wantStatus:   StaffingFulfilled,
+			wantProposed: []string{"cv-1"},
+		},
+		{
+			name:         "experience gap is flagged",
+			req:          lead,
+			assignments:  []teamAssignment{{Requirement: 1, CandidateID: "cv-2"}},
+			wantStatus:   StaffingGaps,
+			wantProposed: []string{"cv-2"},
+			wantMissing:  []string{"Mindestens 5 Jahre Berufserfahrung (Lebenslauf: 3)"},
+		},
+		{
+			name:         "experience gap named by model is not repeated",
+			req:          lead,
+			assignments:  []teamAssignment{{Requirement: 1, CandidateID: "cv-2", MissingQualifications: []string{"Berufserfahrung nur 3 Jahre"}}},
+			wantStatus:   StaffingGaps,
+			wantProposed: []string{"cv-2"},
+			wantMissing:  []string{"Berufserfahrung nur 3 Jahre"},
+		},
+		{
+			name: "missing qualifications are deduplicated",
+			req:  PersonnelRequirement{Role: "SiGeKo", Count: 1, RequiredQualifications: []string{"SiGeKo nach RAB 30"}},
+			assignments: []teamAssignment{{Requirement: 1, CandidateID: "cv-1", MissingQualifications: []string{
+				"SiGeKo nach RAB 30", "sigeko nach rab 30", " ",
+			}}},
+			wantStatus:   StaffingGaps,
+			wantProposed: []string{"cv-1"},
+			wantMissing:  []string{"SiGeKo nach RAB 30"},
+		},
+		{
+			name:         "open position leaves role unfilled",
+			req:          site,
+			assignments:  []teamAssignment{{Requirement: 1, CandidateID: "cv-1"}},
+			wantStatus:   StaffingUnfilled,
+			wantOpen:     1,
+			wantProposed: []string{"cv-1"},
+		},
+		{
+			name: "unknown ids, wrong numbers and duplicates are dropped",
+			req:  site,
+			assignments: []teamAssignment{
+				{Requirement: 1, CandidateID: "cv-9"},
+				{Requirement: 7, CandidateID: "cv-2"},
+				{Requirement: 0, CandidateID: "cv-2"},
+				{Requirement: 1, CandidateID: "cv-1"},
+				{Requirement: 1, CandidateID: " cv-1 "},
+			},
+			wantStatus:   StaffingUnfilled,
+			wantOpen:     1,
+			wantProposed: []string{"cv-1"},
+		},
+		{
+			name: "no more people than required",
+			req:  PersonnelRequirement{Role: "Projektleitung", Count: 1},
+			assignments: []teamAssignment{
+				{Requirement: 1, CandidateID: "cv-1"},
+				{Requirement: 1, CandidateID: "cv-2"},
+			},
+			wantStatus:   StaffingFulfilled,
+			wantProposed: []string{"cv-1"},
+		},
+		{
+			name:       "no assignment",
+			req:        lead,
+			wantStatus: StaffingUnfilled,
+			wantOpen:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles := staffRoles([]PersonnelRequirement{tt.req}, staffingCandidates(), tt.assignments)
+			if len(roles) != 1 {
+				t.Fatalf("expected 1 role, got %+v", roles)
+			}
+			role := roles[0]
+
+			var proposed []string
+			for _, p := range role.Proposed {
+				proposed = append(proposed, p.CandidateID)
+			}
+			if role.Status != tt.wantStatus || role.OpenPositions != tt.wantOpen || !slices.Equal(proposed, tt.wantProposed) {
+				t.Errorf("got status %s, open %d, proposed %v; want %s, %d, %v",
+					role.Status, role.OpenPositions, proposed, tt.wantStatus, tt.wantOpen, tt.wantProposed)
+			}
+			if len(role.Proposed) > 0 && !slices.Equal(role.Proposed[0].MissingQualifications, tt.wantMissing) {
+				t.Errorf("missing qualifications = %q, want %q", role.Proposed[0].MissingQualifications, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestStaffRolesListsOtherRoles(t *testing.T) {
+	reqs := []PersonnelRequirement{{Role: "Projektleitung", Count: 1}, {Role: "SiGeKo", Count: 1}}
+	roles := staffRoles(reqs, staffingCandidates(), []teamAssignment{
+		{Requirement: 1, CandidateID: "cv-1"},
+		{Requirement: 2, CandidateID: "cv-1"},
+	})
+
+	for i, want := range []string{"SiGeKo", "Projektleitung"} {
+		if got := roles[i].Proposed[0].OtherRoles; !slices.Equal(got, []string{want}) {
+			t.Errorf("role %d: other roles = %q, want %q", i, got, want)
+		}
+	}
+	if name := roles[0].Proposed[0].Name; name != "Anna Weber" {
+		t.Errorf("expected name from CV, got %q", name)
+	}
+}
+
+func TestMergePersonnelRequirements(t *testing.T) {
+	merged := mergePersonnelRequirements([]PersonnelRequirement{
+		{Role: "Projektleitung", Count: 1, RequiredDegrees: []string{"Dipl.-Ing. Elektrotechnik"}, MinYearsExperience: 5, Mandatory: true},
+		{Role: "Bauleitung", Count: 0},
+		{Role: " projektleitung ", Count: 2, RequiredDegrees: []string{"dipl.-ing. elektrotechnik"}, RequiredQualifications: []string{"SiGeKo"},
+			MinYearsExperience: 8, SourceDocument: "Leistungsbeschreibung.pdf", SourcePage: 4},
+	})
+
+	if len(merged) != 2 || merged[0].Role != "Projektleitung" || merged[1].Role != "Bauleitung" {
+		t.Fatalf("expected roles in first-seen order, got %+v", merged)
+	}
+	lead := merged[0]
+	if lead.Count != 2 || lead.MinYearsExperience != 8 || !lead.Mandatory {
+		t.Errorf("expected stricter values, got %+v", lead)
+	}
+	if !slices.Equal(lead.RequiredDegrees, []string{"Dipl.-Ing. Elektrotechnik"}) || !slices.Equal(lead.RequiredQualifications, []string{"SiGeKo"}) {
+		t.Errorf("expected deduplicated union, got %q / %q", lead.RequiredDegrees, lead.RequiredQualifications)
+	}
+	if lead.SourceDocument != "Leistungsbeschreibung.pdf" || lead.SourcePage != 4 {
+		t.Errorf("expected page reference from second mention, got %q p. %d", lead.SourceDocument, lead.SourcePage)
+	}
+	if merged[1].Count != 1 {
+		t.Errorf("expected count of at least 1, got %d", merged[1].Count)
+	}
+}
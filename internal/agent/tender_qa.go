@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// NotInDocumentsAnswer ist die feste Antwort, wenn die Unterlagen die Frage nicht beantworten
+const NotInDocumentsAnswer = "Das steht nicht in den Unterlagen."
+
+// Rollen im Gesprächsverlauf
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// TenderQAInput ist eine Frage zu einer Ausschreibung samt Verlauf und gefundenen Textstellen
+type TenderQAInput struct {
+	Question string
+	History  []ChatTurn
+	Passages []Passage
+}
+
+// ChatTurn ist eine frühere Nachricht im Gespräch
+type ChatTurn struct {
+	Role    string
+	Content string
+}
+
+// Passage ist eine per Retrieval gefundene Textstelle aus den Unterlagen
+type Passage struct {
+	Document string
+	Page     int
+	Text     string
+}
+
+// Citation ist eine Fundstelle, auf die sich die Antwort stützt
+type Citation struct {
+	Document string `json:"document"`
+	Page     int    `json:"page,omitempty"`
+	Quote    string `json:"quote"`
+}
+
+// TenderAnswer ist die Antwort auf eine Frage. Found ist false, wenn die Unterlagen die Frage
+// nicht beantworten; Answer ist dann NotInDocumentsAnswer.
+type TenderAnswer struct {
+	Answer    string     `json:"answer"`
+	Found     bool       `json:"found"`
+	Citations []Citation `json:"citations"`
+	Run       RunInfo    `json:"-"`
+}
+
+type qaCitation struct {
+	Passage int    `json:"passage" jsonschema:"description=Nummer der Textstelle aus [n]"`
+	Quote   string `json:"quote" jsonschema:"description=Wörtliches Zitat aus dieser Textstelle, das die Antwort belegt"`
+}
+
+type qaAnswer struct {
+	Found     bool         `json:"found" jsonschema:"description=Beantworten die Textstellen die Frage? false, wenn nicht oder nur durch Vermutung"`
+	Answer    string       `json:"answer" jsonschema:"description=Antwort auf Deutsch, knapp und sachlich (leer, wenn found = false)"`
+	Citations []qaCitation `json:"citations" jsonschema:"description=Belege aus den Textstellen (leer, wenn found = false)"`
+}
+
+func (a *qaAnswer) validate() error {
+	if !a.Found {
+		return nil
+	}
+	var problems []string
+	if strings.TrimSpace(a.Answer) == "" {
+		problems = append(problems, "answer is empty although found is true")
+	}
+	if len(a.Citations) == 0 {
+		problems = append(problems, "found is true but no citations given")
+	}
+	for i, c := range a.Citations {
+		if strings.TrimSpace(c.Quote) == "" {
+			problems = append(problems, fmt.Sprintf("citations[%d].quote is empty", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid answer: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// maxQAHistoryTurns begrenzt den Verlauf im Prompt
+const maxQAHistoryTurns = 10
+
+// TenderQAAgent beantwortet Fragen zu einer Ausschreibung auf Basis gefundener Textstellen
+type TenderQAAgent struct {
+	chain   compose.Runnable[TenderQAInput, qaAnswer]
+	prompts *promptResolver
+}
+
+// NewTenderQAAgent nutzt dieselbe Modellkonfiguration wie der Compliance-Agent
+func NewTenderQAAgent(ctx context.Context, cfg ComplianceAgentConfig) (*TenderQAAgent, error) {
+	chatModel, _, err := newOpenRouterChatModel(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newTenderQAAgent(ctx, chatModel, cfg)
+}
+
+func newTenderQAAgent(ctx context.Context, chatModel model.ToolCallingChatModel, cfg ComplianceAgentConfig) (*TenderQAAgent, error) {
+	callCfg := structuredCallConfig{
+		ForceToolChoice: !cfg.DisableForcedToolChoice,
+		MaxRepairs:      cfg.MaxRepairAttempts,
+	}
+	if callCfg.MaxRepairs == 0 {
+		callCfg.MaxRepairs = defaultMaxRepairAttempts
+	}
+
+	submit, err := newStructuredCall[qaAnswer](chatModel,
+		"submit_answer", "Reicht die belegte Antwort auf die Frage ein.", callCfg)
+	if err != nil {
+		return nil, err
+	}
+	prompts, err := newPromptResolver(AgentTenderQA, cfg, &qaAnswer{})
+	if err != nil {
+		return nil, err
+	}
+
+	c := compose.NewChain[TenderQAInput, qaAnswer]()
+	c.AppendLambda(compose.InvokableLambda(func(ctx context.Context, input TenderQAInput) ([]*schema.Message, error) {
+		return qaMessages(prompts.prompt(ctx, "system"), input), nil
+	}))
+	c.AppendLambda(compose.InvokableLambda(submit.invoke))
+
+	runnable, err := c.Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("compile tender qa chain: %w", err)
+	}
+	return &TenderQAAgent{chain: runnable, prompts: prompts}, nil
+}
+
+// Answer beantwortet die Frage. Ohne Textstellen wird das Modell nicht aufgerufen. Zitate
+// werden auf die gelieferten Textstellen zurückgeführt; bleibt kein gültiger Beleg übrig,
+// gilt die Frage als nicht beantwortet.
+func (a *TenderQAAgent) Answer(ctx context.Context, input TenderQAInput) (*TenderAnswer, error) {
+	if a == nil || a.chain == nil {
+		return nil, errors.New("tender qa agent is not initialized")
+	}
+	if len(input.Passages) == 0 {
+		return notInDocuments(RunInfo{}), nil
+	}
+	ctx, run, err := a.prompts.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := a.chain.Invoke(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("agent error: %w", err)
+	}
+	if !result.Found {
+		return notInDocuments(run.info), nil
+	}
+
+	citations := []Citation{}
+	for _, c := range result.Citations {
+		// Nummern im Prompt sind 1-basiert
+		if c.Passage < 1 || c.Passage > len(input.Passages) {
+			continue
+		}
+		p := input.Passages[c.Passage-1]
+		citations = append(citations, Citation{
+			Document: p.Document,
+			Page:     p.Page,
+			Quote:    strings.TrimSpace(c.Quote),
+		})
+	}
+	if len(citations) == 0 {
+		return notInDocuments(run.info), nil
+	}
+
+	return &TenderAnswer{
+		Answer:    strings.TrimSpace(result.Answer),
+		Found:     true,
+		Citations: citations,
+		Run:       run.info,
+	}, nil
+}
+
+// notInDocuments ist die feste Antwort; ohne Modellaufruf bleibt run leer
+func notInDocuments(run RunInfo) *TenderAnswer {
+	return &TenderAnswer{Answer: NotInDocumentsAnswer, Citations: []Citation{}, Run: run}
+}
+
+// qaMessages baut System-Prompt, Verlauf und die Frage mit nummerierten Textstellen
+func qaMessages(systemPrompt string, input TenderQAInput) []*schema.Message {
+	messages := []*schema.Message{schema.SystemMessage(systemPrompt)}
+
+	history := input.History
+	if len(history) > maxQAHistoryTurns {
+		history = history[len(history)-maxQAHistoryTurns:]
+	}
+	for _, turn := range history {
+		switch turn.Role {
+		case ChatRoleUser:
+			messages = append(messages, schema.UserMessage(turn.Content))
+		case ChatRoleAssistant:
+			messages = append(messages, schema.AssistantMessage(turn.Content, nil))
+		}
+	}
+
+	question := "TEXTSTELLEN:\n" + formatPassages(input.Passages) + "FRAGE: " + strings.TrimSpace(input.Question)
+	return append(messages, schema.UserMessage(question))
+}
+
+// formatPassages nummeriert die Textstellen ab 1 und nennt Dokument und Seite
+func formatPassages(passages []Passage) string {
+	var b strings.Builder
+	for i, p := range passages {
+		fmt.Fprintf(&b, "[%d] %s", i+1, p.Document)
+		if p.Page > 0 {
+			fmt.Fprintf(&b, ", Seite %d", p.Page)
+		}
+		b.WriteString("\n")
+		b.WriteString(strings.TrimSpace(p.Text))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func newTestQAAgent(t *testing.T, fake *scriptedModel) *TenderQAAgent {
+	t.Helper()
+	a, err := newTenderQAAgent(context.Background(), fake, ComplianceAgentConfig{})
+	if err != nil {
+		t.Fatalf("newTenderQAAgent: %v", err)
+	}
+	return a
+}
+
+func qaInput() TenderQAInput {
+	return TenderQAInput{
+		Question: "Ist eine Ortsbesichtigung Pflicht?",
+		History: []ChatTurn{
+			{Role: ChatRoleUser, Content: "Wann ist Abgabe?"},
+			{Role: ChatRoleAssistant, Content: "Am 12.03."},
+		},
+		Passages: []Passage{
+			{Document: "Aufforderung.pdf", Page: 2, Text: "Eine Ortsbesichtigung ist nicht vorgesehen."},
+			{Document: "Leistungsbeschreibung.pdf", Page: 7, Text: "Die Ortsbesichtigung am 03.03. ist verpflichtend."},
+		},
+	}
+}
+
+func TestTenderQAResolvesCitationsFromPassages(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_answer", `{"found": true, "answer": "Ja, am 03.03.", "citations": [
+		{"passage": 2, "quote": "Die Ortsbesichtigung am 03.03. ist verpflichtend."},
+		{"passage": 9, "quote": "erfunden"}
+	]}`))
+	a := newTestQAAgent(t, fake)
+
+	got, err := a.Answer(context.Background(), qaInput())
+	if err != nil {
+		t.Fatalf("Answer: %v", err)
+	}
+	if !got.Found || got.Answer != "Ja, am 03.03." {
+		t.Fatalf("unexpected answer: %+v", got)
+	}
+	if len(got.Citations) != 1 || got.Citations[0].Document != "Leistungsbeschreibung.pdf" || got.Citations[0].Page != 7 {
+		t.Fatalf("expected only the valid citation with document and page, got %+v", got.Citations)
+	}
+
+	// Verlauf vor der Frage, Textstellen nummeriert in der letzten Nachricht
+	msgs := fake.callsFor("submit_answer")[0].messages
+	if len(msgs) != 4 || msgs[1].Role != schema.User || msgs[2].Role != schema.Assistant {
+		t.Fatalf("expected system, history and question, got %d messages", len(msgs))
+	}
+	last := msgs[len(msgs)-1].Content
+	if !strings.Contains(last, "[2] Leistungsbeschreibung.pdf, Seite 7") || !strings.HasSuffix(last, "FRAGE: Ist eine Ortsbesichtigung Pflicht?") {
+		t.Errorf("unexpected question message: %q", last)
+	}
+}
+
+func TestTenderQANotFound(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_answer", `{"found": false, "answer": "Vermutlich nein", "citations": []}`))
+	a := newTestQAAgent(t, fake)
+
+	got, err := a.Answer(context.Background(), qaInput())
+	if err != nil {
+		t.Fatalf("Answer: %v", err)
+	}
+	if got.Found || got.Answer != NotInDocumentsAnswer || len(got.Citations) != 0 {
+		t.Fatalf("expected fixed not-found answer, got %+v", got)
+	}
+}
+
+func TestTenderQAWithoutValidCitationIsNotFound(t *testing.T) {
+	fake := newScriptedModel(toolCallReply("submit_answer", `{"found": true, "answer": "Ja", "citations": [{"passage": 0, "quote": "irgendwas"}]}`))
+	a := newTestQAAgent(t, fake)
+
+	got, err := a.Answer(context.Background(), qaInput())
+	if err != nil {
+		t.Fatalf("Answer: %v", err)
+	}
+	if got.Found || got.Answer != NotInDocumentsAnswer {
+		t.Fatalf("expected not-found answer without valid citations, got %+v", got)
+	}
+}
+
+func TestTenderQARepairsAnswerWithoutCitations(t *testing.T) {
+	fake := newScriptedModel(
+		toolCallReply("submit_answer", `{"found": true, "answer": "Ja", "citations": []}`),
+		toolCallReply("submit_answer", `{"found": true, "answer": "Ja", "citations": [{"passage": 2, "quote": "ist verpflichtend"}]}`),
+	)
+	a := newTestQAAgent(t, fake)
+
+	got, err := a.Answer(context.Background(), qaInput())
+	if err != nil {
+		t.Fatalf("Answer: %v", err)
+	}
+	if !got.Found || len(got.Citations) != 1 {
+		t.Fatalf("unexpected answer: %+v", got)
+	}
+	if n := len(fake.callsFor("submit_answer")); n != 2 {
+		t.Errorf("expected one repair, got %d calls", n)
+	}
+}
+
+func TestTenderQAWithoutPassagesSkipsModel(t *testing.T) {
+	fake := newScriptedModel()
+	a := newTestQAAgent(t, fake)
+
+	input := qaInput()
+	input.Passages = nil
+	got, err := a.Answer(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Answer: %v", err)
+	}
+	if got.Found || got.Answer != NotInDocumentsAnswer {
+		t.Fatalf("unexpected answer: %+v", got)
+	}
+	if n := len(fake.callsFor("submit_answer")); n != 0 {
+		t.Errorf("expected no model call, got %d", n)
+	}
+}
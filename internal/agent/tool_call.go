@@ -0,0 +1,532 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+)
+
+const defaultMaxRepairAttempts = 2
+
+// ModelError: das Modell hat geantwortet, aber auch nach allen Reparaturversuchen kein
+// gültiges Ergebnis über das Tool gemeldet.
+type ModelError struct {
+	Tool     string
+	Attempts int
+	Err      error
+	// Content ist der Text der letzten Antwort (gekürzt), falls vorhanden
+	Content string
+}
+
+func (e *ModelError) Error() string {
+	return fmt.Sprintf("model returned no valid %s result after %d attempts: %v", e.Tool, e.Attempts, e.Err)
+}
+
+func (e *ModelError) Unwrap() error { return e.Err }
+
+// TransportError: der Aufruf des Modell-Anbieters selbst ist fehlgeschlagen
+// (Netzwerk, HTTP-Fehler, Timeout, Abbruch).
+type TransportError struct {
+	Tool string
+	Err  error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("model call for %s failed: %v", e.Tool, e.Err)
+}
+
+func (e *TransportError) Unwrap() error { return e.Err }
+
+// validator wird von Ergebnistypen implementiert, die über das JSON-Schema hinaus
+// inhaltlich geprüft werden
+type validator interface {
+	validate() error
+}
+
+// structuredCall ruft ein Modell auf und erwartet das Ergebnis als Aufruf eines Tools.
+// Antwortet das Modell mit Text, mit ungültigem JSON oder schemawidrig, wird es mit der
+// Fehlermeldung erneut gefragt (höchstens maxRepairs Mal). Reine Textantworten werden
+// vorher noch auf eingebettetes JSON untersucht.
+type structuredCall[T any] struct {
+	model      model.ToolCallingChatModel
+	tool       *schema.ToolInfo
+	schema     *jsonschema.Schema
+	force      bool
+	maxRepairs int
+
+	// streamItems: Wenn gesetzt und streamItems(ctx) eine Funktion liefert, wird gestreamt und
+	// jedes vollständige Element des Arrays streamKey sofort gemeldet
+	streamKey   string
+	streamItems func(ctx context.Context) func(json.RawMessage)
+}
+
+type structuredCallConfig struct {
+	ForceToolChoice bool
+	MaxRepairs      int
+	// ExtraTools werden zusätzlich gebunden (z. B. get_company_profile für den Verlauf)
+	ExtraTools []*schema.ToolInfo
+}
+
+func newStructuredCall[T any](chatModel model.ToolCallingChatModel, name, desc string, cfg structuredCallConfig) (*structuredCall[T], error) {
+	reflector := jsonschema.Reflector{ExpandedStruct: true}
+	var zero T
+	jsonSchema := reflector.Reflect(&zero)
+
+	tool := &schema.ToolInfo{
+		Name:        name,
+		Desc:        desc,
+		ParamsOneOf: schema.NewParamsOneOfByJSONSchema(jsonSchema),
+	}
+
+	bound, err := chatModel.WithTools(append([]*schema.ToolInfo{tool}, cfg.ExtraTools...))
+	if err != nil {
+		return nil, fmt.Errorf("bind tool %s: %w", name, err)
+	}
+
+	maxRepairs := cfg.MaxRepairs
+	if maxRepairs < 0 {
+		maxRepairs = 0
+	}
+
+	return &structuredCall[T]{
+		model:      bound,
+		tool:       tool,
+		schema:     jsonSchema,
+		force:      cfg.ForceToolChoice,
+		maxRepairs: maxRepairs,
+	}, nil
+}
+
+// invoke führt den Aufruf inklusive Reparaturschleife aus
+func (c *structuredCall[T]) invoke(ctx context.Context, messages []*schema.Message) (T, error) {
+	var zero T
+
+	opts := append([]model.Option(nil), runOptions(ctx)...)
+	if c.force {
+		opts = append(opts, model.WithToolChoice(schema.ToolChoiceForced))
+	}
+
+	history := append([]*schema.Message(nil), messages...)
+	var lastErr error
+	var lastContent string
+
+	var onItem func(json.RawMessage)
+	if c.streamItems != nil && c.streamKey != "" {
+		onItem = c.streamItems(ctx)
+	}
+
+	for attempt := 0; attempt <= c.maxRepairs; attempt++ {
+		var msg *schema.Message
+		var err error
+		if onItem != nil {
+			msg, err = c.generateStream(ctx, history, opts, onItem)
+		} else {
+			msg, err = c.model.Generate(ctx, history, opts...)
+		}
+		if err != nil {
+			return zero, &TransportError{Tool: c.tool.Name, Err: err}
+		}
+		if msg == nil {
+			return zero, &TransportError{Tool: c.tool.Name, Err: errors.New("empty response")}
+		}
+
+		result, err := c.parse(msg)
+		if err == nil {
+			return result, nil
+		}
+		lastErr, lastContent = err, truncate(msg.Content, 500)
+
+		history = append(history, c.repairMessages(msg, err)...)
+	}
+
+	return zero, &ModelError{
+		Tool:     c.tool.Name,
+		Attempts: c.maxRepairs + 1,
+		Err:      lastErr,
+		Content:  lastContent,
+	}
+}
+
+// generateStream streamt die Antwort und meldet Array-Elemente der Tool-Argumente, sobald
+// sie vollständig sind. Nach einer Reparatur können Elemente erneut gemeldet werden.
+func (c *structuredCall[T]) generateStream(ctx context.Context, history []*schema.Message, opts []model.Option, onItem func(json.RawMessage)) (*schema.Message, error) {
+	sr, err := c.model.Stream(ctx, history, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+
+	var chunks []*schema.Message
+	args := make(map[int]*strings.Builder)
+	names := make(map[int]string)
+	scanner := &arrayItemScanner{key: c.streamKey}
+
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+
+		for i, call := range chunk.ToolCalls {
+			index := i
+			if call.Index != nil {
+				index = *call.Index
+			}
+			if call.Function.Name != "" {
+				names[index] = call.Function.Name
+			}
+			if args[index] == nil {
+				args[index] = &strings.Builder{}
+			}
+			args[index].WriteString(call.Function.Arguments)
+
+			if names[index] == c.tool.Name {
+				scanner.scan(args[index].String(), onItem)
+			}
+		}
+	}
+
+	if len(chunks) == 0 {
+		return nil, errors.New("empty stream")
+	}
+	return schema.ConcatMessages(chunks)
+}
+
+// arrayItemScanner findet in einem wachsenden JSON-Text die vollständigen Objekte des
+// Arrays key (z. B. "requirements": [{...}, {...}, ...)
+type arrayItemScanner struct {
+	key  string
+	next int // 0 = Array noch nicht gefunden
+	done bool
+}
+
+func (s *arrayItemScanner) scan(buf string, emit func(json.RawMessage)) {
+	if s.done {
+		return
+	}
+	if s.next == 0 {
+		start, ok := findArrayStart(buf, s.key)
+		if !ok {
+			return
+		}
+		s.next = start
+	}
+
+	for {
+		for s.next < len(buf) && strings.IndexByte(" \t\r\n,", buf[s.next]) >= 0 {
+			s.next++
+		}
+		if s.next >= len(buf) {
+			return
+		}
+		switch buf[s.next] {
+		case ']':
+			s.done = true
+			return
+		case '{':
+			end := matchingBrace(buf, s.next)
+			if end < 0 {
+				return
+			}
+			item := json.RawMessage(buf[s.next : end+1])
+			s.next = end + 1
+			if json.Valid(item) {
+				emit(item)
+			}
+		default:
+			// Kein Objekt-Array -> nichts zu melden
+			s.done = true
+			return
+		}
+	}
+}
+
+// findArrayStart liefert die Position hinter `"key": [`, sofern schon vorhanden
+func findArrayStart(buf, key string) (int, bool) {
+	needle := `"` + key + `"`
+	offset := 0
+	for {
+		idx := strings.Index(buf[offset:], needle)
+		if idx < 0 {
+			return 0, false
+		}
+		idx += offset
+		offset = idx + len(needle)
+		if idx > 0 && buf[idx-1] == '\\' {
+			continue
+		}
+
+		pos := offset
+		for pos < len(buf) && strings.IndexByte(" \t\r\n", buf[pos]) >= 0 {
+			pos++
+		}
+		if pos >= len(buf) {
+			return 0, false
+		}
+		if buf[pos] != ':' {
+			continue
+		}
+		pos++
+		for pos < len(buf) && strings.IndexByte(" \t\r\n", buf[pos]) >= 0 {
+			pos++
+		}
+		if pos >= len(buf) {
+			return 0, false
+		}
+		if buf[pos] != '[' {
+			continue
+		}
+		return pos + 1, true
+	}
+}
+
+// parse liest das Ergebnis aus dem Tool-Aufruf oder, als Rückfall, aus JSON im Text
+func (c *structuredCall[T]) parse(msg *schema.Message) (T, error) {
+	var zero T
+
+	raw, err := c.extractArguments(msg)
+	if err != nil {
+		return zero, err
+	}
+
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return zero, fmt.Errorf("arguments are not valid JSON: %w", err)
+	}
+	if problems := validateSchema(c.schema, c.schema, generic, ""); len(problems) > 0 {
+		return zero, fmt.Errorf("schema validation failed: %s", strings.Join(problems, "; "))
+	}
+
+	var result T
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return zero, fmt.Errorf("decode arguments: %w", err)
+	}
+	if v, ok := any(&result).(validator); ok {
+		if err := v.validate(); err != nil {
+			return zero, err
+		}
+	}
+	return result, nil
+}
+
+func (c *structuredCall[T]) extractArguments(msg *schema.Message) (json.RawMessage, error) {
+	for _, call := range msg.ToolCalls {
+		if call.Function.Name == c.tool.Name {
+			return json.RawMessage(call.Function.Arguments), nil
+		}
+	}
+	if len(msg.ToolCalls) > 0 {
+		return nil, fmt.Errorf("called tool %q instead of %q", msg.ToolCalls[0].Function.Name, c.tool.Name)
+	}
+
+	raw, ok := extractJSONObject(msg.Content, c.tool.Name)
+	if !ok {
+		return nil, fmt.Errorf("no call of tool %q and no JSON object in the answer", c.tool.Name)
+	}
+	return raw, nil
+}
+
+// repairMessages hängt die fehlerhafte Antwort samt Fehlermeldung an den Verlauf an.
+// Jeder Tool-Aufruf braucht eine Antwort, sonst lehnen Anbieter den Verlauf ab.
+func (c *structuredCall[T]) repairMessages(msg *schema.Message, parseErr error) []*schema.Message {
+	instruction := fmt.Sprintf("Deine Antwort konnte nicht verarbeitet werden: %s. Rufe jetzt das Tool '%s' mit vollständigen, gültigen Argumenten auf. Antworte NICHT mit Text.",
+		parseErr.Error(), c.tool.Name)
+
+	repair := []*schema.Message{schema.AssistantMessage(msg.Content, msg.ToolCalls)}
+	for _, call := range msg.ToolCalls {
+		content := "Fehler: " + instruction
+		if call.Function.Name != c.tool.Name {
+			content = "Dieses Ergebnis liegt bereits im Verlauf vor. " + instruction
+		}
+		repair = append(repair, schema.ToolMessage(content, call.ID, schema.WithToolName(call.Function.Name)))
+	}
+	if len(msg.ToolCalls) == 0 {
+		repair = append(repair, schema.UserMessage(instruction))
+	}
+	return repair
+}
+
+// extractJSONObject sucht das erste JSON-Objekt im Text (auch in ```json-Blöcken).
+// Hat das Modell den Tool-Aufruf als Text geschrieben ({"name": ..., "arguments": {...}}),
+// werden die Argumente zurückgegeben.
+func extractJSONObject(content, toolName string) (json.RawMessage, bool) {
+	for start := strings.IndexByte(content, '{'); start >= 0; {
+		if end := matchingBrace(content, start); end > start {
+			candidate := json.RawMessage(content[start : end+1])
+			if json.Valid(candidate) {
+				var call struct {
+					Name       string          `json:"name"`
+					Arguments  json.RawMessage `json:"arguments"`
+					Parameters json.RawMessage `json:"parameters"`
+				}
+				if json.Unmarshal(candidate, &call) == nil && call.Name == toolName {
+					if len(call.Arguments) > 0 {
+						return unquoteArguments(call.Arguments), true
+					}
+					if len(call.Parameters) > 0 {
+						return call.Parameters, true
+					}
+				}
+				return candidate, true
+			}
+		}
+
+		next := strings.IndexByte(content[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return nil, false
+}
+
+// unquoteArguments behandelt Argumente, die als JSON-String statt als Objekt vorliegen
+func unquoteArguments(raw json.RawMessage) json.RawMessage {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return json.RawMessage(s)
+	}
+	return raw
+}
+
+// matchingBrace liefert die Position der schließenden Klammer zu content[start] == '{'
+func matchingBrace(content string, start int) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(content); i++ {
+		ch := content[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case ch == '"':
+			inString = !inString
+		case inString:
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// validateSchema prüft einen dekodierten JSON-Wert gegen das per Reflection erzeugte
+// Schema (Typen, Pflichtfelder, Enums). Unterstützt den Umfang, den der Reflector erzeugt;
+// verschachtelte Structs liegen als $ref in den $defs des Wurzelschemas.
+func validateSchema(root, s *jsonschema.Schema, value any, path string) []string {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		def, ok := root.Definitions[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown schema reference %s", path, s.Ref)}
+		}
+		return validateSchema(root, def, value, path)
+	}
+	label := path
+	if label == "" {
+		label = "root"
+	}
+
+	var problems []string
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return []string{label + ": expected object"}
+		}
+		for _, key := range s.Required {
+			if _, ok := obj[key]; !ok {
+				problems = append(problems, joinPath(path, key)+": required field missing")
+			}
+		}
+		if s.Properties != nil {
+			keys := make([]string, 0, len(obj))
+			for key := range obj {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if prop, ok := s.Properties.Get(key); ok && obj[key] != nil {
+					problems = append(problems, validateSchema(root, prop, obj[key], joinPath(path, key))...)
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return []string{label + ": expected array"}
+		}
+		for i, item := range items {
+			problems = append(problems, validateSchema(root, s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return []string{label + ": expected string"}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return []string{label + ": expected integer"}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return []string{label + ": expected number"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{label + ": expected boolean"}
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		allowed := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", label, value, s.Enum))
+		}
+	}
+	return problems
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max] + "…"
+}
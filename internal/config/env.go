@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// LoadEnvFiles lädt KEY=VALUE-Paare aus den angegebenen Dateien in die Prozess-Umgebung.
+// Fehlende Dateien werden übersprungen, bereits gesetzte Variablen nicht überschrieben.
+func LoadEnvFiles(paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		if err := parseEnvFile(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseEnvFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		line = strings.TrimPrefix(line, "$env:")
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(kv[0])
+		if key == "" {
+			continue
+		}
+
+		value := strings.TrimSpace(kv[1])
+		value = strings.Trim(value, `"'`)
+
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -17,6 +17,7 @@ type Company struct {
 	Name                string          `json:"name"`
 	LegalForm           string          `json:"legal_form"`
 	TaxID               string          `json:"tax_id"`
+	Industry            string          `json:"industry"`
 	IndustryTags        pq.StringArray  `gorm:"type:text[]" json:"industry_tags"`
 	ContactName         string          `json:"contact_name"`
 	ContactEmail        string          `json:"contact_email"`
@@ -32,6 +33,7 @@ type Company struct {
 	EmployeeCount       int             `json:"employee_count"`
 	AnnualRevenue       float64         `gorm:"type:numeric(12,2)" json:"annual_revenue"`
 	FoundingYear        int             `json:"founding_year"`
+	IsAVPQ              bool            `gorm:"column:is_avpq;default:false" json:"is_avpq"`
 	ProfileSummary      string          `json:"profile_summary"`
 	ProfileEmbedding    pgvector.Vector `gorm:"type:vector(1536);<-:update" json:"profile_embedding"` // Changed to 100 dims as per SQL
 	Certifications      json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"certifications"`
@@ -61,6 +63,9 @@ type Tender struct {
 	ProcedureType        string          `json:"procedure_type"`
 	AwardCriteria        string          `json:"award_criteria"`
 	EstimatedValue       float64         `gorm:"type:numeric(20,2)" json:"estimated_value"`
+	MinAnnualRevenue     *float64        `gorm:"type:numeric(20,2)" json:"min_annual_revenue"`
+	MinEmployeeCount     *int            `json:"min_employee_count"`
+	RequiredCertificates pq.StringArray  `gorm:"type:text[]" json:"required_certificates"`
 	Currency             string          `gorm:"default:'EUR'" json:"currency"`
 	BudgetType           string          `json:"budget_type"`
 	PublishedAt          *time.Time      `gorm:"type:timestamptz" json:"published_at"`
@@ -81,6 +86,11 @@ type Tender struct {
 	ScrapedAt            *time.Time      `gorm:"type:timestamptz" json:"scraped_at"`
 	CreatedAt            time.Time       `gorm:"type:timestamptz;default:now()" json:"created_at"`
 
+	// Frist für Bieterfragen aus den Unterlagen (für alle Firmen gleich); von Hand
+	// gesetzte Fristen liegen je Firma in TenderQuestionDeadline
+	QuestionDeadlineAt    *time.Time `gorm:"type:timestamptz" json:"question_deadline_at"`
+	QuestionDeadlineQuote string     `json:"question_deadline_quote,omitempty"`
+
 	// Legacy fields for compatibility (mapped to new columns)
 	Deadline  time.Time `gorm:"-" json:"deadline,omitempty"`
 	RegionZIP string    `gorm:"-" json:"region_zip,omitempty"`
@@ -95,24 +105,95 @@ type Match struct {
 	Reason    string    `gorm:"column:reason_text" json:"reason_text"`
 	Status    string    `json:"status"`
 
+	// Ergebnis der Eignungsprüfung (wird beim Matching berechnet, nicht gespeichert)
+	Eligibility       string             `gorm:"-" json:"eligibility"`
+	EligibilityIssues []EligibilityIssue `gorm:"-" json:"eligibility_issues,omitempty"`
+
 	Company Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
 	Tender  Tender  `gorm:"foreignKey:TenderID" json:"tender,omitempty"`
 }
 
 type ComplianceCheck struct {
-	ID             uuid.UUID      `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	CompanyID      uuid.UUID      `gorm:"type:uuid;index" json:"company_id"`
-	TenderID       uuid.UUID      `gorm:"type:uuid;index" json:"tender_id"`
-	IsFeasible     bool           `json:"is_feasible"`
-	MissingDocs    pq.StringArray `gorm:"type:text[]" json:"missing_docs"`
-	CriticalIssues pq.StringArray `gorm:"type:text[]" json:"critical_issues"`
-	CreatedAt      time.Time      `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID  uuid.UUID `gorm:"type:uuid;index" json:"company_id"`
+	TenderID   uuid.UUID `gorm:"type:uuid;index" json:"tender_id"`
+	IsFeasible bool      `json:"is_feasible"`
+	// InputDocuments protokolliert, welche Dokumente in die Prüfung eingeflossen sind ([]ComplianceInputDocument)
+	InputDocuments json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"input_documents"`
+	CreatedAt      time.Time       `gorm:"type:timestamptz;default:now()" json:"created_at"`
+
+	// Cache-Schlüssel: gleiche Unterlagen, gleiches Profil und gleiche Prompt-/Modellversion
+	// liefern das gespeicherte Ergebnis statt eines neuen LLM-Laufs
+	InputHash     string `json:"input_hash"`
+	ProfileHash   string `json:"profile_hash"`
+	PromptVersion string `json:"prompt_version"`
+	ModelID       string `gorm:"column:model_id" json:"model_id"`
+
+	// Veraltet, sobald ein Anhang hinzukommt oder das Firmenprofil geändert wird
+	IsStale     bool       `gorm:"default:false" json:"is_stale"`
+	StaleReason string     `json:"stale_reason,omitempty"`
+	StaleAt     *time.Time `gorm:"type:timestamptz" json:"stale_at,omitempty"`
+
+	// Teilergebnis: mindestens ein Abschnitt ist fehlgeschlagen (siehe InputDocuments);
+	// wird nie aus dem Cache geliefert, da Blocker aus diesen Abschnitten fehlen können
+	IsPartial bool `gorm:"default:false" json:"is_partial"`
+
+	// Cached ist true, wenn das Ergebnis aus dem Cache stammt (nicht gespeichert)
+	Cached bool `gorm:"-" json:"cached"`
+
+	// Einzelne Anforderungen und Blocker (werden mit dem Check angelegt)
+	Requirements []ComplianceRequirement `gorm:"foreignKey:ComplianceCheckID" json:"requirements"`
+	Blockers     []ComplianceBlocker     `gorm:"foreignKey:ComplianceCheckID" json:"blockers"`
 
 	// Relationen (Optional, falls GORM Preloading genutzt wird)
 	Company Company `gorm:"foreignKey:CompanyID" json:"-"`
 	Tender  Tender  `gorm:"foreignKey:TenderID" json:"-"`
 }
 
+// Status eines Dokuments in der Eingabe des Compliance-Agents
+const (
+	InputDocumentIncluded = "included"
+	InputDocumentPartial  = "partial"
+	InputDocumentSkipped  = "skipped"
+)
+
+// ComplianceInputDocument beschreibt, ob und wie weit ein Dokument in die Prüfung eingeflossen ist
+type ComplianceInputDocument struct {
+	Name          string     `json:"name"`
+	AttachmentID  *uuid.UUID `json:"attachment_id,omitempty"`
+	Status        string     `json:"status"` // included | partial | skipped
+	Reason        string     `json:"reason,omitempty"`
+	Pages         int        `json:"pages"`
+	TotalChars    int        `json:"total_chars"`
+	IncludedChars int        `json:"included_chars"`
+}
+
+// ComplianceRequirement ist eine einzelne Anforderung aus den Vergabeunterlagen mit Quelle und Bewertung
+type ComplianceRequirement struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ComplianceCheckID uuid.UUID `gorm:"type:uuid;index" json:"compliance_check_id"`
+	Position          int       `json:"position"`
+	Title             string    `json:"title"`
+	Category          string    `json:"category"` // eignung | ausschluss | nachweis | technical
+	SourceQuote       string    `json:"source_quote"`
+	SourceDocument    string    `json:"source_document"`
+	SourcePage        *int      `json:"source_page"`
+	Status            string    `json:"status"` // fulfilled | not_fulfilled | unknown
+	Evidence          string    `json:"evidence"`
+	IsKnockout        bool      `gorm:"default:false" json:"is_knockout"`
+	CreatedAt         time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+// ComplianceBlocker ist ein fehlender Nachweis oder ein K.O.-Kriterium eines Checks
+type ComplianceBlocker struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ComplianceCheckID uuid.UUID `gorm:"type:uuid;index" json:"compliance_check_id"`
+	Position          int       `json:"position"`
+	Kind              string    `json:"kind"` // missing_document | critical_issue
+	Description       string    `json:"description"`
+	CreatedAt         time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
 // TenderAttachment repräsentiert ein PDF/Dokument das zu einer Ausschreibung gehört
 type TenderAttachment struct {
 	ID               uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
@@ -130,6 +211,404 @@ type TenderAttachment struct {
 	ContentEmbedding pgvector.Vector `gorm:"column:content_embedding;type:vector(1536);<-:update" json:"content_embedding"`
 	CreatedAt        time.Time       `gorm:"type:timestamptz;default:now()" json:"created_at"`
 
+	// Vorausgefülltes Formular: nur für die Firma sichtbar, die es erzeugt hat
+	CompanyID          *uuid.UUID      `gorm:"type:uuid;index" json:"company_id,omitempty"`
+	SourceAttachmentID *uuid.UUID      `gorm:"type:uuid" json:"source_attachment_id,omitempty"`
+	FormTemplate       string          `json:"form_template,omitempty"`
+	FormFields         json.RawMessage `gorm:"type:jsonb" json:"form_fields,omitempty"` // []FilledFormField
+
 	// Relation
 	Tender Tender `gorm:"foreignKey:TenderID" json:"-"`
 }
+
+// DocumentType für vorausgefüllte Formulare
+const DocumentTypeFilledForm = "ausgefuelltes_formular"
+
+// Status eines Formularfelds beim Vorausfüllen
+const (
+	FormFieldFilled      = "filled"       // aus dem Firmenprofil befüllt
+	FormFieldPrefilled   = "prefilled"    // hatte bereits einen Wert, unverändert
+	FormFieldMissingData = "missing_data" // bekanntes Feld, im Firmenprofil fehlt der Wert
+	FormFieldUnknown     = "unknown"      // Feld nicht zugeordnet, muss von Hand ausgefüllt werden
+)
+
+// FilledFormField beschreibt ein Feld eines vorausgefüllten Formulars
+type FilledFormField struct {
+	Name   string `json:"name"`
+	Key    string `json:"key,omitempty"` // zugeordnetes Firmenfeld, z.B. tax_id
+	Value  string `json:"value,omitempty"`
+	Status string `json:"status"`
+}
+
+// Feedback-Werte für MatchFeedback.Vote
+const (
+	FeedbackVoteUp   = "up"
+	FeedbackVoteDown = "down"
+)
+
+// Gründe, die ein Nutzer beim Verwerfen einer Ausschreibung angeben kann
+const (
+	FeedbackReasonTooFar     = "too_far"
+	FeedbackReasonWrongTrade = "wrong_trade"
+	FeedbackReasonTooBig     = "too_big"
+	FeedbackReasonOther      = "other"
+)
+
+// MatchFeedback speichert das Daumen-hoch/runter-Feedback einer Firma zu einer Ausschreibung
+type MatchFeedback struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID  uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_match_feedback_company_tender" json:"company_id"`
+	TenderID   uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_match_feedback_company_tender" json:"tender_id"`
+	AuthUserID uuid.UUID `gorm:"type:uuid" json:"auth_user_id"`
+	Vote       string    `json:"vote"`
+	Reason     string    `json:"reason"`
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+
+	Tender Tender `gorm:"foreignKey:TenderID" json:"-"`
+}
+
+// Eignungsstatus einer Firma für eine Ausschreibung
+const (
+	EligibilityEligible   = "eligible"
+	EligibilityAtRisk     = "at_risk"
+	EligibilityIneligible = "ineligible"
+)
+
+// EligibilityIssue beschreibt ein Kriterium, das die Eignung gefährdet oder ausschließt
+type EligibilityIssue struct {
+	Criterion string `json:"criterion"` // turnover | headcount | certificate
+	Severity  string `json:"severity"`  // at_risk | ineligible
+	Message   string `json:"message"`
+}
+
+// Bearbeitungsstatus eines Checklisteneintrags
+const (
+	ChecklistStatusOpen          = "open"
+	ChecklistStatusInProgress    = "in_progress"
+	ChecklistStatusDone          = "done"
+	ChecklistStatusNotApplicable = "not_applicable"
+)
+
+// SubmissionChecklistItem ist ein einzureichendes Dokument auf der Abgabe-Checkliste einer Firma
+// für eine Ausschreibung. Einträge stammen vom Checklisten-Agent oder werden vom Nutzer angelegt.
+type SubmissionChecklistItem struct {
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID        uuid.UUID `gorm:"type:uuid;index:idx_checklist_company_tender" json:"company_id"`
+	TenderID         uuid.UUID `gorm:"type:uuid;index:idx_checklist_company_tender" json:"tender_id"`
+	Position         int       `json:"position"`
+	Title            string    `json:"title"`
+	Category         string    `json:"category"` // formular | eigenerklaerung | nachweis | preisblatt | konzept | sonstiges
+	DueForm          string    `json:"due_form"`
+	SubmissionTiming string    `json:"submission_timing"` // mit_angebot | auf_verlangen
+	PageLimit        *int      `json:"page_limit"`
+	SourceQuote      string    `json:"source_quote"`
+	SourceDocument   string    `json:"source_document"`
+	SourcePage       *int      `json:"source_page"`
+	Status           string    `gorm:"default:open" json:"status"` // open | in_progress | done | not_applicable
+	Note             string    `json:"note"`
+	// IsCustom: vom Nutzer angelegt, bleibt beim Neu-Generieren erhalten
+	IsCustom bool `gorm:"default:false" json:"is_custom"`
+	// Nur generierte Einträge: Prompt-Version und Modell des Agents
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	ModelID       string    `gorm:"column:model_id" json:"model_id,omitempty"`
+	CreatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+// TenderChatMessage ist eine Nachricht im Frage-Antwort-Verlauf eines Nutzers zu einer Ausschreibung
+type TenderChatMessage struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	AuthUserID uuid.UUID `gorm:"type:uuid;index:idx_tender_chat_user_tender" json:"auth_user_id"`
+	TenderID   uuid.UUID `gorm:"type:uuid;index:idx_tender_chat_user_tender" json:"tender_id"`
+	Role       string    `json:"role"` // user | assistant
+	Content    string    `json:"content"`
+	// Nur Antworten: Fundstellen ([]agent.Citation) und ob die Unterlagen die Frage beantworten
+	Citations json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"citations"`
+	Found     bool            `gorm:"default:false" json:"found"`
+	// Nur Antworten des Agents: Prompt-Version und Modell (leer ohne Modellaufruf)
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	ModelID       string    `gorm:"column:model_id" json:"model_id,omitempty"`
+	CreatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+// Bearbeitungsstatus einer Bieterfrage
+const (
+	BidderQuestionStatusDraft     = "draft"
+	BidderQuestionStatusApproved  = "approved"
+	BidderQuestionStatusSubmitted = "submitted"
+	BidderQuestionStatusDiscarded = "discarded"
+)
+
+// BidderQuestion ist ein Entwurf für eine Bieterfrage einer Firma an die Vergabestelle.
+// Entwürfe stammen vom Agent oder werden vom Nutzer angelegt.
+type BidderQuestion struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID      uuid.UUID `gorm:"type:uuid;index:idx_bidder_questions_company_tender" json:"company_id"`
+	TenderID       uuid.UUID `gorm:"type:uuid;index:idx_bidder_questions_company_tender" json:"tender_id"`
+	Position       int       `json:"position"`
+	Subject        string    `json:"subject"`
+	Question       string    `json:"question"`
+	Reason         string    `json:"reason"`
+	Kind           string    `json:"kind"` // widerspruch | unklarheit | luecke
+	SourceQuote    string    `json:"source_quote"`
+	SourceDocument string    `json:"source_document"`
+	SourcePage     *int      `json:"source_page"`
+	Status         string    `gorm:"default:draft" json:"status"` // draft | approved | submitted | discarded
+	// Antwort der Vergabestelle (vom Nutzer nachgetragen)
+	Answer string `json:"answer"`
+	// IsCustom: vom Nutzer angelegt, bleibt beim Neu-Generieren erhalten
+	IsCustom bool `gorm:"default:false" json:"is_custom"`
+	// Nur Vorschläge des Agents: Prompt-Version und Modell
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	ModelID       string    `gorm:"column:model_id" json:"model_id,omitempty"`
+	CreatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+// TenderQuestionDeadline ist die von einer Firma von Hand gesetzte Frist für Bieterfragen.
+// Sie geht der Frist aus den Unterlagen vor; nil heißt, die Firma hat die Frist gelöscht.
+type TenderQuestionDeadline struct {
+	CompanyID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"company_id"`
+	TenderID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"tender_id"`
+	QuestionDeadlineAt *time.Time `gorm:"type:timestamptz" json:"question_deadline_at"`
+	UpdatedBy          uuid.UUID  `gorm:"type:uuid" json:"updated_by"`
+	UpdatedAt          time.Time  `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+// Status einer Bewerbung
+const (
+	ApplicationStatusInProgress = "in_progress"
+	ApplicationStatusSubmitted  = "submitted"
+	ApplicationStatusWithdrawn  = "withdrawn"
+)
+
+// Status der Entwurfserstellung einer Bewerbung
+const (
+	DraftGenerationRunning = "running"
+	DraftGenerationDone    = "done"
+	DraftGenerationFailed  = "failed"
+)
+
+// Application ist die Bewerbung einer Firma auf eine Ausschreibung (eine je Firma und Ausschreibung)
+type Application struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_applications_company_tender" json:"company_id"`
+	TenderID  uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_applications_company_tender" json:"tender_id"`
+	Status    string    `gorm:"default:in_progress" json:"status"` // in_progress | submitted | withdrawn
+
+	// Entwurfserstellung (auch im Hintergrund bei auto_generate)
+	GenerationStatus    string     `json:"generation_status,omitempty"` // running | done | failed
+	GenerationError     string     `json:"generation_error,omitempty"`
+	GenerationStartedAt *time.Time `gorm:"type:timestamptz" json:"generation_started_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+
+	Drafts []BidDraft `gorm:"foreignKey:ApplicationID" json:"drafts,omitempty"`
+}
+
+// Herkunft einer Entwurfsversion
+const (
+	BidDraftSourceGenerated = "generated"
+	BidDraftSourceEdited    = "edited"
+)
+
+// BidDraft ist eine Version des Angebotskonzepts einer Bewerbung. Versionen werden nie
+// überschrieben: Generieren und Bearbeiten legen jeweils eine neue Version an.
+type BidDraft struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ApplicationID  uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_bid_drafts_application_version" json:"application_id"`
+	Version        int       `gorm:"uniqueIndex:idx_bid_drafts_application_version" json:"version"`
+	Source         string    `json:"source"` // generated | edited
+	BasedOnVersion *int      `json:"based_on_version"`
+	// Sections enthält die Abschnitte ([]agent.BidDraftSection)
+	Sections       json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"sections,omitempty"`
+	OutlineDerived bool            `gorm:"default:false" json:"outline_derived"`
+	// Nur generierte Versionen: fehlgeschlagene Abschnitte und eingeflossene Dokumente
+	FailedSections json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"failed_sections,omitempty"`
+	InputDocuments json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"input_documents,omitempty"`
+	PromptVersion  string          `json:"prompt_version,omitempty"`
+	ModelID        string          `json:"model_id,omitempty"`
+	CreatedBy      *uuid.UUID      `gorm:"type:uuid" json:"created_by"`
+	CreatedAt      time.Time       `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+// PromptTemplate ist eine Prompt-Version eines Agents
+type PromptTemplate struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Agent   string    `gorm:"uniqueIndex:idx_prompt_templates_agent_version" json:"agent"`
+	Version string    `gorm:"uniqueIndex:idx_prompt_templates_agent_version" json:"version"`
+	// Prompts enthält die Texte je Name (map[string]string, z. B. "system")
+	Prompts     json.RawMessage `gorm:"type:jsonb" json:"prompts"`
+	Description string          `json:"description"`
+	CreatedAt   time.Time       `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+// PromptAssignment legt Prompt-Version und Modell eines Agents für eine Umgebung fest
+type PromptAssignment struct {
+	Environment   string    `gorm:"primaryKey" json:"environment"`
+	Agent         string    `gorm:"primaryKey" json:"agent"`
+	PromptVersion string    `gorm:"default:v1" json:"prompt_version"`
+	Model         *string   `json:"model"`
+	Temperature   *float32  `json:"temperature"`
+	UpdatedAt     time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+// LLMUsage ist ein kostenpflichtiger Aufruf (OCR, Embedding, Chat) mit geschätzten Kosten.
+// Ohne Firma zählt er als Systemverbrauch (z. B. Import von Ausschreibungen).
+type LLMUsage struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID        *uuid.UUID `gorm:"type:uuid;index:idx_llm_usages_company_created" json:"company_id"`
+	TenderID         *uuid.UUID `gorm:"type:uuid" json:"tender_id"`
+	Operation        string     `json:"operation"`
+	Kind             string     `json:"kind"`
+	Provider         string     `json:"provider"`
+	Model            string     `json:"model"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	Pages            int        `json:"pages"`
+	LatencyMS        int64      `gorm:"column:latency_ms" json:"latency_ms"`
+	CostUSD          float64    `gorm:"column:cost_usd;type:numeric(12,6)" json:"cost_usd"`
+	Failed           bool       `json:"failed"`
+	CreatedAt        time.Time  `gorm:"type:timestamptz;default:now();index:idx_llm_usages_company_created" json:"created_at"`
+}
+
+// Rollen in einer Firma; jede Rolle umfasst die Rechte der folgenden
+const (
+	RoleOwner      = "owner"       // Firma löschen, Inhaberschaft übertragen
+	RoleAdmin      = "admin"       // Profil bearbeiten, Mitglieder und Einladungen verwalten
+	RoleBidManager = "bid_manager" // Prüfungen, Checklisten, Bieterfragen, Konzepte, Formulare
+	RoleViewer     = "viewer"      // nur lesen
+)
+
+// CompanyMember verknüpft einen Login mit einer Firma. Jeder Nutzer gehört zu höchstens
+// einer Firma, jede Firma hat genau einen owner.
+type CompanyMember struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID  uuid.UUID  `gorm:"type:uuid;index" json:"company_id"`
+	AuthUserID uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"auth_user_id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"` // owner | admin | bid_manager | viewer
+	InvitedBy  *uuid.UUID `gorm:"type:uuid" json:"invited_by,omitempty"`
+	CreatedAt  time.Time  `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+// CompanyInvitation lädt eine E-Mail-Adresse mit einer Rolle in die Firma ein. Gespeichert
+// wird nur der Hash des Tokens aus dem Einladungslink.
+type CompanyInvitation struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID  uuid.UUID  `gorm:"type:uuid;index" json:"company_id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	TokenHash  string     `gorm:"uniqueIndex" json:"-"`
+	InvitedBy  uuid.UUID  `gorm:"type:uuid" json:"invited_by"`
+	ExpiresAt  time.Time  `gorm:"type:timestamptz" json:"expires_at"`
+	AcceptedAt *time.Time `gorm:"type:timestamptz" json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID `gorm:"type:uuid" json:"accepted_by,omitempty"`
+	CreatedAt  time.Time  `gorm:"type:timestamptz;default:now()" json:"created_at"`
+}
+
+// Gültigkeitsstatus eines Nachweises (berechnet, nicht gespeichert)
+const (
+	CertificateValid    = "valid"
+	CertificateExpiring = "expiring" // läuft in den nächsten 90 Tagen ab
+	CertificateExpired  = "expired"
+)
+
+// CompanyCertificate ist ein strukturierter Nachweis der Firma (Zertifikat, Präqualifikation,
+// Meisterbrief, Versicherungsbestätigung) mit Gültigkeit und optionaler Datei im Storage.
+type CompanyCertificate struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID         uuid.UUID  `gorm:"type:uuid;index" json:"company_id"`
+	Type              string     `json:"type"` // iso_9001 | iso_14001 | iso_27001 | ... | insurance | other
+	Name              string     `json:"name"`
+	Issuer            string     `json:"issuer"`
+	Scope             string     `json:"scope"`
+	CertificateNumber string     `json:"certificate_number"`
+	ValidFrom         *time.Time `gorm:"type:date" json:"valid_from"`
+	ValidUntil        *time.Time `gorm:"type:date" json:"valid_until"` // nil = unbefristet
+	StoragePath       string     `json:"-"`
+	Filename          string     `json:"filename,omitempty"`
+	ContentType       string     `json:"content_type,omitempty"`
+	FileSize          int        `json:"file_size,omitempty"`
+	// Zuletzt verschickte Ablauf-Erinnerung (Tage vor Ablauf: 90, 30, 7, 0 = abgelaufen)
+	LastReminderDays *int      `json:"-"`
+	CreatedBy        uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt        time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+
+	// Berechnet beim Laden
+	Status          string `gorm:"-" json:"status"`
+	DaysUntilExpiry *int   `gorm:"-" json:"days_until_expiry,omitempty"`
+}
+
+// Auftraggeberart eines Referenzprojekts
+const (
+	ClientTypePublic  = "public"
+	ClientTypePrivate = "private"
+)
+
+// ProjectReference ist ein Referenzprojekt der Firma. Das Embedding (Spalte embedding) wird nur
+// per SQL geschrieben und gelesen und ist deshalb nicht Teil des Modells.
+type ProjectReference struct {
+	ID            uuid.UUID      `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID     uuid.UUID      `gorm:"type:uuid;index" json:"company_id"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	Client        string         `json:"client"`
+	ClientType    string         `json:"client_type"` // public | private | "" (unbekannt)
+	ContractValue *float64       `gorm:"type:numeric(20,2)" json:"contract_value"`
+	StartDate     *time.Time     `gorm:"type:date" json:"start_date"`
+	EndDate       *time.Time     `gorm:"type:date" json:"end_date"` // nil = laufend oder unbekannt
+	CPVCodes      pq.StringArray `gorm:"type:text[]" json:"cpv_codes"`
+	LocationCity  string         `json:"location_city"`
+	LocationZip   string         `json:"location_zip"`
+	ContactName   string         `json:"contact_name"`
+	ContactEmail  string         `json:"contact_email"`
+	ContactPhone  string         `json:"contact_phone"`
+	// Der Auftraggeber hat der Nennung als Referenz zugestimmt
+	ReleasedForDisclosure bool      `json:"released_for_disclosure"`
+	CreatedBy             uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt             time.Time `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt             time.Time `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
+
+// Verarbeitungsstand eines Lebenslaufs
+const (
+	CVStatusProcessing = "processing"
+	CVStatusExtracted  = "extracted"
+	CVStatusFailed     = "failed"
+)
+
+// EmployeeCV ist der Lebenslauf eines Mitarbeiters mit den daraus extrahierten Angaben.
+// Die Felder können nach der Extraktion von Hand korrigiert werden.
+type EmployeeCV struct {
+	ID                uuid.UUID       `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CompanyID         uuid.UUID       `gorm:"type:uuid;index" json:"company_id"`
+	FullName          string          `json:"full_name"`
+	Position          string          `json:"position"`
+	YearsOfExperience int             `json:"years_of_experience"`
+	Degrees           json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"degrees"` // [{degree, field, institution, year}]
+	Certifications    pq.StringArray  `gorm:"type:text[]" json:"certifications"`
+	Skills            pq.StringArray  `gorm:"type:text[]" json:"skills"`
+	Languages         pq.StringArray  `gorm:"type:text[]" json:"languages"`
+	// [{project, role, client, from_year, to_year, description}]
+	ProjectRoles json.RawMessage `gorm:"type:jsonb;default:'[]'" json:"project_roles"`
+	Summary      string          `json:"summary"`
+	StoragePath  string          `json:"-"` // Bucket company-assets
+	Filename     string          `json:"filename"`
+	ContentType  string          `json:"content_type"`
+	FileSize     int             `json:"file_size"`
+	// processing | extracted | failed
+	ExtractionStatus string     `gorm:"default:'processing'" json:"extraction_status"`
+	ExtractionError  string     `json:"extraction_error,omitempty"`
+	ExtractedAt      *time.Time `gorm:"type:timestamptz" json:"extracted_at,omitempty"`
+	PromptVersion    string     `json:"prompt_version,omitempty"`
+	ModelID          string     `json:"model_id,omitempty"`
+	CreatedBy        uuid.UUID  `gorm:"type:uuid" json:"created_by"`
+	CreatedAt        time.Time  `gorm:"type:timestamptz;default:now()" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"type:timestamptz;default:now()" json:"updated_at"`
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type BidDraftHandler struct {
+	svc *service.BidDraftService
+}
+
+func NewBidDraftHandler(svc *service.BidDraftService) *BidDraftHandler {
+	return &BidDraftHandler{svc: svc}
+}
+
+// OpenApplication legt die Bewerbung an (bei auto_generate inkl. erstem Entwurf im Hintergrund)
+func (h *BidDraftHandler) OpenApplication(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	application, err := h.svc.OpenApplication(ctx, authUserID, tenderID)
+	if err != nil {
+		writeBidDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+// GetApplication liefert die Bewerbung mit der Versionsliste
+func (h *BidDraftHandler) GetApplication(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	application, err := h.svc.GetApplication(ctx, authUserID, tenderID)
+	if err != nil {
+		writeBidDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+type applicationStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// UpdateApplication setzt den Status der Bewerbung
+func (h *BidDraftHandler) UpdateApplication(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	applicationID, err := uuid.Parse(c.Param("applicationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid application ID"})
+		return
+	}
+
+	var req applicationStatusRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	application, err := h.svc.UpdateApplicationStatus(ctx, authUserID, applicationID, req.Status)
+	if err != nil {
+		writeBidDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+type generateDraftRequest struct {
+	Outline []agent.BidOutlineSection `json:"outline"`
+}
+
+// GenerateDraft erstellt eine neue Version des Angebotskonzepts
+func (h *BidDraftHandler) GenerateDraft(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	applicationID, err := uuid.Parse(c.Param("applicationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid application ID"})
+		return
+	}
+
+	// Body ist optional (ohne Body: Gliederung aus Checkliste bzw. Unterlagen)
+	var req generateDraftRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindAndValidate(&req); err != nil {
+			c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	draft, err := h.svc.GenerateDraft(ctx, authUserID, applicationID, req.Outline)
+	if err != nil {
+		writeBidDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, draft)
+}
+
+// GetDraft liefert eine Version mit allen Abschnitten
+func (h *BidDraftHandler) GetDraft(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(c.Param("draftId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid draft ID"})
+		return
+	}
+
+	draft, err := h.svc.GetDraft(ctx, authUserID, draftID)
+	if err != nil {
+		writeBidDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+type saveDraftRequest struct {
+	Sections []service.BidDraftSectionInput `json:"sections"`
+}
+
+// SaveDraft speichert bearbeitete Abschnitte als neue Version
+func (h *BidDraftHandler) SaveDraft(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(c.Param("draftId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid draft ID"})
+		return
+	}
+
+	var req saveDraftRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	draft, err := h.svc.SaveDraft(ctx, authUserID, draftID, req.Sections)
+	if err != nil {
+		writeBidDraftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, draft)
+}
+
+// ExportDraft liefert eine Version als Datei (?format=markdown|docx)
+func (h *BidDraftHandler) ExportDraft(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	draftID, err := uuid.Parse(c.Param("draftId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid draft ID"})
+		return
+	}
+
+	file, err := h.svc.ExportDraft(ctx, authUserID, draftID, c.DefaultQuery("format", service.ExportFormatMarkdown))
+	if err != nil {
+		writeBidDraftError(c, err)
+		return
+	}
+
+	c.Response.Header.Set("Content-Disposition", `attachment; filename="`+file.Filename+`"`)
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
+
+func writeBidDraftError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBidDraft):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrApplicationNotFound), errors.Is(err, service.ErrBidDraftNotFound), errors.Is(err, service.ErrTenderNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrDraftGenerationRunning):
+		c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+	}
+}
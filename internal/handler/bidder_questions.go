@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type BidderQuestionHandler struct {
+	svc *service.BidderQuestionService
+}
+
+func NewBidderQuestionHandler(svc *service.BidderQuestionService) *BidderQuestionHandler {
+	return &BidderQuestionHandler{svc: svc}
+}
+
+// Generate schlägt Bieterfragen vor und übernimmt die Frist für Bieterfragen aus den Unterlagen
+func (h *BidderQuestionHandler) Generate(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	list, err := h.svc.GenerateQuestions(ctx, authUserID, tenderID)
+	if err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// List liefert die gespeicherten Bieterfragen samt Frist
+func (h *BidderQuestionHandler) List(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	list, err := h.svc.ListQuestions(ctx, authUserID, tenderID)
+	if err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Add legt eine eigene Frage an
+func (h *BidderQuestionHandler) Add(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	var input service.BidderQuestionInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	question, err := h.svc.AddQuestion(ctx, authUserID, tenderID, input)
+	if err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, question)
+}
+
+// Update ändert Wortlaut, Status oder Antwort einer Frage
+func (h *BidderQuestionHandler) Update(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	questionID, err := uuid.Parse(c.Param("questionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid question ID"})
+		return
+	}
+
+	var input service.BidderQuestionInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	question, err := h.svc.UpdateQuestion(ctx, authUserID, questionID, input)
+	if err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
+
+// Delete entfernt eine Frage
+func (h *BidderQuestionHandler) Delete(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	questionID, err := uuid.Parse(c.Param("questionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid question ID"})
+		return
+	}
+
+	if err := h.svc.DeleteQuestion(ctx, authUserID, questionID); err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// Export liefert die Fragen als Datei zum Einreichen (?format=markdown|text)
+func (h *BidderQuestionHandler) Export(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", service.ExportFormatMarkdown)
+	content, err := h.svc.ExportQuestions(ctx, authUserID, tenderID, format)
+	if err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	contentType, filename := "text/markdown; charset=utf-8", "bieterfragen.md"
+	if format == service.ExportFormatText {
+		contentType, filename = "text/plain; charset=utf-8", "bieterfragen.txt"
+	}
+	c.Response.Header.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, content)
+}
+
+type questionDeadlineRequest struct {
+	QuestionDeadlineAt *time.Time `json:"question_deadline_at"`
+}
+
+// SetDeadline korrigiert die Frist für Bieterfragen für die eigene Firma (null löscht sie)
+func (h *BidderQuestionHandler) SetDeadline(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req questionDeadlineRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	list, err := h.svc.SetQuestionDeadline(ctx, authUserID, tenderID, req.QuestionDeadlineAt)
+	if err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// ResetDeadline verwirft die Frist der eigenen Firma (wieder Frist aus den Unterlagen)
+func (h *BidderQuestionHandler) ResetDeadline(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	list, err := h.svc.ResetQuestionDeadline(ctx, authUserID, tenderID)
+	if err != nil {
+		writeBidderQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func writeBidderQuestionError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBidderQuestion):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrBidderQuestionNotFound), errors.Is(err, service.ErrTenderNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+	}
+}
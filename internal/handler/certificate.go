@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type CertificateHandler struct {
+	svc *service.CertificateService
+}
+
+func NewCertificateHandler(svc *service.CertificateService) *CertificateHandler {
+	return &CertificateHandler{svc: svc}
+}
+
+// List listet die Nachweise der eigenen Firma mit Gültigkeitsstatus
+func (h *CertificateHandler) List(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	certificates, err := h.svc.ListCertificates(ctx, authUserID)
+	if err != nil {
+		writeCertificateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, certificates)
+}
+
+// Create legt einen Nachweis an (Datei danach per PUT .../file)
+func (h *CertificateHandler) Create(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input service.CertificateInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	certificate, err := h.svc.CreateCertificate(ctx, authUserID, input)
+	if err != nil {
+		writeCertificateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, certificate)
+}
+
+// Update ändert einzelne Felder eines Nachweises
+func (h *CertificateHandler) Update(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	certificateID, err := uuid.Parse(c.Param("certificateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid certificate ID"})
+		return
+	}
+
+	var patch service.CertificatePatch
+	if err := c.BindAndValidate(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	certificate, err := h.svc.UpdateCertificate(ctx, authUserID, certificateID, patch)
+	if err != nil {
+		writeCertificateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, certificate)
+}
+
+// Delete löscht einen Nachweis samt Datei
+func (h *CertificateHandler) Delete(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	certificateID, err := uuid.Parse(c.Param("certificateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid certificate ID"})
+		return
+	}
+
+	if err := h.svc.DeleteCertificate(ctx, authUserID, certificateID); err != nil {
+		writeCertificateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// UploadFile hinterlegt die Datei (Multipart-Feld "file") und ersetzt eine vorhandene
+func (h *CertificateHandler) UploadFile(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	certificateID, err := uuid.Parse(c.Param("certificateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid certificate ID"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "File fehlt"})
+		return
+	}
+	src, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Datei konnte nicht geöffnet werden"})
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Datei konnte nicht gelesen werden"})
+		return
+	}
+
+	certificate, err := h.svc.UploadCertificateFile(ctx, authUserID, certificateID, fileHeader.Filename, data)
+	if err != nil {
+		writeCertificateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, certificate)
+}
+
+// DownloadFile liefert die hinterlegte Datei
+func (h *CertificateHandler) DownloadFile(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	certificateID, err := uuid.Parse(c.Param("certificateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid certificate ID"})
+		return
+	}
+
+	file, err := h.svc.DownloadCertificateFile(ctx, authUserID, certificateID)
+	if err != nil {
+		writeCertificateError(c, err)
+		return
+	}
+
+	c.Response.Header.Set("Content-Disposition", `attachment; filename="`+file.Filename+`"`)
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
+
+func writeCertificateError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCertificate):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrCertificateNotFound), errors.Is(err, service.ErrCertificateFileMissing):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrStorageUnavailable):
+		c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
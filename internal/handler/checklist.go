@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type ChecklistHandler struct {
+	svc *service.ChecklistService
+}
+
+func NewChecklistHandler(svc *service.ChecklistService) *ChecklistHandler {
+	return &ChecklistHandler{svc: svc}
+}
+
+// Generate erstellt die Abgabe-Checkliste aus den Unterlagen (neu), Bearbeitungsstand bleibt erhalten
+func (h *ChecklistHandler) Generate(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	checklist, err := h.svc.GenerateChecklist(ctx, authUserID, tenderID)
+	if err != nil {
+		writeChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, checklist)
+}
+
+// Get liefert die gespeicherte Checkliste
+func (h *ChecklistHandler) Get(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	checklist, err := h.svc.GetChecklist(ctx, authUserID, tenderID)
+	if err != nil {
+		writeChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, checklist)
+}
+
+// AddItem legt einen eigenen Eintrag an
+func (h *ChecklistHandler) AddItem(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	var input service.ChecklistItemInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	item, err := h.svc.AddItem(ctx, authUserID, tenderID, input)
+	if err != nil {
+		writeChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateItem ändert Status, Notiz oder Angaben eines Eintrags
+func (h *ChecklistHandler) UpdateItem(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid item ID"})
+		return
+	}
+
+	var input service.ChecklistItemInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	item, err := h.svc.UpdateItem(ctx, authUserID, itemID, input)
+	if err != nil {
+		writeChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteItem entfernt einen Eintrag
+func (h *ChecklistHandler) DeleteItem(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid item ID"})
+		return
+	}
+
+	if err := h.svc.DeleteItem(ctx, authUserID, itemID); err != nil {
+		writeChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func writeChecklistError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidChecklistItem):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrChecklistItemNotFound), errors.Is(err, service.ErrTenderNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+	}
+}
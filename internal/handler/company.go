@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/cloudwego/hertz/pkg/app"
@@ -17,6 +18,7 @@ func NewCompanyHandler(svc *service.CompanyService) *CompanyHandler {
 	return &CompanyHandler{svc: svc}
 }
 
+// Create legt das Firmenprofil aus dem Onboarding an oder überschreibt es (Upsert)
 func (h *CompanyHandler) Create(ctx context.Context, c *app.RequestContext) {
 	var input service.CompanyInput
 	if err := c.BindAndValidate(&input); err != nil {
@@ -31,12 +33,79 @@ func (h *CompanyHandler) Create(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 	input.AuthUserID = userID.(string)
+	input.AuthEmail = c.GetString(middleware.ContextUserEmailKey)
 
-	company, err := h.svc.CreateCompany(ctx, input)
+	company, err := h.svc.UpsertCompany(ctx, input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writeCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, company)
+}
+
+// Get liefert das Profil der eigenen Firma
+func (h *CompanyHandler) Get(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	company, err := h.svc.GetCompany(ctx, authUserID)
+	if err != nil {
+		writeCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, company)
+}
+
+// Update ändert einzelne Felder des Profils
+func (h *CompanyHandler) Update(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var patch service.CompanyPatch
+	if err := c.BindAndValidate(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	company, err := h.svc.UpdateCompany(ctx, authUserID, patch)
+	if err != nil {
+		writeCompanyError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, company)
 }
+
+// Delete löscht die Firma mit allen zugehörigen Daten
+func (h *CompanyHandler) Delete(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteCompany(ctx, authUserID); err != nil {
+		writeCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func writeCompanyError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCompany):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientRole):
+		c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -3,10 +3,14 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/google/uuid"
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
 	"github.com/vergabe-agent/vergabe-backend/internal/middleware"
 	"github.com/vergabe-agent/vergabe-backend/internal/service"
 )
@@ -42,12 +46,106 @@ func (h *ComplianceHandler) Analyze(ctx context.Context, c *app.RequestContext)
 		return
 	}
 
+	// ?force=true erzwingt einen neuen Lauf trotz gültigem Cache-Eintrag
+	force := c.Query("force") == "true"
+
 	// FIX: Wir übergeben jetzt die authUserID
-	check, err := h.svc.CheckCompliance(ctx, authUserID, tenderID)
+	check, err := h.svc.CheckCompliance(ctx, authUserID, tenderID, force)
+	if err != nil {
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// complianceErrorResponse bildet Fehler des Compliance-Checks auf HTTP-Status und Meldung ab
+func complianceErrorResponse(err error) (int, string) {
+	if errors.Is(err, service.ErrNoTenderDocuments) {
+		return http.StatusUnprocessableEntity, err.Error()
+	}
+	if errors.Is(err, service.ErrCompanyNotFound) || errors.Is(err, service.ErrTenderNotFound) {
+		return http.StatusNotFound, err.Error()
+	}
+	var quotaErr *service.QuotaError
+	if errors.As(err, &quotaErr) {
+		return quotaErrorResponse(quotaErr)
+	}
+	var transportErr *agent.TransportError
+	if errors.As(err, &transportErr) {
+		return http.StatusServiceUnavailable, "KI-Dienst nicht erreichbar: " + transportErr.Err.Error()
+	}
+	var modelErr *agent.ModelError
+	if errors.As(err, &modelErr) {
+		return http.StatusBadGateway, "KI-Antwort ungültig: " + modelErr.Err.Error()
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// quotaErrorResponse: Monatskontingent verbraucht -> 402, zu viele Anfragen -> 429
+func quotaErrorResponse(err *service.QuotaError) (int, string) {
+	if errors.Is(err, service.ErrRateLimited) {
+		seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+		return http.StatusTooManyRequests, fmt.Sprintf("Zu viele KI-Anfragen (max. %.0f pro Minute im Tarif %s), bitte in %d s erneut versuchen", err.Max, err.Tier, seconds)
+	}
+	switch err.Limit {
+	case service.QuotaLimitMonthlyOCRPages:
+		return http.StatusPaymentRequired, fmt.Sprintf("OCR-Kontingent des Tarifs %s für diesen Monat verbraucht (%.0f von %.0f Seiten)", err.Tier, err.Used, err.Max)
+	default:
+		return http.StatusPaymentRequired, fmt.Sprintf("KI-Kontingent des Tarifs %s für diesen Monat verbraucht (%.2f von %.2f USD)", err.Tier, err.Used, err.Max)
+	}
+}
+
+// History listet alle Checks der eigenen Firma für eine Ausschreibung (neueste zuerst)
+func (h *ComplianceHandler) History(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	checks, err := h.svc.ListChecks(ctx, authUserID, tenderID)
 	if err != nil {
+		if errors.Is(err, service.ErrCompanyNotFound) {
+			c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
+	c.JSON(http.StatusOK, map[string]any{"checks": checks})
+}
+
+// GetCheck liefert einen einzelnen Check mit Anforderungen und Blockern
+func (h *ComplianceHandler) GetCheck(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	checkID, err := uuid.Parse(c.Param("checkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid check ID"})
+		return
+	}
+
+	check, err := h.svc.GetCheck(ctx, authUserID, checkID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrComplianceCheckNotFound):
+			c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return
+	}
+
 	c.JSON(http.StatusOK, check)
 }
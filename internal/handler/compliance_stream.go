@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
+	"github.com/google/uuid"
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+)
+
+// sseHeartbeatInterval hält Proxys offen; abgebrochene Verbindungen meldet der Server
+// über den Request-Context (WithSenseClientDisconnection)
+const sseHeartbeatInterval = 15 * time.Second
+
+type sseEvent struct {
+	name string
+	data any
+}
+
+// AnalyzeStream führt den Compliance-Check aus und streamt den Verlauf als Server-Sent Events:
+// progress (Dokumente geladen, Abschnitt N von M), requirement (vorläufige Anforderungen),
+// zum Schluss result (gespeicherter Check) oder error. Bricht der Client ab, wird der
+// Modellaufruf sofort über den Context abgebrochen.
+//
+// Die Route ist ein POST mit Bearer-Token; EventSource im Browser kann beides nicht.
+// Das Frontend liest den Stream per fetch() und ReadableStream.
+func (h *ComplianceHandler) AnalyzeStream(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+	force := c.Query("force") == "true"
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Callbacks laufen teils parallel (Map-Phase) -> alles über einen Kanal, geschrieben wird nur hier
+	events := make(chan sseEvent, 64)
+	send := func(name string, data any) {
+		select {
+		case events <- sseEvent{name: name, data: data}:
+		case <-ctx.Done():
+		}
+	}
+
+	runCtx := agent.WithComplianceProgress(ctx, func(p agent.ComplianceProgress) { send("progress", p) })
+	runCtx = agent.WithComplianceRequirements(runCtx, func(r agent.RequirementAssessment) { send("requirement", r) })
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		check, err := h.svc.CheckCompliance(runCtx, authUserID, tenderID, force)
+		if err != nil {
+			status, message := complianceErrorResponse(err)
+			send("error", map[string]any{"status": status, "error": message})
+			return
+		}
+		send("result", check)
+	}()
+
+	c.SetStatusCode(http.StatusOK)
+	c.Response.Header.Set("Content-Type", "text/event-stream")
+	c.Response.Header.Set("Cache-Control", "no-cache")
+	c.Response.Header.Set("Connection", "keep-alive")
+	c.Response.Header.Set("X-Accel-Buffering", "no")
+	c.Response.HijackWriter(resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()))
+	w := c.Response.GetHijackWriter()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Compliance stream for tender %s closed by client", tenderID)
+			return
+		case ev := <-events:
+			if err := writeSSE(w, ev); err != nil {
+				log.Printf("Compliance stream for tender %s aborted: %v", tenderID, err)
+				return
+			}
+		case <-heartbeat.C:
+			if err := writeSSEComment(w, "ping"); err != nil {
+				log.Printf("Compliance stream for tender %s aborted: %v", tenderID, err)
+				return
+			}
+		case <-done:
+			// Restliche Events (inkl. result/error) noch ausliefern
+			for {
+				select {
+				case ev := <-events:
+					if err := writeSSE(w, ev); err != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeSSE(w network.ExtWriter, ev sseEvent) error {
+	payload, err := json.Marshal(ev.data)
+	if err != nil {
+		return fmt.Errorf("marshal %s event: %w", ev.name, err)
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeSSEComment(w network.ExtWriter, comment string) error {
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	return w.Flush()
+}
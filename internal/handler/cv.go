@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type CVHandler struct {
+	svc *service.CVService
+}
+
+func NewCVHandler(svc *service.CVService) *CVHandler {
+	return &CVHandler{svc: svc}
+}
+
+// List listet die Lebensläufe der eigenen Firma mit Auswertungsstatus
+func (h *CVHandler) List(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	cvs, err := h.svc.ListCVs(ctx, authUserID)
+	if err != nil {
+		writeCVError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cvs)
+}
+
+// Upload nimmt einen Lebenslauf (Multipart-Feld "file", PDF oder DOCX) an; die
+// Auswertung läuft im Hintergrund (extraction_status per GET abfragen)
+func (h *CVHandler) Upload(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "File fehlt"})
+		return
+	}
+	src, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Datei konnte nicht geöffnet werden"})
+		return
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": "Datei konnte nicht gelesen werden"})
+		return
+	}
+
+	cv, err := h.svc.UploadCV(ctx, authUserID, fileHeader.Filename, data)
+	if err != nil {
+		writeCVError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, cv)
+}
+
+// Update korrigiert die extrahierten Angaben eines Lebenslaufs
+func (h *CVHandler) Update(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	cvID, err := uuid.Parse(c.Param("cvId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid CV ID"})
+		return
+	}
+
+	var patch service.CVPatch
+	if err := c.BindAndValidate(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	cv, err := h.svc.UpdateCV(ctx, authUserID, cvID, patch)
+	if err != nil {
+		writeCVError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cv)
+}
+
+// Delete löscht einen Lebenslauf samt Datei
+func (h *CVHandler) Delete(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	cvID, err := uuid.Parse(c.Param("cvId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid CV ID"})
+		return
+	}
+
+	if err := h.svc.DeleteCV(ctx, authUserID, cvID); err != nil {
+		writeCVError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// Reextract startet die Auswertung erneut (überschreibt manuelle Korrekturen)
+func (h *CVHandler) Reextract(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	cvID, err := uuid.Parse(c.Param("cvId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid CV ID"})
+		return
+	}
+
+	cv, err := h.svc.ReextractCV(ctx, authUserID, cvID)
+	if err != nil {
+		writeCVError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, cv)
+}
+
+// DownloadFile liefert die hochgeladene Datei
+func (h *CVHandler) DownloadFile(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	cvID, err := uuid.Parse(c.Param("cvId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid CV ID"})
+		return
+	}
+
+	file, err := h.svc.DownloadCVFile(ctx, authUserID, cvID)
+	if err != nil {
+		writeCVError(c, err)
+		return
+	}
+
+	c.Response.Header.Set("Content-Disposition", `attachment; filename="`+file.Filename+`"`)
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
+
+// ProposeTeam schlägt für die Personalanforderungen einer Ausschreibung Mitarbeiter vor
+// und markiert fehlende Qualifikationen
+func (h *CVHandler) ProposeTeam(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	proposal, err := h.svc.ProposeTeam(ctx, authUserID, tenderID)
+	if err != nil {
+		writeCVError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
+
+func writeCVError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCV):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrCVNotFound), errors.Is(err, service.ErrTenderNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCVProcessing):
+		c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrStorageUnavailable):
+		c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	default:
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+	}
+}
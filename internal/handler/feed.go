@@ -35,7 +35,10 @@ func (h *FeedHandler) GetFeed(ctx context.Context, c *app.RequestContext) {
 		limit, _ = strconv.Atoi(l)
 	}
 
-	matches, err := h.svc.FindMatchesHybrid(ctx, userID, limit)
+	// Nicht geeignete Ausschreibungen nur auf ausdrücklichen Wunsch (mit Ausschlussgrund)
+	includeIneligible := c.Query("include_ineligible") == "true"
+
+	matches, err := h.svc.FindMatchesHybrid(ctx, userID, limit, includeIneligible)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
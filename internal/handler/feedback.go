@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/middleware"
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type FeedbackHandler struct {
+	svc *service.FeedbackService
+}
+
+func NewFeedbackHandler(svc *service.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{svc: svc}
+}
+
+// Submit speichert Daumen hoch/runter (+ optionalen Grund) zu einer Ausschreibung
+func (h *FeedbackHandler) Submit(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	var input service.FeedbackInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	feedback, err := h.svc.RecordFeedback(ctx, authUserID, tenderID, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidFeedback):
+			c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrTenderNotFound):
+			c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// List gibt das bisherige Feedback der Firma zurück
+func (h *FeedbackHandler) List(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	feedback, err := h.svc.ListFeedback(ctx, authUserID)
+	if err != nil {
+		if errors.Is(err, service.ErrCompanyNotFound) {
+			c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"feedback": feedback})
+}
+
+// Delete nimmt das Feedback zu einer Ausschreibung zurück
+func (h *FeedbackHandler) Delete(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	if err := h.svc.DeleteFeedback(ctx, authUserID, tenderID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrFeedbackNotFound), errors.Is(err, service.ErrCompanyNotFound):
+			c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// authUserIDFromContext liest die User ID aus der AuthMiddleware.
+// Schreibt bei Fehlern selbst die Antwort und gibt false zurück.
+func authUserIDFromContext(c *app.RequestContext) (uuid.UUID, bool) {
+	userIDVal, exists := c.Get(middleware.ContextUserIDKey)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+
+	authUserID, err := uuid.Parse(userIDVal.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid User ID"})
+		return uuid.Nil, false
+	}
+
+	return authUserID, true
+}
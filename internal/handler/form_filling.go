@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type FormFillingHandler struct {
+	svc *service.FormFillingService
+}
+
+func NewFormFillingHandler(svc *service.FormFillingService) *FormFillingHandler {
+	return &FormFillingHandler{svc: svc}
+}
+
+// Detect listet die Anhänge, die als Standardformular (Eigenerklärung) erkannt werden
+func (h *FormFillingHandler) Detect(ctx context.Context, c *app.RequestContext) {
+	if _, ok := authUserIDFromContext(c); !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	forms, err := h.svc.DetectForms(ctx, tenderID)
+	if err != nil {
+		writeFormFillingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms)
+}
+
+type fillFormsRequest struct {
+	AttachmentIDs []uuid.UUID `json:"attachment_ids"`
+}
+
+// Fill befüllt die erkannten (oder angegebenen) Formulare mit den Firmendaten
+func (h *FormFillingHandler) Fill(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	// Body ist optional (ohne Body: alle erkannten Formulare)
+	var req fillFormsRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindAndValidate(&req); err != nil {
+			c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.svc.FillForms(ctx, authUserID, tenderID, req.AttachmentIDs)
+	if err != nil {
+		writeFormFillingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// ListFilled liefert die vorausgefüllten Formulare der Firma
+func (h *FormFillingHandler) ListFilled(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	forms, err := h.svc.ListFilledForms(ctx, authUserID, tenderID)
+	if err != nil {
+		writeFormFillingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms)
+}
+
+// DeleteFilled entfernt ein vorausgefülltes Formular
+func (h *FormFillingHandler) DeleteFilled(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid attachment ID"})
+		return
+	}
+
+	if err := h.svc.DeleteFilledForm(ctx, authUserID, attachmentID); err != nil {
+		writeFormFillingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func writeFormFillingError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidFormAttachment):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrFilledFormNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrNoFormsDetected):
+		c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrFormFillingUnavailable):
+		c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -18,6 +18,11 @@ func NewIngestionHandler(svc *service.IngestionService) *IngestionHandler {
 }
 
 func (h *IngestionHandler) UploadFile(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, map[string]string{"error": "File fehlt"})
@@ -37,9 +42,10 @@ func (h *IngestionHandler) UploadFile(ctx context.Context, c *app.RequestContext
 		return
 	}
 
-	tender, err := h.svc.ProcessUpload(ctx, bytes, fileHeader.Filename)
+	tender, err := h.svc.ProcessUpload(ctx, authUserID, bytes, fileHeader.Filename)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
 		return
 	}
 
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/middleware"
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+// RequireRole lässt eine Anfrage nur mit Mitgliedschaft und Mindestrolle in der Firma durch:
+// ohne Firma 404, mit zu niedriger Rolle 403
+func RequireRole(svc *service.MembershipService, minRole string) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		authUserID, ok := authUserIDFromContext(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		if _, err := svc.Authorize(ctx, authUserID, minRole); err != nil {
+			status, message := membershipErrorResponse(err)
+			c.AbortWithStatusJSON(status, map[string]string{"error": message})
+			return
+		}
+		c.Next(ctx)
+	}
+}
+
+type MembershipHandler struct {
+	svc *service.MembershipService
+}
+
+func NewMembershipHandler(svc *service.MembershipService) *MembershipHandler {
+	return &MembershipHandler{svc: svc}
+}
+
+// ListMembers listet die Mitglieder der eigenen Firma
+func (h *MembershipHandler) ListMembers(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.svc.ListMembers(ctx, authUserID)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// UpdateMember ändert die Rolle eines Mitglieds
+func (h *MembershipHandler) UpdateMember(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	memberUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	member, err := h.svc.UpdateMemberRole(ctx, authUserID, memberUserID, input.Role)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember entfernt ein Mitglied; mit der eigenen ID verlässt man die Firma
+func (h *MembershipHandler) RemoveMember(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	memberUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.svc.RemoveMember(ctx, authUserID, memberUserID); err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// TransferOwnership übergibt die Firma an ein anderes Mitglied
+func (h *MembershipHandler) TransferOwnership(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		AuthUserID string `json:"auth_user_id"`
+	}
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	newOwnerID, err := uuid.Parse(input.AuthUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	members, err := h.svc.TransferOwnership(ctx, authUserID, newOwnerID)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// ListInvitations listet offene Einladungen
+func (h *MembershipHandler) ListInvitations(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.svc.ListInvitations(ctx, authUserID)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// Invite lädt eine E-Mail-Adresse mit einer Rolle ein
+func (h *MembershipHandler) Invite(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input service.InvitationInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.CreateInvitation(ctx, authUserID, input)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// RevokeInvitation zieht eine offene Einladung zurück
+func (h *MembershipHandler) RevokeInvitation(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.svc.RevokeInvitation(ctx, authUserID, invitationID); err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// AcceptInvitation nimmt eine Einladung mit dem Token aus dem Link an
+func (h *MembershipHandler) AcceptInvitation(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	member, err := h.svc.AcceptInvitation(ctx, authUserID, c.GetString(middleware.ContextUserEmailKey), input.Token)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func writeMembershipError(c *app.RequestContext, err error) {
+	status, message := membershipErrorResponse(err)
+	c.JSON(status, map[string]string{"error": message})
+}
+
+func membershipErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMembership):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, service.ErrInsufficientRole):
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrMemberNotFound), errors.Is(err, service.ErrInvitationNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, service.ErrAlreadyMember):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type PartnerHandler struct {
+	svc *service.MatchingService
+}
+
+func NewPartnerHandler(svc *service.MatchingService) *PartnerHandler {
+	return &PartnerHandler{svc: svc}
+}
+
+// FindPartners listet passende Firmen für eine Ausschreibung (Bietergemeinschaft / Subunternehmer)
+func (h *PartnerHandler) FindPartners(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	opts := service.PartnerSearchOptions{Limit: 20}
+	if l := c.Query("limit"); l != "" {
+		opts.Limit, _ = strconv.Atoi(l)
+	}
+	switch role := c.Query("role"); role {
+	case "", service.PartnerRoleConsortium, service.PartnerRoleSubcontractor:
+		opts.Role = role
+	default:
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "role must be bietergemeinschaft or subunternehmer"})
+		return
+	}
+
+	partners, err := h.svc.FindCompaniesForTender(ctx, authUserID, tenderID, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrTenderNotFound):
+			c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"partners": partners})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type ReferenceHandler struct {
+	svc *service.ReferenceService
+}
+
+func NewReferenceHandler(svc *service.ReferenceService) *ReferenceHandler {
+	return &ReferenceHandler{svc: svc}
+}
+
+// List listet die Referenzprojekte der eigenen Firma
+func (h *ReferenceHandler) List(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	references, err := h.svc.ListReferences(ctx, authUserID)
+	if err != nil {
+		writeReferenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, references)
+}
+
+// Create legt ein Referenzprojekt an
+func (h *ReferenceHandler) Create(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input service.ReferenceInput
+	if err := c.BindAndValidate(&input); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	reference, err := h.svc.CreateReference(ctx, authUserID, input)
+	if err != nil {
+		writeReferenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reference)
+}
+
+// Update ändert einzelne Felder eines Referenzprojekts
+func (h *ReferenceHandler) Update(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	referenceID, err := uuid.Parse(c.Param("referenceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid reference ID"})
+		return
+	}
+
+	var patch service.ReferencePatch
+	if err := c.BindAndValidate(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	reference, err := h.svc.UpdateReference(ctx, authUserID, referenceID, patch)
+	if err != nil {
+		writeReferenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reference)
+}
+
+// Delete löscht ein Referenzprojekt
+func (h *ReferenceHandler) Delete(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	referenceID, err := uuid.Parse(c.Param("referenceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid reference ID"})
+		return
+	}
+
+	if err := h.svc.DeleteReference(ctx, authUserID, referenceID); err != nil {
+		writeReferenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// BestForTender wählt die passendsten Referenzen für eine Ausschreibung (?limit=5)
+func (h *ReferenceHandler) BestForTender(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		limit, _ = strconv.Atoi(l)
+	}
+
+	matches, err := h.svc.BestReferencesForTender(ctx, authUserID, tenderID, limit)
+	if err != nil {
+		writeReferenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"references": matches})
+}
+
+func writeReferenceError(c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReference):
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCompanyNotFound), errors.Is(err, service.ErrReferenceNotFound), errors.Is(err, service.ErrTenderNotFound):
+		c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+	}
+}
@@ -17,16 +17,34 @@ import (
 )
 
 type TenderHandler struct {
-	db         *gorm.DB
-	storage    *service.SupabaseStorageService
-	ocrService *service.OCRService
+	db          *gorm.DB
+	storage     *service.SupabaseStorageService
+	ocrService  *service.OCRService
+	eligibility *service.EligibilityService
+	compliance  *service.ComplianceService
+	usage       *service.UsageService
+	membership  *service.MembershipService
 }
 
-func NewTenderHandler(db *gorm.DB, storage *service.SupabaseStorageService, ocrService *service.OCRService) *TenderHandler {
+func NewTenderHandler(db *gorm.DB, storage *service.SupabaseStorageService, ocrService *service.OCRService, eligibility *service.EligibilityService, compliance *service.ComplianceService, usage *service.UsageService, membership *service.MembershipService) *TenderHandler {
 	return &TenderHandler{
-		db:         db,
-		storage:    storage,
-		ocrService: ocrService,
+		db:          db,
+		storage:     storage,
+		ocrService:  ocrService,
+		eligibility: eligibility,
+		compliance:  compliance,
+		usage:       usage,
+		membership:  membership,
+	}
+}
+
+// markChecksStale markiert bestehende Compliance-Checks der Ausschreibung als veraltet
+func (h *TenderHandler) markChecksStale(ctx context.Context, tenderID uuid.UUID, reason string) {
+	if h.compliance == nil {
+		return
+	}
+	if err := h.compliance.MarkTenderChecksStale(ctx, tenderID, reason); err != nil {
+		log.Printf("Marking compliance checks stale failed for tender %s: %v", tenderID, err)
 	}
 }
 
@@ -48,7 +66,7 @@ func (h *TenderHandler) ListTenders(ctx context.Context, c *app.RequestContext)
 	c.JSON(http.StatusOK, tenders)
 }
 
-// GetTenderAttachments returns all attachments for a tender
+// GetTenderAttachments returns all attachments for a tender (ohne vorausgefüllte Formulare der Firmen)
 func (h *TenderHandler) GetTenderAttachments(ctx context.Context, c *app.RequestContext) {
 	tenderID := c.Param("tenderId")
 	if tenderID == "" {
@@ -64,7 +82,7 @@ func (h *TenderHandler) GetTenderAttachments(ctx context.Context, c *app.Request
 
 	var attachments []domain.TenderAttachment
 	err = h.db.WithContext(ctx).
-		Where("tender_id = ?", tenderUUID).
+		Where("tender_id = ? AND company_id IS NULL", tenderUUID).
 		Order("created_at DESC").
 		Find(&attachments).Error
 
@@ -78,6 +96,11 @@ func (h *TenderHandler) GetTenderAttachments(ctx context.Context, c *app.Request
 
 // UploadAttachment handles PDF upload for a tender
 func (h *TenderHandler) UploadAttachment(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
 	tenderID := c.Param("tenderId")
 	if tenderID == "" {
 		c.JSON(http.StatusBadRequest, map[string]string{"error": "tender_id required"})
@@ -138,6 +161,17 @@ func (h *TenderHandler) UploadAttachment(ctx context.Context, c *app.RequestCont
 		return
 	}
 
+	// OCR zählt für die Firma des hochladenden Nutzers; Kontingent vor dem Upload prüfen
+	runOCR := typeInfo.fileType == "pdf" && h.ocrService != nil
+	ocrCtx := context.Background()
+	if runOCR {
+		if ocrCtx, err = h.usage.BeginForUser(context.WithoutCancel(ctx), authUserID, &tenderUUID, service.OperationAttachmentOCR); err != nil {
+			status, message := complianceErrorResponse(err)
+			c.JSON(status, map[string]string{"error": message})
+			return
+		}
+	}
+
 	// Generate storage path
 	attachmentID := uuid.New()
 	storagePath := tenderID + "/" + attachmentID.String() + ext
@@ -183,11 +217,14 @@ func (h *TenderHandler) UploadAttachment(ctx context.Context, c *app.RequestCont
 		return
 	}
 
+	// Neuer Anhang -> bisherige Compliance-Checks sind nicht mehr vollständig
+	h.markChecksStale(ctx, tenderUUID, service.StaleReasonAttachmentAdded)
+
 	// Run OCR for PDF files in background
-	if typeInfo.fileType == "pdf" && h.ocrService != nil {
+	if runOCR {
 		go func() {
 			log.Printf("Starting OCR for attachment %s", attachment.ID)
-			ocrText, err := h.ocrService.ExtractFromPDF(fileBytes)
+			ocrText, err := h.ocrService.ExtractFromPDF(ocrCtx, fileBytes)
 			if err != nil {
 				log.Printf("OCR failed for attachment %s: %v", attachment.ID, err)
 				return
@@ -203,8 +240,18 @@ func (h *TenderHandler) UploadAttachment(ctx context.Context, c *app.RequestCont
 
 			if updateErr != nil {
 				log.Printf("Failed to save OCR result for attachment %s: %v", attachment.ID, updateErr)
-			} else {
-				log.Printf("OCR completed for attachment %s (%d chars)", attachment.ID, len(ocrText))
+				return
+			}
+			log.Printf("OCR completed for attachment %s (%d chars)", attachment.ID, len(ocrText))
+
+			// Der Anhang-Text fließt erst jetzt in die Prüfung ein (Checks seit dem Upload sind veraltet)
+			h.markChecksStale(context.Background(), tenderUUID, service.StaleReasonAttachmentOCR)
+
+			// Anhänge enthalten oft die Eignungskriterien -> Mindestanforderungen neu extrahieren
+			if h.eligibility != nil {
+				if _, err := h.eligibility.RefreshTenderRequirements(context.Background(), tenderUUID); err != nil {
+					log.Printf("Requirement extraction failed for tender %s: %v", tenderUUID, err)
+				}
 			}
 		}()
 	}
@@ -214,6 +261,18 @@ func (h *TenderHandler) UploadAttachment(ctx context.Context, c *app.RequestCont
 
 // DeleteAttachment removes an attachment
 func (h *TenderHandler) DeleteAttachment(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// Anhänge gelten für alle Firmen -> nur Mitglieder ab bid_manager dürfen löschen
+	if _, err := h.membership.Authorize(ctx, authUserID, domain.RoleBidManager); err != nil {
+		status, message := membershipErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+		return
+	}
+
 	attachmentID := c.Param("attachmentId")
 	if attachmentID == "" {
 		c.JSON(http.StatusBadRequest, map[string]string{"error": "attachment_id required"})
@@ -226,9 +285,9 @@ func (h *TenderHandler) DeleteAttachment(ctx context.Context, c *app.RequestCont
 		return
 	}
 
-	// Get attachment to find storage path
+	// Get attachment to find storage path (vorausgefüllte Formulare nur über /filled-forms)
 	var attachment domain.TenderAttachment
-	if err := h.db.WithContext(ctx).First(&attachment, "id = ?", attachmentUUID).Error; err != nil {
+	if err := h.db.WithContext(ctx).First(&attachment, "id = ? AND company_id IS NULL", attachmentUUID).Error; err != nil {
 		c.JSON(http.StatusNotFound, map[string]string{"error": "Attachment nicht gefunden"})
 		return
 	}
@@ -248,5 +307,7 @@ func (h *TenderHandler) DeleteAttachment(ctx context.Context, c *app.RequestCont
 		return
 	}
 
+	h.markChecksStale(ctx, attachment.TenderID, service.StaleReasonAttachmentRemoved)
+
 	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+type TenderQAHandler struct {
+	svc *service.TenderQAService
+}
+
+func NewTenderQAHandler(svc *service.TenderQAService) *TenderQAHandler {
+	return &TenderQAHandler{svc: svc}
+}
+
+type askRequest struct {
+	Question string `json:"question"`
+}
+
+// Ask beantwortet eine Frage zur Ausschreibung mit Fundstellen aus den Unterlagen
+func (h *TenderQAHandler) Ask(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	var req askRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	answer, err := h.svc.Ask(ctx, authUserID, tenderID, req.Question)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidQuestion) {
+			c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		status, message := complianceErrorResponse(err)
+		c.JSON(status, map[string]string{"error": message})
+		return
+	}
+
+	c.JSON(http.StatusOK, answer)
+}
+
+// History liefert den bisherigen Verlauf des Nutzers zur Ausschreibung
+func (h *TenderQAHandler) History(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	messages, err := h.svc.History(ctx, authUserID, tenderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"messages": messages})
+}
+
+// Clear löscht den Verlauf des Nutzers zur Ausschreibung
+func (h *TenderQAHandler) Clear(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	tenderID, err := uuid.Parse(c.Param("tenderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid tender ID"})
+		return
+	}
+
+	if err := h.svc.ClearHistory(ctx, authUserID, tenderID); err != nil {
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/service"
+)
+
+// maxUsageMonths begrenzt den Zeitraum der Verbrauchsübersicht
+const maxUsageMonths = 24
+
+type UsageHandler struct {
+	svc *service.UsageService
+}
+
+func NewUsageHandler(svc *service.UsageService) *UsageHandler {
+	return &UsageHandler{svc: svc}
+}
+
+// Get liefert Verbrauch und Kosten der eigenen Firma je Monat samt Kontingent des Tarifs
+func (h *UsageHandler) Get(ctx context.Context, c *app.RequestContext) {
+	authUserID, ok := authUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	months := 6
+	if m := c.Query("months"); m != "" {
+		parsed, err := strconv.Atoi(m)
+		if err != nil || parsed < 1 || parsed > maxUsageMonths {
+			c.JSON(http.StatusBadRequest, map[string]string{"error": "months must be between 1 and 24"})
+			return
+		}
+		months = parsed
+	}
+
+	summary, err := h.svc.Summary(ctx, authUserID, months)
+	if err != nil {
+		if errors.Is(err, service.ErrCompanyNotFound) {
+			c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -11,7 +11,10 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const ContextUserIDKey = "user_id"
+const (
+	ContextUserIDKey    = "user_id"
+	ContextUserEmailKey = "user_email" // E-Mail aus dem Supabase-JWT (falls vorhanden)
+)
 
 func AuthMiddleware() app.HandlerFunc {
 	return func(ctx context.Context, c *app.RequestContext) {
@@ -59,6 +62,9 @@ func AuthMiddleware() app.HandlerFunc {
 		}
 
 		c.Set(ContextUserIDKey, sub)
+		if email, ok := claims["email"].(string); ok {
+			c.Set(ContextUserEmailKey, email)
+		}
 		c.Next(ctx)
 	}
 }
@@ -0,0 +1,566 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var (
+	ErrApplicationNotFound    = errors.New("application not found")
+	ErrBidDraftNotFound       = errors.New("bid draft not found")
+	ErrInvalidBidDraft        = errors.New("invalid bid draft")
+	ErrDraftGenerationRunning = errors.New("bid draft generation is already running")
+)
+
+// ExportFormatDocx ist das Word-Format für Angebotskonzepte
+const ExportFormatDocx = "docx"
+
+// Retrieval für das Konzept: größere Abschnitte als im Chat, damit Leistungsbeschreibung
+// und Wertungsmatrix im Zusammenhang ankommen
+const (
+	bidDraftChunkChars  = 2_000
+	bidDraftMaxPassages = 24
+	// Läuft eine Erstellung länger, gilt sie als abgebrochen (z. B. nach einem Neustart)
+	bidDraftGenerationTimeout = 15 * time.Minute
+)
+
+// bidDraftRetrievalQuery beschreibt, wonach für den Konzeptteil gesucht wird
+const bidDraftRetrievalQuery = `Leistungsbeschreibung Leistungsumfang Leistungsverzeichnis Aufgabenstellung Ziel Ziele
+Konzept Konzepte Methodik Vorgehen Vorgehensweise Ablauf Arbeitsschritte Projektorganisation Organisation Kommunikation
+Personal Personaleinsatz Schlüsselpersonal Projektleitung Team Qualifikation Qualitätssicherung Qualitätsmanagement
+Zeitplan Termine Ausführungsfrist Risiken Zuschlagskriterien Zuschlag Wertung Wertungskriterien Bewertung Bewertungsmatrix
+Gewichtung Punkte Gliederung Seiten Seitenzahl höchstens maximal DIN A4`
+
+type BidDraftService struct {
+	draftAgent *agent.BidDraftAgent
+	db         *gorm.DB
+	usage      *UsageService
+}
+
+func NewBidDraftService(draftAgent *agent.BidDraftAgent, db *gorm.DB, usage *UsageService) *BidDraftService {
+	return &BidDraftService{draftAgent: draftAgent, db: db, usage: usage}
+}
+
+// BidDraftSectionInput ist ein Abschnitt beim Speichern einer bearbeiteten Version
+type BidDraftSectionInput struct {
+	Heading        string `json:"heading"`
+	Requirements   string `json:"requirements"`
+	AwardCriterion string `json:"award_criterion"`
+	PageLimit      int    `json:"page_limit"`
+	Content        string `json:"content"`
+}
+
+// ExportedFile ist ein erzeugtes Dokument zum Herunterladen
+type ExportedFile struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+var validApplicationStatuses = map[string]bool{
+	domain.ApplicationStatusInProgress: true,
+	domain.ApplicationStatusSubmitted:  true,
+	domain.ApplicationStatusWithdrawn:  true,
+}
+
+// OpenApplication legt die Bewerbung der Firma auf eine Ausschreibung an bzw. liefert die
+// bestehende. Ist in den Firmeneinstellungen auto_generate gesetzt, wird für eine neue
+// Bewerbung sofort im Hintergrund der erste Konzeptentwurf erstellt.
+func (s *BidDraftService) OpenApplication(ctx context.Context, authUserID, tenderID uuid.UUID) (*domain.Application, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "settings")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).Select("id").First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	application := domain.Application{
+		ID:        uuid.New(),
+		CompanyID: company.ID,
+		TenderID:  tenderID,
+		Status:    domain.ApplicationStatusInProgress,
+	}
+	result := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "company_id"}, {Name: "tender_id"}}, DoNothing: true}).
+		Create(&application)
+	if result.Error != nil {
+		return nil, fmt.Errorf("create application failed: %w", result.Error)
+	}
+	created := result.RowsAffected == 1
+
+	if created && autoGenerateFromSettings(company.Settings) {
+		if err := s.claimGeneration(ctx, application.ID); err != nil {
+			return nil, err
+		}
+		s.generateInBackground(application.ID, authUserID)
+	}
+
+	return s.loadApplication(ctx, company.ID, "tender_id = ?", tenderID)
+}
+
+// GetApplication liefert die Bewerbung zur Ausschreibung mit allen Versionen (ohne Inhalt)
+func (s *BidDraftService) GetApplication(ctx context.Context, authUserID, tenderID uuid.UUID) (*domain.Application, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+	return s.loadApplication(ctx, company.ID, "tender_id = ?", tenderID)
+}
+
+// UpdateApplicationStatus setzt den Status der Bewerbung
+func (s *BidDraftService) UpdateApplicationStatus(ctx context.Context, authUserID, applicationID uuid.UUID, status string) (*domain.Application, error) {
+	if !validApplicationStatuses[status] {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidBidDraft, status)
+	}
+
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.db.WithContext(ctx).Model(&domain.Application{}).
+		Where("id = ? AND company_id = ?", applicationID, company.ID).
+		Updates(map[string]interface{}{"status": status, "updated_at": time.Now()})
+	if result.Error != nil {
+		return nil, fmt.Errorf("update application failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrApplicationNotFound
+	}
+	return s.loadApplication(ctx, company.ID, "id = ?", applicationID)
+}
+
+// GenerateDraft erstellt eine neue Version des Angebotskonzepts. Ohne outline gilt die
+// Gliederung aus den Konzept-Einträgen der Abgabe-Checkliste; gibt es dort keine, leitet
+// der Agent sie aus den Unterlagen ab.
+func (s *BidDraftService) GenerateDraft(ctx context.Context, authUserID, applicationID uuid.UUID, outline []agent.BidOutlineSection) (*domain.BidDraft, error) {
+	for i, section := range outline {
+		if strings.TrimSpace(section.Heading) == "" {
+			return nil, fmt.Errorf("%w: outline[%d].heading is required", ErrInvalidBidDraft, i)
+		}
+		if section.PageLimit < 0 {
+			return nil, fmt.Errorf("%w: outline[%d].page_limit must not be negative", ErrInvalidBidDraft, i)
+		}
+	}
+
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+	application, err := s.loadApplication(ctx, company.ID, "id = ?", applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.claimGeneration(ctx, application.ID); err != nil {
+		return nil, err
+	}
+	draft, err := s.generate(ctx, application, authUserID, outline)
+	s.finishGeneration(context.WithoutCancel(ctx), application.ID, err)
+	return draft, err
+}
+
+// GetDraft liefert eine Version mit allen Abschnitten
+func (s *BidDraftService) GetDraft(ctx context.Context, authUserID, draftID uuid.UUID) (*domain.BidDraft, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+	return s.loadDraft(ctx, company.ID, draftID)
+}
+
+// SaveDraft speichert bearbeitete Abschnitte als neue Version auf Basis von draftID
+func (s *BidDraftService) SaveDraft(ctx context.Context, authUserID, draftID uuid.UUID, input []BidDraftSectionInput) (*domain.BidDraft, error) {
+	if len(input) == 0 {
+		return nil, fmt.Errorf("%w: sections are required", ErrInvalidBidDraft)
+	}
+
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+	base, err := s.loadDraft(ctx, company.ID, draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Belegte Referenzen und Lebensläufe bleiben an gleichnamigen Abschnitten erhalten
+	var baseSections []agent.BidDraftSection
+	_ = json.Unmarshal(base.Sections, &baseSections)
+	byHeading := make(map[string]agent.BidDraftSection, len(baseSections))
+	for _, section := range baseSections {
+		byHeading[normalizeKey(section.Heading)] = section
+	}
+
+	sections := make([]agent.BidDraftSection, 0, len(input))
+	for i, in := range input {
+		heading := strings.TrimSpace(in.Heading)
+		if heading == "" {
+			return nil, fmt.Errorf("%w: sections[%d].heading is required", ErrInvalidBidDraft, i)
+		}
+		if in.PageLimit < 0 {
+			return nil, fmt.Errorf("%w: sections[%d].page_limit must not be negative", ErrInvalidBidDraft, i)
+		}
+
+		section := agent.BidDraftSection{
+			Heading:        heading,
+			Requirements:   strings.TrimSpace(in.Requirements),
+			AwardCriterion: strings.TrimSpace(in.AwardCriterion),
+			PageLimit:      in.PageLimit,
+			Content:        strings.TrimSpace(in.Content),
+		}
+		if previous, ok := byHeading[normalizeKey(heading)]; ok {
+			section.References, section.CVs = previous.References, previous.CVs
+		}
+		section.Measure()
+		sections = append(sections, section)
+	}
+
+	basedOn := base.Version
+	draft := &domain.BidDraft{
+		ApplicationID:  base.ApplicationID,
+		Source:         domain.BidDraftSourceEdited,
+		BasedOnVersion: &basedOn,
+		OutlineDerived: base.OutlineDerived,
+		CreatedBy:      &authUserID,
+	}
+	if draft.Sections, err = json.Marshal(sections); err != nil {
+		return nil, fmt.Errorf("marshal sections failed: %w", err)
+	}
+	if err := s.saveVersion(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// ExportDraft erstellt die Version als Markdown oder Word-Dokument
+func (s *BidDraftService) ExportDraft(ctx context.Context, authUserID, draftID uuid.UUID, format string) (*ExportedFile, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+	draft, err := s.loadDraft(ctx, company.ID, draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).
+		Select("tenders.id", "tenders.title", "tenders.external_id").
+		Joins("JOIN applications ON applications.tender_id = tenders.id").
+		Where("applications.id = ?", draft.ApplicationID).
+		First(&tender).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	var sections []agent.BidDraftSection
+	if err := json.Unmarshal(draft.Sections, &sections); err != nil {
+		return nil, fmt.Errorf("decode sections failed: %w", err)
+	}
+	markdown := renderBidDraftMarkdown(&tender, draft.Version, sections)
+	basename := fmt.Sprintf("angebotskonzept-v%d", draft.Version)
+
+	switch format {
+	case "", ExportFormatMarkdown:
+		return &ExportedFile{Filename: basename + ".md", ContentType: "text/markdown; charset=utf-8", Content: []byte(markdown)}, nil
+	case ExportFormatDocx:
+		content, err := renderMarkdownDocx(markdown)
+		if err != nil {
+			return nil, err
+		}
+		return &ExportedFile{
+			Filename:    basename + ".docx",
+			ContentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			Content:     content,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown export format %q", ErrInvalidBidDraft, format)
+	}
+}
+
+// generate stellt die Eingabe zusammen, ruft den Agent auf und speichert die neue Version
+func (s *BidDraftService) generate(ctx context.Context, application *domain.Application, createdBy uuid.UUID, outline []agent.BidOutlineSection) (*domain.BidDraft, error) {
+	var company domain.Company
+	if err := s.db.WithContext(ctx).First(&company, "id = ?", application.CompanyID).Error; err != nil {
+		return nil, fmt.Errorf("load company failed: %w", err)
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).First(&tender, "id = ?", application.TenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	ctx, err := s.usage.Begin(ctx, &company, &tender.ID, OperationBidDraft)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, records, err := loadTenderDocuments(ctx, s.db, &tender)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(outline) == 0 {
+		if outline, err = s.checklistOutline(ctx, company.ID, tender.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	profile, err := loadProfileRecords(ctx, s.db, company.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bidDraftRetrievalQuery + "\n" + tender.AwardCriteria
+	result, err := s.draftAgent.Generate(ctx, agent.BidDraftInput{
+		TenderTitle:   tender.Title,
+		AwardCriteria: tender.AwardCriteria,
+		Outline:       outline,
+		Passages:      retrievePassages(docs, query, bidDraftChunkChars, bidDraftMaxPassages),
+		Profile:       buildCompanyProfile(&company, profile, now),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &domain.BidDraft{
+		ApplicationID:  application.ID,
+		Source:         domain.BidDraftSourceGenerated,
+		OutlineDerived: result.OutlineDerived,
+		PromptVersion:  result.Run.PromptVersion,
+		ModelID:        result.Run.ModelID,
+		CreatedBy:      &createdBy,
+	}
+	if draft.Sections, err = json.Marshal(result.Sections); err != nil {
+		return nil, fmt.Errorf("marshal sections failed: %w", err)
+	}
+	failed := result.FailedSections
+	if failed == nil {
+		failed = []agent.FailedSection{}
+	}
+	if draft.FailedSections, err = json.Marshal(failed); err != nil {
+		return nil, fmt.Errorf("marshal failed sections failed: %w", err)
+	}
+	if draft.InputDocuments, err = json.Marshal(records); err != nil {
+		return nil, fmt.Errorf("marshal input documents failed: %w", err)
+	}
+
+	if err := s.saveVersion(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// generateInBackground erstellt den ersten Entwurf nach dem Anlegen der Bewerbung
+func (s *BidDraftService) generateInBackground(applicationID, authUserID uuid.UUID) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), bidDraftGenerationTimeout)
+		defer cancel()
+
+		application, err := s.loadApplicationByID(ctx, applicationID)
+		if err == nil {
+			_, err = s.generate(ctx, application, authUserID, nil)
+		}
+		s.finishGeneration(context.Background(), applicationID, err)
+		if err != nil {
+			log.Printf("Auto-generating bid draft for application %s failed: %v", applicationID, err)
+			return
+		}
+		log.Printf("Auto-generated bid draft for application %s", applicationID)
+	}()
+}
+
+// claimGeneration markiert die Erstellung als laufend, sofern nicht schon eine läuft.
+// Hängengebliebene Läufe (älter als bidDraftGenerationTimeout) zählen nicht.
+func (s *BidDraftService) claimGeneration(ctx context.Context, applicationID uuid.UUID) error {
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&domain.Application{}).
+		Where("id = ? AND (generation_status IS NULL OR generation_status <> ? OR generation_started_at < ?)",
+			applicationID, domain.DraftGenerationRunning, now.Add(-bidDraftGenerationTimeout)).
+		Updates(map[string]interface{}{
+			"generation_status":     domain.DraftGenerationRunning,
+			"generation_error":      "",
+			"generation_started_at": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("start draft generation failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDraftGenerationRunning
+	}
+	return nil
+}
+
+// finishGeneration hält das Ergebnis der Erstellung an der Bewerbung fest
+func (s *BidDraftService) finishGeneration(ctx context.Context, applicationID uuid.UUID, genErr error) {
+	updates := map[string]interface{}{
+		"generation_status": domain.DraftGenerationDone,
+		"generation_error":  "",
+		"updated_at":        time.Now(),
+	}
+	if genErr != nil {
+		updates["generation_status"] = domain.DraftGenerationFailed
+		updates["generation_error"] = genErr.Error()
+	}
+	if err := s.db.WithContext(ctx).Model(&domain.Application{}).Where("id = ?", applicationID).Updates(updates).Error; err != nil {
+		log.Printf("Saving draft generation status for application %s failed: %v", applicationID, err)
+	}
+}
+
+// saveVersion vergibt die nächste Versionsnummer (Bewerbung gesperrt) und speichert
+func (s *BidDraftService) saveVersion(ctx context.Context, draft *domain.BidDraft) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var application domain.Application
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			First(&application, "id = ?", draft.ApplicationID).Error; err != nil {
+			return fmt.Errorf("lock application failed: %w", err)
+		}
+
+		var latest int
+		if err := tx.Model(&domain.BidDraft{}).
+			Where("application_id = ?", draft.ApplicationID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return fmt.Errorf("load latest version failed: %w", err)
+		}
+
+		draft.ID = uuid.New()
+		draft.Version = latest + 1
+		draft.CreatedAt = time.Now()
+		if err := tx.Create(draft).Error; err != nil {
+			return fmt.Errorf("save bid draft failed: %w", err)
+		}
+		return tx.Model(&domain.Application{}).Where("id = ?", draft.ApplicationID).
+			Update("updated_at", draft.CreatedAt).Error
+	})
+}
+
+// checklistOutline übernimmt die Konzept-Einträge der Abgabe-Checkliste als Gliederung
+func (s *BidDraftService) checklistOutline(ctx context.Context, companyID, tenderID uuid.UUID) ([]agent.BidOutlineSection, error) {
+	var items []domain.SubmissionChecklistItem
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND tender_id = ? AND category = ? AND status <> ?",
+			companyID, tenderID, agent.ChecklistCategoryKonzept, domain.ChecklistStatusNotApplicable).
+		Order("position ASC").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("load checklist failed: %w", err)
+	}
+
+	var outline []agent.BidOutlineSection
+	for _, item := range items {
+		section := agent.BidOutlineSection{
+			Heading:      item.Title,
+			Requirements: item.SourceQuote,
+		}
+		if item.PageLimit != nil {
+			section.PageLimit = *item.PageLimit
+		}
+		outline = append(outline, section)
+	}
+	return outline, nil
+}
+
+// loadApplication lädt eine Bewerbung der Firma samt Versionsliste (neueste zuerst)
+func (s *BidDraftService) loadApplication(ctx context.Context, companyID uuid.UUID, query string, arg interface{}) (*domain.Application, error) {
+	var application domain.Application
+	err := s.db.WithContext(ctx).
+		Preload("Drafts", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "application_id", "version", "source", "based_on_version", "outline_derived", "prompt_version", "model_id", "created_by", "created_at").
+				Order("version DESC")
+		}).
+		Where("company_id = ?", companyID).
+		Where(query, arg).
+		First(&application).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, fmt.Errorf("load application failed: %w", err)
+	}
+	return &application, nil
+}
+
+func (s *BidDraftService) loadApplicationByID(ctx context.Context, applicationID uuid.UUID) (*domain.Application, error) {
+	var application domain.Application
+	if err := s.db.WithContext(ctx).First(&application, "id = ?", applicationID).Error; err != nil {
+		return nil, fmt.Errorf("load application failed: %w", err)
+	}
+	return &application, nil
+}
+
+// loadDraft lädt eine Version, sofern sie zu einer Bewerbung der Firma gehört
+func (s *BidDraftService) loadDraft(ctx context.Context, companyID, draftID uuid.UUID) (*domain.BidDraft, error) {
+	var draft domain.BidDraft
+	err := s.db.WithContext(ctx).
+		Joins("JOIN applications ON applications.id = bid_drafts.application_id").
+		Where("bid_drafts.id = ? AND applications.company_id = ?", draftID, companyID).
+		First(&draft).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBidDraftNotFound
+		}
+		return nil, fmt.Errorf("load bid draft failed: %w", err)
+	}
+	return &draft, nil
+}
+
+// autoGenerateFromSettings liest das Flag auto_generate aus Company.Settings
+func autoGenerateFromSettings(settings json.RawMessage) bool {
+	var prefs struct {
+		AutoGenerate bool `json:"auto_generate"`
+	}
+	if len(settings) > 0 {
+		_ = json.Unmarshal(settings, &prefs)
+	}
+	return prefs.AutoGenerate
+}
+
+// renderBidDraftMarkdown setzt die Abschnitte zu einem Dokument zusammen
+func renderBidDraftMarkdown(tender *domain.Tender, version int, sections []agent.BidDraftSection) string {
+	var b strings.Builder
+	b.WriteString("# Angebotskonzept\n\n")
+	if tender.Title != "" {
+		fmt.Fprintf(&b, "**Ausschreibung:** %s\n\n", tender.Title)
+	}
+	if tender.ExternalID != "" {
+		fmt.Fprintf(&b, "**Vergabenummer:** %s\n\n", tender.ExternalID)
+	}
+	fmt.Fprintf(&b, "**Version:** %d\n", version)
+
+	for i, section := range sections {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, section.Heading)
+		content := strings.TrimSpace(section.Content)
+		if content == "" {
+			content = "[Abschnitt noch nicht erstellt]"
+		}
+		b.WriteString(content)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
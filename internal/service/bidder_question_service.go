@@ -0,0 +1,672 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var (
+	ErrInvalidBidderQuestion  = errors.New("invalid bidder question")
+	ErrBidderQuestionNotFound = errors.New("bidder question not found")
+)
+
+// Exportformate für Bieterfragen
+const (
+	ExportFormatMarkdown = "markdown"
+	ExportFormatText     = "text"
+)
+
+// Herkunft der Frist für Bieterfragen
+const (
+	QuestionDeadlineSourceDocuments = "documents"
+	QuestionDeadlineSourceManual    = "manual"
+)
+
+type BidderQuestionService struct {
+	questionAgent *agent.BidderQuestionAgent
+	db            *gorm.DB
+	usage         *UsageService
+}
+
+func NewBidderQuestionService(questionAgent *agent.BidderQuestionAgent, db *gorm.DB, usage *UsageService) *BidderQuestionService {
+	return &BidderQuestionService{
+		questionAgent: questionAgent,
+		db:            db,
+		usage:         usage,
+	}
+}
+
+// BidderQuestionList sind die Bieterfragen einer Firma zu einer Ausschreibung samt Frist
+type BidderQuestionList struct {
+	TenderID              uuid.UUID  `json:"tender_id"`
+	QuestionDeadlineAt    *time.Time `json:"question_deadline_at"`
+	QuestionDeadlineQuote string     `json:"question_deadline_quote,omitempty"`
+	// documents (aus den Unterlagen) oder manual (von der eigenen Firma gesetzt)
+	QuestionDeadlineSource string                  `json:"question_deadline_source,omitempty"`
+	DeadlinePassed         bool                    `json:"deadline_passed"`
+	DaysUntilDeadline      *int                    `json:"days_until_deadline,omitempty"`
+	Questions              []domain.BidderQuestion `json:"questions"`
+	// Nur nach dem Generieren: eingeflossene Dokumente
+	InputDocuments []domain.ComplianceInputDocument `json:"input_documents,omitempty"`
+}
+
+// BidderQuestionInput ist der Request-Body zum Anlegen und Bearbeiten einer Frage.
+// Beim Bearbeiten werden nur gesetzte Felder übernommen.
+type BidderQuestionInput struct {
+	Subject  *string `json:"subject"`
+	Question *string `json:"question"`
+	Reason   *string `json:"reason"`
+	Kind     *string `json:"kind"`
+	Status   *string `json:"status"`
+	Answer   *string `json:"answer"`
+}
+
+var validBidderQuestionKinds = map[string]bool{
+	agent.BidderQuestionKindContradiction: true,
+	agent.BidderQuestionKindAmbiguity:     true,
+	agent.BidderQuestionKindGap:           true,
+}
+
+var validBidderQuestionStatuses = map[string]bool{
+	domain.BidderQuestionStatusDraft:     true,
+	domain.BidderQuestionStatusApproved:  true,
+	domain.BidderQuestionStatusSubmitted: true,
+	domain.BidderQuestionStatusDiscarded: true,
+}
+
+// GenerateQuestions schlägt Bieterfragen aus den Unterlagen und den Befunden des letzten
+// Compliance-Checks vor. Bisherige Vorschläge im Status draft werden ersetzt; bearbeitete,
+// freigegebene, eingereichte und selbst angelegte Fragen bleiben erhalten. Eine gefundene
+// Frist für Bieterfragen wird an der Ausschreibung gespeichert; eine von Hand gesetzte Frist
+// der Firma geht ihr weiterhin vor.
+func (s *BidderQuestionService) GenerateQuestions(ctx context.Context, authUserID, tenderID uuid.UUID) (*BidderQuestionList, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "subscription_tier")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	ctx, err = s.usage.Begin(ctx, company, &tenderID, OperationBidderQuestions)
+	if err != nil {
+		return nil, err
+	}
+
+	docInput, err := buildComplianceInput(ctx, s.db, &tender)
+	if err != nil {
+		return nil, err
+	}
+
+	findings, err := s.latestFindings(ctx, company.ID, tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := docInput.Sections
+	if len(sections) == 0 {
+		sections = []agent.DocumentSection{{ID: 0, Label: "Unterlagen", Text: docInput.Text}}
+	}
+
+	result, err := s.questionAgent.Propose(ctx, agent.BidderQuestionInput{
+		Sections: sections,
+		Findings: findings,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docInput.Sections) > 0 {
+		docInput.markFailedSections(result.FailedSections)
+	}
+
+	var questions []domain.BidderQuestion
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result.QuestionDeadline != nil {
+			if err := tx.Model(&domain.Tender{}).Where("id = ?", tenderID).Updates(map[string]interface{}{
+				"question_deadline_at":    *result.QuestionDeadline,
+				"question_deadline_quote": result.DeadlineQuote,
+			}).Error; err != nil {
+				return fmt.Errorf("save question deadline failed: %w", err)
+			}
+			tender.QuestionDeadlineAt = result.QuestionDeadline
+			tender.QuestionDeadlineQuote = result.DeadlineQuote
+		}
+
+		if err := tx.Where("company_id = ? AND tender_id = ? AND is_custom = false AND status = ?",
+			company.ID, tenderID, domain.BidderQuestionStatusDraft).
+			Delete(&domain.BidderQuestion{}).Error; err != nil {
+			return fmt.Errorf("delete draft questions failed: %w", err)
+		}
+
+		var kept []domain.BidderQuestion
+		if err := tx.Where("company_id = ? AND tender_id = ?", company.ID, tenderID).
+			Order("position ASC").
+			Find(&kept).Error; err != nil {
+			return fmt.Errorf("load bidder questions failed: %w", err)
+		}
+
+		// Zu Textstellen, die schon eine bearbeitete Frage haben, keinen neuen Vorschlag
+		keptQuotes := make(map[string]bool)
+		for i := range kept {
+			if key := normalizeKey(kept[i].SourceQuote); key != "" {
+				keptQuotes[key] = true
+			}
+			if kept[i].Position != i {
+				kept[i].Position = i
+				if err := tx.Model(&kept[i]).Update("position", i).Error; err != nil {
+					return fmt.Errorf("reorder bidder questions failed: %w", err)
+				}
+			}
+		}
+
+		var drafts []agent.BidderQuestionDraft
+		for _, q := range result.Questions {
+			if !keptQuotes[normalizeKey(q.SourceQuote)] {
+				drafts = append(drafts, q)
+			}
+		}
+
+		generated := toBidderQuestions(company.ID, tenderID, drafts, len(kept), result.Run)
+		if len(generated) > 0 {
+			if err := tx.Create(&generated).Error; err != nil {
+				return fmt.Errorf("save bidder questions failed: %w", err)
+			}
+		}
+
+		questions = append(kept, generated...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.applyDeadlineOverride(ctx, company.ID, &tender)
+	if err != nil {
+		return nil, err
+	}
+	list := newBidderQuestionList(&tender, source, questions, time.Now())
+	list.InputDocuments = docInput.Documents
+	return list, nil
+}
+
+// ListQuestions liefert die gespeicherten Bieterfragen samt Frist
+func (s *BidderQuestionService) ListQuestions(ctx context.Context, authUserID, tenderID uuid.UUID) (*BidderQuestionList, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).
+		Select("id", "question_deadline_at", "question_deadline_quote").
+		First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	source, err := s.applyDeadlineOverride(ctx, company.ID, &tender)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.loadQuestions(ctx, company.ID, tenderID)
+	if err != nil {
+		return nil, err
+	}
+	return newBidderQuestionList(&tender, source, questions, time.Now()), nil
+}
+
+// AddQuestion legt eine eigene Frage am Ende der Liste an
+func (s *BidderQuestionService) AddQuestion(ctx context.Context, authUserID, tenderID uuid.UUID, input BidderQuestionInput) (*domain.BidderQuestion, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureTender(ctx, tenderID); err != nil {
+		return nil, err
+	}
+
+	question := &domain.BidderQuestion{
+		ID:        uuid.New(),
+		CompanyID: company.ID,
+		TenderID:  tenderID,
+		Kind:      agent.BidderQuestionKindAmbiguity,
+		Status:    domain.BidderQuestionStatusDraft,
+		IsCustom:  true,
+	}
+	if err := applyBidderQuestionInput(question, input); err != nil {
+		return nil, err
+	}
+	if question.Question == "" {
+		return nil, fmt.Errorf("%w: question is required", ErrInvalidBidderQuestion)
+	}
+
+	var maxPosition *int
+	if err := s.db.WithContext(ctx).Model(&domain.BidderQuestion{}).
+		Where("company_id = ? AND tender_id = ?", company.ID, tenderID).
+		Select("max(position)").
+		Scan(&maxPosition).Error; err != nil {
+		return nil, fmt.Errorf("load bidder questions failed: %w", err)
+	}
+	if maxPosition != nil {
+		question.Position = *maxPosition + 1
+	}
+
+	now := time.Now()
+	question.CreatedAt, question.UpdatedAt = now, now
+	if err := s.db.WithContext(ctx).Create(question).Error; err != nil {
+		return nil, fmt.Errorf("save bidder question failed: %w", err)
+	}
+	return question, nil
+}
+
+// UpdateQuestion ändert eine Frage der eigenen Firma (Wortlaut, Status, Antwort)
+func (s *BidderQuestionService) UpdateQuestion(ctx context.Context, authUserID, questionID uuid.UUID, input BidderQuestionInput) (*domain.BidderQuestion, error) {
+	question, err := s.findQuestion(ctx, authUserID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyBidderQuestionInput(question, input); err != nil {
+		return nil, err
+	}
+	if question.Question == "" {
+		return nil, fmt.Errorf("%w: question must not be empty", ErrInvalidBidderQuestion)
+	}
+
+	question.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Save(question).Error; err != nil {
+		return nil, fmt.Errorf("update bidder question failed: %w", err)
+	}
+	return question, nil
+}
+
+// DeleteQuestion entfernt eine Frage der eigenen Firma
+func (s *BidderQuestionService) DeleteQuestion(ctx context.Context, authUserID, questionID uuid.UUID) error {
+	question, err := s.findQuestion(ctx, authUserID, questionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&domain.BidderQuestion{}, "id = ?", question.ID).Error; err != nil {
+		return fmt.Errorf("delete bidder question failed: %w", err)
+	}
+	return nil
+}
+
+// SetQuestionDeadline setzt oder löscht (nil) die Frist für Bieterfragen nur für die eigene
+// Firma; die Frist aus den Unterlagen an der Ausschreibung bleibt unverändert
+func (s *BidderQuestionService) SetQuestionDeadline(ctx context.Context, authUserID, tenderID uuid.UUID, deadline *time.Time) (*BidderQuestionList, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureTender(ctx, tenderID); err != nil {
+		return nil, err
+	}
+
+	override := &domain.TenderQuestionDeadline{
+		CompanyID:          company.ID,
+		TenderID:           tenderID,
+		QuestionDeadlineAt: deadline,
+		UpdatedBy:          authUserID,
+		UpdatedAt:          time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}, {Name: "tender_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"question_deadline_at", "updated_by", "updated_at"}),
+	}).Create(override).Error; err != nil {
+		return nil, fmt.Errorf("save question deadline failed: %w", err)
+	}
+	return s.ListQuestions(ctx, authUserID, tenderID)
+}
+
+// ResetQuestionDeadline verwirft die Frist der eigenen Firma; danach gilt wieder die Frist
+// aus den Unterlagen
+func (s *BidderQuestionService) ResetQuestionDeadline(ctx context.Context, authUserID, tenderID uuid.UUID) (*BidderQuestionList, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureTender(ctx, tenderID); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND tender_id = ?", company.ID, tenderID).
+		Delete(&domain.TenderQuestionDeadline{}).Error; err != nil {
+		return nil, fmt.Errorf("delete question deadline failed: %w", err)
+	}
+	return s.ListQuestions(ctx, authUserID, tenderID)
+}
+
+// ExportQuestions erstellt ein Dokument mit allen nicht verworfenen Fragen zum Einreichen
+// bei der Vergabestelle. Interne Begründungen und Antworten werden nicht exportiert.
+func (s *BidderQuestionService) ExportQuestions(ctx context.Context, authUserID, tenderID uuid.UUID, format string) ([]byte, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).
+		Select("id", "title", "external_id", "awarding_authority", "question_deadline_at").
+		First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+	if _, err := s.applyDeadlineOverride(ctx, company.ID, &tender); err != nil {
+		return nil, err
+	}
+
+	questions, err := s.loadQuestions(ctx, company.ID, tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var exported []domain.BidderQuestion
+	for _, q := range questions {
+		if q.Status != domain.BidderQuestionStatusDiscarded {
+			exported = append(exported, q)
+		}
+	}
+
+	switch format {
+	case "", ExportFormatMarkdown:
+		return renderBidderQuestions(&tender, exported, true), nil
+	case ExportFormatText:
+		return renderBidderQuestions(&tender, exported, false), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown export format %q", ErrInvalidBidderQuestion, format)
+	}
+}
+
+// latestFindings liefert offene und nicht erfüllte Anforderungen des letzten aktuellen Checks
+func (s *BidderQuestionService) latestFindings(ctx context.Context, companyID, tenderID uuid.UUID) ([]agent.ComplianceFinding, error) {
+	var checks []domain.ComplianceCheck
+	if err := s.db.WithContext(ctx).
+		Preload("Requirements", func(db *gorm.DB) *gorm.DB {
+			return db.Where("status <> ?", agent.RequirementStatusFulfilled).Order("position ASC")
+		}).
+		Where("company_id = ? AND tender_id = ? AND is_stale = false", companyID, tenderID).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&checks).Error; err != nil {
+		return nil, fmt.Errorf("load compliance check failed: %w", err)
+	}
+	if len(checks) == 0 {
+		return nil, nil
+	}
+
+	var findings []agent.ComplianceFinding
+	for _, r := range checks[0].Requirements {
+		findings = append(findings, agent.ComplianceFinding{
+			Title:       r.Title,
+			Status:      r.Status,
+			SourceQuote: r.SourceQuote,
+			Evidence:    r.Evidence,
+		})
+	}
+	return findings, nil
+}
+
+// applyDeadlineOverride ersetzt die Frist aus den Unterlagen durch die der Firma, falls gesetzt,
+// und liefert die Herkunft der Frist
+func (s *BidderQuestionService) applyDeadlineOverride(ctx context.Context, companyID uuid.UUID, tender *domain.Tender) (string, error) {
+	var overrides []domain.TenderQuestionDeadline
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND tender_id = ?", companyID, tender.ID).
+		Limit(1).
+		Find(&overrides).Error; err != nil {
+		return "", fmt.Errorf("load question deadline failed: %w", err)
+	}
+	if len(overrides) == 0 {
+		if tender.QuestionDeadlineAt == nil {
+			return "", nil
+		}
+		return QuestionDeadlineSourceDocuments, nil
+	}
+
+	tender.QuestionDeadlineAt = overrides[0].QuestionDeadlineAt
+	tender.QuestionDeadlineQuote = ""
+	return QuestionDeadlineSourceManual, nil
+}
+
+func (s *BidderQuestionService) ensureTender(ctx context.Context, tenderID uuid.UUID) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&domain.Tender{}).Where("id = ?", tenderID).Count(&count).Error; err != nil {
+		return fmt.Errorf("load tender failed: %w", err)
+	}
+	if count == 0 {
+		return ErrTenderNotFound
+	}
+	return nil
+}
+
+func (s *BidderQuestionService) loadQuestions(ctx context.Context, companyID, tenderID uuid.UUID) ([]domain.BidderQuestion, error) {
+	questions := []domain.BidderQuestion{}
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND tender_id = ?", companyID, tenderID).
+		Order("position ASC").
+		Find(&questions).Error; err != nil {
+		return nil, fmt.Errorf("load bidder questions failed: %w", err)
+	}
+	return questions, nil
+}
+
+func (s *BidderQuestionService) findQuestion(ctx context.Context, authUserID, questionID uuid.UUID) (*domain.BidderQuestion, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var question domain.BidderQuestion
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND company_id = ?", questionID, company.ID).
+		First(&question).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBidderQuestionNotFound
+		}
+		return nil, fmt.Errorf("load bidder question failed: %w", err)
+	}
+	return &question, nil
+}
+
+func newBidderQuestionList(tender *domain.Tender, deadlineSource string, questions []domain.BidderQuestion, now time.Time) *BidderQuestionList {
+	list := &BidderQuestionList{
+		TenderID:               tender.ID,
+		QuestionDeadlineAt:     tender.QuestionDeadlineAt,
+		QuestionDeadlineQuote:  tender.QuestionDeadlineQuote,
+		QuestionDeadlineSource: deadlineSource,
+		Questions:              questions,
+	}
+	if deadline := tender.QuestionDeadlineAt; deadline != nil {
+		list.DeadlinePassed = now.After(*deadline)
+		if !list.DeadlinePassed {
+			days := int(deadline.Sub(now).Hours() / 24)
+			list.DaysUntilDeadline = &days
+		}
+	}
+	return list
+}
+
+// applyBidderQuestionInput übernimmt die gesetzten Felder und prüft Art und Status
+func applyBidderQuestionInput(question *domain.BidderQuestion, input BidderQuestionInput) error {
+	if input.Subject != nil {
+		question.Subject = strings.TrimSpace(*input.Subject)
+	}
+	if input.Question != nil {
+		question.Question = strings.TrimSpace(*input.Question)
+	}
+	if input.Reason != nil {
+		question.Reason = strings.TrimSpace(*input.Reason)
+	}
+	if input.Kind != nil {
+		kind := strings.ToLower(strings.TrimSpace(*input.Kind))
+		if !validBidderQuestionKinds[kind] {
+			return fmt.Errorf("%w: unknown kind %q", ErrInvalidBidderQuestion, kind)
+		}
+		question.Kind = kind
+	}
+	if input.Status != nil {
+		status := strings.ToLower(strings.TrimSpace(*input.Status))
+		if !validBidderQuestionStatuses[status] {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidBidderQuestion, status)
+		}
+		question.Status = status
+	}
+	if input.Answer != nil {
+		question.Answer = strings.TrimSpace(*input.Answer)
+	}
+	return nil
+}
+
+// toBidderQuestions übernimmt die Vorschläge des Agents ab Position offset und korrigiert
+// unbekannte Arten, damit die Check-Constraints der Tabelle halten
+func toBidderQuestions(companyID, tenderID uuid.UUID, drafts []agent.BidderQuestionDraft, offset int, run agent.RunInfo) []domain.BidderQuestion {
+	now := time.Now()
+	questions := make([]domain.BidderQuestion, 0, len(drafts))
+	for _, d := range drafts {
+		text := strings.TrimSpace(d.Question)
+		if text == "" {
+			continue
+		}
+
+		kind := strings.ToLower(strings.TrimSpace(d.Kind))
+		if !validBidderQuestionKinds[kind] {
+			kind = agent.BidderQuestionKindAmbiguity
+		}
+
+		var page *int
+		if d.SourcePage > 0 {
+			p := d.SourcePage
+			page = &p
+		}
+
+		questions = append(questions, domain.BidderQuestion{
+			ID:             uuid.New(),
+			CompanyID:      companyID,
+			TenderID:       tenderID,
+			Position:       offset + len(questions),
+			Subject:        strings.TrimSpace(d.Subject),
+			Question:       text,
+			Reason:         strings.TrimSpace(d.Reason),
+			Kind:           kind,
+			SourceQuote:    strings.TrimSpace(d.SourceQuote),
+			SourceDocument: strings.TrimSpace(d.SourceDocument),
+			SourcePage:     page,
+			Status:         domain.BidderQuestionStatusDraft,
+			PromptVersion:  run.PromptVersion,
+			ModelID:        run.ModelID,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+	return questions
+}
+
+// renderBidderQuestions setzt die Fragen als Markdown oder Klartext für die Vergabestelle
+func renderBidderQuestions(tender *domain.Tender, questions []domain.BidderQuestion, markdown bool) []byte {
+	var b bytes.Buffer
+	heading := func(text string) {
+		if markdown {
+			fmt.Fprintf(&b, "# %s\n\n", text)
+		} else {
+			fmt.Fprintf(&b, "%s\n%s\n\n", text, strings.Repeat("=", len([]rune(text))))
+		}
+	}
+	field := func(label, value string) {
+		if value == "" {
+			return
+		}
+		if markdown {
+			fmt.Fprintf(&b, "**%s:** %s  \n", label, value)
+		} else {
+			fmt.Fprintf(&b, "%s: %s\n", label, value)
+		}
+	}
+
+	heading("Bieterfragen")
+	field("Ausschreibung", tender.Title)
+	field("Vergabenummer", tender.ExternalID)
+	field("Vergabestelle", tender.AwardingAuthority)
+	if tender.QuestionDeadlineAt != nil {
+		field("Frist für Bieterfragen", formatGermanDateTime(*tender.QuestionDeadlineAt))
+	}
+	b.WriteString("\n")
+
+	if len(questions) == 0 {
+		b.WriteString("Keine Fragen.\n")
+		return b.Bytes()
+	}
+
+	for i, q := range questions {
+		title := fmt.Sprintf("Frage %d", i+1)
+		if q.Subject != "" {
+			title += ": " + q.Subject
+		}
+		if markdown {
+			fmt.Fprintf(&b, "## %s\n\n", title)
+		} else {
+			fmt.Fprintf(&b, "%s\n%s\n", title, strings.Repeat("-", len([]rune(title))))
+		}
+
+		if ref := sourceReference(q); ref != "" {
+			field("Bezug", ref)
+			if markdown {
+				b.WriteString("\n")
+			}
+		}
+		if q.SourceQuote != "" {
+			if markdown {
+				fmt.Fprintf(&b, "> %s\n\n", strings.ReplaceAll(q.SourceQuote, "\n", "\n> "))
+			} else {
+				fmt.Fprintf(&b, "„%s“\n\n", q.SourceQuote)
+			}
+		} else if !markdown {
+			b.WriteString("\n")
+		}
+		b.WriteString(q.Question)
+		b.WriteString("\n\n")
+	}
+	return b.Bytes()
+}
+
+func sourceReference(q domain.BidderQuestion) string {
+	ref := q.SourceDocument
+	if q.SourcePage != nil {
+		if ref != "" {
+			ref += ", "
+		}
+		ref += fmt.Sprintf("Seite %d", *q.SourcePage)
+	}
+	return ref
+}
+
+// formatGermanDateTime formatiert in deutscher Ortszeit, z. B. "12.03.2025, 12:00 Uhr"
+func formatGermanDateTime(t time.Time) string {
+	if location, err := time.LoadLocation("Europe/Berlin"); err == nil {
+		t = t.In(location)
+	}
+	return t.Format("02.01.2006, 15:04") + " Uhr"
+}
@@ -0,0 +1,624 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCertificate     = errors.New("invalid certificate")
+	ErrCertificateNotFound    = errors.New("certificate not found")
+	ErrCertificateFileMissing = errors.New("certificate has no file")
+	ErrStorageUnavailable     = errors.New("file storage is not configured")
+)
+
+const (
+	// certificateExpiringDays: ab hier gilt ein Nachweis als "läuft ab"
+	certificateExpiringDays = 90
+	// certificateMaxFileSize begrenzt hochgeladene Nachweise (Scans, PDFs)
+	certificateMaxFileSize = 10 << 20
+	dateInputLayout        = "2006-01-02"
+)
+
+// certificateReminderStages sind die Erinnerungszeitpunkte in Tagen vor Ablauf; 0 = abgelaufen
+var certificateReminderStages = []int{90, 30, 7}
+
+// certificateType beschreibt eine Nachweisart. requirement ist der kanonische Name aus
+// certificatePatterns, gegen den Anforderungen der Ausschreibungen geprüft werden.
+type certificateType struct {
+	label       string
+	requirement string
+}
+
+var certificateTypes = map[string]certificateType{
+	"iso_9001":               {"ISO 9001 (Qualitätsmanagement)", "ISO 9001"},
+	"iso_14001":              {"ISO 14001 (Umweltmanagement)", "ISO 14001"},
+	"iso_27001":              {"ISO/IEC 27001 (Informationssicherheit)", "ISO 27001"},
+	"iso_45001":              {"ISO 45001 (Arbeitsschutz)", "ISO 45001"},
+	"iso_50001":              {"ISO 50001 (Energiemanagement)", "ISO 50001"},
+	"scc":                    {"SCC (Sicherheits-Certifikat-Contraktoren)", "SCC"},
+	"avpq":                   {"Präqualifikation AVPQ (Liefer- und Dienstleistungen)", certificatePrequalification},
+	"pq_vob":                 {"Präqualifikation PQ-VOB (Bauleistungen)", certificatePrequalification},
+	"meisterbrief":           {"Meisterbrief", "Meisterbrief"},
+	"entsorgungsfachbetrieb": {"Zertifikat Entsorgungsfachbetrieb", "Entsorgungsfachbetrieb"},
+	"insurance":              {"Versicherungsbestätigung (Betriebs-/Berufshaftpflicht)", certificateLiabilityInsurance},
+	"other":                  {"Sonstiger Nachweis", ""},
+}
+
+// certificateFileTypes sind die erlaubten Dateiformate für Nachweise
+var certificateFileTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+}
+
+// CertificateInput legt einen Nachweis an; Datumsangaben als YYYY-MM-DD
+type CertificateInput struct {
+	Type              string `json:"type"`
+	Name              string `json:"name"` // leer = Bezeichnung der Nachweisart
+	Issuer            string `json:"issuer"`
+	Scope             string `json:"scope"`
+	CertificateNumber string `json:"certificate_number"`
+	ValidFrom         string `json:"valid_from"`
+	ValidUntil        string `json:"valid_until"` // leer = unbefristet
+}
+
+// CertificatePatch ändert nur gesetzte Felder; "" löscht ein Datum
+type CertificatePatch struct {
+	Type              *string `json:"type"`
+	Name              *string `json:"name"`
+	Issuer            *string `json:"issuer"`
+	Scope             *string `json:"scope"`
+	CertificateNumber *string `json:"certificate_number"`
+	ValidFrom         *string `json:"valid_from"`
+	ValidUntil        *string `json:"valid_until"`
+}
+
+type CertificateService struct {
+	db      *gorm.DB
+	storage *SupabaseStorageService
+	mailer  *Mailer
+}
+
+// NewCertificateService: ohne storage sind keine Dateien möglich, ohne mailer keine Erinnerungen
+func NewCertificateService(db *gorm.DB, storage *SupabaseStorageService, mailer *Mailer) *CertificateService {
+	return &CertificateService{db: db, storage: storage, mailer: mailer}
+}
+
+// ListCertificates listet die Nachweise der eigenen Firma, zuerst die bald ablaufenden
+func (s *CertificateService) ListCertificates(ctx context.Context, authUserID uuid.UUID) ([]domain.CompanyCertificate, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+	return loadCompanyCertificates(ctx, s.db, member.CompanyID, time.Now())
+}
+
+// CreateCertificate legt einen Nachweis für die eigene Firma an
+func (s *CertificateService) CreateCertificate(ctx context.Context, authUserID uuid.UUID, input CertificateInput) (*domain.CompanyCertificate, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cert := &domain.CompanyCertificate{
+		ID:                uuid.New(),
+		CompanyID:         member.CompanyID,
+		Type:              strings.TrimSpace(input.Type),
+		Name:              strings.TrimSpace(input.Name),
+		Issuer:            strings.TrimSpace(input.Issuer),
+		Scope:             strings.TrimSpace(input.Scope),
+		CertificateNumber: strings.TrimSpace(input.CertificateNumber),
+		CreatedBy:         authUserID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	var problems []string
+	if cert.ValidFrom, err = parseDateInput(input.ValidFrom); err != nil {
+		problems = append(problems, "valid_from: "+err.Error())
+	}
+	if cert.ValidUntil, err = parseDateInput(input.ValidUntil); err != nil {
+		problems = append(problems, "valid_until: "+err.Error())
+	}
+	if err := validateCertificate(cert, problems); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Create(cert).Error; err != nil {
+		return nil, fmt.Errorf("create certificate failed: %w", err)
+	}
+	s.markChecksStale(ctx, cert.CompanyID)
+
+	applyCertificateStatus(cert, germanToday(now))
+	return cert, nil
+}
+
+// UpdateCertificate ändert einen Nachweis. Ein neues Ablaufdatum setzt die Erinnerungen zurück.
+func (s *CertificateService) UpdateCertificate(ctx context.Context, authUserID, certificateID uuid.UUID, patch CertificatePatch) (*domain.CompanyCertificate, error) {
+	cert, err := s.findCertificate(ctx, authUserID, certificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	setText := func(column string, value *string, field *string) {
+		if value != nil {
+			*field = strings.TrimSpace(*value)
+			updates[column] = *field
+		}
+	}
+	setText("type", patch.Type, &cert.Type)
+	setText("name", patch.Name, &cert.Name)
+	setText("issuer", patch.Issuer, &cert.Issuer)
+	setText("scope", patch.Scope, &cert.Scope)
+	setText("certificate_number", patch.CertificateNumber, &cert.CertificateNumber)
+
+	var problems []string
+	if patch.ValidFrom != nil {
+		if cert.ValidFrom, err = parseDateInput(*patch.ValidFrom); err != nil {
+			problems = append(problems, "valid_from: "+err.Error())
+		}
+		updates["valid_from"] = cert.ValidFrom
+	}
+	if patch.ValidUntil != nil {
+		previous := cert.ValidUntil
+		if cert.ValidUntil, err = parseDateInput(*patch.ValidUntil); err != nil {
+			problems = append(problems, "valid_until: "+err.Error())
+		}
+		updates["valid_until"] = cert.ValidUntil
+		if !sameDate(previous, cert.ValidUntil) {
+			// Neues Ablaufdatum -> Erinnerungen beginnen von vorn
+			cert.LastReminderDays = nil
+			updates["last_reminder_days"] = nil
+		}
+	}
+	if err := validateCertificate(cert, problems); err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		applyCertificateStatus(cert, germanToday(time.Now()))
+		return cert, nil
+	}
+
+	cert.UpdatedAt = time.Now()
+	updates["updated_at"] = cert.UpdatedAt
+	if err := s.db.WithContext(ctx).
+		Model(&domain.CompanyCertificate{}).
+		Where("id = ?", cert.ID).
+		Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("update certificate failed: %w", err)
+	}
+	s.markChecksStale(ctx, cert.CompanyID)
+
+	applyCertificateStatus(cert, germanToday(time.Now()))
+	return cert, nil
+}
+
+// DeleteCertificate löscht einen Nachweis samt Datei
+func (s *CertificateService) DeleteCertificate(ctx context.Context, authUserID, certificateID uuid.UUID) error {
+	cert, err := s.findCertificate(ctx, authUserID, certificateID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&domain.CompanyCertificate{}, "id = ?", cert.ID).Error; err != nil {
+		return fmt.Errorf("delete certificate failed: %w", err)
+	}
+	s.deleteFile(cert.StoragePath)
+	s.markChecksStale(ctx, cert.CompanyID)
+	return nil
+}
+
+// UploadCertificateFile hinterlegt die Datei zu einem Nachweis und ersetzt eine vorhandene
+func (s *CertificateService) UploadCertificateFile(ctx context.Context, authUserID, certificateID uuid.UUID, filename string, data []byte) (*domain.CompanyCertificate, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	cert, err := s.findCertificate(ctx, authUserID, certificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	filename = filepath.Base(strings.TrimSpace(filename))
+	contentType, ok := certificateFileTypes[strings.ToLower(filepath.Ext(filename))]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%w: file must be PDF, PNG or JPG", ErrInvalidCertificate)
+	case len(data) == 0:
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidCertificate)
+	case len(data) > certificateMaxFileSize:
+		return nil, fmt.Errorf("%w: file must not exceed %d MB", ErrInvalidCertificate, certificateMaxFileSize>>20)
+	}
+
+	storagePath := fmt.Sprintf("certificates/%s/%s/%s%s", cert.CompanyID, cert.ID, uuid.New(), strings.ToLower(filepath.Ext(filename)))
+	if err := s.storage.UploadFile(companyAssetsBucket, storagePath, data, contentType); err != nil {
+		return nil, fmt.Errorf("upload certificate file failed: %w", err)
+	}
+
+	previousPath := cert.StoragePath
+	cert.StoragePath = storagePath
+	cert.Filename = filename
+	cert.ContentType = contentType
+	cert.FileSize = len(data)
+	cert.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).
+		Model(&domain.CompanyCertificate{}).
+		Where("id = ?", cert.ID).
+		Updates(map[string]any{
+			"storage_path": cert.StoragePath,
+			"filename":     cert.Filename,
+			"content_type": cert.ContentType,
+			"file_size":    cert.FileSize,
+			"updated_at":   cert.UpdatedAt,
+		}).Error; err != nil {
+		s.deleteFile(storagePath)
+		return nil, fmt.Errorf("save certificate file failed: %w", err)
+	}
+	s.deleteFile(previousPath)
+
+	applyCertificateStatus(cert, germanToday(time.Now()))
+	return cert, nil
+}
+
+// DownloadCertificateFile lädt die Datei eines Nachweises (der Bucket ist privat)
+func (s *CertificateService) DownloadCertificateFile(ctx context.Context, authUserID, certificateID uuid.UUID) (*ExportedFile, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	cert, err := s.findCertificate(ctx, authUserID, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if cert.StoragePath == "" {
+		return nil, ErrCertificateFileMissing
+	}
+
+	content, err := s.storage.DownloadFile(companyAssetsBucket, cert.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("download certificate file failed: %w", err)
+	}
+	return &ExportedFile{Filename: cert.Filename, ContentType: cert.ContentType, Content: content}, nil
+}
+
+// StartExpiryReminders prüft sofort und danach in jedem Intervall, ob Nachweise bald ablaufen.
+// Läuft, bis ctx beendet wird; ohne Mailer passiert nichts.
+func (s *CertificateService) StartExpiryReminders(ctx context.Context, interval time.Duration) {
+	if s.mailer == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if sent, err := s.SendExpiryReminders(ctx, time.Now()); err != nil {
+				log.Printf("Certificate reminder warning: %v", err)
+			} else if sent > 0 {
+				log.Printf("Certificate reminders sent for %d certificates", sent)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SendExpiryReminders verschickt je Firma eine Mail mit allen Nachweisen, die eine neue
+// Erinnerungsstufe (90/30/7 Tage, abgelaufen) erreicht haben, an owner, admins und
+// bid_manager. Jede Stufe wird per bedingtem Update reserviert, damit mehrere Instanzen
+// nicht doppelt erinnern. Liefert die Anzahl erinnerter Nachweise.
+func (s *CertificateService) SendExpiryReminders(ctx context.Context, now time.Time) (int, error) {
+	today := germanToday(now)
+
+	var candidates []domain.CompanyCertificate
+	if err := s.db.WithContext(ctx).
+		Where("valid_until IS NOT NULL AND valid_until <= ?", today.AddDate(0, 0, certificateReminderStages[0])).
+		Where("last_reminder_days IS NULL OR last_reminder_days > 0").
+		Order("company_id, valid_until").
+		Find(&candidates).Error; err != nil {
+		return 0, fmt.Errorf("load expiring certificates failed: %w", err)
+	}
+
+	due := make(map[uuid.UUID][]domain.CompanyCertificate)
+	var companyIDs []uuid.UUID
+	for _, cert := range candidates {
+		stage, ok := dueReminderStage(&cert, today)
+		if !ok {
+			continue
+		}
+
+		claim := s.db.WithContext(ctx).
+			Model(&domain.CompanyCertificate{}).
+			Where("id = ? AND valid_until = ?", cert.ID, *cert.ValidUntil).
+			Where("last_reminder_days IS NULL OR last_reminder_days > ?", stage).
+			Update("last_reminder_days", stage)
+		if claim.Error != nil {
+			return 0, fmt.Errorf("claim certificate reminder failed: %w", claim.Error)
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		if _, seen := due[cert.CompanyID]; !seen {
+			companyIDs = append(companyIDs, cert.CompanyID)
+		}
+		due[cert.CompanyID] = append(due[cert.CompanyID], cert)
+	}
+
+	sent := 0
+	for _, companyID := range companyIDs {
+		certs := due[companyID]
+		if err := s.sendExpiryReminder(ctx, companyID, certs); err != nil {
+			// Stufe freigeben, damit der nächste Lauf es erneut versucht
+			log.Printf("Certificate reminder for company %s failed: %v", companyID, err)
+			for _, cert := range certs {
+				if err := s.db.WithContext(ctx).
+					Model(&domain.CompanyCertificate{}).
+					Where("id = ?", cert.ID).
+					Update("last_reminder_days", cert.LastReminderDays).Error; err != nil {
+					log.Printf("Releasing reminder stage of certificate %s failed: %v", cert.ID, err)
+				}
+			}
+			continue
+		}
+		sent += len(certs)
+	}
+	return sent, nil
+}
+
+func (s *CertificateService) sendExpiryReminder(ctx context.Context, companyID uuid.UUID, certs []domain.CompanyCertificate) error {
+	company, err := findCompanyByID(ctx, s.db, companyID, "id", "name")
+	if err != nil {
+		return err
+	}
+
+	var recipients []string
+	if err := s.db.WithContext(ctx).
+		Model(&domain.CompanyMember{}).
+		Where("company_id = ? AND role IN ? AND coalesce(email, '') <> ''", companyID,
+			[]string{domain.RoleOwner, domain.RoleAdmin, domain.RoleBidManager}).
+		Pluck("email", &recipients).Error; err != nil {
+		return fmt.Errorf("load reminder recipients failed: %w", err)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	var lines strings.Builder
+	for _, cert := range certs {
+		lines.WriteString("- " + cert.Name)
+		if cert.Issuer != "" {
+			lines.WriteString(" (" + cert.Issuer + ")")
+		}
+		days := *cert.DaysUntilExpiry
+		switch {
+		case days < 0:
+			fmt.Fprintf(&lines, ": abgelaufen am %s\n", cert.ValidUntil.Format("02.01.2006"))
+		case days == 0:
+			lines.WriteString(": läuft heute ab\n")
+		default:
+			fmt.Fprintf(&lines, ": gültig bis %s (noch %d Tage)\n", cert.ValidUntil.Format("02.01.2006"), days)
+		}
+	}
+
+	subject := fmt.Sprintf("Nachweise von %s laufen ab", company.Name)
+	body := fmt.Sprintf(`Guten Tag,
+
+folgende Nachweise von %s laufen bald ab oder sind bereits abgelaufen:
+
+%s
+Ohne gültigen Nachweis droht in laufenden Vergabeverfahren der Ausschluss. Bitte verlängern Sie
+die Nachweise rechtzeitig und hinterlegen Sie die neuen Dokumente im Vergabe-Agent.
+`, company.Name, lines.String())
+
+	var failed []string
+	for _, to := range recipients {
+		if err := s.mailer.Send(to, subject, body); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", to, err))
+		}
+	}
+	if len(failed) == len(recipients) {
+		return fmt.Errorf("send reminder failed: %s", strings.Join(failed, "; "))
+	}
+	for _, problem := range failed {
+		log.Printf("Certificate reminder warning: %s", problem)
+	}
+	return nil
+}
+
+func (s *CertificateService) findCertificate(ctx context.Context, authUserID, certificateID uuid.UUID) (*domain.CompanyCertificate, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cert domain.CompanyCertificate
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND company_id = ?", certificateID, member.CompanyID).
+		First(&cert).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCertificateNotFound
+		}
+		return nil, fmt.Errorf("load certificate failed: %w", err)
+	}
+	return &cert, nil
+}
+
+// markChecksStale: Nachweise sind Teil des Firmenprofils für die Compliance-Prüfung
+func (s *CertificateService) markChecksStale(ctx context.Context, companyID uuid.UUID) {
+	if err := markComplianceChecksStale(ctx, s.db, "company_id", companyID, StaleReasonCertificates); err != nil {
+		log.Printf("Compliance stale warning: %v", err)
+	}
+}
+
+func (s *CertificateService) deleteFile(path string) {
+	if s.storage == nil || path == "" {
+		return
+	}
+	if err := s.storage.DeleteFile(companyAssetsBucket, path); err != nil {
+		log.Printf("Storage delete failed for certificate file %s: %v", path, err)
+	}
+}
+
+// loadCompanyCertificates lädt alle Nachweise einer Firma mit berechnetem Status
+func loadCompanyCertificates(ctx context.Context, db *gorm.DB, companyID uuid.UUID, now time.Time) ([]domain.CompanyCertificate, error) {
+	certs := []domain.CompanyCertificate{}
+	if err := db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Order("valid_until ASC NULLS LAST, name ASC").
+		Find(&certs).Error; err != nil {
+		return nil, fmt.Errorf("load certificates failed: %w", err)
+	}
+
+	today := germanToday(now)
+	for i := range certs {
+		applyCertificateStatus(&certs[i], today)
+	}
+	return certs, nil
+}
+
+// validateCertificate ergänzt die Datumsfehler um die übrigen Prüfungen und meldet alle gemeinsam.
+// Ein leerer Name wird durch die Bezeichnung der Nachweisart ersetzt.
+func validateCertificate(cert *domain.CompanyCertificate, problems []string) error {
+	certType, ok := certificateTypes[cert.Type]
+	if !ok {
+		types := make([]string, 0, len(certificateTypes))
+		for key := range certificateTypes {
+			types = append(types, key)
+		}
+		sort.Strings(types)
+		problems = append(problems, "type: must be one of "+strings.Join(types, ", "))
+	}
+	if cert.Name == "" && ok && cert.Type != "other" {
+		cert.Name = certType.label
+	}
+
+	for _, check := range []struct {
+		field, value string
+		min, max     int
+	}{
+		{"name", cert.Name, 2, 200},
+		{"issuer", cert.Issuer, 0, 200},
+		{"scope", cert.Scope, 0, 2000},
+		{"certificate_number", cert.CertificateNumber, 0, 100},
+	} {
+		if problem := checkLength(check.value, check.min, check.max); problem != "" {
+			problems = append(problems, check.field+": "+problem)
+		}
+	}
+
+	if cert.ValidFrom != nil && cert.ValidUntil != nil && cert.ValidUntil.Before(*cert.ValidFrom) {
+		problems = append(problems, "valid_until: must not be before valid_from")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidCertificate, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseDateInput liest YYYY-MM-DD; leer = kein Datum
+func parseDateInput(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(dateInputLayout, value)
+	if err != nil {
+		return nil, errors.New("must be a date in format YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
+// germanToday ist das heutige Datum in deutscher Zeit, als UTC-Mitternacht wie die date-Spalten
+func germanToday(now time.Time) time.Time {
+	if location, err := time.LoadLocation("Europe/Berlin"); err == nil {
+		now = now.In(location)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// applyCertificateStatus berechnet Status und Resttage; unbefristete Nachweise sind gültig
+func applyCertificateStatus(cert *domain.CompanyCertificate, today time.Time) {
+	cert.Status = domain.CertificateValid
+	cert.DaysUntilExpiry = nil
+	if cert.ValidUntil == nil {
+		return
+	}
+
+	until := time.Date(cert.ValidUntil.Year(), cert.ValidUntil.Month(), cert.ValidUntil.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(until.Sub(today).Hours() / 24)
+	cert.DaysUntilExpiry = &days
+	switch {
+	case days < 0:
+		cert.Status = domain.CertificateExpired
+	case days <= certificateExpiringDays:
+		cert.Status = domain.CertificateExpiring
+	}
+}
+
+// certificateReminderStage ordnet die Resttage der engsten Erinnerungsstufe zu (0 = abgelaufen)
+func certificateReminderStage(days int) (int, bool) {
+	if days < 0 {
+		return 0, true
+	}
+	for i := len(certificateReminderStages) - 1; i >= 0; i-- {
+		if days <= certificateReminderStages[i] {
+			return certificateReminderStages[i], true
+		}
+	}
+	return 0, false
+}
+
+// dueReminderStage berechnet Status und Resttage und liefert die heute fällige Erinnerungsstufe.
+// Bereits erinnerte Stufen (last_reminder_days) und weitere davor lösen nichts mehr aus.
+func dueReminderStage(cert *domain.CompanyCertificate, today time.Time) (int, bool) {
+	applyCertificateStatus(cert, today)
+	if cert.DaysUntilExpiry == nil {
+		return 0, false
+	}
+	stage, ok := certificateReminderStage(*cert.DaysUntilExpiry)
+	if !ok || (cert.LastReminderDays != nil && *cert.LastReminderDays <= stage) {
+		return 0, false
+	}
+	return stage, true
+}
+
+// certificateRequirement liefert den kanonischen Nachweis, den ein Eintrag abdeckt ("" = keiner).
+// Sonstige Nachweise werden über ihren Namen erkannt.
+func certificateRequirement(cert *domain.CompanyCertificate) string {
+	if requirement := certificateTypes[cert.Type].requirement; requirement != "" {
+		return requirement
+	}
+	for _, pattern := range certificatePatterns {
+		if pattern.pattern.MatchString(cert.Name) {
+			return pattern.name
+		}
+	}
+	return ""
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format(dateInputLayout) == b.Format(dateInputLayout)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+func TestCertificateReminderStage(t *testing.T) {
+	tests := []struct {
+		days      int
+		wantStage int
+		wantOK    bool
+	}{
+		{365, 0, false},
+		{91, 0, false},
+		{90, 90, true},
+		{31, 90, true},
+		{30, 30, true},
+		{8, 30, true},
+		{7, 7, true},
+		{0, 7, true},
+		{-1, 0, true},
+		{-400, 0, true},
+	}
+	for _, tt := range tests {
+		stage, ok := certificateReminderStage(tt.days)
+		if stage != tt.wantStage || ok != tt.wantOK {
+			t.Errorf("certificateReminderStage(%d) = %d, %v; want %d, %v", tt.days, stage, ok, tt.wantStage, tt.wantOK)
+		}
+	}
+}
+
+func TestDueReminderStage(t *testing.T) {
+	today := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	inDays := func(days int) *time.Time {
+		d := today.AddDate(0, 0, days)
+		return &d
+	}
+	reminded := func(stage int) *int { return &stage }
+
+	tests := []struct {
+		name       string
+		cert       domain.CompanyCertificate
+		wantStage  int
+		wantDue    bool
+		wantStatus string
+	}{
+		{
+			name:       "no expiry",
+			cert:       domain.CompanyCertificate{},
+			wantStatus: domain.CertificateValid,
+		},
+		{
+			name:       "outside reminder window",
+			cert:       domain.CompanyCertificate{ValidUntil: inDays(120)},
+			wantStatus: domain.CertificateValid,
+		},
+		{
+			name:      "90 days",
+			cert:      domain.CompanyCertificate{ValidUntil: inDays(90)},
+			wantStage: 90, wantDue: true, wantStatus: domain.CertificateExpiring,
+		},
+		{
+			name:      "30 days after 90 day reminder",
+			cert:      domain.CompanyCertificate{ValidUntil: inDays(30), LastReminderDays: reminded(90)},
+			wantStage: 30, wantDue: true, wantStatus: domain.CertificateExpiring,
+		},
+		{
+			name:      "7 days skips missed stages",
+			cert:      domain.CompanyCertificate{ValidUntil: inDays(5)},
+			wantStage: 7, wantDue: true, wantStatus: domain.CertificateExpiring,
+		},
+		{
+			name:      "expired after 7 day reminder",
+			cert:      domain.CompanyCertificate{ValidUntil: inDays(-1), LastReminderDays: reminded(7)},
+			wantStage: 0, wantDue: true, wantStatus: domain.CertificateExpired,
+		},
+		{
+			name:       "stage already reminded",
+			cert:       domain.CompanyCertificate{ValidUntil: inDays(20), LastReminderDays: reminded(30)},
+			wantStatus: domain.CertificateExpiring,
+		},
+		{
+			name:       "expired reminder is sent once",
+			cert:       domain.CompanyCertificate{ValidUntil: inDays(-30), LastReminderDays: reminded(0)},
+			wantStatus: domain.CertificateExpired,
+		},
+		{
+			// UpdateCertificate setzt last_reminder_days bei neuem valid_until zurück
+			name:      "extended certificate is reminded again",
+			cert:      domain.CompanyCertificate{ValidUntil: inDays(80), LastReminderDays: nil},
+			wantStage: 90, wantDue: true, wantStatus: domain.CertificateExpiring,
+		},
+		{
+			// ohne Zurücksetzen bliebe die alte 7-Tage-Stufe stehen
+			name:       "extended certificate without reset stays silent",
+			cert:       domain.CompanyCertificate{ValidUntil: inDays(80), LastReminderDays: reminded(7)},
+			wantStatus: domain.CertificateExpiring,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage, due := dueReminderStage(&tt.cert, today)
+			if stage != tt.wantStage || due != tt.wantDue || tt.cert.Status != tt.wantStatus {
+				t.Errorf("dueReminderStage = %d, %v (status %s); want %d, %v (status %s)",
+					stage, due, tt.cert.Status, tt.wantStage, tt.wantDue, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDueReminderStageAfterClaim(t *testing.T) {
+	// Zwei Instanzen laden denselben Nachweis; nach der Reservierung durch die erste
+	// (last_reminder_days = Stufe) ist er für die zweite nicht mehr fällig
+	today := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	validUntil := today.AddDate(0, 0, 25)
+	cert := domain.CompanyCertificate{ValidUntil: &validUntil}
+
+	stage, due := dueReminderStage(&cert, today)
+	if !due || stage != 30 {
+		t.Fatalf("first instance: got %d, %v; want 30, true", stage, due)
+	}
+	cert.LastReminderDays = &stage
+
+	if _, due := dueReminderStage(&cert, today); due {
+		t.Error("second instance must not remind the claimed stage again")
+	}
+	if _, due := dueReminderStage(&cert, today.AddDate(0, 0, 1)); due {
+		t.Error("next day within the same stage must not remind again")
+	}
+	if stage, due := dueReminderStage(&cert, today.AddDate(0, 0, 18)); !due || stage != 7 {
+		t.Errorf("7 days before expiry: got %d, %v; want 7, true", stage, due)
+	}
+}
@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var (
+	ErrInvalidChecklistItem  = errors.New("invalid checklist item")
+	ErrChecklistItemNotFound = errors.New("checklist item not found")
+)
+
+type ChecklistService struct {
+	checklistAgent *agent.ChecklistAgent
+	db             *gorm.DB
+	usage          *UsageService
+}
+
+func NewChecklistService(checklistAgent *agent.ChecklistAgent, db *gorm.DB, usage *UsageService) *ChecklistService {
+	return &ChecklistService{
+		checklistAgent: checklistAgent,
+		db:             db,
+		usage:          usage,
+	}
+}
+
+// SubmissionChecklist ist die Abgabe-Checkliste einer Firma für eine Ausschreibung
+type SubmissionChecklist struct {
+	TenderID uuid.UUID                        `json:"tender_id"`
+	Items    []domain.SubmissionChecklistItem `json:"items"`
+	// Nur nach dem Generieren: eingeflossene Dokumente
+	InputDocuments []domain.ComplianceInputDocument `json:"input_documents,omitempty"`
+}
+
+// ChecklistItemInput ist der Request-Body zum Anlegen und Bearbeiten eines Eintrags.
+// Beim Bearbeiten werden nur gesetzte Felder übernommen.
+type ChecklistItemInput struct {
+	Title            *string `json:"title"`
+	Category         *string `json:"category"`
+	DueForm          *string `json:"due_form"`
+	SubmissionTiming *string `json:"submission_timing"`
+	PageLimit        *int    `json:"page_limit"`
+	Status           *string `json:"status"`
+	Note             *string `json:"note"`
+}
+
+var validChecklistCategories = map[string]bool{
+	agent.ChecklistCategoryFormular:        true,
+	agent.ChecklistCategoryEigenerklaerung: true,
+	agent.ChecklistCategoryNachweis:        true,
+	agent.ChecklistCategoryPreisblatt:      true,
+	agent.ChecklistCategoryKonzept:         true,
+	agent.ChecklistCategorySonstiges:       true,
+}
+
+var validChecklistStatuses = map[string]bool{
+	domain.ChecklistStatusOpen:          true,
+	domain.ChecklistStatusInProgress:    true,
+	domain.ChecklistStatusDone:          true,
+	domain.ChecklistStatusNotApplicable: true,
+}
+
+// GenerateChecklist erstellt die Checkliste aus Beschreibung und allen verarbeiteten Anhängen.
+// Bisher generierte Einträge werden ersetzt; Status und Notiz gleichnamiger Einträge bleiben
+// erhalten, selbst angelegte Einträge bleiben unverändert. Nicht erneut erzeugte Einträge
+// bleiben stehen, wenn sie bearbeitet wurden (Status nicht offen oder Notiz) oder der Lauf
+// nur ein Teilergebnis lieferte (fehlgeschlagene Abschnitte).
+func (s *ChecklistService) GenerateChecklist(ctx context.Context, authUserID, tenderID uuid.UUID) (*SubmissionChecklist, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "subscription_tier")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	ctx, err = s.usage.Begin(ctx, company, &tenderID, OperationChecklist)
+	if err != nil {
+		return nil, err
+	}
+
+	docInput, err := buildComplianceInput(ctx, s.db, &tender)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := docInput.Sections
+	if len(sections) == 0 {
+		sections = []agent.DocumentSection{{ID: 0, Label: "Unterlagen", Text: docInput.Text}}
+	}
+
+	result, err := s.checklistAgent.Generate(ctx, sections)
+	if err != nil {
+		return nil, err
+	}
+	if len(docInput.Sections) > 0 {
+		docInput.markFailedSections(result.FailedSections)
+	}
+
+	var items []domain.SubmissionChecklistItem
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []domain.SubmissionChecklistItem
+		if err := tx.Where("company_id = ? AND tender_id = ?", company.ID, tenderID).
+			Order("position ASC").
+			Find(&existing).Error; err != nil {
+			return fmt.Errorf("load checklist failed: %w", err)
+		}
+
+		// Bearbeitungsstand bisheriger Einträge nach Titel übernehmen
+		previous := make(map[string]domain.SubmissionChecklistItem)
+		var custom []domain.SubmissionChecklistItem
+		for _, item := range existing {
+			if item.IsCustom {
+				custom = append(custom, item)
+				continue
+			}
+			previous[normalizeKey(item.Title)] = item
+		}
+
+		generated := toChecklistItems(company.ID, tenderID, result.Items, result.Run)
+		for i := range generated {
+			key := normalizeKey(generated[i].Title)
+			if prev, ok := previous[key]; ok {
+				generated[i].Status = prev.Status
+				generated[i].Note = prev.Note
+				delete(previous, key)
+			}
+		}
+
+		// Übrige Einträge: bearbeitete und bei Teilergebnis alle behalten, sonst löschen
+		partial := len(result.FailedSections) > 0
+		var kept []domain.SubmissionChecklistItem
+		var dropped []uuid.UUID
+		for _, item := range existing {
+			if item.IsCustom {
+				continue
+			}
+			if _, ok := previous[normalizeKey(item.Title)]; !ok {
+				dropped = append(dropped, item.ID)
+				continue
+			}
+			if partial || item.Status != domain.ChecklistStatusOpen || strings.TrimSpace(item.Note) != "" {
+				kept = append(kept, item)
+				continue
+			}
+			dropped = append(dropped, item.ID)
+		}
+
+		if len(dropped) > 0 {
+			if err := tx.Where("id IN ?", dropped).Delete(&domain.SubmissionChecklistItem{}).Error; err != nil {
+				return fmt.Errorf("delete checklist failed: %w", err)
+			}
+		}
+		if len(generated) > 0 {
+			if err := tx.Create(&generated).Error; err != nil {
+				return fmt.Errorf("save checklist failed: %w", err)
+			}
+		}
+
+		// Behaltene und eigene Einträge hinter die generierten schieben
+		rest := append(kept, custom...)
+		for i := range rest {
+			rest[i].Position = len(generated) + i
+			if err := tx.Model(&rest[i]).Update("position", rest[i].Position).Error; err != nil {
+				return fmt.Errorf("reorder checklist failed: %w", err)
+			}
+		}
+
+		items = append(generated, rest...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SubmissionChecklist{
+		TenderID:       tenderID,
+		Items:          items,
+		InputDocuments: docInput.Documents,
+	}, nil
+}
+
+// GetChecklist liefert die gespeicherte Checkliste (leer, wenn noch nicht generiert)
+func (s *ChecklistService) GetChecklist(ctx context.Context, authUserID, tenderID uuid.UUID) (*SubmissionChecklist, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	items := []domain.SubmissionChecklistItem{}
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND tender_id = ?", company.ID, tenderID).
+		Order("position ASC").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("load checklist failed: %w", err)
+	}
+
+	return &SubmissionChecklist{TenderID: tenderID, Items: items}, nil
+}
+
+// AddItem legt einen eigenen Eintrag am Ende der Checkliste an
+func (s *ChecklistService) AddItem(ctx context.Context, authUserID, tenderID uuid.UUID, input ChecklistItemInput) (*domain.SubmissionChecklistItem, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&domain.Tender{}).Where("id = ?", tenderID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrTenderNotFound
+	}
+
+	item := &domain.SubmissionChecklistItem{
+		ID:               uuid.New(),
+		CompanyID:        company.ID,
+		TenderID:         tenderID,
+		Category:         agent.ChecklistCategorySonstiges,
+		SubmissionTiming: agent.SubmissionWithBid,
+		Status:           domain.ChecklistStatusOpen,
+		IsCustom:         true,
+	}
+	if err := applyChecklistInput(item, input); err != nil {
+		return nil, err
+	}
+	if item.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidChecklistItem)
+	}
+
+	var maxPosition *int
+	if err := s.db.WithContext(ctx).Model(&domain.SubmissionChecklistItem{}).
+		Where("company_id = ? AND tender_id = ?", company.ID, tenderID).
+		Select("max(position)").
+		Scan(&maxPosition).Error; err != nil {
+		return nil, fmt.Errorf("load checklist failed: %w", err)
+	}
+	if maxPosition != nil {
+		item.Position = *maxPosition + 1
+	}
+
+	now := time.Now()
+	item.CreatedAt, item.UpdatedAt = now, now
+	if err := s.db.WithContext(ctx).Create(item).Error; err != nil {
+		return nil, fmt.Errorf("save checklist item failed: %w", err)
+	}
+	return item, nil
+}
+
+// UpdateItem ändert einen Eintrag der eigenen Firma (z. B. Status abhaken, Notiz)
+func (s *ChecklistService) UpdateItem(ctx context.Context, authUserID, itemID uuid.UUID, input ChecklistItemInput) (*domain.SubmissionChecklistItem, error) {
+	item, err := s.findItem(ctx, authUserID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyChecklistInput(item, input); err != nil {
+		return nil, err
+	}
+	if item.Title == "" {
+		return nil, fmt.Errorf("%w: title must not be empty", ErrInvalidChecklistItem)
+	}
+
+	item.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Save(item).Error; err != nil {
+		return nil, fmt.Errorf("update checklist item failed: %w", err)
+	}
+	return item, nil
+}
+
+// DeleteItem entfernt einen Eintrag der eigenen Firma
+func (s *ChecklistService) DeleteItem(ctx context.Context, authUserID, itemID uuid.UUID) error {
+	item, err := s.findItem(ctx, authUserID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&domain.SubmissionChecklistItem{}, "id = ?", item.ID).Error; err != nil {
+		return fmt.Errorf("delete checklist item failed: %w", err)
+	}
+	return nil
+}
+
+func (s *ChecklistService) findItem(ctx context.Context, authUserID, itemID uuid.UUID) (*domain.SubmissionChecklistItem, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var item domain.SubmissionChecklistItem
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND company_id = ?", itemID, company.ID).
+		First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChecklistItemNotFound
+		}
+		return nil, fmt.Errorf("load checklist item failed: %w", err)
+	}
+	return &item, nil
+}
+
+// applyChecklistInput übernimmt die gesetzten Felder und prüft Kategorie, Zeitpunkt und Status
+func applyChecklistInput(item *domain.SubmissionChecklistItem, input ChecklistItemInput) error {
+	if input.Title != nil {
+		item.Title = strings.TrimSpace(*input.Title)
+	}
+	if input.Category != nil {
+		category := strings.ToLower(strings.TrimSpace(*input.Category))
+		if !validChecklistCategories[category] {
+			return fmt.Errorf("%w: unknown category %q", ErrInvalidChecklistItem, category)
+		}
+		item.Category = category
+	}
+	if input.DueForm != nil {
+		item.DueForm = strings.TrimSpace(*input.DueForm)
+	}
+	if input.SubmissionTiming != nil {
+		timing := strings.ToLower(strings.TrimSpace(*input.SubmissionTiming))
+		if timing != agent.SubmissionWithBid && timing != agent.SubmissionOnRequest {
+			return fmt.Errorf("%w: submission_timing must be '%s' or '%s'", ErrInvalidChecklistItem, agent.SubmissionWithBid, agent.SubmissionOnRequest)
+		}
+		item.SubmissionTiming = timing
+	}
+	if input.PageLimit != nil {
+		switch {
+		case *input.PageLimit < 0:
+			return fmt.Errorf("%w: page_limit must not be negative", ErrInvalidChecklistItem)
+		case *input.PageLimit == 0:
+			item.PageLimit = nil
+		default:
+			limit := *input.PageLimit
+			item.PageLimit = &limit
+		}
+	}
+	if input.Status != nil {
+		status := strings.ToLower(strings.TrimSpace(*input.Status))
+		if !validChecklistStatuses[status] {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidChecklistItem, status)
+		}
+		item.Status = status
+	}
+	if input.Note != nil {
+		item.Note = strings.TrimSpace(*input.Note)
+	}
+	return nil
+}
+
+// toChecklistItems übernimmt die Einträge des Agents und korrigiert unbekannte Werte,
+// damit die Check-Constraints der Tabelle halten
+func toChecklistItems(companyID, tenderID uuid.UUID, items []agent.ChecklistItem, run agent.RunInfo) []domain.SubmissionChecklistItem {
+	now := time.Now()
+	result := make([]domain.SubmissionChecklistItem, 0, len(items))
+	for _, item := range items {
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			continue
+		}
+
+		category := strings.ToLower(strings.TrimSpace(item.Category))
+		if !validChecklistCategories[category] {
+			category = agent.ChecklistCategorySonstiges
+		}
+		timing := item.SubmissionTiming
+		if timing != agent.SubmissionOnRequest {
+			timing = agent.SubmissionWithBid
+		}
+
+		var pageLimit, sourcePage *int
+		if item.PageLimit > 0 {
+			limit := item.PageLimit
+			pageLimit = &limit
+		}
+		if item.SourcePage > 0 {
+			page := item.SourcePage
+			sourcePage = &page
+		}
+
+		result = append(result, domain.SubmissionChecklistItem{
+			ID:               uuid.New(),
+			CompanyID:        companyID,
+			TenderID:         tenderID,
+			Position:         len(result),
+			Title:            title,
+			Category:         category,
+			DueForm:          strings.TrimSpace(item.DueForm),
+			SubmissionTiming: timing,
+			PageLimit:        pageLimit,
+			SourceQuote:      strings.TrimSpace(item.SourceQuote),
+			SourceDocument:   strings.TrimSpace(item.SourceDocument),
+			SourcePage:       sourcePage,
+			Status:           domain.ChecklistStatusOpen,
+			PromptVersion:    run.PromptVersion,
+			ModelID:          run.ModelID,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		})
+	}
+	return result
+}
+
+// normalizeKey vergleicht Titel und Zitate unabhängig von Groß-/Kleinschreibung und Leerraum
+func normalizeKey(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
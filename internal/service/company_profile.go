@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+// profileRecords sind die strukturierten Daten einer Firma neben den Onboarding-Feldern
+type profileRecords struct {
+	Certificates []domain.CompanyCertificate
+	References   []domain.ProjectReference
+	CVs          []domain.EmployeeCV
+}
+
+// loadProfileRecords lädt Nachweise (mit Status zum Zeitpunkt now), Referenzen und Lebensläufe
+func loadProfileRecords(ctx context.Context, db *gorm.DB, companyID uuid.UUID, now time.Time) (profileRecords, error) {
+	var records profileRecords
+	var err error
+	if records.Certificates, err = loadCompanyCertificates(ctx, db, companyID, now); err != nil {
+		return records, err
+	}
+	if records.References, err = loadProjectReferences(ctx, db, companyID); err != nil {
+		return records, err
+	}
+	if records.CVs, err = loadEmployeeCVs(ctx, db, companyID); err != nil {
+		return records, err
+	}
+	return records, nil
+}
+
+// buildCompanyProfile stellt das Unternehmensdossier für den Compliance-Agent aus allen
+// Feldern der Firma und den hinterlegten Dokumenten zusammen. Die JSONB-Spalten stammen
+// aus dem Onboarding und werden tolerant gelesen (Strings oder Objekte). Sind strukturierte
+// Nachweise hinterlegt, ersetzen deren nicht abgelaufene Einträge die Onboarding-Zertifikate;
+// strukturierte Referenzprojekte und ausgewertete Lebensläufe ersetzen die Onboarding-Einträge.
+func buildCompanyProfile(company *domain.Company, records profileRecords, now time.Time) agent.CompanyProfile {
+	profile := agent.CompanyProfile{
+		AsOf:            now.Format("2006-01-02"),
+		Name:            company.Name,
+		LegalForm:       company.LegalForm,
+		FoundingYear:    company.FoundingYear,
+		EmployeeCount:   company.EmployeeCount,
+		AnnualRevenue:   company.AnnualRevenue,
+		IsAVPQ:          company.IsAVPQ,
+		City:            company.AddressCity,
+		Zip:             company.AddressZip,
+		Country:         company.AddressCountry,
+		ServiceRadiusKM: company.ServiceRadiusKM,
+		IndustryTags:    company.IndustryTags,
+		Summary:         strings.TrimSpace(company.ProfileSummary),
+		References:      parseProjectReferences(company.ProjectReferences, now.Year()),
+		EmployeeCVs:     parseProfileDocuments(company.EmployeeCVs),
+
+		FinancialDocuments: parseProfileDocuments(company.FinancialDocuments),
+	}
+	if len(records.References) > 0 {
+		profile.References = profileReferences(records.References, now)
+	}
+	if cvs := profileCVs(records.CVs); len(cvs) > 0 {
+		profile.EmployeeCVs = cvs
+	}
+	if company.FoundingYear > 0 && company.FoundingYear <= now.Year() {
+		profile.YearsInBusiness = now.Year() - company.FoundingYear
+	}
+
+	// certifications enthält ausgewählte Zertifikate (Strings) und hochgeladene Nachweise (Objekte)
+	profile.Certificates = []string{}
+	profile.UploadedDocuments = []agent.ProfileDocument{}
+	for _, raw := range jsonArray(company.Certifications) {
+		var name string
+		if json.Unmarshal(raw, &name) == nil {
+			if name = strings.TrimSpace(name); name != "" && len(records.Certificates) == 0 {
+				profile.Certificates = append(profile.Certificates, name)
+			}
+			continue
+		}
+		if doc, ok := parseProfileDocument(raw); ok {
+			profile.UploadedDocuments = append(profile.UploadedDocuments, doc)
+		}
+	}
+
+	for _, cert := range records.Certificates {
+		record := agent.CertificateRecord{
+			Type:        cert.Type,
+			Name:        cert.Name,
+			Issuer:      cert.Issuer,
+			Scope:       cert.Scope,
+			Status:      cert.Status,
+			HasDocument: cert.StoragePath != "",
+		}
+		if cert.ValidFrom != nil {
+			record.ValidFrom = cert.ValidFrom.Format(dateInputLayout)
+		}
+		if cert.ValidUntil != nil {
+			record.ValidUntil = cert.ValidUntil.Format(dateInputLayout)
+		}
+		profile.CertificateRecords = append(profile.CertificateRecords, record)
+		if cert.Status != domain.CertificateExpired {
+			profile.Certificates = append(profile.Certificates, cert.Name)
+		}
+	}
+
+	return profile
+}
+
+// profileCVs übernimmt die ausgewerteten Lebensläufe als Dokumente mit den Angaben in Details
+func profileCVs(cvs []domain.EmployeeCV) []agent.ProfileDocument {
+	docs := []agent.ProfileDocument{}
+	for _, cv := range cvs {
+		if cv.ExtractionStatus != domain.CVStatusExtracted || strings.TrimSpace(cv.FullName) == "" {
+			continue
+		}
+		details := map[string]string{}
+		add := func(key, value string) {
+			if value = strings.TrimSpace(value); value != "" {
+				details[key] = value
+			}
+		}
+		add("position", cv.Position)
+		if cv.YearsOfExperience > 0 {
+			add("years_of_experience", strconv.Itoa(cv.YearsOfExperience))
+		}
+		add("degrees", strings.Join(cvDegreeLabels(cv.Degrees), "; "))
+		add("certifications", strings.Join(cv.Certifications, "; "))
+		add("skills", strings.Join(cv.Skills, ", "))
+		add("languages", strings.Join(cv.Languages, ", "))
+		add("project_roles", strings.Join(cvProjectRoleLabels(cv.ProjectRoles), "; "))
+		docs = append(docs, agent.ProfileDocument{Name: cv.FullName, Type: "cv", Details: details})
+	}
+	return docs
+}
+
+type projectReferenceRecord struct {
+	Name     string          `json:"name"`
+	Client   string          `json:"client"`
+	Year     json.RawMessage `json:"year"`
+	Budget   json.RawMessage `json:"budget"`
+	Keywords []string        `json:"keywords"`
+}
+
+// profileReferences übernimmt die strukturierten Referenzen; Jahr ist das Projektende
+// (bei laufenden Projekten der Beginn), sortiert nach Jahr wie die Onboarding-Referenzen
+func profileReferences(references []domain.ProjectReference, now time.Time) []agent.ProjectReference {
+	result := make([]agent.ProjectReference, 0, len(references))
+	for _, ref := range references {
+		released := ref.ReleasedForDisclosure
+		item := agent.ProjectReference{
+			Name:                  ref.Title,
+			Client:                ref.Client,
+			Description:           ref.Description,
+			ClientType:            ref.ClientType,
+			Period:                referencePeriod(ref),
+			CPVCodes:              ref.CPVCodes,
+			Location:              strings.TrimSpace(ref.LocationZip + " " + ref.LocationCity),
+			ReleasedForDisclosure: &released,
+		}
+		if ref.ContractValue != nil && *ref.ContractValue > 0 {
+			item.Budget = formatEuro(*ref.ContractValue)
+		}
+		switch {
+		case ref.EndDate != nil && !ref.EndDate.After(now):
+			item.Year = ref.EndDate.Year()
+		case ref.StartDate != nil:
+			item.Year = ref.StartDate.Year()
+		}
+		if item.Year > 0 && item.Year <= now.Year() {
+			yearsAgo := now.Year() - item.Year
+			item.YearsAgo = &yearsAgo
+		}
+		result = append(result, item)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Year > result[j].Year })
+	return result
+}
+
+// referencePeriod formatiert den Projektzeitraum ("03/2022 – 11/2023", "seit 05/2024")
+func referencePeriod(ref domain.ProjectReference) string {
+	switch {
+	case ref.StartDate != nil && ref.EndDate != nil:
+		return ref.StartDate.Format("01/2006") + " – " + ref.EndDate.Format("01/2006")
+	case ref.StartDate != nil:
+		return "seit " + ref.StartDate.Format("01/2006")
+	case ref.EndDate != nil:
+		return "bis " + ref.EndDate.Format("01/2006")
+	}
+	return ""
+}
+
+func parseProjectReferences(raw json.RawMessage, currentYear int) []agent.ProjectReference {
+	references := []agent.ProjectReference{}
+	for _, item := range jsonArray(raw) {
+		var record projectReferenceRecord
+		if err := json.Unmarshal(item, &record); err != nil {
+			continue
+		}
+		ref := agent.ProjectReference{
+			Name:     strings.TrimSpace(record.Name),
+			Client:   strings.TrimSpace(record.Client),
+			Year:     int(jsonNumber(record.Year)),
+			Budget:   jsonScalar(record.Budget),
+			Keywords: record.Keywords,
+		}
+		if ref.Name == "" && ref.Client == "" {
+			continue
+		}
+		if ref.Year > 0 && ref.Year <= currentYear {
+			yearsAgo := currentYear - ref.Year
+			ref.YearsAgo = &yearsAgo
+		}
+		references = append(references, ref)
+	}
+
+	// Neueste Referenzen zuerst
+	sort.SliceStable(references, func(i, j int) bool { return references[i].Year > references[j].Year })
+	return references
+}
+
+func parseProfileDocuments(raw json.RawMessage) []agent.ProfileDocument {
+	docs := []agent.ProfileDocument{}
+	for _, item := range jsonArray(raw) {
+		if doc, ok := parseProfileDocument(item); ok {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// parseProfileDocument liest einen Dokumenteintrag. Name kommt aus name/title/filename,
+// weitere skalare Felder (außer Speicherpfad/IDs) landen in Details.
+func parseProfileDocument(raw json.RawMessage) (agent.ProfileDocument, bool) {
+	var name string
+	if json.Unmarshal(raw, &name) == nil {
+		name = strings.TrimSpace(name)
+		return agent.ProfileDocument{Name: name}, name != ""
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return agent.ProfileDocument{}, false
+	}
+
+	var doc agent.ProfileDocument
+	for _, key := range []string{"name", "title", "filename"} {
+		if v := jsonScalar(fields[key]); v != "" {
+			doc.Name = v
+			break
+		}
+	}
+	doc.Type = jsonScalar(fields["type"])
+
+	for key, value := range fields {
+		switch key {
+		case "id", "path", "url", "size", "name", "title", "filename", "type":
+			continue
+		}
+		if v := jsonScalar(value); v != "" {
+			if doc.Details == nil {
+				doc.Details = make(map[string]string)
+			}
+			doc.Details[key] = v
+		}
+	}
+	return doc, doc.Name != "" || len(doc.Details) > 0
+}
+
+func jsonArray(raw json.RawMessage) []json.RawMessage {
+	var items []json.RawMessage
+	if len(raw) == 0 || json.Unmarshal(raw, &items) != nil {
+		return nil
+	}
+	return items
+}
+
+// jsonScalar gibt Strings, Zahlen und Bools als Text zurück, sonst ""
+func jsonScalar(raw json.RawMessage) string {
+	var value any
+	if len(raw) == 0 || json.Unmarshal(raw, &value) != nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func jsonNumber(raw json.RawMessage) float64 {
+	n, err := strconv.ParseFloat(jsonScalar(raw), 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -3,23 +3,40 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
-	openaiembed "github.com/cloudwego/eino-ext/components/embedding/openai"
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
 	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"github.com/vergabe-agent/vergabe-backend/internal/usage"
 	"gorm.io/gorm"
 )
 
+var ErrInvalidCompany = errors.New("invalid company profile")
+
+// companyAssetsBucket enthält die beim Onboarding hochgeladenen Nachweise (Upload im Frontend)
+const companyAssetsBucket = "company-assets"
+
 type CompanyService struct {
 	db       *gorm.DB
-	embedder *openaiembed.Embedder
+	embedder embedding.Embedder
 	geocoder *GeocodingService
+	storage  *SupabaseStorageService
 }
 
-func NewCompanyService(db *gorm.DB, embedCfg EmbeddingProviderConfig) (*CompanyService, error) {
+// NewCompanyService: storage darf nil sein, dann bleiben Dateien beim Löschen liegen
+func NewCompanyService(db *gorm.DB, embedCfg EmbeddingProviderConfig, storage *SupabaseStorageService) (*CompanyService, error) {
 	ctx := context.Background()
 	emb, err := newEmbeddingClient(ctx, embedCfg)
 	if err != nil {
@@ -30,11 +47,13 @@ func NewCompanyService(db *gorm.DB, embedCfg EmbeddingProviderConfig) (*CompanyS
 		db:       db,
 		embedder: emb,
 		geocoder: NewGeocodingService(),
+		storage:  storage,
 	}, nil
 }
 
 type CompanyInput struct {
 	AuthUserID  string          `json:"-"` // Set from context
+	AuthEmail   string          `json:"-"` // E-Mail aus dem JWT, für die Mitgliedschaft des owners
 	Basics      BasicsData      `json:"basics"`
 	References  ReferencesData  `json:"references"`
 	Preferences PreferencesData `json:"preferences"`
@@ -76,88 +95,312 @@ type PreferencesData struct {
 	BudgetRange    []int    `json:"budgetRange"`
 	Regions        []string `json:"regions"`
 	Consent        bool     `json:"consent"`
+
+	// Opt-in für die Partnersuche (Bietergemeinschaft / Subunternehmer)
+	DiscoverableAsConsortiumPartner bool `json:"discoverableAsConsortiumPartner"`
+	DiscoverableAsSubcontractor     bool `json:"discoverableAsSubcontractor"`
 }
 
-func (s *CompanyService) CreateCompany(ctx context.Context, input CompanyInput) (*domain.Company, error) {
-	// 1. Generate Embedding
-	embeddingText := fmt.Sprintf("%s\n%s\n%s\n%s",
-		input.Basics.CompanyName,
-		input.Basics.Industry,
-		strings.Join(input.Basics.CPVCodes, " "),
-		input.Basics.ProfileSummary,
-	)
+// UpsertCompany legt das Firmenprofil aus dem Onboarding an (der Nutzer wird owner) oder
+// überschreibt das Profil seiner Firma (ab admin). Embedding und Koordinaten werden nur neu
+// berechnet, wenn sich die zugrunde liegenden Felder ändern.
+func (s *CompanyService) UpsertCompany(ctx context.Context, input CompanyInput) (*domain.Company, error) {
+	authUserID, err := uuid.Parse(input.AuthUserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth user id: %w", err)
+	}
+
+	existing, err := findCompanyByAuthUser(ctx, s.db, authUserID)
+	if err != nil && !errors.Is(err, ErrCompanyNotFound) {
+		return nil, err
+	}
 
-	vectors64, err := s.embedder.EmbedStrings(ctx, []string{embeddingText})
+	if existing != nil {
+		member, err := findMembership(ctx, s.db, authUserID)
+		if err != nil {
+			return nil, err
+		}
+		if !RoleAtLeast(member.Role, domain.RoleAdmin) {
+			return nil, fmt.Errorf("%w: requires %s, you are %s", ErrInsufficientRole, domain.RoleAdmin, member.Role)
+		}
+	}
+
+	company := companyFromInput(authUserID, input)
+	if err := validateCompany(company, nil); err != nil {
+		return nil, err
+	}
+
+	// Embedding zählt für die bestehende Firma, bei der ersten Anlage als Systemverbrauch
+	attribution := usage.Attribution{Operation: OperationCompanyProfile}
+	embed, geocode := true, true
+	if existing != nil {
+		attribution.CompanyID = &existing.ID
+		company.ID = existing.ID
+		company.AuthUserID = existing.AuthUserID
+		embed = embeddingOutdated(existing, company)
+		geocode = geocodeOutdated(existing, company)
+		if !geocode {
+			company.Latitude, company.Longitude = existing.Latitude, existing.Longitude
+		}
+	}
+	ctx = usage.WithAttribution(ctx, attribution)
+
+	var vector *pgvector.Vector
+	if embed {
+		if vector, err = s.embedProfile(ctx, company); err != nil {
+			return nil, err
+		}
+	}
+	if geocode {
+		s.geocode(ctx, company)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if existing != nil {
+			if err := tx.Model(&domain.Company{}).
+				Where("id = ?", existing.ID).
+				Select(companyProfileColumns).
+				Updates(company).Error; err != nil {
+				return fmt.Errorf("update company failed: %w", err)
+			}
+		} else {
+			if err := tx.Create(company).Error; err != nil {
+				return fmt.Errorf("create company failed: %w", err)
+			}
+			owner := &domain.CompanyMember{
+				ID:         uuid.New(),
+				CompanyID:  company.ID,
+				AuthUserID: authUserID,
+				Email:      strings.ToLower(strings.TrimSpace(input.AuthEmail)),
+				Role:       domain.RoleOwner,
+				CreatedAt:  company.UpdatedAt,
+				UpdatedAt:  company.UpdatedAt,
+			}
+			if err := tx.Create(owner).Error; err != nil {
+				return fmt.Errorf("create owner membership failed: %w", err)
+			}
+		}
+		if err := syncOnboardingCertificates(tx, company.ID, authUserID, input.References.Certificates); err != nil {
+			return err
+		}
+		if err := syncOnboardingReferences(tx, company.ID, authUserID, input.References.References); err != nil {
+			return err
+		}
+		return saveProfileEmbedding(tx, company.ID, vector)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("embedding failed: %w", err)
+		return nil, err
 	}
 
-	vector32 := make([]float32, len(vectors64[0]))
-	for i, v := range vectors64[0] {
-		vector32[i] = float32(v)
+	// Profil geändert -> bisherige Compliance-Checks sind veraltet
+	if existing != nil {
+		if err := markComplianceChecksStale(ctx, s.db, "company_id", existing.ID, StaleReasonProfileUpdated); err != nil {
+			log.Printf("Compliance stale warning: %v", err)
+		}
 	}
 
-	// 2. Prepare JSONs
-	// 2. Prepare JSONs
-	projectReferencesJSON, _ := json.Marshal(input.References.References)
-	certificationsJSON, _ := json.Marshal(input.References.Documents)
-	settingsJSON, _ := json.Marshal(input.Preferences)
+	return findCompanyByAuthUser(ctx, s.db, authUserID)
+}
 
-	// Convert EmployeeCount string to int (approximate)
-	var empCount int
-	switch input.Basics.EmployeeCount {
-	case "1-10":
-		empCount = 10
-	case "11-50":
-		empCount = 50
-	case "51-200":
-		empCount = 200
-	case "201-500":
-		empCount = 500
-	case "500+":
-		empCount = 1000
-	default:
-		empCount = 1
+// GetCompany lädt das vollständige Profil der eigenen Firma
+func (s *CompanyService) GetCompany(ctx context.Context, authUserID uuid.UUID) (*domain.Company, error) {
+	return findCompanyByAuthUser(ctx, s.db, authUserID)
+}
+
+// CompanyPatch ist der Request-Body für PATCH /companies/me. Es werden nur gesetzte Felder
+// geprüft und übernommen; Zertifikate und Referenzen haben eigene Endpunkte.
+type CompanyPatch struct {
+	Name            *string          `json:"name"`
+	LegalForm       *string          `json:"legal_form"`
+	TaxID           *string          `json:"tax_id"`
+	Industry        *string          `json:"industry"`
+	IndustryTags    *[]string        `json:"industry_tags"`
+	ContactName     *string          `json:"contact_name"`
+	ContactEmail    *string          `json:"contact_email"`
+	ContactPhone    *string          `json:"contact_phone"`
+	AddressStreet   *string          `json:"address_street"`
+	AddressZip      *string          `json:"address_zip"`
+	AddressCity     *string          `json:"address_city"`
+	AddressCountry  *string          `json:"address_country"`
+	ServiceRadiusKM *int             `json:"service_radius_km"`
+	EmployeeCount   *int             `json:"employee_count"`
+	AnnualRevenue   *float64         `json:"annual_revenue"`
+	FoundingYear    *int             `json:"founding_year"`
+	IsAVPQ          *bool            `json:"is_avpq"`
+	ProfileSummary  *string          `json:"profile_summary"`
+	Settings        *json.RawMessage `json:"settings"`
+}
+
+// UpdateCompany übernimmt einzelne Felder des Profils. Embedding und Koordinaten werden nur
+// bei geänderten Profil- bzw. Adressfeldern neu berechnet; ohne Änderung bleibt alles unberührt.
+func (s *CompanyService) UpdateCompany(ctx context.Context, authUserID uuid.UUID, patch CompanyPatch) (*domain.Company, error) {
+	existing, err := findCompanyByAuthUser(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Convert RevenueTier to numeric (approximate)
-	var revenue float64
-	switch input.Basics.RevenueTier {
-	case 0:
-		revenue = 500000 // <1M
-	case 1:
-		revenue = 5000000 // 1-10M
-	case 2:
-		revenue = 15000000 // >10M
-	default:
-		revenue = 0
+	plan, err := planCompanyUpdate(existing, patch)
+	if err != nil {
+		return nil, err
 	}
+	if len(plan.updates) == 0 {
+		return existing, nil
+	}
+	company, updates := plan.company, plan.updates
+
+	ctx = usage.WithAttribution(ctx, usage.Attribution{CompanyID: &existing.ID, Operation: OperationCompanyProfile})
+
+	var vector *pgvector.Vector
+	if plan.embed {
+		if vector, err = s.embedProfile(ctx, &company); err != nil {
+			return nil, err
+		}
+	}
+	if plan.geocode {
+		s.geocode(ctx, &company)
+		updates["latitude"], updates["longitude"] = company.Latitude, company.Longitude
+	}
+	updates["updated_at"] = time.Now()
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Company{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("update company failed: %w", err)
+		}
+		return saveProfileEmbedding(tx, existing.ID, vector)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := markComplianceChecksStale(ctx, s.db, "company_id", existing.ID, StaleReasonProfileUpdated); err != nil {
+		log.Printf("Compliance stale warning: %v", err)
+	}
+
+	return findCompanyByAuthUser(ctx, s.db, authUserID)
+}
+
+// companyScopedModels sind alle Tabellen mit Daten einer Firma (Spalte company_id).
+// Sie werden beim Löschen explizit entfernt, damit die Löschung nicht von den
+// Fremdschlüsseln des Ausgangsschemas abhängt.
+var companyScopedModels = []any{
+	&domain.Match{},
+	&domain.MatchFeedback{},
+	&domain.ComplianceCheck{},
+	&domain.SubmissionChecklistItem{},
+	&domain.BidderQuestion{},
+	&domain.TenderQuestionDeadline{},
+	&domain.Application{},
+	&domain.TenderAttachment{},
+	&domain.LLMUsage{},
+	&domain.CompanyCertificate{},
+	&domain.ProjectReference{},
+	&domain.EmployeeCV{},
+	&domain.CompanyInvitation{},
+	&domain.CompanyMember{},
+}
+
+// DeleteCompany löscht die Firma mit allen zugehörigen Daten (DSGVO Art. 17): Matches, Feedback,
+// Compliance-Checks, Checklisten, Bieterfragen samt Fristen, Bewerbungen samt Konzepten,
+// vorausgefüllte Formulare, Verbrauch, Nachweise, Referenzen, Lebensläufe, Mitglieder,
+// Einladungen und den Chatverlauf aller Mitglieder. Dateien im Storage werden danach entfernt; schlägt das fehl,
+// wird nur geloggt. Die Supabase-Konten der Mitglieder bleiben bestehen.
+func (s *CompanyService) DeleteCompany(ctx context.Context, authUserID uuid.UUID) error {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "certifications")
+	if err != nil {
+		return err
+	}
+
+	// Vorausgefüllte Formulare der Firma liegen im Bucket der Anhänge
+	var formPaths []string
+	if err := s.db.WithContext(ctx).
+		Model(&domain.TenderAttachment{}).
+		Where("company_id = ?", company.ID).
+		Pluck("storage_path", &formPaths).Error; err != nil {
+		return fmt.Errorf("load filled forms failed: %w", err)
+	}
+	var certificatePaths []string
+	if err := s.db.WithContext(ctx).
+		Model(&domain.CompanyCertificate{}).
+		Where("company_id = ? AND coalesce(storage_path, '') <> ''", company.ID).
+		Pluck("storage_path", &certificatePaths).Error; err != nil {
+		return fmt.Errorf("load certificate files failed: %w", err)
+	}
+	var cvPaths []string
+	if err := s.db.WithContext(ctx).
+		Model(&domain.EmployeeCV{}).
+		Where("company_id = ? AND coalesce(storage_path, '') <> ''", company.ID).
+		Pluck("storage_path", &cvPaths).Error; err != nil {
+		return fmt.Errorf("load cv files failed: %w", err)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		members := tx.Model(&domain.CompanyMember{}).Select("auth_user_id").Where("company_id = ?", company.ID)
+		if err := tx.Where("auth_user_id IN (?)", members).Delete(&domain.TenderChatMessage{}).Error; err != nil {
+			return fmt.Errorf("delete chat history failed: %w", err)
+		}
+		for _, model := range companyScopedModels {
+			if err := tx.Where("company_id = ?", company.ID).Delete(model).Error; err != nil {
+				return fmt.Errorf("delete company data failed: %w", err)
+			}
+		}
+		if err := tx.Delete(&domain.Company{}, "id = ?", company.ID).Error; err != nil {
+			return fmt.Errorf("delete company failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.storage != nil {
+		for _, path := range formPaths {
+			if err := s.storage.DeleteFile(tenderAttachmentsBucket, path); err != nil {
+				log.Printf("Storage delete failed for filled form %s: %v", path, err)
+			}
+		}
+		assetPaths := append(companyAssetPaths(company.Certifications), certificatePaths...)
+		for _, path := range append(assetPaths, cvPaths...) {
+			if err := s.storage.DeleteFile(companyAssetsBucket, path); err != nil {
+				log.Printf("Storage delete failed for company asset %s: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// companyFromInput übersetzt die Onboarding-Daten in ein Firmenprofil
+func companyFromInput(authUserID uuid.UUID, input CompanyInput) *domain.Company {
+	projectReferencesJSON, _ := json.Marshal(input.References.References)
+	// Ausgewählte Zertifikate (Strings) und hochgeladene Nachweise (Objekte) gemeinsam ablegen
+	certificationsJSON, _ := json.Marshal(append(append([]any{}, input.References.Certificates...), input.References.Documents...))
+	settingsJSON, _ := json.Marshal(input.Preferences)
 
-	// 3. Create Company
 	company := &domain.Company{
 		ID:                  uuid.New(),
-		AuthUserID:          uuid.MustParse(input.AuthUserID),
-		Name:                input.Basics.CompanyName,
-		LegalForm:           input.Basics.LegalForm,
-		TaxID:               input.Basics.TaxID,
+		AuthUserID:          authUserID,
+		Name:                strings.TrimSpace(input.Basics.CompanyName),
+		LegalForm:           strings.TrimSpace(input.Basics.LegalForm),
+		TaxID:               strings.TrimSpace(input.Basics.TaxID),
+		Industry:            strings.TrimSpace(input.Basics.Industry),
 		IndustryTags:        input.Basics.CPVCodes, // Using CPV codes as industry tags for now
-		ContactName:         input.Basics.ContactName,
-		ContactEmail:        input.Basics.ContactEmail,
-		ContactPhone:        input.Basics.ContactPhone,
-		AddressStreet:       input.Basics.AddressStreet,
-		AddressZip:          input.Basics.AddressZip,
-		AddressCity:         input.Basics.AddressCity,
-		AddressCountry:      input.Basics.AddressCountry,
+		ContactName:         strings.TrimSpace(input.Basics.ContactName),
+		ContactEmail:        strings.TrimSpace(input.Basics.ContactEmail),
+		ContactPhone:        strings.TrimSpace(input.Basics.ContactPhone),
+		AddressStreet:       strings.TrimSpace(input.Basics.AddressStreet),
+		AddressZip:          strings.TrimSpace(input.Basics.AddressZip),
+		AddressCity:         strings.TrimSpace(input.Basics.AddressCity),
+		AddressCountry:      strings.ToUpper(strings.TrimSpace(input.Basics.AddressCountry)),
 		ServiceRadiusKM:     input.Basics.ServiceRadius,
-		EmployeeCount:       empCount,
-		AnnualRevenue:       revenue,
+		EmployeeCount:       employeeCountFromRange(input.Basics.EmployeeCount),
+		AnnualRevenue:       revenueFromTier(input.Basics.RevenueTier),
 		FoundingYear:        input.Basics.FoundingYear,
-		ProfileSummary:      input.Basics.ProfileSummary,
-		ProfileEmbedding:    pgvector.NewVector(vector32),
+		IsAVPQ:              input.Basics.IsAvpq,
+		ProfileSummary:      strings.TrimSpace(input.Basics.ProfileSummary),
 		Certifications:      certificationsJSON,
 		ProjectReferences:   projectReferencesJSON,
 		Settings:            settingsJSON,
 		OnboardingCompleted: true,
+		UpdatedAt:           time.Now(),
 	}
 
 	if company.AddressCountry == "" {
@@ -166,54 +409,591 @@ func (s *CompanyService) CreateCompany(ctx context.Context, input CompanyInput)
 	if company.ServiceRadiusKM == 0 {
 		company.ServiceRadiusKM = 100
 	}
+	return company
+}
+
+// employeeCountFromRange wandelt die Spanne aus dem Frontend in eine Obergrenze (approximate)
+func employeeCountFromRange(employeeRange string) int {
+	switch employeeRange {
+	case "1-10":
+		return 10
+	case "11-50":
+		return 50
+	case "51-200":
+		return 200
+	case "201-500":
+		return 500
+	case "500+":
+		return 1000
+	default:
+		return 1
+	}
+}
+
+// revenueFromTier wandelt die Umsatzklasse (0-2) in einen Näherungswert
+func revenueFromTier(tier int) float64 {
+	switch tier {
+	case 0:
+		return 500000 // <1M
+	case 1:
+		return 5000000 // 1-10M
+	case 2:
+		return 15000000 // >10M
+	default:
+		return 0
+	}
+}
+
+// companyProfileColumns werden beim Überschreiben aus dem Onboarding gesetzt; owner, Tarif,
+// Embedding und Verifizierung bleiben unberührt
+var companyProfileColumns = []string{
+	"name", "legal_form", "tax_id", "industry", "industry_tags",
+	"contact_name", "contact_email", "contact_phone",
+	"address_street", "address_zip", "address_city", "address_country", "service_radius_km",
+	"latitude", "longitude", "employee_count", "annual_revenue", "founding_year", "is_avpq",
+	"profile_summary", "certifications", "project_references", "settings",
+	"onboarding_completed", "updated_at",
+}
+
+// companyPatchColumns ordnet die Felder des PATCH-Bodys den Spalten zu
+var companyPatchColumns = map[string]string{
+	"name":              "name",
+	"legal_form":        "legal_form",
+	"tax_id":            "tax_id",
+	"industry":          "industry",
+	"industry_tags":     "industry_tags",
+	"contact_name":      "contact_name",
+	"contact_email":     "contact_email",
+	"contact_phone":     "contact_phone",
+	"address_street":    "address_street",
+	"address_zip":       "address_zip",
+	"address_city":      "address_city",
+	"address_country":   "address_country",
+	"service_radius_km": "service_radius_km",
+	"employee_count":    "employee_count",
+	"annual_revenue":    "annual_revenue",
+	"founding_year":     "founding_year",
+	"is_avpq":           "is_avpq",
+	"profile_summary":   "profile_summary",
+	"settings":          "settings",
+}
+
+// companyUpdate ist die Wirkung eines PATCH auf das gespeicherte Profil
+type companyUpdate struct {
+	company domain.Company
+	updates map[string]any // tatsächlich geänderte Spalten
+	embed   bool           // Profil-Embedding neu berechnen
+	geocode bool           // Koordinaten neu bestimmen
+}
+
+// planCompanyUpdate wendet den PATCH auf eine Kopie an, prüft die gesetzten Felder und
+// ermittelt geänderte Spalten sowie nötige Neuberechnungen. Ohne Änderung bleibt updates leer.
+func planCompanyUpdate(existing *domain.Company, patch CompanyPatch) (*companyUpdate, error) {
+	plan := &companyUpdate{company: *existing, updates: map[string]any{}}
+	fields := applyCompanyPatch(&plan.company, patch)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: no fields to update", ErrInvalidCompany)
+	}
+	if err := validateCompany(&plan.company, fields); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		column := companyPatchColumns[field]
+		if column == "" {
+			continue
+		}
+		if value := companyColumnValue(&plan.company, column); !reflect.DeepEqual(value, companyColumnValue(existing, column)) {
+			plan.updates[column] = value
+		}
+	}
+	if len(plan.updates) > 0 {
+		plan.embed = embeddingOutdated(existing, &plan.company)
+		plan.geocode = geocodeOutdated(existing, &plan.company)
+	}
+	return plan, nil
+}
+
+// applyCompanyPatch übernimmt die gesetzten Felder und liefert deren Namen
+func applyCompanyPatch(company *domain.Company, patch CompanyPatch) []string {
+	var fields []string
+	setString := func(name string, value *string, target *string) {
+		if value != nil {
+			*target = strings.TrimSpace(*value)
+			fields = append(fields, name)
+		}
+	}
+	setString("name", patch.Name, &company.Name)
+	setString("legal_form", patch.LegalForm, &company.LegalForm)
+	setString("tax_id", patch.TaxID, &company.TaxID)
+	setString("industry", patch.Industry, &company.Industry)
+	setString("contact_name", patch.ContactName, &company.ContactName)
+	setString("contact_email", patch.ContactEmail, &company.ContactEmail)
+	setString("contact_phone", patch.ContactPhone, &company.ContactPhone)
+	setString("address_street", patch.AddressStreet, &company.AddressStreet)
+	setString("address_zip", patch.AddressZip, &company.AddressZip)
+	setString("address_city", patch.AddressCity, &company.AddressCity)
+	setString("profile_summary", patch.ProfileSummary, &company.ProfileSummary)
 
-	// 4. Geocode address to coordinates
-	lat, lng, err := s.geocoder.Geocode(ctx, input.Basics.AddressZip, input.Basics.AddressCity, company.AddressCountry)
+	if patch.IndustryTags != nil {
+		tags := make(pq.StringArray, 0, len(*patch.IndustryTags))
+		for _, tag := range *patch.IndustryTags {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		company.IndustryTags = tags
+		fields = append(fields, "industry_tags")
+	}
+	if patch.AddressCountry != nil {
+		company.AddressCountry = strings.ToUpper(strings.TrimSpace(*patch.AddressCountry))
+		fields = append(fields, "address_country")
+	}
+	if patch.ServiceRadiusKM != nil {
+		company.ServiceRadiusKM = *patch.ServiceRadiusKM
+		fields = append(fields, "service_radius_km")
+	}
+	if patch.EmployeeCount != nil {
+		company.EmployeeCount = *patch.EmployeeCount
+		fields = append(fields, "employee_count")
+	}
+	if patch.AnnualRevenue != nil {
+		company.AnnualRevenue = *patch.AnnualRevenue
+		fields = append(fields, "annual_revenue")
+	}
+	if patch.FoundingYear != nil {
+		company.FoundingYear = *patch.FoundingYear
+		fields = append(fields, "founding_year")
+	}
+	if patch.IsAVPQ != nil {
+		company.IsAVPQ = *patch.IsAVPQ
+		fields = append(fields, "is_avpq")
+	}
+	if patch.Settings != nil {
+		company.Settings = *patch.Settings
+		fields = append(fields, "settings")
+	}
+	return fields
+}
+
+// companyColumnValue liefert den Wert einer Profilspalte für Update und Vergleich
+func companyColumnValue(company *domain.Company, column string) any {
+	switch column {
+	case "name":
+		return company.Name
+	case "legal_form":
+		return company.LegalForm
+	case "tax_id":
+		return company.TaxID
+	case "industry":
+		return company.Industry
+	case "industry_tags":
+		return company.IndustryTags
+	case "contact_name":
+		return company.ContactName
+	case "contact_email":
+		return company.ContactEmail
+	case "contact_phone":
+		return company.ContactPhone
+	case "address_street":
+		return company.AddressStreet
+	case "address_zip":
+		return company.AddressZip
+	case "address_city":
+		return company.AddressCity
+	case "address_country":
+		return company.AddressCountry
+	case "service_radius_km":
+		return company.ServiceRadiusKM
+	case "employee_count":
+		return company.EmployeeCount
+	case "annual_revenue":
+		return company.AnnualRevenue
+	case "founding_year":
+		return company.FoundingYear
+	case "is_avpq":
+		return company.IsAVPQ
+	case "profile_summary":
+		return company.ProfileSummary
+	case "settings":
+		return company.Settings
+	}
+	return nil
+}
+
+var (
+	cpvCodePattern     = regexp.MustCompile(`^\d{8}(-\d)?$`)
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	zipPatterns        = map[string]*regexp.Regexp{
+		"DE": regexp.MustCompile(`^\d{5}$`),
+		"AT": regexp.MustCompile(`^\d{4}$`),
+		"CH": regexp.MustCompile(`^\d{4}$`),
+	}
+)
+
+// companyFieldChecks prüfen je Feld die Regeln des Onboardings; Rückgabe leer = gültig
+var companyFieldChecks = map[string]func(c *domain.Company) string{
+	"name": func(c *domain.Company) string {
+		return checkLength(c.Name, 2, 200)
+	},
+	"legal_form": func(c *domain.Company) string {
+		return checkLength(c.LegalForm, 0, 50)
+	},
+	"tax_id": func(c *domain.Company) string {
+		return checkLength(c.TaxID, 0, 30)
+	},
+	"industry": func(c *domain.Company) string {
+		return checkLength(c.Industry, 0, 100)
+	},
+	"industry_tags": func(c *domain.Company) string {
+		if len(c.IndustryTags) == 0 {
+			return "at least one CPV code is required"
+		}
+		for _, code := range c.IndustryTags {
+			if !cpvCodePattern.MatchString(code) {
+				return fmt.Sprintf("%q is not a CPV code (e.g. 45000000-7)", code)
+			}
+		}
+		return ""
+	},
+	"contact_name": func(c *domain.Company) string {
+		return checkLength(c.ContactName, 2, 200)
+	},
+	"contact_email": func(c *domain.Company) string {
+		if addr, err := mail.ParseAddress(c.ContactEmail); err != nil || addr.Address != c.ContactEmail {
+			return "must be a valid e-mail address"
+		}
+		return ""
+	},
+	"contact_phone": func(c *domain.Company) string {
+		return checkLength(c.ContactPhone, 0, 50)
+	},
+	"address_street": func(c *domain.Company) string {
+		return checkLength(c.AddressStreet, 0, 200)
+	},
+	"address_zip": func(c *domain.Company) string {
+		if pattern, ok := zipPatterns[c.AddressCountry]; ok && !pattern.MatchString(c.AddressZip) {
+			return fmt.Sprintf("is not a valid postal code for %s", c.AddressCountry)
+		}
+		return checkLength(c.AddressZip, 3, 10)
+	},
+	"address_city": func(c *domain.Company) string {
+		return checkLength(c.AddressCity, 2, 100)
+	},
+	"address_country": func(c *domain.Company) string {
+		if !countryCodePattern.MatchString(c.AddressCountry) {
+			return "must be an ISO 3166 alpha-2 code (e.g. DE)"
+		}
+		return ""
+	},
+	"service_radius_km": func(c *domain.Company) string {
+		if c.ServiceRadiusKM < 0 || c.ServiceRadiusKM > 1000 {
+			return "must be between 0 and 1000"
+		}
+		return ""
+	},
+	"employee_count": func(c *domain.Company) string {
+		if c.EmployeeCount < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"annual_revenue": func(c *domain.Company) string {
+		// numeric(12,2)
+		if c.AnnualRevenue < 0 || c.AnnualRevenue >= 1e10 {
+			return "must be between 0 and 9999999999.99"
+		}
+		return ""
+	},
+	"founding_year": func(c *domain.Company) string {
+		if c.FoundingYear != 0 && (c.FoundingYear < 1800 || c.FoundingYear > time.Now().Year()+1) {
+			return fmt.Sprintf("must be between 1800 and %d", time.Now().Year()+1)
+		}
+		return ""
+	},
+	"profile_summary": func(c *domain.Company) string {
+		return checkLength(c.ProfileSummary, 0, 5000)
+	},
+	"settings": func(c *domain.Company) string {
+		var settings map[string]any
+		if err := json.Unmarshal(c.Settings, &settings); err != nil || settings == nil {
+			return "must be a JSON object"
+		}
+		return ""
+	},
+}
+
+// validateCompany prüft die angegebenen Felder (nil = alle) und meldet alle Verstöße gemeinsam
+func validateCompany(company *domain.Company, fields []string) error {
+	if fields == nil {
+		for field := range companyFieldChecks {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var problems []string
+	for _, field := range fields {
+		if check := companyFieldChecks[field]; check != nil {
+			if problem := check(company); problem != "" {
+				problems = append(problems, field+": "+problem)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidCompany, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func checkLength(value string, min, max int) string {
+	n := utf8.RuneCountInString(value)
+	switch {
+	case n == 0 && min > 0:
+		return "is required"
+	case n < min:
+		return fmt.Sprintf("must have at least %d characters", min)
+	case n > max:
+		return fmt.Sprintf("must not exceed %d characters", max)
+	}
+	return ""
+}
+
+// profileEmbeddingText ist die Grundlage des Profil-Embeddings fürs Matching
+func profileEmbeddingText(company *domain.Company) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s",
+		company.Name,
+		company.Industry,
+		strings.Join(company.IndustryTags, " "),
+		company.ProfileSummary,
+	)
+}
+
+// embeddingOutdated: Profiltext geändert oder noch kein Embedding gespeichert
+func embeddingOutdated(existing, updated *domain.Company) bool {
+	return len(existing.ProfileEmbedding.Slice()) == 0 ||
+		profileEmbeddingText(existing) != profileEmbeddingText(updated)
+}
+
+// geocodeOutdated: Adresse geändert oder noch keine Koordinaten vorhanden
+func geocodeOutdated(existing, updated *domain.Company) bool {
+	if existing.Latitude == 0 && existing.Longitude == 0 {
+		return true
+	}
+	return existing.AddressZip != updated.AddressZip ||
+		existing.AddressCity != updated.AddressCity ||
+		existing.AddressCountry != updated.AddressCountry
+}
+
+func (s *CompanyService) embedProfile(ctx context.Context, company *domain.Company) (*pgvector.Vector, error) {
+	vectors64, err := s.embedder.EmbedStrings(ctx, []string{profileEmbeddingText(company)})
 	if err != nil {
-		// Log but don't fail - geocoding is optional
-		fmt.Printf("Geocoding warning: %v\n", err)
-	} else {
-		company.Latitude = lat
-		company.Longitude = lng
-	}
-
-	// Save to DB
-	if err := s.db.Create(company).Error; err != nil {
-		// Check for unique constraint violation on auth_user_id
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			// Update existing
-			var existing domain.Company
-			if err := s.db.Where("auth_user_id = ?", input.AuthUserID).First(&existing).Error; err == nil {
-				existing.Name = company.Name
-				existing.LegalForm = company.LegalForm
-				existing.TaxID = company.TaxID
-				existing.IndustryTags = company.IndustryTags
-				existing.ContactName = company.ContactName
-				existing.ContactEmail = company.ContactEmail
-				existing.ContactPhone = company.ContactPhone
-				existing.AddressStreet = company.AddressStreet
-				existing.AddressZip = company.AddressZip
-				existing.AddressCity = company.AddressCity
-				existing.AddressCountry = company.AddressCountry
-				existing.ServiceRadiusKM = company.ServiceRadiusKM
-				existing.EmployeeCount = company.EmployeeCount
-				existing.AnnualRevenue = company.AnnualRevenue
-				existing.FoundingYear = company.FoundingYear
-				existing.ProfileSummary = company.ProfileSummary
-				existing.ProfileEmbedding = company.ProfileEmbedding
-				existing.Certifications = company.Certifications
-				existing.ProjectReferences = company.ProjectReferences
-				existing.Settings = company.Settings
-				existing.OnboardingCompleted = true
-
-				if err := s.db.Save(&existing).Error; err != nil {
-					return nil, fmt.Errorf("update company failed: %w", err)
-				}
-				return &existing, nil
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(vectors64) == 0 {
+		return nil, fmt.Errorf("embedding failed: no embedding returned")
+	}
+
+	vector32 := make([]float32, len(vectors64[0]))
+	for i, v := range vectors64[0] {
+		vector32[i] = float32(v)
+	}
+	vector := pgvector.NewVector(vector32)
+	return &vector, nil
+}
+
+// geocode setzt die Koordinaten der Adresse; Fehler werden nur geloggt (Geocoding ist optional)
+func (s *CompanyService) geocode(ctx context.Context, company *domain.Company) {
+	lat, lng, err := s.geocoder.Geocode(ctx, company.AddressZip, company.AddressCity, company.AddressCountry)
+	if err != nil {
+		log.Printf("Geocoding warning: %v", err)
+		lat, lng = 0, 0
+	}
+	company.Latitude = lat
+	company.Longitude = lng
+}
+
+// saveProfileEmbedding schreibt ein neu berechnetes Embedding (nil = unverändert). Die Spalte ist
+// im Modell nur per Update beschreibbar.
+func saveProfileEmbedding(tx *gorm.DB, companyID uuid.UUID, vector *pgvector.Vector) error {
+	if vector == nil {
+		return nil
+	}
+	if err := tx.Model(&domain.Company{}).
+		Where("id = ?", companyID).
+		Update("profile_embedding", *vector).Error; err != nil {
+		return fmt.Errorf("save profile embedding failed: %w", err)
+	}
+	return nil
+}
+
+// syncOnboardingCertificates legt für im Onboarding ausgewählte Zertifikate (Strings) strukturierte
+// Nachweise ohne Gültigkeitsangaben an, sofern die Firma noch keinen gleicher Art hat
+func syncOnboardingCertificates(tx *gorm.DB, companyID, authUserID uuid.UUID, selected []any) error {
+	var existing []domain.CompanyCertificate
+	if err := tx.Where("company_id = ?", companyID).Find(&existing).Error; err != nil {
+		return fmt.Errorf("load certificates failed: %w", err)
+	}
+	known := make(map[string]bool)
+	for i := range existing {
+		known[strings.ToLower(existing[i].Name)] = true
+		if requirement := certificateRequirement(&existing[i]); requirement != "" {
+			known[requirement] = true
+		}
+	}
+
+	now := time.Now()
+	for _, item := range selected {
+		name, _ := item.(string)
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		cert := domain.CompanyCertificate{
+			ID:        uuid.New(),
+			CompanyID: companyID,
+			Type:      onboardingCertificateType(name),
+			Name:      name,
+			CreatedBy: authUserID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		key := certificateRequirement(&cert)
+		if key == "" {
+			key = strings.ToLower(name)
+		}
+		if known[key] || known[strings.ToLower(name)] {
+			continue
+		}
+		if err := tx.Create(&cert).Error; err != nil {
+			return fmt.Errorf("create certificate failed: %w", err)
+		}
+		known[key] = true
+	}
+	return nil
+}
+
+// syncOnboardingReferences legt für Referenzen aus dem Onboarding ({name, client, year, budget,
+// keywords}) strukturierte Referenzprojekte an, sofern noch keines mit gleichem Titel existiert.
+// Das Jahr wird zum Projektende (31.12.), Stichworte zur Beschreibung; das Embedding berechnet
+// die Referenzauswahl bei Bedarf.
+func syncOnboardingReferences(tx *gorm.DB, companyID, authUserID uuid.UUID, selected []any) error {
+	var titles []string
+	if err := tx.Model(&domain.ProjectReference{}).
+		Where("company_id = ?", companyID).
+		Pluck("lower(title)", &titles).Error; err != nil {
+		return fmt.Errorf("load project references failed: %w", err)
+	}
+	known := make(map[string]bool, len(titles))
+	for _, title := range titles {
+		known[title] = true
+	}
+
+	now := time.Now()
+	for _, item := range selected {
+		raw, err := json.Marshal(item)
+		if err != nil {
+			continue
+		}
+		var record projectReferenceRecord
+		if json.Unmarshal(raw, &record) != nil {
+			continue
+		}
+		title := strings.TrimSpace(record.Name)
+		if title == "" {
+			title = strings.TrimSpace(record.Client)
+		}
+		if title == "" || known[strings.ToLower(title)] {
+			continue
+		}
+
+		ref := domain.ProjectReference{
+			ID:          uuid.New(),
+			CompanyID:   companyID,
+			Title:       title,
+			Description: strings.Join(record.Keywords, ", "),
+			Client:      strings.TrimSpace(record.Client),
+			CPVCodes:    pq.StringArray{},
+			CreatedBy:   authUserID,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if year := int(jsonNumber(record.Year)); year >= 1900 && year <= now.Year()+10 {
+			end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+			ref.EndDate = &end
+		}
+		if budget := jsonNumber(record.Budget); budget > 0 {
+			ref.ContractValue = &budget
+		}
+		if err := tx.Create(&ref).Error; err != nil {
+			return fmt.Errorf("create project reference failed: %w", err)
+		}
+		known[strings.ToLower(title)] = true
+	}
+	return nil
+}
+
+// onboardingCertificateType ordnet einen Zertifikatsnamen aus dem Onboarding einer Nachweisart zu
+func onboardingCertificateType(name string) string {
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "pq-vob") {
+		return "pq_vob"
+	}
+	for _, pattern := range certificatePatterns {
+		if !pattern.pattern.MatchString(name) {
+			continue
+		}
+		for key, certType := range certificateTypes {
+			if certType.requirement == pattern.name && key != "pq_vob" {
+				return key
 			}
 		}
-		return nil, fmt.Errorf("create company failed: %w", err)
+	}
+	return "other"
+}
+
+// companyAssetPaths liefert die Storage-Pfade der beim Onboarding hochgeladenen Nachweise
+func companyAssetPaths(certifications json.RawMessage) []string {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(certifications, &entries); err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		var document struct {
+			Path string `json:"path"`
+		}
+		if json.Unmarshal(entry, &document) == nil && document.Path != "" {
+			paths = append(paths, document.Path)
+		}
+	}
+	return paths
+}
+
+// findCompanyByAuthUser lädt die Firma, der der eingeloggte Nutzer als Mitglied angehört.
+// Ohne Spaltenangabe werden alle Felder geladen.
+func findCompanyByAuthUser(ctx context.Context, db *gorm.DB, authUserID uuid.UUID, columns ...string) (*domain.Company, error) {
+	membership := db.Model(&domain.CompanyMember{}).Select("company_id").Where("auth_user_id = ?", authUserID)
+	return loadCompany(db.WithContext(ctx).Where("id IN (?)", membership), columns)
+}
+
+// findCompanyByID lädt eine Firma ohne Prüfung der Mitgliedschaft
+func findCompanyByID(ctx context.Context, db *gorm.DB, companyID uuid.UUID, columns ...string) (*domain.Company, error) {
+	return loadCompany(db.WithContext(ctx).Where("id = ?", companyID), columns)
+}
+
+func loadCompany(query *gorm.DB, columns []string) (*domain.Company, error) {
+	if len(columns) > 0 {
+		query = query.Select(columns)
+	}
+
+	var company domain.Company
+	if err := query.First(&company).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCompanyNotFound
+		}
+		return nil, fmt.Errorf("load company failed: %w", err)
 	}
 
-	return company, nil
+	return &company, nil
 }
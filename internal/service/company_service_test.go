@@ -0,0 +1,270 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+// testCompany ist ein vollständiges, gültiges Profil mit Embedding und Koordinaten
+func testCompany() domain.Company {
+	return domain.Company{
+		Name:             "Elektro Huber GmbH",
+		LegalForm:        "GmbH",
+		Industry:         "Elektroinstallation",
+		IndustryTags:     pq.StringArray{"45310000-3"},
+		ContactName:      "Anna Huber",
+		ContactEmail:     "info@elektro-huber.de",
+		ContactPhone:     "08031 12345",
+		AddressStreet:    "Innstraße 1",
+		AddressZip:       "83022",
+		AddressCity:      "Rosenheim",
+		AddressCountry:   "DE",
+		ServiceRadiusKM:  80,
+		EmployeeCount:    37,
+		FoundingYear:     1998,
+		ProfileSummary:   "Elektroinstallation für öffentliche Gebäude",
+		Settings:         json.RawMessage(`{"notifications": true}`),
+		Latitude:         47.8561,
+		Longitude:        12.1289,
+		ProfileEmbedding: pgvector.NewVector([]float32{0.1, 0.2, 0.3}),
+	}
+}
+
+func TestEmbeddingOutdated(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *domain.Company)
+		want   bool
+	}{
+		{"unchanged", func(c *domain.Company) {}, false},
+		{"contact phone", func(c *domain.Company) { c.ContactPhone = "0171 555" }, false},
+		{"address", func(c *domain.Company) { c.AddressCity = "Kolbermoor" }, false},
+		{"name", func(c *domain.Company) { c.Name = "Huber Elektrotechnik GmbH" }, true},
+		{"industry tags", func(c *domain.Company) { c.IndustryTags = pq.StringArray{"45310000-3", "45315000-8"} }, true},
+		{"profile summary", func(c *domain.Company) { c.ProfileSummary = "Photovoltaik" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing, updated := testCompany(), testCompany()
+			tt.change(&updated)
+			if got := embeddingOutdated(&existing, &updated); got != tt.want {
+				t.Errorf("embeddingOutdated = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no embedding stored", func(t *testing.T) {
+		existing := testCompany()
+		existing.ProfileEmbedding = pgvector.Vector{}
+		if !embeddingOutdated(&existing, &existing) {
+			t.Error("missing embedding must be computed")
+		}
+	})
+}
+
+func TestGeocodeOutdated(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *domain.Company)
+		want   bool
+	}{
+		{"unchanged", func(c *domain.Company) {}, false},
+		{"street only", func(c *domain.Company) { c.AddressStreet = "Innstraße 2" }, false},
+		{"profile text", func(c *domain.Company) { c.ProfileSummary = "Photovoltaik" }, false},
+		{"zip", func(c *domain.Company) { c.AddressZip = "83059" }, true},
+		{"city", func(c *domain.Company) { c.AddressCity = "Kolbermoor" }, true},
+		{"country", func(c *domain.Company) { c.AddressCountry = "AT" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing, updated := testCompany(), testCompany()
+			tt.change(&updated)
+			if got := geocodeOutdated(&existing, &updated); got != tt.want {
+				t.Errorf("geocodeOutdated = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no coordinates stored", func(t *testing.T) {
+		existing := testCompany()
+		existing.Latitude, existing.Longitude = 0, 0
+		if !geocodeOutdated(&existing, &existing) {
+			t.Error("missing coordinates must be geocoded")
+		}
+	})
+}
+
+func TestApplyCompanyPatch(t *testing.T) {
+	phone := "  0171 555  "
+	country := " at "
+	tags := []string{" 45310000-3 ", "", "45315000-8"}
+	avpq := false
+	radius := 0
+
+	company := testCompany()
+	company.IsAVPQ = true
+	fields := applyCompanyPatch(&company, CompanyPatch{
+		ContactPhone:    &phone,
+		AddressCountry:  &country,
+		IndustryTags:    &tags,
+		IsAVPQ:          &avpq,
+		ServiceRadiusKM: &radius,
+	})
+
+	want := "contact_phone, industry_tags, address_country, service_radius_km, is_avpq"
+	if got := strings.Join(fields, ", "); got != want {
+		t.Errorf("fields = %q, want %q", got, want)
+	}
+	if company.ContactPhone != "0171 555" || company.AddressCountry != "AT" {
+		t.Errorf("strings not normalized: %q, %q", company.ContactPhone, company.AddressCountry)
+	}
+	if strings.Join(company.IndustryTags, ",") != "45310000-3,45315000-8" {
+		t.Errorf("industry tags = %v", company.IndustryTags)
+	}
+	// Nullwerte sind gesetzte Felder, keine fehlenden
+	if company.IsAVPQ || company.ServiceRadiusKM != 0 {
+		t.Errorf("zero values not applied: avpq %v, radius %d", company.IsAVPQ, company.ServiceRadiusKM)
+	}
+	if company.Name != "Elektro Huber GmbH" || company.AddressCity != "Rosenheim" {
+		t.Error("unset fields must stay unchanged")
+	}
+
+	if fields := applyCompanyPatch(&company, CompanyPatch{}); len(fields) != 0 {
+		t.Errorf("empty patch reported fields %v", fields)
+	}
+}
+
+func TestValidateCompany(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(c *domain.Company)
+		fields   []string
+		problems []string
+	}{
+		{"valid profile", func(c *domain.Company) {}, nil, nil},
+		{"empty name", func(c *domain.Company) { c.Name = "" }, nil, []string{"name: is required"}},
+		{"invalid e-mail", func(c *domain.Company) { c.ContactEmail = "Anna <info@huber.de>" }, nil, []string{"contact_email"}},
+		{"no CPV code", func(c *domain.Company) { c.IndustryTags = pq.StringArray{"Elektro"} }, nil, []string{"industry_tags"}},
+		{"zip of wrong country", func(c *domain.Company) { c.AddressCountry = "AT" }, nil, []string{"address_zip: is not a valid postal code for AT"}},
+		{"settings no object", func(c *domain.Company) { c.Settings = json.RawMessage(`[]`) }, nil, []string{"settings"}},
+		{
+			name:     "all problems reported",
+			change:   func(c *domain.Company) { c.Name, c.ServiceRadiusKM, c.EmployeeCount = "X", 2000, -1 },
+			problems: []string{"employee_count", "name", "service_radius_km"},
+		},
+		{
+			// PATCH prüft nur die gesetzten Felder
+			name:   "only given fields",
+			change: func(c *domain.Company) { c.Name, c.ContactPhone = "", "08031 999" },
+			fields: []string{"contact_phone"},
+		},
+		{
+			name:     "unknown fields ignored",
+			change:   func(c *domain.Company) { c.FoundingYear = 1500 },
+			fields:   []string{"founding_year", "owner_id"},
+			problems: []string{"founding_year"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			company := testCompany()
+			tt.change(&company)
+			err := validateCompany(&company, tt.fields)
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidCompany) {
+				t.Fatalf("expected ErrInvalidCompany, got %v", err)
+			}
+			if got := strings.Count(err.Error(), ";") + 1; got != len(tt.problems) {
+				t.Errorf("expected %d problems, got %q", len(tt.problems), err)
+			}
+			for _, problem := range tt.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("expected %q in %q", problem, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanCompanyUpdate(t *testing.T) {
+	str := func(v string) *string { return &v }
+
+	tests := []struct {
+		name        string
+		patch       CompanyPatch
+		wantColumns string
+		wantEmbed   bool
+		wantGeocode bool
+	}{
+		{
+			name:        "contact phone only",
+			patch:       CompanyPatch{ContactPhone: str("0171 555")},
+			wantColumns: "contact_phone",
+		},
+		{
+			name:  "same values",
+			patch: CompanyPatch{ContactPhone: str(" 08031 12345 "), AddressCity: str("Rosenheim")},
+		},
+		{
+			name:        "profile summary",
+			patch:       CompanyPatch{ProfileSummary: str("Photovoltaik und Elektroinstallation")},
+			wantColumns: "profile_summary",
+			wantEmbed:   true,
+		},
+		{
+			name:        "new city",
+			patch:       CompanyPatch{AddressZip: str("83059"), AddressCity: str("Kolbermoor")},
+			wantColumns: "address_city, address_zip",
+			wantGeocode: true,
+		},
+		{
+			name:        "street only",
+			patch:       CompanyPatch{AddressStreet: str("Innstraße 2")},
+			wantColumns: "address_street",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := testCompany()
+			plan, err := planCompanyUpdate(&existing, tt.patch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var columns []string
+			for column := range plan.updates {
+				columns = append(columns, column)
+			}
+			sort.Strings(columns)
+			if got := strings.Join(columns, ", "); got != tt.wantColumns {
+				t.Errorf("updated columns = %q, want %q", got, tt.wantColumns)
+			}
+			if plan.embed != tt.wantEmbed || plan.geocode != tt.wantGeocode {
+				t.Errorf("embed %v, geocode %v; want %v, %v", plan.embed, plan.geocode, tt.wantEmbed, tt.wantGeocode)
+			}
+			if existing.ContactPhone != "08031 12345" || existing.AddressCity != "Rosenheim" {
+				t.Error("existing company must not be modified")
+			}
+		})
+	}
+
+	existing := testCompany()
+	if _, err := planCompanyUpdate(&existing, CompanyPatch{}); !errors.Is(err, ErrInvalidCompany) {
+		t.Errorf("empty patch: expected ErrInvalidCompany, got %v", err)
+	}
+	if _, err := planCompanyUpdate(&existing, CompanyPatch{ContactEmail: str("keine-adresse")}); !errors.Is(err, ErrInvalidCompany) {
+		t.Errorf("invalid e-mail: expected ErrInvalidCompany, got %v", err)
+	}
+}
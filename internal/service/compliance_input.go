@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var ErrNoTenderDocuments = errors.New("tender has no text to assess")
+
+// Eingabebudget des Compliance-Agents in Zeichen: ~4 Zeichen pro Token bei 128k Kontext,
+// abzüglich System-Prompt, Firmenprofil und Platz für die Antwort.
+const (
+	complianceInputBudgetChars = 320_000
+	complianceChunkChars       = 2_000
+)
+
+// Map-Reduce für Unterlagen über dem Einzel-Prompt-Budget: Abschnitte von ~60k Zeichen,
+// höchstens complianceMaxSections Stück (darüber greift wieder die BM25-Auswahl).
+const (
+	complianceSectionChars = 60_000
+	complianceMaxSections  = 40
+)
+
+// complianceRetrievalQuery beschreibt, wonach in überlangen Unterlagen gesucht wird
+const complianceRetrievalQuery = `Eignung Eignungskriterien Eignungsnachweise Mindestanforderungen Mindestanforderung mindestens
+Nachweis Nachweise Erklärung Eigenerklärung Eigenerklärungen Ausschluss Ausschlussgründe Ausschlusskriterien GWB
+Umsatz Jahresumsatz Mindestumsatz Gesamtumsatz Referenz Referenzen Referenzprojekte vergleichbare Leistungen
+Zertifikat Zertifikate Zertifizierung ISO DIN Präqualifikation PQ AVPQ Beschäftigte Mitarbeiter Fachkräfte
+Berufshaftpflicht Haftpflichtversicherung Versicherung Handelsregister Handelsregisterauszug Gewerbeanmeldung
+Unbedenklichkeitsbescheinigung Sozialversicherung Steuern Tariftreue Mindestlohn Leistungsfähigkeit Fachkunde
+technische Anforderungen Leistungsbeschreibung vorzulegen beizufügen einzureichen zwingend K.O.`
+
+// complianceInput ist der zusammengestellte Text für den Agent samt Protokoll der Dokumente.
+// Bei überlangen Unterlagen ist Text leer und Sections enthält die Abschnitte für den Map-Reduce-Graphen.
+type complianceInput struct {
+	Text      string
+	Sections  []agent.DocumentSection
+	Documents []domain.ComplianceInputDocument
+	// Hash über alle Dokumenttexte (Cache-Schlüssel)
+	Hash string
+
+	sectionChunks [][]documentChunk
+	recordIndex   []int
+}
+
+// buildComplianceInput stellt die Agent-Eingabe aus Beschreibung und allen verarbeiteten
+// Anhängen zusammen. Passt alles ins Budget, geht der Volltext in einen Prompt; sonst werden
+// die Unterlagen in Abschnitte für Map-Reduce zerlegt. Erst wenn auch das Abschnittslimit
+// überschritten ist, werden die relevantesten Abschnitte per BM25 ausgewählt.
+// Jedes Dokument wird mit Status protokolliert. Wird auch für die Abgabe-Checkliste genutzt.
+func buildComplianceInput(ctx context.Context, db *gorm.DB, tender *domain.Tender) (*complianceInput, error) {
+	docs, records, err := loadTenderDocuments(ctx, db, tender)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := chunkDocuments(docs, complianceChunkChars)
+
+	// Alle Abschnitte passen ins Budget -> Volltext
+	selected := make([]bool, len(chunks))
+	used := 0
+	for i, chunk := range chunks {
+		used += len(chunk.Text) + chunkMarkerOverhead(chunk)
+		selected[i] = true
+	}
+
+	mapReduce := used > complianceInputBudgetChars
+	if budget := complianceSectionChars * complianceMaxSections; used > budget {
+		// Retrieval: relevanteste Abschnitte zuerst, bis das Budget erschöpft ist
+		selected = make([]bool, len(chunks))
+		used = 0
+		for _, idx := range rankChunksBM25(complianceRetrievalQuery, chunks) {
+			cost := len(chunks[idx].Text) + chunkMarkerOverhead(chunks[idx])
+			if used+cost > budget {
+				continue
+			}
+			selected[idx] = true
+			used += cost
+		}
+	}
+
+	// Dokument-Index -> Protokolleintrag
+	recordIndex := make([]int, 0, len(docs))
+	for i, r := range records {
+		if r.Status != domain.InputDocumentSkipped {
+			recordIndex = append(recordIndex, i)
+		}
+	}
+
+	var picked []documentChunk
+	chunkCount := make([]int, len(docs))
+	pickedCount := make([]int, len(docs))
+	for i, chunk := range chunks {
+		chunkCount[chunk.DocIndex]++
+		if !selected[i] {
+			continue
+		}
+		picked = append(picked, chunk)
+		pickedCount[chunk.DocIndex]++
+		records[recordIndex[chunk.DocIndex]].IncludedChars += len(chunk.Text)
+	}
+
+	for docIndex := range docs {
+		record := &records[recordIndex[docIndex]]
+		switch {
+		case pickedCount[docIndex] == chunkCount[docIndex]:
+			record.Status = domain.InputDocumentIncluded
+		case pickedCount[docIndex] == 0:
+			record.Status = domain.InputDocumentSkipped
+			record.Reason = "Abschnittslimit: keine relevanten Abschnitte gefunden"
+		default:
+			record.Status = domain.InputDocumentPartial
+			record.Reason = fmt.Sprintf("Abschnittslimit: %d von %d Abschnitten per Retrieval ausgewählt", pickedCount[docIndex], chunkCount[docIndex])
+		}
+	}
+
+	input := &complianceInput{
+		Documents:   records,
+		Hash:        hashDocuments(docs),
+		recordIndex: recordIndex,
+	}
+	if !mapReduce {
+		input.Text = renderChunks(picked)
+		return input, nil
+	}
+
+	input.sectionChunks = groupSections(picked, complianceSectionChars)
+	for i, sectionChunks := range input.sectionChunks {
+		input.Sections = append(input.Sections, agent.DocumentSection{
+			ID:    i,
+			Label: sectionLabel(sectionChunks),
+			Text:  renderChunks(sectionChunks),
+		})
+	}
+	return input, nil
+}
+
+// loadTenderDocuments lädt Beschreibung, OCR-Extrakt und alle Anhänge einer Ausschreibung als
+// seitenweise Dokumente. records protokolliert jedes Dokument; Dokumente ohne Text sind skipped.
+func loadTenderDocuments(ctx context.Context, db *gorm.DB, tender *domain.Tender) ([]sourceDocument, []domain.ComplianceInputDocument, error) {
+	var attachments []domain.TenderAttachment
+	if err := db.WithContext(ctx).
+		Select("id", "filename", "title", "content_ocr", "ocr_processed").
+		Where("tender_id = ? AND company_id IS NULL", tender.ID).
+		Order("created_at ASC").
+		Find(&attachments).Error; err != nil {
+		return nil, nil, fmt.Errorf("load attachments failed: %w", err)
+	}
+
+	var docs []sourceDocument
+	var records []domain.ComplianceInputDocument
+
+	addDocument := func(name string, attachmentID *uuid.UUID, pages []string) {
+		total := 0
+		for _, p := range pages {
+			total += len(strings.TrimSpace(p))
+		}
+		record := domain.ComplianceInputDocument{
+			Name:         name,
+			AttachmentID: attachmentID,
+			Pages:        len(pages),
+			TotalChars:   total,
+		}
+		if total == 0 {
+			record.Status = domain.InputDocumentSkipped
+			record.Reason = "kein Text vorhanden"
+			records = append(records, record)
+			return
+		}
+		docs = append(docs, sourceDocument{Name: name, Pages: pages})
+		records = append(records, record)
+	}
+
+	if text := strings.TrimSpace(tender.DescriptionFull); text != "" {
+		addDocument("Bekanntmachung", nil, []string{text})
+	}
+	if text := strings.TrimSpace(tender.OCRCompressedText); text != "" {
+		addDocument("OCR-Extrakt", nil, []string{text})
+	}
+
+	for i := range attachments {
+		att := attachments[i]
+		name := att.Title
+		if name == "" {
+			name = att.Filename
+		}
+		if !att.OCRProcessed || strings.TrimSpace(att.ContentOCR) == "" {
+			records = append(records, domain.ComplianceInputDocument{
+				Name:         name,
+				AttachmentID: &att.ID,
+				Status:       domain.InputDocumentSkipped,
+				Reason:       "kein OCR-Text (noch nicht verarbeitet oder kein PDF)",
+			})
+			continue
+		}
+		addDocument(name, &att.ID, splitOCRPages(att.ContentOCR))
+	}
+
+	if len(docs) == 0 {
+		return nil, nil, ErrNoTenderDocuments
+	}
+	return docs, records, nil
+}
+
+// hashDocuments bildet einen SHA-256 über Namen und Seiten aller Dokumente
+func hashDocuments(docs []sourceDocument) string {
+	h := sha256.New()
+	for _, doc := range docs {
+		fmt.Fprintf(h, "%s\x00%d\x00", doc.Name, len(doc.Pages))
+		for _, page := range doc.Pages {
+			fmt.Fprintf(h, "%d\x00%s\x00", len(page), page)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// markFailedSections korrigiert das Dokumentprotokoll für Abschnitte, die der Graph nicht
+// analysieren konnte: betroffene Dokumente werden als partial bzw. skipped markiert.
+func (in *complianceInput) markFailedSections(failed []agent.FailedSection) {
+	for _, f := range failed {
+		if f.SectionID < 0 || f.SectionID >= len(in.sectionChunks) {
+			continue
+		}
+		for _, chunk := range in.sectionChunks[f.SectionID] {
+			record := &in.Documents[in.recordIndex[chunk.DocIndex]]
+			record.IncludedChars -= len(chunk.Text)
+			if record.IncludedChars > 0 {
+				record.Status = domain.InputDocumentPartial
+			} else {
+				record.IncludedChars = 0
+				record.Status = domain.InputDocumentSkipped
+			}
+			record.Reason = "Analyse eines Abschnitts fehlgeschlagen: " + f.Label
+		}
+	}
+}
+
+// groupSections fasst die Abschnitte in Dokument-/Seitenreihenfolge zu Map-Abschnitten
+// von höchstens maxChars Zeichen zusammen.
+func groupSections(chunks []documentChunk, maxChars int) [][]documentChunk {
+	sort.SliceStable(chunks, func(i, j int) bool {
+		if chunks[i].DocIndex != chunks[j].DocIndex {
+			return chunks[i].DocIndex < chunks[j].DocIndex
+		}
+		return chunks[i].Seq < chunks[j].Seq
+	})
+
+	var sections [][]documentChunk
+	var current []documentChunk
+	size := 0
+	for _, chunk := range chunks {
+		cost := len(chunk.Text) + chunkMarkerOverhead(chunk)
+		if len(current) > 0 && size+cost > maxChars {
+			sections = append(sections, current)
+			current, size = nil, 0
+		}
+		current = append(current, chunk)
+		size += cost
+	}
+	if len(current) > 0 {
+		sections = append(sections, current)
+	}
+	return sections
+}
+
+// sectionLabel beschreibt einen Map-Abschnitt für Fortschritt und Fehlerprotokoll,
+// z. B. "Leistungsbeschreibung S. 3-17"
+func sectionLabel(chunks []documentChunk) string {
+	first, last := chunks[0], chunks[len(chunks)-1]
+	if first.DocIndex == last.DocIndex {
+		if first.Page == last.Page {
+			return fmt.Sprintf("%s S. %d", first.Document, first.Page)
+		}
+		return fmt.Sprintf("%s S. %d-%d", first.Document, first.Page, last.Page)
+	}
+	return fmt.Sprintf("%s S. %d bis %s S. %d", first.Document, first.Page, last.Document, last.Page)
+}
+
+// renderChunks setzt die Abschnitte in Dokument-/Seitenreihenfolge mit Markierungen zusammen
+func renderChunks(chunks []documentChunk) string {
+	sort.SliceStable(chunks, func(i, j int) bool {
+		if chunks[i].DocIndex != chunks[j].DocIndex {
+			return chunks[i].DocIndex < chunks[j].DocIndex
+		}
+		return chunks[i].Seq < chunks[j].Seq
+	})
+
+	var b strings.Builder
+	lastDoc, lastPage, lastSeq := -1, 0, -1
+	for _, chunk := range chunks {
+		if chunk.DocIndex != lastDoc {
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			fmt.Fprintf(&b, "[DOKUMENT: %s]\n", chunk.Document)
+			lastPage, lastSeq = 0, -1
+		} else if chunk.Seq != lastSeq+1 {
+			// Lücke durch Retrieval kenntlich machen
+			b.WriteString("\n[...]\n")
+		}
+		if chunk.Page != lastPage {
+			fmt.Fprintf(&b, "[SEITE: %d]\n", chunk.Page)
+		}
+		b.WriteString(chunk.Text)
+		b.WriteString("\n")
+
+		lastDoc, lastPage, lastSeq = chunk.DocIndex, chunk.Page, chunk.Seq
+	}
+	return b.String()
+}
+
+// chunkMarkerOverhead schätzt die Zeichen für [DOKUMENT]/[SEITE]-Markierungen eines Abschnitts
+func chunkMarkerOverhead(chunk documentChunk) int {
+	return len(chunk.Document) + 32
+}
@@ -3,7 +3,13 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,54 +21,400 @@ import (
 type ComplianceService struct {
 	complianceAgent *agent.ComplianceAgent
 	db              *gorm.DB
+	usage           *UsageService
 }
 
-func NewComplianceService(agent *agent.ComplianceAgent, db *gorm.DB) *ComplianceService {
+func NewComplianceService(agent *agent.ComplianceAgent, db *gorm.DB, usage *UsageService) *ComplianceService {
 	return &ComplianceService{
 		complianceAgent: agent,
 		db:              db,
+		usage:           usage,
 	}
 }
 
-func (s *ComplianceService) CheckCompliance(ctx context.Context, authUserID, tenderID uuid.UUID) (*domain.ComplianceCheck, error) {
+var ErrComplianceCheckNotFound = errors.New("compliance check not found")
+
+// Gründe, aus denen ein Check als veraltet markiert wird
+const (
+	StaleReasonAttachmentAdded   = "attachment_added"
+	StaleReasonAttachmentRemoved = "attachment_removed"
+	StaleReasonAttachmentOCR     = "attachment_processed"
+	StaleReasonProfileUpdated    = "profile_updated"
+	StaleReasonCertificates      = "certificates_updated"
+	StaleReasonReferences        = "references_updated"
+	StaleReasonCVs               = "cvs_updated"
+)
+
+// CheckCompliance prüft eine Ausschreibung gegen das Firmenprofil. Existiert bereits ein
+// nicht veralteter, vollständiger Check mit gleichen Unterlagen, gleichem Profil und gleicher
+// Prompt-/Modellversion, wird dieser zurückgegeben (Cached = true), außer bei force.
+// Läufe mit fehlgeschlagenen Abschnitten werden als Teilergebnis gespeichert und nie wiederverwendet.
+func (s *ComplianceService) CheckCompliance(ctx context.Context, authUserID, tenderID uuid.UUID, force bool) (*domain.ComplianceCheck, error) {
 	// 1. Company und Tender laden
-	var company domain.Company
 	var tender domain.Tender
 
-	if err := s.db.Where("auth_user_id = ?", authUserID).First(&company).Error; err != nil {
-		return nil, fmt.Errorf("company profile not found for user: %w", err)
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	// 2. Eingabe aus Beschreibung + allen verarbeiteten Anhängen zusammenstellen
+	docInput, err := buildComplianceInput(ctx, s.db, &tender)
+	if err != nil {
+		return nil, err
+	}
+	agent.ReportComplianceProgress(ctx, documentsProgress(docInput))
+
+	now := time.Now()
+	records, err := loadProfileRecords(ctx, s.db, company.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	profile := buildCompanyProfile(company, records, now)
+	profileHash, err := hashCompanyProfile(profile)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.db.First(&tender, "id = ?", tenderID).Error; err != nil {
-		return nil, fmt.Errorf("tender not found: %w", err)
+	// Prompt-Version und Modell für Cache-Schlüssel und Lauf festlegen
+	ctx, run, err := s.complianceAgent.Prepare(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. Compliance Agent aufrufen
-	input := agent.ComplianceInput{
-		OCRText: tender.OCRCompressedText,
-		ProfileSummary: fmt.Sprintf("Firma: %s, Branche: %v, Referenzen: %s",
-			company.Name, company.IndustryTags, string(company.ProjectReferences)),
+	// 3. Cache: gleicher Schlüssel -> gespeichertes Ergebnis
+	key := complianceCacheKey{
+		InputHash:     docInput.Hash,
+		ProfileHash:   profileHash,
+		PromptVersion: run.PromptVersion,
+		ModelID:       run.ModelID,
+	}
+	cached, err := s.findCachedCheck(ctx, company.ID, tenderID, key, force)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		return cached, nil
 	}
 
-	assessment, err := s.complianceAgent.Assess(ctx, input)
+	// Kontingent erst vor einem neuen Lauf prüfen, Cache-Treffer kosten nichts
+	ctx, err = s.usage.Begin(ctx, company, &tenderID, OperationComplianceCheck)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Ergebnis speichern
+	// 4. Compliance Agent aufrufen: ein Prompt oder Map-Reduce über die Abschnitte
+	var assessment *agent.ComplianceAssessment
+	partial := false
+	if len(docInput.Sections) > 0 {
+		result, err := s.complianceAgent.AssessSections(ctx, agent.ComplianceGraphInput{
+			Sections: docInput.Sections,
+			Profile:  profile,
+		})
+		if err != nil {
+			return nil, err
+		}
+		docInput.markFailedSections(result.FailedSections)
+		assessment = &result.Assessment
+		partial = len(result.FailedSections) > 0
+	} else {
+		assessment, err = s.complianceAgent.Assess(ctx, agent.ComplianceInput{
+			OCRText: docInput.Text,
+			Profile: profile,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	inputDocuments, err := json.Marshal(docInput.Documents)
+	if err != nil {
+		return nil, fmt.Errorf("marshal input documents failed: %w", err)
+	}
+
+	// 5. Ergebnis speichern (Anforderungen und Blocker als eigene Zeilen)
 	check := &domain.ComplianceCheck{
 		ID:             uuid.New(),
 		CompanyID:      company.ID,
 		TenderID:       tenderID,
 		IsFeasible:     assessment.IsFeasible,
-		MissingDocs:    assessment.Blockers,
-		CriticalIssues: []string{},
+		InputDocuments: inputDocuments,
+		InputHash:      docInput.Hash,
+		ProfileHash:    profileHash,
+		PromptVersion:  run.PromptVersion,
+		ModelID:        run.ModelID,
+		IsPartial:      partial,
+		Requirements:   toComplianceRequirements(assessment.Requirements),
+		Blockers:       toComplianceBlockers(assessment.Blockers),
 		CreatedAt:      time.Now(),
 	}
 
-	if err := s.db.Create(check).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(check).Error; err != nil {
 		return nil, err
 	}
 
 	return check, nil
 }
+
+// documentsProgress fasst die geladenen Dokumente für die Fortschrittsanzeige zusammen
+func documentsProgress(in *complianceInput) agent.ComplianceProgress {
+	loaded := 0
+	for _, doc := range in.Documents {
+		if doc.Status != domain.InputDocumentSkipped {
+			loaded++
+		}
+	}
+
+	message := fmt.Sprintf("%d von %d Dokumenten geladen", loaded, len(in.Documents))
+	if len(in.Sections) > 0 {
+		message += fmt.Sprintf(", Analyse in %d Abschnitten", len(in.Sections))
+	}
+	return agent.ComplianceProgress{
+		Stage:     agent.ComplianceStageDocuments,
+		Completed: loaded,
+		Total:     len(in.Documents),
+		Message:   message,
+	}
+}
+
+// complianceCacheKey: gleiche Unterlagen, gleiches Profil und gleiche Prompt-/Modellversion
+type complianceCacheKey struct {
+	InputHash     string
+	ProfileHash   string
+	PromptVersion string
+	ModelID       string
+}
+
+// matches: nur vollständige, nicht veraltete Checks mit identischem Schlüssel
+func (k complianceCacheKey) matches(check *domain.ComplianceCheck) bool {
+	return !check.IsStale && !check.IsPartial &&
+		check.InputHash == k.InputHash &&
+		check.ProfileHash == k.ProfileHash &&
+		check.PromptVersion == k.PromptVersion &&
+		check.ModelID == k.ModelID
+}
+
+// selectCachedCheck wählt aus den Checks (neueste zuerst) den ersten Treffer; force umgeht den Cache
+func selectCachedCheck(checks []domain.ComplianceCheck, key complianceCacheKey, force bool) *domain.ComplianceCheck {
+	if force {
+		return nil
+	}
+	for i := range checks {
+		if key.matches(&checks[i]) {
+			return &checks[i]
+		}
+	}
+	return nil
+}
+
+func (s *ComplianceService) findCachedCheck(ctx context.Context, companyID, tenderID uuid.UUID, key complianceCacheKey, force bool) (*domain.ComplianceCheck, error) {
+	if force {
+		return nil, nil
+	}
+
+	// Kandidaten ohne Details laden, Schlüssel in selectCachedCheck vergleichen
+	var candidates []domain.ComplianceCheck
+	err := s.db.WithContext(ctx).
+		Select("id, input_hash, profile_hash, prompt_version, model_id, is_stale, COALESCE(is_partial, false) AS is_partial").
+		Where("company_id = ? AND tender_id = ? AND input_hash = ?", companyID, tenderID, key.InputHash).
+		Order("created_at DESC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("load cached check failed: %w", err)
+	}
+	hit := selectCachedCheck(candidates, key, force)
+	if hit == nil {
+		return nil, nil
+	}
+
+	var check domain.ComplianceCheck
+	if err := preloadCheckDetails(s.db.WithContext(ctx)).First(&check, "id = ?", hit.ID).Error; err != nil {
+		return nil, fmt.Errorf("load cached check failed: %w", err)
+	}
+	check.Cached = true
+	return &check, nil
+}
+
+// ComplianceCheckSummary ist ein Eintrag im Verlauf der Checks einer Ausschreibung
+type ComplianceCheckSummary struct {
+	ID                uuid.UUID  `json:"id"`
+	TenderID          uuid.UUID  `json:"tender_id"`
+	IsFeasible        bool       `json:"is_feasible"`
+	IsStale           bool       `json:"is_stale"`
+	IsPartial         bool       `json:"is_partial"`
+	StaleReason       string     `json:"stale_reason,omitempty"`
+	StaleAt           *time.Time `json:"stale_at,omitempty"`
+	PromptVersion     string     `json:"prompt_version"`
+	ModelID           string     `json:"model_id"`
+	RequirementCount  int        `json:"requirement_count"`
+	FulfilledCount    int        `json:"fulfilled_count"`
+	NotFulfilledCount int        `json:"not_fulfilled_count"`
+	UnknownCount      int        `json:"unknown_count"`
+	BlockerCount      int        `json:"blocker_count"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// ListChecks liefert den Verlauf der Checks der eigenen Firma für eine Ausschreibung (neueste zuerst)
+func (s *ComplianceService) ListChecks(ctx context.Context, authUserID, tenderID uuid.UUID) ([]ComplianceCheckSummary, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []ComplianceCheckSummary{}
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT
+			cc.id,
+			cc.tender_id,
+			cc.is_feasible,
+			COALESCE(cc.is_stale, false) AS is_stale,
+			COALESCE(cc.is_partial, false) AS is_partial,
+			COALESCE(cc.stale_reason, '') AS stale_reason,
+			cc.stale_at,
+			COALESCE(cc.prompt_version, '') AS prompt_version,
+			COALESCE(cc.model_id, '') AS model_id,
+			(SELECT count(*) FROM compliance_requirements r WHERE r.compliance_check_id = cc.id) AS requirement_count,
+			(SELECT count(*) FROM compliance_requirements r WHERE r.compliance_check_id = cc.id AND r.status = 'fulfilled') AS fulfilled_count,
+			(SELECT count(*) FROM compliance_requirements r WHERE r.compliance_check_id = cc.id AND r.status = 'not_fulfilled') AS not_fulfilled_count,
+			(SELECT count(*) FROM compliance_requirements r WHERE r.compliance_check_id = cc.id AND r.status = 'unknown') AS unknown_count,
+			(SELECT count(*) FROM compliance_blockers b WHERE b.compliance_check_id = cc.id) AS blocker_count,
+			cc.created_at
+		FROM compliance_checks cc
+		WHERE cc.company_id = @company_id AND cc.tender_id = @tender_id
+		ORDER BY cc.created_at DESC
+	`, sql.Named("company_id", company.ID), sql.Named("tender_id", tenderID)).Scan(&summaries).Error
+	if err != nil {
+		return nil, fmt.Errorf("list compliance checks failed: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetCheck lädt einen Check der eigenen Firma mit Anforderungen und Blockern
+func (s *ComplianceService) GetCheck(ctx context.Context, authUserID, checkID uuid.UUID) (*domain.ComplianceCheck, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var check domain.ComplianceCheck
+	err = preloadCheckDetails(s.db.WithContext(ctx)).
+		Where("id = ? AND company_id = ?", checkID, company.ID).
+		First(&check).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrComplianceCheckNotFound
+		}
+		return nil, fmt.Errorf("load compliance check failed: %w", err)
+	}
+
+	return &check, nil
+}
+
+// MarkTenderChecksStale markiert alle aktuellen Checks einer Ausschreibung als veraltet
+func (s *ComplianceService) MarkTenderChecksStale(ctx context.Context, tenderID uuid.UUID, reason string) error {
+	return markComplianceChecksStale(ctx, s.db, "tender_id", tenderID, reason)
+}
+
+// markComplianceChecksStale setzt is_stale für alle noch aktuellen Checks (column = tender_id | company_id)
+func markComplianceChecksStale(ctx context.Context, db *gorm.DB, column string, id uuid.UUID, reason string) error {
+	err := db.WithContext(ctx).
+		Model(&domain.ComplianceCheck{}).
+		Where(column+" = ? AND is_stale = false", id).
+		Updates(map[string]interface{}{
+			"is_stale":     true,
+			"stale_reason": reason,
+			"stale_at":     time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("mark compliance checks stale failed: %w", err)
+	}
+	return nil
+}
+
+func preloadCheckDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Requirements", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Blockers", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") })
+}
+
+// hashCompanyProfile bildet den Profil-Hash ohne Stichtag, damit der Cache nicht täglich verfällt
+func hashCompanyProfile(profile agent.CompanyProfile) (string, error) {
+	profile.AsOf = ""
+	payload, err := json.Marshal(profile)
+	if err != nil {
+		return "", fmt.Errorf("marshal company profile failed: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// toComplianceRequirements übernimmt die Anforderungen des Agents und korrigiert
+// unbekannte Kategorien/Status, damit die Check-Constraints der Tabelle halten.
+func toComplianceRequirements(items []agent.RequirementAssessment) []domain.ComplianceRequirement {
+	requirements := make([]domain.ComplianceRequirement, 0, len(items))
+	for i, item := range items {
+		category := strings.ToLower(strings.TrimSpace(item.Category))
+		switch category {
+		case agent.RequirementCategoryEignung, agent.RequirementCategoryAusschluss,
+			agent.RequirementCategoryNachweis, agent.RequirementCategoryTechnical:
+		default:
+			category = agent.RequirementCategoryTechnical
+		}
+
+		status := strings.ToLower(strings.TrimSpace(item.Status))
+		switch status {
+		case agent.RequirementStatusFulfilled, agent.RequirementStatusNotFulfilled, agent.RequirementStatusUnknown:
+		default:
+			status = agent.RequirementStatusUnknown
+		}
+
+		var page *int
+		if item.SourcePage > 0 {
+			p := item.SourcePage
+			page = &p
+		}
+
+		requirements = append(requirements, domain.ComplianceRequirement{
+			ID:             uuid.New(),
+			Position:       i,
+			Title:          strings.TrimSpace(item.Title),
+			Category:       category,
+			SourceQuote:    strings.TrimSpace(item.SourceQuote),
+			SourceDocument: strings.TrimSpace(item.SourceDocument),
+			SourcePage:     page,
+			Status:         status,
+			Evidence:       strings.TrimSpace(item.Evidence),
+			IsKnockout:     item.IsKnockout,
+		})
+	}
+	return requirements
+}
+
+func toComplianceBlockers(items []agent.ComplianceBlocker) []domain.ComplianceBlocker {
+	blockers := make([]domain.ComplianceBlocker, 0, len(items))
+	for _, item := range items {
+		description := strings.TrimSpace(item.Description)
+		if description == "" {
+			continue
+		}
+		kind := item.Kind
+		if kind != agent.BlockerKindMissingDocument {
+			kind = agent.BlockerKindCriticalIssue
+		}
+		blockers = append(blockers, domain.ComplianceBlocker{
+			ID:          uuid.New(),
+			Position:    len(blockers),
+			Kind:        kind,
+			Description: description,
+		})
+	}
+	return blockers
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+func TestSelectCachedCheck(t *testing.T) {
+	key := complianceCacheKey{InputHash: "in-1", ProfileHash: "profile-1", PromptVersion: "v2", ModelID: "gpt-4o"}
+	stored := func(mod func(*domain.ComplianceCheck)) domain.ComplianceCheck {
+		check := domain.ComplianceCheck{ID: uuid.New(), InputHash: "in-1", ProfileHash: "profile-1", PromptVersion: "v2", ModelID: "gpt-4o"}
+		if mod != nil {
+			mod(&check)
+		}
+		return check
+	}
+	hit := stored(nil)
+
+	tests := []struct {
+		name   string
+		checks []domain.ComplianceCheck
+		force  bool
+		want   *uuid.UUID
+	}{
+		{"no checks", nil, false, nil},
+		{"same key", []domain.ComplianceCheck{hit}, false, &hit.ID},
+		{"force bypasses cache", []domain.ComplianceCheck{hit}, true, nil},
+		{"changed documents", []domain.ComplianceCheck{stored(func(c *domain.ComplianceCheck) { c.InputHash = "in-0" })}, false, nil},
+		{"changed profile", []domain.ComplianceCheck{stored(func(c *domain.ComplianceCheck) { c.ProfileHash = "profile-0" })}, false, nil},
+		{"changed prompt version", []domain.ComplianceCheck{stored(func(c *domain.ComplianceCheck) { c.PromptVersion = "v1" })}, false, nil},
+		{"changed model", []domain.ComplianceCheck{stored(func(c *domain.ComplianceCheck) { c.ModelID = "gpt-4o-mini" })}, false, nil},
+		{"stale check", []domain.ComplianceCheck{stored(func(c *domain.ComplianceCheck) { c.IsStale = true })}, false, nil},
+		{"partial check", []domain.ComplianceCheck{stored(func(c *domain.ComplianceCheck) { c.IsPartial = true })}, false, nil},
+		{
+			"newest valid check after a stale one",
+			[]domain.ComplianceCheck{stored(func(c *domain.ComplianceCheck) { c.IsStale = true }), hit, stored(nil)},
+			false,
+			&hit.ID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectCachedCheck(tt.checks, key, tt.force)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("expected cache miss, got %s", got.ID)
+			case tt.want != nil && (got == nil || got.ID != *tt.want):
+				t.Errorf("expected cache hit %s, got %+v", *tt.want, got)
+			}
+		})
+	}
+}
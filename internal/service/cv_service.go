@@ -0,0 +1,558 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var (
+	ErrInvalidCV    = errors.New("invalid cv")
+	ErrCVNotFound   = errors.New("cv not found")
+	ErrCVProcessing = errors.New("cv is still being processed")
+)
+
+const (
+	// cvMaxFileSize begrenzt hochgeladene Lebensläufe
+	cvMaxFileSize = 10 << 20
+	// Unterhalb dieser Zeichenzahl gilt die Textebene eines PDF als leer (Scan) -> OCR
+	cvMinTextLayerChars = 200
+	// cvExtractionTimeout begrenzt Textauslese (ggf. OCR) und Extraktion im Hintergrund
+	cvExtractionTimeout = 5 * time.Minute
+)
+
+// cvFileTypes sind die erlaubten Dateiformate für Lebensläufe
+var cvFileTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// CVPatch korrigiert die extrahierten Angaben; nur gesetzte Felder werden übernommen
+type CVPatch struct {
+	FullName          *string                `json:"full_name"`
+	Position          *string                `json:"position"`
+	YearsOfExperience *int                   `json:"years_of_experience"`
+	Degrees           *[]agent.CVDegree      `json:"degrees"`
+	Certifications    *[]string              `json:"certifications"`
+	Skills            *[]string              `json:"skills"`
+	Languages         *[]string              `json:"languages"`
+	ProjectRoles      *[]agent.CVProjectRole `json:"project_roles"`
+	Summary           *string                `json:"summary"`
+}
+
+// TeamProposal ist der Besetzungsvorschlag für die Personalanforderungen einer Ausschreibung
+type TeamProposal struct {
+	TenderID uuid.UUID           `json:"tender_id"`
+	Roles    []agent.StaffedRole `json:"roles"`
+	// Lebensläufe ohne ausgewertete Angaben (in Verarbeitung oder fehlgeschlagen) fließen nicht ein
+	SkippedCVs     int                              `json:"skipped_cvs"`
+	FailedSections []agent.FailedSection            `json:"failed_sections,omitempty"`
+	InputDocuments []domain.ComplianceInputDocument `json:"input_documents"`
+	PromptVersion  string                           `json:"prompt_version"`
+	ModelID        string                           `json:"model_id"`
+}
+
+type CVService struct {
+	db        *gorm.DB
+	storage   *SupabaseStorageService
+	ocr       *OCRService
+	extractor *agent.CVExtractionAgent
+	staffing  *agent.StaffingAgent
+	usage     *UsageService
+}
+
+// NewCVService: ohne storage keine Uploads, ohne ocr werden nur PDFs mit Textebene gelesen
+func NewCVService(db *gorm.DB, storage *SupabaseStorageService, ocr *OCRService, extractor *agent.CVExtractionAgent, staffing *agent.StaffingAgent, usageSvc *UsageService) *CVService {
+	return &CVService{db: db, storage: storage, ocr: ocr, extractor: extractor, staffing: staffing, usage: usageSvc}
+}
+
+// ListCVs listet die Lebensläufe der eigenen Firma
+func (s *CVService) ListCVs(ctx context.Context, authUserID uuid.UUID) ([]domain.EmployeeCV, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+	return loadEmployeeCVs(ctx, s.db, member.CompanyID)
+}
+
+// UploadCV speichert einen Lebenslauf (PDF oder DOCX) und wertet ihn im Hintergrund aus.
+// Das Kontingent wird vor dem Upload geprüft; das Ergebnis steht danach per GET bereit.
+func (s *CVService) UploadCV(ctx context.Context, authUserID uuid.UUID, filename string, data []byte) (*domain.EmployeeCV, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	filename = filepath.Base(strings.TrimSpace(filename))
+	ext := strings.ToLower(filepath.Ext(filename))
+	contentType, ok := cvFileTypes[ext]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%w: file must be PDF or DOCX", ErrInvalidCV)
+	case len(data) == 0:
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidCV)
+	case len(data) > cvMaxFileSize:
+		return nil, fmt.Errorf("%w: file must not exceed %d MB", ErrInvalidCV, cvMaxFileSize>>20)
+	}
+
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "subscription_tier")
+	if err != nil {
+		return nil, err
+	}
+	extractCtx, err := s.usage.Begin(context.WithoutCancel(ctx), company, nil, OperationCVExtraction)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cv := &domain.EmployeeCV{
+		ID:               uuid.New(),
+		CompanyID:        company.ID,
+		FullName:         strings.TrimSuffix(filename, filepath.Ext(filename)),
+		Degrees:          json.RawMessage("[]"),
+		ProjectRoles:     json.RawMessage("[]"),
+		Certifications:   pq.StringArray{},
+		Skills:           pq.StringArray{},
+		Languages:        pq.StringArray{},
+		Filename:         filename,
+		ContentType:      contentType,
+		FileSize:         len(data),
+		ExtractionStatus: domain.CVStatusProcessing,
+		CreatedBy:        authUserID,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	cv.StoragePath = fmt.Sprintf("cvs/%s/%s/%s%s", company.ID, cv.ID, uuid.New(), ext)
+	if err := s.storage.UploadFile(companyAssetsBucket, cv.StoragePath, data, contentType); err != nil {
+		return nil, fmt.Errorf("upload cv failed: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Create(cv).Error; err != nil {
+		s.deleteFile(cv.StoragePath)
+		return nil, fmt.Errorf("create cv failed: %w", err)
+	}
+
+	go s.extract(extractCtx, cv, data)
+	return cv, nil
+}
+
+// ReextractCV wertet einen Lebenslauf erneut aus (z. B. nach einem Fehler); manuelle
+// Korrekturen werden dabei überschrieben
+func (s *CVService) ReextractCV(ctx context.Context, authUserID, cvID uuid.UUID) (*domain.EmployeeCV, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	cv, err := s.findCV(ctx, authUserID, cvID)
+	if err != nil {
+		return nil, err
+	}
+	company, err := findCompanyByID(ctx, s.db, cv.CompanyID, "id", "subscription_tier")
+	if err != nil {
+		return nil, err
+	}
+	extractCtx, err := s.usage.Begin(context.WithoutCancel(ctx), company, nil, OperationCVExtraction)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nur einen Lauf je Lebenslauf zulassen
+	cv.UpdatedAt = time.Now()
+	res := s.db.WithContext(ctx).
+		Model(&domain.EmployeeCV{}).
+		Where("id = ? AND extraction_status <> ?", cv.ID, domain.CVStatusProcessing).
+		Updates(map[string]any{
+			"extraction_status": domain.CVStatusProcessing,
+			"extraction_error":  "",
+			"updated_at":        cv.UpdatedAt,
+		})
+	if res.Error != nil {
+		return nil, fmt.Errorf("update cv failed: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrCVProcessing
+	}
+	cv.ExtractionStatus = domain.CVStatusProcessing
+	cv.ExtractionError = ""
+
+	data, err := s.storage.DownloadFile(companyAssetsBucket, cv.StoragePath)
+	if err != nil {
+		s.failExtraction(context.WithoutCancel(ctx), cv, fmt.Errorf("download cv failed: %w", err))
+		return nil, fmt.Errorf("download cv failed: %w", err)
+	}
+
+	go s.extract(extractCtx, cv, data)
+	return cv, nil
+}
+
+// UpdateCV korrigiert die extrahierten Angaben eines Lebenslaufs
+func (s *CVService) UpdateCV(ctx context.Context, authUserID, cvID uuid.UUID, patch CVPatch) (*domain.EmployeeCV, error) {
+	cv, err := s.findCV(ctx, authUserID, cvID)
+	if err != nil {
+		return nil, err
+	}
+	if cv.ExtractionStatus == domain.CVStatusProcessing {
+		return nil, ErrCVProcessing
+	}
+
+	var problems []string
+	if patch.FullName != nil {
+		if cv.FullName = strings.TrimSpace(*patch.FullName); cv.FullName == "" {
+			problems = append(problems, "full_name must not be empty")
+		}
+	}
+	if patch.Position != nil {
+		cv.Position = strings.TrimSpace(*patch.Position)
+	}
+	if patch.YearsOfExperience != nil {
+		if *patch.YearsOfExperience < 0 || *patch.YearsOfExperience > 60 {
+			problems = append(problems, "years_of_experience must be between 0 and 60")
+		}
+		cv.YearsOfExperience = *patch.YearsOfExperience
+	}
+	if patch.Degrees != nil {
+		cv.Degrees, _ = json.Marshal(nonNilSlice(*patch.Degrees))
+	}
+	if patch.Certifications != nil {
+		cv.Certifications = cleanStrings(*patch.Certifications)
+	}
+	if patch.Skills != nil {
+		cv.Skills = cleanStrings(*patch.Skills)
+	}
+	if patch.Languages != nil {
+		cv.Languages = cleanStrings(*patch.Languages)
+	}
+	if patch.ProjectRoles != nil {
+		cv.ProjectRoles, _ = json.Marshal(nonNilSlice(*patch.ProjectRoles))
+	}
+	if patch.Summary != nil {
+		cv.Summary = strings.TrimSpace(*patch.Summary)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCV, strings.Join(problems, "; "))
+	}
+
+	cv.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).
+		Model(&domain.EmployeeCV{}).
+		Where("id = ?", cv.ID).
+		Updates(map[string]any{
+			"full_name":           cv.FullName,
+			"position":            cv.Position,
+			"years_of_experience": cv.YearsOfExperience,
+			"degrees":             cv.Degrees,
+			"certifications":      cv.Certifications,
+			"skills":              cv.Skills,
+			"languages":           cv.Languages,
+			"project_roles":       cv.ProjectRoles,
+			"summary":             cv.Summary,
+			"updated_at":          cv.UpdatedAt,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("update cv failed: %w", err)
+	}
+	s.markChecksStale(ctx, cv.CompanyID)
+	return cv, nil
+}
+
+// DeleteCV löscht einen Lebenslauf samt Datei
+func (s *CVService) DeleteCV(ctx context.Context, authUserID, cvID uuid.UUID) error {
+	cv, err := s.findCV(ctx, authUserID, cvID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&domain.EmployeeCV{}, "id = ?", cv.ID).Error; err != nil {
+		return fmt.Errorf("delete cv failed: %w", err)
+	}
+	s.deleteFile(cv.StoragePath)
+	s.markChecksStale(ctx, cv.CompanyID)
+	return nil
+}
+
+// DownloadCVFile lädt die hochgeladene Datei (der Bucket ist privat)
+func (s *CVService) DownloadCVFile(ctx context.Context, authUserID, cvID uuid.UUID) (*ExportedFile, error) {
+	if s.storage == nil {
+		return nil, ErrStorageUnavailable
+	}
+
+	cv, err := s.findCV(ctx, authUserID, cvID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.storage.DownloadFile(companyAssetsBucket, cv.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("download cv failed: %w", err)
+	}
+	return &ExportedFile{Filename: cv.Filename, ContentType: cv.ContentType, Content: content}, nil
+}
+
+// ProposeTeam ermittelt die Personalanforderungen aus Beschreibung und Anhängen und schlägt
+// für jede Funktion Mitarbeiter aus den ausgewerteten Lebensläufen vor. Fehlende Abschlüsse,
+// Qualifikationen und Berufsjahre sowie unbesetzte Positionen werden markiert.
+func (s *CVService) ProposeTeam(ctx context.Context, authUserID, tenderID uuid.UUID) (*TeamProposal, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "subscription_tier")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	ctx, err = s.usage.Begin(ctx, company, &tenderID, OperationTeamProposal)
+	if err != nil {
+		return nil, err
+	}
+
+	docInput, err := buildComplianceInput(ctx, s.db, &tender)
+	if err != nil {
+		return nil, err
+	}
+	sections := docInput.Sections
+	if len(sections) == 0 {
+		sections = []agent.DocumentSection{{ID: 0, Label: "Unterlagen", Text: docInput.Text}}
+	}
+
+	cvs, err := loadEmployeeCVs(ctx, s.db, company.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidates, skipped := staffingCandidates(cvs)
+	proposal := &TeamProposal{TenderID: tenderID, SkippedCVs: skipped}
+
+	result, err := s.staffing.Propose(ctx, agent.StaffingInput{Sections: sections, Candidates: candidates})
+	if err != nil {
+		return nil, err
+	}
+	if len(docInput.Sections) > 0 {
+		docInput.markFailedSections(result.FailedSections)
+	}
+
+	proposal.Roles = result.Roles
+	proposal.FailedSections = result.FailedSections
+	proposal.InputDocuments = docInput.Documents
+	proposal.PromptVersion = result.Run.PromptVersion
+	proposal.ModelID = result.Run.ModelID
+	return proposal, nil
+}
+
+// extract liest den Text (DOCX, PDF-Textebene oder OCR) und speichert die extrahierten Angaben.
+// Läuft im Hintergrund; Fehler landen am Lebenslauf.
+func (s *CVService) extract(ctx context.Context, cv *domain.EmployeeCV, data []byte) {
+	ctx, cancel := context.WithTimeout(ctx, cvExtractionTimeout)
+	defer cancel()
+
+	text, err := s.cvText(ctx, cv.ContentType, data)
+	if err != nil {
+		s.failExtraction(ctx, cv, err)
+		return
+	}
+	result, err := s.extractor.Extract(ctx, text, germanToday(time.Now()).Format(dateInputLayout))
+	if err != nil {
+		s.failExtraction(ctx, cv, err)
+		return
+	}
+
+	profile := result.Profile
+	degrees, _ := json.Marshal(profile.Degrees)
+	projectRoles, _ := json.Marshal(profile.ProjectRoles)
+	now := time.Now()
+	if err := s.db.WithContext(ctx).
+		Model(&domain.EmployeeCV{}).
+		Where("id = ?", cv.ID).
+		Updates(map[string]any{
+			"full_name":           profile.FullName,
+			"position":            profile.Position,
+			"years_of_experience": profile.YearsOfExperience,
+			"degrees":             json.RawMessage(degrees),
+			"certifications":      pq.StringArray(profile.Certifications),
+			"skills":              pq.StringArray(profile.Skills),
+			"languages":           pq.StringArray(profile.Languages),
+			"project_roles":       json.RawMessage(projectRoles),
+			"summary":             profile.Summary,
+			"extraction_status":   domain.CVStatusExtracted,
+			"extraction_error":    "",
+			"extracted_at":        now,
+			"prompt_version":      result.Run.PromptVersion,
+			"model_id":            result.Run.ModelID,
+			"updated_at":          now,
+		}).Error; err != nil {
+		log.Printf("Saving extracted cv %s failed: %v", cv.ID, err)
+		return
+	}
+	log.Printf("CV %s extracted (%d chars, truncated: %v)", cv.ID, len(text), result.Truncated)
+	s.markChecksStale(ctx, cv.CompanyID)
+}
+
+// cvText liest den Text eines Lebenslaufs. PDFs ohne nennenswerte Textebene (Scans) gehen an die OCR.
+func (s *CVService) cvText(ctx context.Context, contentType string, data []byte) (string, error) {
+	if contentType == cvFileTypes[".docx"] {
+		return docxDocumentText(data)
+	}
+
+	text, err := pdfTextLayer(data)
+	if err != nil {
+		log.Printf("PDF text layer unavailable, falling back to OCR: %v", err)
+	}
+	if len(strings.TrimSpace(text)) >= cvMinTextLayerChars {
+		return text, nil
+	}
+	if s.ocr == nil {
+		return "", errors.New("PDF enthält keinen Text und OCR ist nicht konfiguriert")
+	}
+	return s.ocr.ExtractFromPDF(ctx, data)
+}
+
+func (s *CVService) failExtraction(ctx context.Context, cv *domain.EmployeeCV, cause error) {
+	log.Printf("CV extraction failed for %s: %v", cv.ID, cause)
+	if err := s.db.WithContext(context.WithoutCancel(ctx)).
+		Model(&domain.EmployeeCV{}).
+		Where("id = ?", cv.ID).
+		Updates(map[string]any{
+			"extraction_status": domain.CVStatusFailed,
+			"extraction_error":  cause.Error(),
+			"updated_at":        time.Now(),
+		}).Error; err != nil {
+		log.Printf("Saving cv extraction error for %s failed: %v", cv.ID, err)
+	}
+}
+
+func (s *CVService) findCV(ctx context.Context, authUserID, cvID uuid.UUID) (*domain.EmployeeCV, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cv domain.EmployeeCV
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND company_id = ?", cvID, member.CompanyID).
+		First(&cv).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCVNotFound
+		}
+		return nil, fmt.Errorf("load cv failed: %w", err)
+	}
+	return &cv, nil
+}
+
+// markChecksStale: Lebensläufe sind Teil des Firmenprofils für die Compliance-Prüfung
+func (s *CVService) markChecksStale(ctx context.Context, companyID uuid.UUID) {
+	if err := markComplianceChecksStale(ctx, s.db, "company_id", companyID, StaleReasonCVs); err != nil {
+		log.Printf("Compliance stale warning: %v", err)
+	}
+}
+
+func (s *CVService) deleteFile(path string) {
+	if s.storage == nil || path == "" {
+		return
+	}
+	if err := s.storage.DeleteFile(companyAssetsBucket, path); err != nil {
+		log.Printf("Storage delete failed for cv file %s: %v", path, err)
+	}
+}
+
+// loadEmployeeCVs lädt alle Lebensläufe einer Firma nach Name
+func loadEmployeeCVs(ctx context.Context, db *gorm.DB, companyID uuid.UUID) ([]domain.EmployeeCV, error) {
+	cvs := []domain.EmployeeCV{}
+	if err := db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Order("full_name ASC, created_at ASC").
+		Find(&cvs).Error; err != nil {
+		return nil, fmt.Errorf("load cvs failed: %w", err)
+	}
+	return cvs, nil
+}
+
+// staffingCandidates wählt die ausgewerteten Lebensläufe für den Teamvorschlag aus; noch
+// laufende oder fehlgeschlagene Auswertungen werden nur gezählt
+func staffingCandidates(cvs []domain.EmployeeCV) (candidates []agent.StaffingCandidate, skipped int) {
+	for _, cv := range cvs {
+		if cv.ExtractionStatus != domain.CVStatusExtracted {
+			skipped++
+			continue
+		}
+		candidates = append(candidates, agent.StaffingCandidate{ID: cv.ID.String(), Profile: cvProfile(cv)})
+	}
+	return candidates, skipped
+}
+
+// cvProfile übersetzt einen gespeicherten Lebenslauf zurück in die Struktur des Agents
+func cvProfile(cv domain.EmployeeCV) agent.CVProfile {
+	profile := agent.CVProfile{
+		FullName:          cv.FullName,
+		Position:          cv.Position,
+		YearsOfExperience: cv.YearsOfExperience,
+		Certifications:    cv.Certifications,
+		Skills:            cv.Skills,
+		Languages:         cv.Languages,
+		Summary:           cv.Summary,
+	}
+	_ = json.Unmarshal(cv.Degrees, &profile.Degrees)
+	_ = json.Unmarshal(cv.ProjectRoles, &profile.ProjectRoles)
+	return profile
+}
+
+// cvDegreeLabels formatiert Abschlüsse ("M.Sc. Elektrotechnik (2012)")
+func cvDegreeLabels(raw json.RawMessage) []string {
+	var degrees []agent.CVDegree
+	_ = json.Unmarshal(raw, &degrees)
+	labels := make([]string, 0, len(degrees))
+	for _, d := range degrees {
+		label := strings.TrimSpace(d.Degree + " " + d.Field)
+		if d.Year > 0 {
+			label += fmt.Sprintf(" (%d)", d.Year)
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+// cvProjectRoleLabels formatiert Projektrollen ("Projektleiter: Neubau Feuerwache, 2021–2023")
+func cvProjectRoleLabels(raw json.RawMessage) []string {
+	var roles []agent.CVProjectRole
+	_ = json.Unmarshal(raw, &roles)
+	labels := make([]string, 0, len(roles))
+	for _, r := range roles {
+		label := r.Role + ": " + r.Project
+		switch {
+		case r.FromYear > 0 && r.ToYear > 0:
+			label += fmt.Sprintf(", %d–%d", r.FromYear, r.ToYear)
+		case r.FromYear > 0:
+			label += fmt.Sprintf(", seit %d", r.FromYear)
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+// cleanStrings entfernt leere Einträge
+func cleanStrings(values []string) pq.StringArray {
+	out := pq.StringArray{}
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func nonNilSlice[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+func TestStaffingCandidates(t *testing.T) {
+	lead := domain.EmployeeCV{
+		ID:                uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		FullName:          "Anna Weber",
+		Position:          "Projektleiterin",
+		YearsOfExperience: 12,
+		Degrees:           json.RawMessage(`[{"degree": "Dipl.-Ing. (FH)", "field": "Elektrotechnik", "year": 2012}]`),
+		Certifications:    pq.StringArray{"SiGeKo nach RAB 30"},
+		ProjectRoles:      json.RawMessage(`[{"project": "Neubau Feuerwache", "role": "Projektleiterin", "from_year": 2021}]`),
+		ExtractionStatus:  domain.CVStatusExtracted,
+	}
+	leadProfile := agent.CVProfile{
+		FullName:          "Anna Weber",
+		Position:          "Projektleiterin",
+		YearsOfExperience: 12,
+		Degrees:           []agent.CVDegree{{Degree: "Dipl.-Ing. (FH)", Field: "Elektrotechnik", Year: 2012}},
+		Certifications:    []string{"SiGeKo nach RAB 30"},
+		ProjectRoles:      []agent.CVProjectRole{{Project: "Neubau Feuerwache", Role: "Projektleiterin", FromYear: 2021}},
+	}
+
+	broken := domain.EmployeeCV{
+		ID:                uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+		FullName:          "Jonas Brandt",
+		YearsOfExperience: 3,
+		Degrees:           json.RawMessage(`kaputt`),
+		ExtractionStatus:  domain.CVStatusExtracted,
+	}
+
+	tests := []struct {
+		name        string
+		cvs         []domain.EmployeeCV
+		want        []agent.StaffingCandidate
+		wantSkipped int
+	}{
+		{"no cvs", nil, nil, 0},
+		{
+			"extracted cv with degrees and project roles",
+			[]domain.EmployeeCV{lead},
+			[]agent.StaffingCandidate{{ID: lead.ID.String(), Profile: leadProfile}},
+			0,
+		},
+		{
+			"pending and failed extractions are skipped",
+			[]domain.EmployeeCV{
+				{ID: uuid.New(), ExtractionStatus: domain.CVStatusProcessing},
+				lead,
+				{ID: uuid.New(), ExtractionStatus: domain.CVStatusFailed},
+			},
+			[]agent.StaffingCandidate{{ID: lead.ID.String(), Profile: leadProfile}},
+			2,
+		},
+		{
+			"invalid json keeps the remaining profile",
+			[]domain.EmployeeCV{broken},
+			[]agent.StaffingCandidate{{ID: broken.ID.String(), Profile: agent.CVProfile{FullName: "Jonas Brandt", YearsOfExperience: 3}}},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skipped := staffingCandidates(tt.cvs)
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staffingCandidates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
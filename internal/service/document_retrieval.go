@@ -0,0 +1,186 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BM25-Parameter (Standardwerte)
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// sourceDocument ist ein Dokument einer Ausschreibung (Beschreibung oder OCR-Anhang), seitenweise
+type sourceDocument struct {
+	Name  string
+	Pages []string
+}
+
+// documentChunk ist ein Textabschnitt eines Dokuments mit Seitenangabe (1-basiert)
+type documentChunk struct {
+	DocIndex int
+	Document string
+	Page     int
+	Seq      int
+	Text     string
+}
+
+// splitOCRPages teilt einen OCR-Text an den Seitentrennern des OCRService
+func splitOCRPages(text string) []string {
+	return strings.Split(text, ocrPageSeparator)
+}
+
+// chunkDocuments zerlegt Dokumente seitenweise in Abschnitte von höchstens maxChars Zeichen.
+// Geschnitten wird an Absatz- bzw. Wortgrenzen.
+func chunkDocuments(docs []sourceDocument, maxChars int) []documentChunk {
+	var chunks []documentChunk
+	for docIndex, doc := range docs {
+		seq := 0
+		for pageIndex, page := range doc.Pages {
+			for _, part := range splitText(page, maxChars) {
+				chunks = append(chunks, documentChunk{
+					DocIndex: docIndex,
+					Document: doc.Name,
+					Page:     pageIndex + 1,
+					Seq:      seq,
+					Text:     part,
+				})
+				seq++
+			}
+		}
+	}
+	return chunks
+}
+
+func splitText(text string, maxChars int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	var parts []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			parts = append(parts, s)
+		}
+		current.Reset()
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(paragraph)+2 > maxChars {
+			flush()
+		}
+		// Überlange Absätze an Wortgrenzen zerlegen
+		for len(paragraph) > maxChars {
+			cut := strings.LastIndexAny(paragraph[:maxChars], " \n")
+			if cut <= 0 {
+				cut = maxChars
+				for cut > 0 && !utf8.RuneStart(paragraph[cut]) {
+					cut--
+				}
+			}
+			current.WriteString(paragraph[:cut])
+			flush()
+			paragraph = strings.TrimSpace(paragraph[cut:])
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	flush()
+
+	return parts
+}
+
+// rankChunksBM25 bewertet die Abschnitte lexikalisch gegen die Anfrage (BM25) und gibt
+// die Indizes absteigend nach Relevanz zurück. Abschnitte ohne Treffer werden ausgelassen.
+func rankChunksBM25(query string, chunks []documentChunk) []int {
+	queryTerms := tokenize(query)
+	if len(queryTerms) == 0 || len(chunks) == 0 {
+		return nil
+	}
+
+	termFreqs := make([]map[string]int, len(chunks))
+	docFreq := make(map[string]int)
+	var totalLen float64
+	for i, chunk := range chunks {
+		tf := make(map[string]int)
+		tokens := tokenize(chunk.Text)
+		for _, t := range tokens {
+			tf[t]++
+		}
+		for t := range tf {
+			docFreq[t]++
+		}
+		termFreqs[i] = tf
+		totalLen += float64(len(tokens))
+	}
+	avgLen := totalLen / float64(len(chunks))
+	n := float64(len(chunks))
+
+	type scored struct {
+		index int
+		score float64
+	}
+	var results []scored
+	for i, tf := range termFreqs {
+		var docLen float64
+		for _, c := range tf {
+			docLen += float64(c)
+		}
+
+		var score float64
+		for _, term := range queryTerms {
+			f := float64(tf[term])
+			if f == 0 {
+				continue
+			}
+			df := float64(docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * (f * (bm25K1 + 1)) / (f + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+		}
+		if score > 0 {
+			results = append(results, scored{index: i, score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool { return results[a].score > results[b].score })
+
+	indexes := make([]int, len(results))
+	for i, r := range results {
+		indexes[i] = r.index
+	}
+	return indexes
+}
+
+// germanStopwords filtert häufige Füllwörter aus Anfrage und Abschnitten
+var germanStopwords = map[string]bool{
+	"der": true, "die": true, "das": true, "und": true, "oder": true, "ist": true, "sind": true,
+	"ein": true, "eine": true, "einer": true, "eines": true, "dem": true, "den": true, "des": true,
+	"mit": true, "von": true, "für": true, "auf": true, "im": true, "in": true, "zu": true, "zur": true,
+	"zum": true, "bei": true, "als": true, "an": true, "am": true, "auch": true, "nicht": true,
+	"wird": true, "werden": true, "kann": true, "können": true, "muss": true, "müssen": true,
+	"sich": true, "es": true, "wie": true, "was": true, "wer": true, "nach": true, "aus": true,
+}
+
+// tokenize zerlegt Text in kleingeschriebene Terme (Buchstaben/Ziffern, ohne Stoppwörter)
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) < 2 || germanStopwords[f] {
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Minimales Word-Dokument (Office Open XML) ohne externe Bibliothek. Unterstützt wird das
+// Markdown, das die Agents erzeugen: Überschriften, Absätze, Aufzählungen, **fett**, *kursiv*.
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:lang w:val="de-DE"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="30"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="80"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="60"/><w:ind w:left="720" w:hanging="360"/></w:pPr></w:style>
+</w:styles>`
+
+// DIN A4 mit üblichen Rändern
+const docxSectionProperties = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1417" w:right="1417" w:bottom="1134" w:left="1417" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`
+
+// renderMarkdownDocx wandelt Markdown in ein .docx-Dokument um. Die erste Überschrift
+// der Ebene 1 wird zum Dokumenttitel.
+func renderMarkdownDocx(markdown string) ([]byte, error) {
+	var body strings.Builder
+	var paragraph []string
+	titleDone := false
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			writeDocxParagraph(&body, "", strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			text := strings.TrimSpace(trimmed[level:])
+			style := "Heading3"
+			switch {
+			case level == 1 && !titleDone:
+				style, titleDone = "Title", true
+			case level <= 2:
+				style = "Heading1"
+			case level == 3:
+				style = "Heading2"
+			}
+			writeDocxParagraph(&body, style, text)
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "), strings.HasPrefix(trimmed, "+ "):
+			flush()
+			writeDocxParagraph(&body, "ListParagraph", "•\t"+strings.TrimSpace(trimmed[2:]))
+		case isOrderedListItem(trimmed):
+			flush()
+			number, text, _ := strings.Cut(trimmed, " ")
+			writeDocxParagraph(&body, "ListParagraph", number+"\t"+strings.TrimSpace(text))
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		body.String() + docxSectionProperties + `</w:body></w:document>`
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/styles.xml", docxStyles},
+		{"word/document.xml", document},
+	} {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("create docx part %s: %w", part.name, err)
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("write docx part %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close docx: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// isOrderedListItem erkennt "1. Text"
+func isOrderedListItem(line string) bool {
+	number, _, ok := strings.Cut(line, ". ")
+	if !ok || number == "" || len(number) > 3 {
+		return false
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// writeDocxParagraph schreibt einen Absatz; **fett** und *kursiv* werden zu Formatierungen
+func writeDocxParagraph(b *strings.Builder, style, text string) {
+	b.WriteString("<w:p>")
+	if style != "" {
+		fmt.Fprintf(b, `<w:pPr><w:pStyle w:val="%s"/></w:pPr>`, style)
+	}
+
+	bold, italic := false, false
+	var run strings.Builder
+	writeRun := func() {
+		if run.Len() == 0 {
+			return
+		}
+		b.WriteString("<w:r>")
+		if bold || italic {
+			b.WriteString("<w:rPr>")
+			if bold {
+				b.WriteString("<w:b/>")
+			}
+			if italic {
+				b.WriteString("<w:i/>")
+			}
+			b.WriteString("</w:rPr>")
+		}
+		for i, part := range strings.Split(run.String(), "\t") {
+			if i > 0 {
+				b.WriteString("<w:tab/>")
+			}
+			if part != "" {
+				b.WriteString(`<w:t xml:space="preserve">`)
+				_ = xml.EscapeText(b, []byte(part))
+				b.WriteString("</w:t>")
+			}
+		}
+		b.WriteString("</w:r>")
+		run.Reset()
+	}
+
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], "**"):
+			writeRun()
+			bold = !bold
+			i++
+		case text[i] == '*':
+			writeRun()
+			italic = !italic
+		default:
+			run.WriteByte(text[i])
+		}
+	}
+	writeRun()
+	b.WriteString("</w:p>")
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+// Liegt die Firma mindestens bei diesem Anteil der Anforderung, gilt sie als "at_risk"
+// statt "ineligible" (Umsatz und Mitarbeiter stammen aus groben Onboarding-Spannen).
+const eligibilityTolerance = 0.5
+
+// TenderRequirements sind die aus den Vergabeunterlagen extrahierten Mindestanforderungen
+type TenderRequirements struct {
+	MinAnnualRevenue     float64
+	MinEmployeeCount     int
+	RequiredCertificates []string
+}
+
+// certificatePatterns ordnet kanonischen Nachweisen ihre Schreibweisen in Unterlagen zu
+var certificatePatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"ISO 9001", regexp.MustCompile(`(?i)\biso\s*9001`)},
+	{"ISO 14001", regexp.MustCompile(`(?i)\biso\s*14001`)},
+	{"ISO 27001", regexp.MustCompile(`(?i)\biso\s*(?:/\s*iec\s*)?27001`)},
+	{"ISO 45001", regexp.MustCompile(`(?i)\biso\s*45001`)},
+	{"ISO 50001", regexp.MustCompile(`(?i)\biso\s*50001`)},
+	{"SCC", regexp.MustCompile(`\bSCC\b|(?i)\bscc-zertifi`)},
+	{"Entsorgungsfachbetrieb", regexp.MustCompile(`(?i)entsorgungsfachbetrieb`)},
+	{"Meisterbrief", regexp.MustCompile(`(?i)meisterbrief|meisterprüfung`)},
+	{"Präqualifikation", regexp.MustCompile(`(?i)präqualifi|\bpq-vob\b|\bavpq\b`)},
+	{"Haftpflichtversicherung", regexp.MustCompile(`(?i)(?:betriebs|berufs)haftpflicht|haftpflichtversicherung`)},
+}
+
+const (
+	certificatePrequalification   = "Präqualifikation"
+	certificateLiabilityInsurance = "Haftpflichtversicherung"
+)
+
+var (
+	revenueKeywordRe = regexp.MustCompile(`(?i)(mindest)?(?:jahres|gesamt|netto)?umsatz`)
+	// Beträge wie "10 Mio. EUR", "1.500.000,00 €", "EUR 2 Mio."
+	amountRe      = regexp.MustCompile(`(?i)(?:(?:eur(?:o)?|€)\s*(\d{1,3}(?:[.\s]\d{3})+(?:,\d+)?|\d+(?:,\d+)?)\s*(mio\.?|millionen|tsd\.?|tausend)?|(\d{1,3}(?:[.\s]\d{3})+(?:,\d+)?|\d+(?:,\d+)?)\s*(mio\.?|millionen|tsd\.?|tausend|t€|k€)?\s*(?:eur(?:o)?\b|€))`)
+	minimumHintRe = regexp.MustCompile(`(?i)mindestens|wenigstens|\bmin\.|nicht\s+(?:unter|weniger\s+als)|≥|>=`)
+
+	employeeMinFirstRe  = regexp.MustCompile(`(?i)(?:mindestens|wenigstens|\bmin\.)\s*(\d{1,5})\s+(?:[a-zäöüß-]+\s+){0,2}(?:beschäftigte|mitarbeiter(?:innen)?|mitarbeitende|arbeitnehmer(?:innen)?|arbeitskräfte|fachkräfte)`)
+	employeeNounFirstRe = regexp.MustCompile(`(?i)(?:beschäftigtenzahl|mitarbeiterzahl|mitarbeiteranzahl|anzahl\s+der\s+(?:beschäftigten|mitarbeiter(?:innen)?|mitarbeitenden))\s+(?:von\s+)?(?:mindestens|wenigstens|min\.)\s*(\d{1,5})`)
+
+	// Zertifikate zählen nur in Sätzen, die sie als Nachweis fordern und nicht ausdrücklich erlassen
+	certificateDemandRe = regexp.MustCompile(`(?i)nachweis|nachzuweisen|vorzulegen|vorlage|erforderlich|gefordert|verlangt|beizufügen|einzureichen|voraussetzung`)
+	certificateWaivedRe = regexp.MustCompile(`(?i)\bnicht\s+(?:mehr\s+)?(?:erforderlich|gefordert|verlangt|notwendig|nachzuweisen|vorzulegen)|\bkein(?:e|en)?\s+(?:nachweis|zertifi)|entfällt`)
+	// Satzende: Satzzeichen vor einem Großbuchstaben, Semikolon oder Zeilenumbruch ("bzw." trennt nicht)
+	sentenceEndRe = regexp.MustCompile(`[.!?]\s+[A-ZÄÖÜ]|[;\n]`)
+)
+
+// ExtractTenderRequirements sucht Mindestumsatz, Mindestbeschäftigtenzahl und
+// geforderte Zertifikate per Regex. Bei mehreren Angaben gilt die strengste.
+func ExtractTenderRequirements(text string) TenderRequirements {
+	var req TenderRequirements
+	if strings.TrimSpace(text) == "" {
+		return req
+	}
+
+	// Umsatz: Betrag im Umfeld eines Umsatz-Stichworts, das als Mindestanforderung formuliert ist
+	for _, loc := range revenueKeywordRe.FindAllStringSubmatchIndex(text, -1) {
+		if strings.HasPrefix(strings.ToLower(text[loc[1]:min(len(text), loc[1]+6)]), "steuer") {
+			continue
+		}
+		window := text[loc[0]:min(len(text), loc[1]+160)]
+		isMinimum := loc[2] >= 0 || minimumHintRe.MatchString(window)
+		if !isMinimum {
+			continue
+		}
+		if amount, ok := parseAmount(window); ok && amount > req.MinAnnualRevenue {
+			req.MinAnnualRevenue = amount
+		}
+	}
+
+	// Beschäftigte
+	for _, re := range []*regexp.Regexp{employeeMinFirstRe, employeeNounFirstRe} {
+		for _, m := range re.FindAllStringSubmatch(text, -1) {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > req.MinEmployeeCount {
+				req.MinEmployeeCount = n
+			}
+		}
+	}
+
+	// Zertifikate: nur in Sätzen mit einer Nachweis-Formulierung, z.B. nicht in Vertrags- oder Haftungsklauseln
+	var demands []string
+	for _, sentence := range splitSentences(text) {
+		if certificateDemandRe.MatchString(sentence) && !certificateWaivedRe.MatchString(sentence) {
+			demands = append(demands, sentence)
+		}
+	}
+	for _, cert := range certificatePatterns {
+		for _, sentence := range demands {
+			if cert.pattern.MatchString(sentence) {
+				req.RequiredCertificates = append(req.RequiredCertificates, cert.name)
+				break
+			}
+		}
+	}
+
+	return req
+}
+
+// splitSentences zerlegt den Text grob in Sätze
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEndRe.FindAllStringIndex(text, -1) {
+		end := loc[0] + 1
+		sentences = append(sentences, text[start:end])
+		start = end
+	}
+	return append(sentences, text[start:])
+}
+
+// parseAmount liest den ersten Euro-Betrag aus s (deutsches Zahlenformat)
+func parseAmount(s string) (float64, bool) {
+	m := amountRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+
+	number, unit := m[1], m[2]
+	if number == "" {
+		number, unit = m[3], m[4]
+	}
+
+	number = strings.NewReplacer(".", "", " ", "", ",", ".").Replace(number)
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+
+	switch strings.TrimSuffix(strings.ToLower(unit), ".") {
+	case "mio", "millionen":
+		value *= 1_000_000
+	case "tsd", "tausend", "t€", "k€":
+		value *= 1_000
+	}
+	return value, true
+}
+
+// EligibilityService hält die Mindestanforderungen der Ausschreibungen aktuell
+type EligibilityService struct {
+	db *gorm.DB
+}
+
+func NewEligibilityService(db *gorm.DB) *EligibilityService {
+	return &EligibilityService{db: db}
+}
+
+// RefreshTenderRequirements extrahiert die Anforderungen aus Beschreibung und allen
+// verarbeiteten Anhängen neu und speichert sie an der Ausschreibung.
+func (s *EligibilityService) RefreshTenderRequirements(ctx context.Context, tenderID uuid.UUID) (*TenderRequirements, error) {
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).
+		Select("id", "description_full", "ocr_compressed_text").
+		First(&tender, "id = ?", tenderID).Error; err != nil {
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	var attachmentTexts []string
+	if err := s.db.WithContext(ctx).Model(&domain.TenderAttachment{}).
+		Where("tender_id = ? AND ocr_processed = ?", tenderID, true).
+		Pluck("content_ocr", &attachmentTexts).Error; err != nil {
+		return nil, fmt.Errorf("load attachments failed: %w", err)
+	}
+
+	texts := append([]string{tender.DescriptionFull, tender.OCRCompressedText}, attachmentTexts...)
+	req := ExtractTenderRequirements(strings.Join(texts, "\n\n"))
+
+	updates := map[string]any{
+		"min_annual_revenue":    nil,
+		"min_employee_count":    nil,
+		"required_certificates": pq.StringArray(req.RequiredCertificates),
+	}
+	if req.MinAnnualRevenue > 0 {
+		updates["min_annual_revenue"] = req.MinAnnualRevenue
+	}
+	if req.MinEmployeeCount > 0 {
+		updates["min_employee_count"] = req.MinEmployeeCount
+	}
+	if req.RequiredCertificates == nil {
+		updates["required_certificates"] = pq.StringArray{}
+	}
+
+	if err := s.db.WithContext(ctx).Model(&domain.Tender{}).
+		Where("id = ?", tenderID).
+		Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("save requirements failed: %w", err)
+	}
+
+	return &req, nil
+}
+
+// evaluateEligibility vergleicht die Mindestanforderungen mit dem Firmenprofil.
+// Umsatz/Mitarbeiter unterhalb der Toleranz schließen aus, nicht hinterlegte Werte sind nur
+// ein Risiko. Fehlende, abgelaufene oder vor der Angebotsfrist ablaufende Zertifikate sind
+// ebenfalls nur ein Risiko, weil gleichwertige Nachweise zulässig sind (§ 49 VgV) und
+// Verlängerungen nachgereicht werden können.
+func evaluateEligibility(company *domain.Company, held map[string]certificateHolding, today, deadline time.Time, minRevenue sql.NullFloat64, minEmployees sql.NullInt64, certificates []string) (string, []domain.EligibilityIssue) {
+	var issues []domain.EligibilityIssue
+
+	if minRevenue.Valid && minRevenue.Float64 > 0 {
+		required := minRevenue.Float64
+		switch {
+		case company.AnnualRevenue <= 0:
+			issues = append(issues, domain.EligibilityIssue{
+				Criterion: "turnover",
+				Severity:  domain.EligibilityAtRisk,
+				Message:   fmt.Sprintf("Mindestumsatz %s gefordert, Ihr Umsatz ist nicht hinterlegt", formatEuro(required)),
+			})
+		case company.AnnualRevenue < required*eligibilityTolerance:
+			issues = append(issues, domain.EligibilityIssue{
+				Criterion: "turnover",
+				Severity:  domain.EligibilityIneligible,
+				Message:   fmt.Sprintf("Mindestumsatz %s gefordert, Ihr Umsatz liegt bei ca. %s", formatEuro(required), formatEuro(company.AnnualRevenue)),
+			})
+		case company.AnnualRevenue < required:
+			issues = append(issues, domain.EligibilityIssue{
+				Criterion: "turnover",
+				Severity:  domain.EligibilityAtRisk,
+				Message:   fmt.Sprintf("Mindestumsatz %s gefordert, Ihr Umsatz liegt knapp darunter (ca. %s)", formatEuro(required), formatEuro(company.AnnualRevenue)),
+			})
+		}
+	}
+
+	if minEmployees.Valid && minEmployees.Int64 > 0 {
+		required := int(minEmployees.Int64)
+		switch {
+		case company.EmployeeCount <= 0:
+			issues = append(issues, domain.EligibilityIssue{
+				Criterion: "headcount",
+				Severity:  domain.EligibilityAtRisk,
+				Message:   fmt.Sprintf("Mindestens %d Beschäftigte gefordert, Ihre Mitarbeiterzahl ist nicht hinterlegt", required),
+			})
+		case float64(company.EmployeeCount) < float64(required)*eligibilityTolerance:
+			issues = append(issues, domain.EligibilityIssue{
+				Criterion: "headcount",
+				Severity:  domain.EligibilityIneligible,
+				Message:   fmt.Sprintf("Mindestens %d Beschäftigte gefordert, Sie haben ca. %d", required, company.EmployeeCount),
+			})
+		case company.EmployeeCount < required:
+			issues = append(issues, domain.EligibilityIssue{
+				Criterion: "headcount",
+				Severity:  domain.EligibilityAtRisk,
+				Message:   fmt.Sprintf("Mindestens %d Beschäftigte gefordert, Sie liegen knapp darunter (ca. %d)", required, company.EmployeeCount),
+			})
+		}
+	}
+
+	for _, cert := range certificates {
+		holding, ok := held[cert]
+		var message string
+		switch {
+		case !ok && cert == certificatePrequalification:
+			message = "Präqualifikation (AVPQ/PQ-VOB) gefordert oder erwünscht, Ihre Firma ist nicht präqualifiziert"
+		case !ok:
+			message = fmt.Sprintf("Nachweis \"%s\" gefordert, aber nicht im Profil hinterlegt", cert)
+		case holding.validUntil == nil:
+			continue
+		case holding.validUntil.Before(today):
+			message = fmt.Sprintf("Nachweis \"%s\" gefordert, Ihr Nachweis ist seit %s abgelaufen", cert, holding.validUntil.Format("02.01.2006"))
+		case !deadline.IsZero() && holding.validUntil.Before(germanToday(deadline)):
+			message = fmt.Sprintf("Nachweis \"%s\" gefordert, Ihr Nachweis läuft am %s vor Ende der Angebotsfrist (%s) ab",
+				cert, holding.validUntil.Format("02.01.2006"), germanToday(deadline).Format("02.01.2006"))
+		default:
+			continue
+		}
+		issues = append(issues, domain.EligibilityIssue{
+			Criterion: "certificate",
+			Severity:  domain.EligibilityAtRisk,
+			Message:   message,
+		})
+	}
+
+	status := domain.EligibilityEligible
+	for _, issue := range issues {
+		if issue.Severity == domain.EligibilityIneligible {
+			status = domain.EligibilityIneligible
+			break
+		}
+		status = domain.EligibilityAtRisk
+	}
+
+	// Ausschließende Kriterien zuerst anzeigen
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Severity == domain.EligibilityIneligible && issues[j].Severity != domain.EligibilityIneligible
+	})
+
+	return status, issues
+}
+
+// certificateHolding ist ein Nachweis der Firma; validUntil nil = unbefristet oder ohne Angabe
+type certificateHolding struct {
+	validUntil *time.Time
+}
+
+// companyCertificates ermittelt die Nachweise der Firma. Maßgeblich sind die strukturierten
+// Nachweise (bei mehreren gleicher Art der am längsten gültige); ohne solche wird wie bisher
+// das Onboarding-JSON durchsucht. Das AVPQ-Häkchen zählt, solange keine Präqualifikation
+// mit Ablaufdatum hinterlegt ist.
+func companyCertificates(company *domain.Company, records []domain.CompanyCertificate) map[string]certificateHolding {
+	held := make(map[string]certificateHolding)
+	for i := range records {
+		requirement := certificateRequirement(&records[i])
+		if requirement == "" {
+			continue
+		}
+		current, ok := held[requirement]
+		until := records[i].ValidUntil
+		if !ok || (current.validUntil != nil && (until == nil || until.After(*current.validUntil))) {
+			held[requirement] = certificateHolding{validUntil: until}
+		}
+	}
+
+	if len(records) == 0 {
+		raw := string(company.Certifications)
+		for _, cert := range certificatePatterns {
+			if cert.pattern.MatchString(raw) {
+				held[cert.name] = certificateHolding{}
+			}
+		}
+	}
+	if _, ok := held[certificatePrequalification]; !ok && company.IsAVPQ {
+		held[certificatePrequalification] = certificateHolding{}
+	}
+	return held
+}
+
+// formatEuro formatiert Beträge lesbar ("2,5 Mio. €", "750.000 €")
+func formatEuro(value float64) string {
+	if value >= 1_000_000 {
+		s := strconv.FormatFloat(math.Round(value/100_000)/10, 'f', -1, 64)
+		return strings.Replace(s, ".", ",", 1) + " Mio. €"
+	}
+
+	digits := strconv.FormatInt(int64(math.Round(value)), 10)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + " €"
+}
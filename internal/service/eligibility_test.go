@@ -0,0 +1,251 @@
+package service
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+func TestExtractTenderRequirements(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want TenderRequirements
+	}{
+		{"empty", "   ", TenderRequirements{}},
+		{
+			"minimum revenue in millions",
+			"Mindestumsatz von 2 Mio. EUR in den letzten drei Geschäftsjahren",
+			TenderRequirements{MinAnnualRevenue: 2_000_000},
+		},
+		{
+			"revenue with minimum hint",
+			"Der Jahresumsatz muss mindestens 1.500.000,00 € betragen.",
+			TenderRequirements{MinAnnualRevenue: 1_500_000},
+		},
+		{
+			"revenue in thousands",
+			"Gesamtumsatz nicht unter 750 Tsd. EUR",
+			TenderRequirements{MinAnnualRevenue: 750_000},
+		},
+		{
+			"strictest revenue wins",
+			"Mindestumsatz 1 Mio. EUR (Los 1).\n\n" + "Weitere Angaben folgen in den Unterlagen. " +
+				"Für Los 2 gelten abweichende Bedingungen, die in der Leistungsbeschreibung ausführlich " +
+				"beschrieben sind und hier nicht wiederholt werden.\n\nMindestumsatz 3 Mio. EUR (Los 2).",
+			TenderRequirements{MinAnnualRevenue: 3_000_000},
+		},
+		{
+			"revenue without minimum is ignored",
+			"Der Umsatz des Auftrags wird auf 500.000 EUR geschätzt.",
+			TenderRequirements{},
+		},
+		{
+			"value added tax is ignored",
+			"Umsatzsteuer mindestens auf 100.000 EUR ausweisen",
+			TenderRequirements{},
+		},
+		{
+			"employees count first",
+			"Der Bieter muss mindestens 20 Beschäftigte nachweisen.",
+			TenderRequirements{MinEmployeeCount: 20},
+		},
+		{
+			"employees with adjective",
+			"mindestens 10 festangestellte Mitarbeiter",
+			TenderRequirements{MinEmployeeCount: 10},
+		},
+		{
+			"strictest headcount wins",
+			"mindestens 20 Beschäftigte; Beschäftigtenzahl von mindestens 50 im Jahresmittel",
+			TenderRequirements{MinEmployeeCount: 50},
+		},
+		{
+			"certificates in canonical order",
+			"Nachweis einer Betriebshaftpflicht sowie Zertifizierung nach DIN EN ISO 14001 und ISO 9001, ISO/IEC 27001",
+			TenderRequirements{RequiredCertificates: []string{"ISO 9001", "ISO 14001", "ISO 27001", "Haftpflichtversicherung"}},
+		},
+		{
+			"certificate required with a verb",
+			"Eine gültige SCC-Zertifizierung ist vorzulegen.",
+			TenderRequirements{RequiredCertificates: []string{"SCC"}},
+		},
+		{
+			"waived certificate is ignored",
+			"Nachweis der Zertifizierung nach ISO 9001 bzw. gleichwertig. ISO 14001 wird nicht verlangt.",
+			TenderRequirements{RequiredCertificates: []string{"ISO 9001"}},
+		},
+		{
+			"certificate not required",
+			"ISO 9001 wird nicht verlangt.",
+			TenderRequirements{},
+		},
+		{
+			"insurance clause in contract terms",
+			"§ 12 Haftung: Der Auftragnehmer hat während der Vertragslaufzeit eine Betriebshaftpflichtversicherung zu unterhalten.",
+			TenderRequirements{},
+		},
+		{
+			"certificate mentioned without demand",
+			"Der Auftraggeber ist selbst nach ISO 27001 zertifiziert.",
+			TenderRequirements{},
+		},
+		{
+			"unrelated SCC abbreviation",
+			"Störungen sind an das SCC (Service Control Center) der Stadtwerke zu melden.\nDie Eignung ist durch Referenzen nachzuweisen.",
+			TenderRequirements{},
+		},
+		{
+			"prequalification",
+			"Präqualifizierte Unternehmen (AVPQ) können die Eignung vereinfacht nachweisen.",
+			TenderRequirements{RequiredCertificates: []string{"Präqualifikation"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractTenderRequirements(tt.text)
+			if got.MinAnnualRevenue != tt.want.MinAnnualRevenue ||
+				got.MinEmployeeCount != tt.want.MinEmployeeCount ||
+				!slices.Equal(got.RequiredCertificates, tt.want.RequiredCertificates) {
+				t.Errorf("ExtractTenderRequirements(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateEligibility(t *testing.T) {
+	today := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	deadline := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	expired := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	beforeDeadline := time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)
+	afterDeadline := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	revenue := func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} }
+	employees := func(v int64) sql.NullInt64 { return sql.NullInt64{Int64: v, Valid: true} }
+
+	tests := []struct {
+		name         string
+		company      domain.Company
+		held         map[string]certificateHolding
+		minRevenue   sql.NullFloat64
+		minEmployees sql.NullInt64
+		certificates []string
+		wantStatus   string
+		// Kriterien der Hinweise in Ausgabereihenfolge
+		wantCriteria []string
+	}{
+		{
+			name:       "no requirements",
+			wantStatus: domain.EligibilityEligible,
+		},
+		{
+			name:       "revenue not set",
+			minRevenue: revenue(1_000_000),
+			wantStatus: domain.EligibilityAtRisk, wantCriteria: []string{"turnover"},
+		},
+		{
+			name:       "revenue far below",
+			company:    domain.Company{AnnualRevenue: 400_000},
+			minRevenue: revenue(1_000_000),
+			wantStatus: domain.EligibilityIneligible, wantCriteria: []string{"turnover"},
+		},
+		{
+			name:       "revenue slightly below",
+			company:    domain.Company{AnnualRevenue: 800_000},
+			minRevenue: revenue(1_000_000),
+			wantStatus: domain.EligibilityAtRisk, wantCriteria: []string{"turnover"},
+		},
+		{
+			name:       "revenue sufficient",
+			company:    domain.Company{AnnualRevenue: 1_000_000},
+			minRevenue: revenue(1_000_000),
+			wantStatus: domain.EligibilityEligible,
+		},
+		{
+			name:         "headcount not set",
+			minEmployees: employees(20),
+			wantStatus:   domain.EligibilityAtRisk, wantCriteria: []string{"headcount"},
+		},
+		{
+			name:         "headcount far below",
+			company:      domain.Company{EmployeeCount: 5},
+			minEmployees: employees(20),
+			wantStatus:   domain.EligibilityIneligible, wantCriteria: []string{"headcount"},
+		},
+		{
+			name:         "headcount slightly below",
+			company:      domain.Company{EmployeeCount: 15},
+			minEmployees: employees(20),
+			wantStatus:   domain.EligibilityAtRisk, wantCriteria: []string{"headcount"},
+		},
+		{
+			name:         "headcount sufficient",
+			company:      domain.Company{EmployeeCount: 20},
+			minEmployees: employees(20),
+			wantStatus:   domain.EligibilityEligible,
+		},
+		{
+			name:         "missing profile values are never ineligible",
+			minRevenue:   revenue(5_000_000),
+			minEmployees: employees(100),
+			wantStatus:   domain.EligibilityAtRisk, wantCriteria: []string{"turnover", "headcount"},
+		},
+		{
+			name:         "ineligible issues first",
+			company:      domain.Company{AnnualRevenue: 800_000, EmployeeCount: 5},
+			minRevenue:   revenue(1_000_000),
+			minEmployees: employees(20),
+			certificates: []string{"ISO 9001"},
+			wantStatus:   domain.EligibilityIneligible, wantCriteria: []string{"headcount", "turnover", "certificate"},
+		},
+		{
+			name:         "certificate missing",
+			certificates: []string{"ISO 9001"},
+			wantStatus:   domain.EligibilityAtRisk, wantCriteria: []string{"certificate"},
+		},
+		{
+			name:         "prequalification missing",
+			certificates: []string{certificatePrequalification},
+			wantStatus:   domain.EligibilityAtRisk, wantCriteria: []string{"certificate"},
+		},
+		{
+			name:         "certificate without expiry",
+			held:         map[string]certificateHolding{"ISO 9001": {}},
+			certificates: []string{"ISO 9001"},
+			wantStatus:   domain.EligibilityEligible,
+		},
+		{
+			name:         "certificate expired",
+			held:         map[string]certificateHolding{"ISO 9001": {validUntil: &expired}},
+			certificates: []string{"ISO 9001"},
+			wantStatus:   domain.EligibilityAtRisk, wantCriteria: []string{"certificate"},
+		},
+		{
+			name:         "certificate expires before deadline",
+			held:         map[string]certificateHolding{"ISO 9001": {validUntil: &beforeDeadline}},
+			certificates: []string{"ISO 9001"},
+			wantStatus:   domain.EligibilityAtRisk, wantCriteria: []string{"certificate"},
+		},
+		{
+			name:         "certificate valid beyond deadline",
+			held:         map[string]certificateHolding{"ISO 9001": {validUntil: &afterDeadline}},
+			certificates: []string{"ISO 9001"},
+			wantStatus:   domain.EligibilityEligible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, issues := evaluateEligibility(&tt.company, tt.held, today, deadline, tt.minRevenue, tt.minEmployees, tt.certificates)
+			var criteria []string
+			for _, issue := range issues {
+				criteria = append(criteria, issue.Criterion)
+			}
+			if status != tt.wantStatus || !slices.Equal(criteria, tt.wantCriteria) {
+				t.Errorf("evaluateEligibility = %s %v, want %s %v", status, criteria, tt.wantStatus, tt.wantCriteria)
+			}
+		})
+	}
+}
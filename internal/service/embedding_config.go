@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	openaiembed "github.com/cloudwego/eino-ext/components/embedding/openai"
+	"github.com/cloudwego/eino/components/embedding"
 	orclient "github.com/vergabe-agent/vergabe-backend/internal/openrouter"
+	"github.com/vergabe-agent/vergabe-backend/internal/usage"
 )
 
 const defaultEmbeddingModel = "text-embedding-3-small"
@@ -18,9 +20,11 @@ type EmbeddingProviderConfig struct {
 	BaseURL string
 	AppName string
 	AppURL  string
+	// Usage erfasst Tokens und Kosten der Embedding-Aufrufe (nil = keine Erfassung)
+	Usage usage.Recorder
 }
 
-func newEmbeddingClient(ctx context.Context, cfg EmbeddingProviderConfig) (*openaiembed.Embedder, error) {
+func newEmbeddingClient(ctx context.Context, cfg EmbeddingProviderConfig) (embedding.Embedder, error) {
 	apiKey := strings.TrimSpace(cfg.APIKey)
 	if apiKey == "" {
 		return nil, errors.New("missing embedding API key")
@@ -36,10 +40,14 @@ func newEmbeddingClient(ctx context.Context, cfg EmbeddingProviderConfig) (*open
 		baseURL = orclient.DefaultBaseURL
 	}
 
-	return openaiembed.NewEmbedder(ctx, &openaiembed.EmbeddingConfig{
+	emb, err := openaiembed.NewEmbedder(ctx, &openaiembed.EmbeddingConfig{
 		APIKey:     apiKey,
 		Model:      model,
 		BaseURL:    baseURL,
 		HTTPClient: orclient.NewHTTPClient(cfg.AppURL, cfg.AppName),
 	})
+	if err != nil {
+		return nil, err
+	}
+	return usage.WrapEmbedder(emb, cfg.Usage, model), nil
 }
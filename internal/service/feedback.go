@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var (
+	ErrInvalidFeedback  = errors.New("invalid feedback")
+	ErrFeedbackNotFound = errors.New("feedback not found")
+	ErrTenderNotFound   = errors.New("tender not found")
+)
+
+type FeedbackService struct {
+	db *gorm.DB
+}
+
+func NewFeedbackService(db *gorm.DB) *FeedbackService {
+	return &FeedbackService{db: db}
+}
+
+// FeedbackInput ist der Request-Body für Daumen hoch/runter auf einem Match
+type FeedbackInput struct {
+	Vote    string `json:"vote"`
+	Reason  string `json:"reason"`
+	Comment string `json:"comment"`
+}
+
+var validFeedbackReasons = map[string]bool{
+	domain.FeedbackReasonTooFar:     true,
+	domain.FeedbackReasonWrongTrade: true,
+	domain.FeedbackReasonTooBig:     true,
+	domain.FeedbackReasonOther:      true,
+}
+
+// RecordFeedback speichert das Feedback der Firma zu einer Ausschreibung.
+// Erneutes Bewerten derselben Ausschreibung überschreibt das vorherige Feedback.
+func (s *FeedbackService) RecordFeedback(ctx context.Context, authUserID, tenderID uuid.UUID, input FeedbackInput) (*domain.MatchFeedback, error) {
+	vote := strings.ToLower(strings.TrimSpace(input.Vote))
+	if vote != domain.FeedbackVoteUp && vote != domain.FeedbackVoteDown {
+		return nil, fmt.Errorf("%w: vote must be 'up' or 'down'", ErrInvalidFeedback)
+	}
+
+	reason := strings.ToLower(strings.TrimSpace(input.Reason))
+	if reason != "" && !validFeedbackReasons[reason] {
+		return nil, fmt.Errorf("%w: unknown reason %q", ErrInvalidFeedback, reason)
+	}
+	if vote == domain.FeedbackVoteUp {
+		// Gründe beschreiben nur, warum etwas NICHT passt
+		reason = ""
+	}
+
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&domain.Tender{}).Where("id = ?", tenderID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrTenderNotFound
+	}
+
+	now := time.Now()
+	feedback := &domain.MatchFeedback{
+		ID:         uuid.New(),
+		CompanyID:  company.ID,
+		TenderID:   tenderID,
+		AuthUserID: authUserID,
+		Vote:       vote,
+		Reason:     reason,
+		Comment:    strings.TrimSpace(input.Comment),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	err = s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}, {Name: "tender_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"auth_user_id", "vote", "reason", "comment", "updated_at"}),
+	}).Create(feedback).Error
+	if err != nil {
+		return nil, fmt.Errorf("save feedback failed: %w", err)
+	}
+
+	return feedback, nil
+}
+
+// ListFeedback gibt alle Feedbacks der Firma zurück (neueste zuerst)
+func (s *FeedbackService) ListFeedback(ctx context.Context, authUserID uuid.UUID) ([]domain.MatchFeedback, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var feedback []domain.MatchFeedback
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ?", company.ID).
+		Order("updated_at DESC").
+		Find(&feedback).Error; err != nil {
+		return nil, fmt.Errorf("load feedback failed: %w", err)
+	}
+
+	return feedback, nil
+}
+
+// DeleteFeedback nimmt ein Feedback zurück
+func (s *FeedbackService) DeleteFeedback(ctx context.Context, authUserID, tenderID uuid.UUID) error {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).
+		Where("company_id = ? AND tender_id = ?", company.ID, tenderID).
+		Delete(&domain.MatchFeedback{})
+	if result.Error != nil {
+		return fmt.Errorf("delete feedback failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFeedbackNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+// Word-Formulare kommen in drei Bauarten vor, die nacheinander befüllt werden:
+// Inhaltssteuerelemente (w:sdt) mit Titel/Tag, Tabellenzeilen "Bezeichnung | leere Zelle"
+// und Absätze "Bezeichnung: ________". Das XML wird gezielt ersetzt statt neu erzeugt,
+// damit Layout und Formatierung der Vorlage erhalten bleiben.
+
+var (
+	docxSdtPattern        = regexp.MustCompile(`(?s)<w:sdt>.*?</w:sdt>`)
+	docxSdtContentPattern = regexp.MustCompile(`(?s)<w:sdtContent>(.*)</w:sdtContent>`)
+	docxAliasPattern      = regexp.MustCompile(`<w:alias w:val="([^"]*)"/>`)
+	docxTagPattern        = regexp.MustCompile(`<w:tag w:val="([^"]*)"/>`)
+	docxTextPattern       = regexp.MustCompile(`(<w:t(?: [^>]*)?>)([^<]*)(</w:t>)`)
+	docxPlaceholderStyle  = regexp.MustCompile(`<w:rStyle w:val="(?:PlaceholderText|Platzhaltertext)"/>`)
+	docxRowPattern        = regexp.MustCompile(`(?s)<w:tr[ >].*?</w:tr>`)
+	docxCellPattern       = regexp.MustCompile(`(?s)<w:tc>.*?</w:tc>`)
+	docxParagraphPattern  = regexp.MustCompile(`(?s)<w:p[ >].*?</w:p>`)
+	docxEmptyParagraph    = regexp.MustCompile(`<w:p((?: [^>]*)?)/>`)
+	docxUnderscoreBlank   = regexp.MustCompile(`_{4,}`)
+)
+
+// docxLabelTrailingChars werden am Ende einer Bezeichnung entfernt ("Firma:*", geschützte Leerzeichen)
+const docxLabelTrailingChars = ": \t*\u00a0"
+
+var docxEntityReplacer = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+
+// fillDocxForm befüllt word/document.xml eines .docx; alle anderen Teile werden unverändert kopiert
+func fillDocxForm(docxBytes []byte, values formValues) ([]byte, []domain.FilledFormField, error) {
+	reader, document, xmlText, err := openDocxDocument(docxBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fields []domain.FilledFormField
+	xmlText = fillDocxContentControls(xmlText, values, &fields)
+	xmlText = fillDocxTableRows(xmlText, values, &fields)
+	xmlText = fillDocxUnderscoreBlanks(xmlText, values, &fields)
+	if len(fields) == 0 {
+		return nil, nil, errNoFormFields
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range reader.File {
+		if f != document {
+			if err := zw.Copy(f); err != nil {
+				return nil, nil, fmt.Errorf("copy docx part %s: %w", f.Name, err)
+			}
+			continue
+		}
+		header := f.FileHeader
+		w, err := zw.CreateHeader(&header)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create docx part %s: %w", f.Name, err)
+		}
+		if _, err := w.Write([]byte(xmlText)); err != nil {
+			return nil, nil, fmt.Errorf("write docx part %s: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("close docx: %w", err)
+	}
+	return buf.Bytes(), fields, nil
+}
+
+// openDocxDocument öffnet ein Word-Dokument und liefert den Hauptteil word/document.xml
+func openDocxDocument(docxBytes []byte) (*zip.Reader, *zip.File, string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(docxBytes), int64(len(docxBytes)))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("open docx: %w", err)
+	}
+
+	var document *zip.File
+	for _, f := range reader.File {
+		if f.Name == "word/document.xml" {
+			document = f
+			break
+		}
+	}
+	if document == nil {
+		return nil, nil, "", fmt.Errorf("open docx: word/document.xml missing")
+	}
+
+	rc, err := document.Open()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("read document.xml: %w", err)
+	}
+	xmlBytes, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("read document.xml: %w", err)
+	}
+	return reader, document, string(xmlBytes), nil
+}
+
+// docxDocumentText liefert den sichtbaren Text eines Word-Dokuments, ein Absatz je Zeile
+func docxDocumentText(docxBytes []byte) (string, error) {
+	_, _, xmlText, err := openDocxDocument(docxBytes)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, paragraph := range docxParagraphPattern.FindAllString(xmlText, -1) {
+		if line := strings.TrimSpace(docxPlainText(paragraph)); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// fillDocxContentControls befüllt Inhaltssteuerelemente, die noch ihren Platzhalter zeigen
+func fillDocxContentControls(xmlText string, values formValues, fields *[]domain.FilledFormField) string {
+	return docxSdtPattern.ReplaceAllStringFunc(xmlText, func(sdt string) string {
+		if strings.Count(sdt, "<w:sdt>") > 1 {
+			// Verschachtelte Steuerelemente lassen sich so nicht sicher abgrenzen
+			return sdt
+		}
+		var labels []string
+		if m := docxAliasPattern.FindStringSubmatch(sdt); m != nil {
+			labels = append(labels, docxEntityReplacer.Replace(m[1]))
+		}
+		if m := docxTagPattern.FindStringSubmatch(sdt); m != nil {
+			labels = append(labels, docxEntityReplacer.Replace(m[1]))
+		}
+		if len(labels) == 0 {
+			return sdt
+		}
+
+		if strings.Contains(sdt, "<w14:checkbox") {
+			*fields = append(*fields, domain.FilledFormField{Name: labels[0], Status: domain.FormFieldUnknown})
+			return sdt
+		}
+
+		field, ok := values.field(labels[0], labels...)
+		if !ok {
+			return sdt
+		}
+
+		content := docxSdtContentPattern.FindStringSubmatch(sdt)
+		current := ""
+		if content != nil {
+			current = strings.TrimSpace(docxPlainText(content[1]))
+		}
+		if current != "" && !strings.Contains(sdt, "<w:showingPlcHdr/>") {
+			field.Value, field.Status = current, domain.FormFieldPrefilled
+			*fields = append(*fields, field)
+			return sdt
+		}
+		*fields = append(*fields, field)
+		if field.Status != domain.FormFieldFilled || content == nil {
+			return sdt
+		}
+
+		filled := docxReplaceText(content[1], field.Value)
+		filled = docxPlaceholderStyle.ReplaceAllString(filled, "")
+		sdt = strings.Replace(sdt, "<w:showingPlcHdr/>", "", 1)
+		return strings.Replace(sdt, content[1], filled, 1)
+	})
+}
+
+// fillDocxTableRows befüllt leere Tabellenzellen rechts neben einer Bezeichnung
+func fillDocxTableRows(xmlText string, values formValues, fields *[]domain.FilledFormField) string {
+	return docxRowPattern.ReplaceAllStringFunc(xmlText, func(row string) string {
+		if strings.Contains(row, "<w:tbl>") {
+			// Verschachtelte Tabellen werden nicht angefasst
+			return row
+		}
+		cells := docxCellPattern.FindAllString(row, -1)
+		for i := 1; i < len(cells); i++ {
+			label := strings.TrimRight(strings.TrimSpace(docxPlainText(cells[i-1])), docxLabelTrailingChars)
+			if label == "" || !docxCellIsBlank(cells[i]) {
+				continue
+			}
+			field, ok := values.field(label, label)
+			if !ok {
+				continue
+			}
+			*fields = append(*fields, field)
+			if field.Status != domain.FormFieldFilled {
+				continue
+			}
+			filled := docxAppendToCell(cells[i], field.Value)
+			row = strings.Replace(row, cells[i], filled, 1)
+			cells[i] = filled
+		}
+		return row
+	})
+}
+
+// fillDocxUnderscoreBlanks ersetzt Unterstrich-Linien ("Firma: ________") durch den Wert.
+// Die Bezeichnung ist der Absatztext seit der vorigen Linie.
+func fillDocxUnderscoreBlanks(xmlText string, values formValues, fields *[]domain.FilledFormField) string {
+	return docxParagraphPattern.ReplaceAllStringFunc(xmlText, func(paragraph string) string {
+		if !docxUnderscoreBlank.MatchString(paragraph) {
+			return paragraph
+		}
+		pending := ""
+		return docxTextPattern.ReplaceAllStringFunc(paragraph, func(run string) string {
+			m := docxTextPattern.FindStringSubmatch(run)
+			text := m[2]
+			blanks := docxUnderscoreBlank.FindAllStringIndex(text, -1)
+			if blanks == nil {
+				pending += docxEntityReplacer.Replace(text)
+				return run
+			}
+
+			var out strings.Builder
+			last := 0
+			for _, blank := range blanks {
+				out.WriteString(text[last:blank[0]])
+				pending += docxEntityReplacer.Replace(text[last:blank[0]])
+				label := strings.TrimRight(strings.TrimSpace(pending), docxLabelTrailingChars)
+				pending = ""
+				last = blank[1]
+
+				field, ok := values.field(label, label)
+				if label == "" || !ok {
+					out.WriteString(text[blank[0]:blank[1]])
+					continue
+				}
+				*fields = append(*fields, field)
+				if field.Status != domain.FormFieldFilled {
+					out.WriteString(text[blank[0]:blank[1]])
+					continue
+				}
+				out.WriteString(docxEscape(field.Value))
+			}
+			out.WriteString(text[last:])
+			pending += docxEntityReplacer.Replace(text[last:])
+			return m[1] + out.String() + m[3]
+		})
+	})
+}
+
+// docxPlainText liefert den sichtbaren Text eines XML-Ausschnitts
+func docxPlainText(fragment string) string {
+	var b strings.Builder
+	for _, m := range docxTextPattern.FindAllStringSubmatch(fragment, -1) {
+		b.WriteString(docxEntityReplacer.Replace(m[2]))
+	}
+	return b.String()
+}
+
+// docxCellIsBlank prüft, ob eine Zelle leer ist und kein eigenes Formularelement enthält
+func docxCellIsBlank(cell string) bool {
+	if strings.Contains(cell, "<w:sdt>") || strings.Contains(cell, "<w:fldChar") {
+		return false
+	}
+	return strings.TrimSpace(docxPlainText(cell)) == ""
+}
+
+// docxReplaceText setzt value in den ersten Textlauf und leert die übrigen
+func docxReplaceText(fragment, value string) string {
+	first := true
+	replaced := docxTextPattern.ReplaceAllStringFunc(fragment, func(run string) string {
+		m := docxTextPattern.FindStringSubmatch(run)
+		if first {
+			first = false
+			return `<w:t xml:space="preserve">` + docxEscape(value) + m[3]
+		}
+		return m[1] + m[3]
+	})
+	if first {
+		return docxAppendToCell(fragment, value)
+	}
+	return replaced
+}
+
+// docxAppendToCell hängt value als Textlauf an den ersten Absatz an
+func docxAppendToCell(fragment, value string) string {
+	run := `<w:r><w:t xml:space="preserve">` + docxEscape(value) + `</w:t></w:r>`
+	if i := strings.Index(fragment, "</w:p>"); i >= 0 {
+		return fragment[:i] + run + fragment[i:]
+	}
+	if loc := docxEmptyParagraph.FindStringSubmatchIndex(fragment); loc != nil {
+		attrs := fragment[loc[2]:loc[3]]
+		return fragment[:loc[0]] + "<w:p" + attrs + ">" + run + "</w:p>" + fragment[loc[1]:]
+	}
+	return fragment + run
+}
+
+func docxEscape(text string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+func testFormValues() formValues {
+	return formValues{
+		formKeyCompanyName: "Müller & Söhne <Bau> GmbH",
+		formKeyCity:        "Rosenheim",
+		formKeyPhone:       "",
+		formKeyTaxID:       "DE123456789",
+	}
+}
+
+// fieldStatuses fasst die gemeldeten Felder als "Name=Status" zusammen
+func fieldStatuses(fields []domain.FilledFormField) string {
+	var parts []string
+	for _, f := range fields {
+		parts = append(parts, f.Name+"="+f.Status)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func TestDocxEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Huber GmbH", "Huber GmbH"},
+		{"Müller & Söhne", "Müller &amp; Söhne"},
+		{"<Bau> GmbH", "&lt;Bau&gt; GmbH"},
+		{`"Nord" KG`, "&#34;Nord&#34; KG"},
+	}
+	for _, tt := range tests {
+		if got := docxEscape(tt.text); got != tt.want {
+			t.Errorf("docxEscape(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFillDocxContentControls(t *testing.T) {
+	sdt := func(alias, props, content string) string {
+		return `<w:sdt><w:sdtPr><w:alias w:val="` + alias + `"/>` + props + `</w:sdtPr><w:sdtContent>` + content + `</w:sdtContent></w:sdt>`
+	}
+	placeholder := `<w:r><w:rPr><w:rStyle w:val="PlaceholderText"/></w:rPr><w:t>Klicken Sie hier.</w:t></w:r>`
+
+	tests := []struct {
+		name         string
+		xml          string
+		wantContains []string
+		wantMissing  []string
+		wantFields   string
+	}{
+		{
+			name:         "placeholder replaced and escaped",
+			xml:          sdt("Firma", `<w:showingPlcHdr/>`, placeholder),
+			wantContains: []string{`<w:t xml:space="preserve">Müller &amp; Söhne &lt;Bau&gt; GmbH</w:t>`},
+			wantMissing:  []string{"Klicken Sie hier.", "<w:showingPlcHdr/>", "PlaceholderText"},
+			wantFields:   "Firma=filled",
+		},
+		{
+			name:         "existing value is kept",
+			xml:          sdt("Ort", "", `<w:r><w:t>Kolbermoor</w:t></w:r>`),
+			wantContains: []string{"<w:t>Kolbermoor</w:t>"},
+			wantFields:   "Ort=prefilled",
+		},
+		{
+			name:         "missing company data keeps placeholder",
+			xml:          sdt("Telefon", `<w:showingPlcHdr/>`, placeholder),
+			wantContains: []string{"Klicken Sie hier."},
+			wantFields:   "Telefon=missing_data",
+		},
+		{
+			name:         "unknown field is flagged",
+			xml:          sdt("Anzahl Nachunternehmer", `<w:showingPlcHdr/>`, placeholder),
+			wantContains: []string{"Klicken Sie hier."},
+			wantFields:   "Anzahl Nachunternehmer=unknown",
+		},
+		{
+			name:         "checkbox is flagged for manual review",
+			xml:          sdt("Ausschlussgründe liegen nicht vor", `<w14:checkbox/>`, `<w:r><w:t>☐</w:t></w:r>`),
+			wantContains: []string{"☐"},
+			wantFields:   "Ausschlussgründe liegen nicht vor=unknown",
+		},
+		{
+			name:       "signature is never reported",
+			xml:        sdt("Unterschrift", `<w:showingPlcHdr/>`, placeholder),
+			wantFields: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []domain.FilledFormField
+			got := fillDocxContentControls(tt.xml, testFormValues(), &fields)
+			for _, want := range tt.wantContains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected %q in %s", want, got)
+				}
+			}
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(got, missing) {
+					t.Errorf("unexpected %q in %s", missing, got)
+				}
+			}
+			if statuses := fieldStatuses(fields); statuses != tt.wantFields {
+				t.Errorf("fields = %q, want %q", statuses, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestFillDocxTableRows(t *testing.T) {
+	cell := func(text string) string {
+		if text == "" {
+			return `<w:tc><w:p/></w:tc>`
+		}
+		return `<w:tc><w:p><w:r><w:t>` + text + `</w:t></w:r></w:p></w:tc>`
+	}
+	row := func(cells ...string) string { return `<w:tr>` + strings.Join(cells, "") + `</w:tr>` }
+
+	tests := []struct {
+		name         string
+		xml          string
+		wantContains string
+		wantFields   string
+	}{
+		{
+			name:         "empty cell next to label",
+			xml:          row(cell("Name des Bieters:"), cell("")),
+			wantContains: `<w:p><w:r><w:t xml:space="preserve">Müller &amp; Söhne &lt;Bau&gt; GmbH</w:t></w:r></w:p>`,
+			wantFields:   "Name des Bieters=filled",
+		},
+		{
+			name:         "filled cell is left alone",
+			xml:          row(cell("Ort"), cell("Kolbermoor")),
+			wantContains: "<w:t>Kolbermoor</w:t>",
+			wantFields:   "",
+		},
+		{
+			name:         "missing data",
+			xml:          row(cell("Telefon"), cell("")),
+			wantContains: `<w:tc><w:p/></w:tc>`,
+			wantFields:   "Telefon=missing_data",
+		},
+		{
+			name:         "unknown label is flagged",
+			xml:          row(cell("Handelsregisternummer"), cell("")),
+			wantContains: `<w:tc><w:p/></w:tc>`,
+			wantFields:   "Handelsregisternummer=unknown",
+		},
+		{
+			name:         "running numbers are no labels",
+			xml:          row(cell("1."), cell("")),
+			wantContains: `<w:tc><w:p/></w:tc>`,
+			wantFields:   "",
+		},
+		{
+			name:         "nested tables are skipped",
+			xml:          `<w:tr>` + cell("Ort") + `<w:tc><w:tbl>` + row(cell("")) + `</w:tbl></w:tc></w:tr>`,
+			wantContains: `<w:tbl>`,
+			wantFields:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []domain.FilledFormField
+			got := fillDocxTableRows(tt.xml, testFormValues(), &fields)
+			if !strings.Contains(got, tt.wantContains) {
+				t.Errorf("expected %q in %s", tt.wantContains, got)
+			}
+			if statuses := fieldStatuses(fields); statuses != tt.wantFields {
+				t.Errorf("fields = %q, want %q", statuses, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestFillDocxUnderscoreBlanks(t *testing.T) {
+	paragraph := func(runs ...string) string {
+		var b strings.Builder
+		b.WriteString("<w:p>")
+		for _, run := range runs {
+			b.WriteString(`<w:r><w:t xml:space="preserve">` + run + `</w:t></w:r>`)
+		}
+		b.WriteString("</w:p>")
+		return b.String()
+	}
+
+	tests := []struct {
+		name       string
+		xml        string
+		want       string
+		wantFields string
+	}{
+		{
+			name:       "label and blank in one run",
+			xml:        paragraph("Firma: __________"),
+			want:       paragraph("Firma: Müller &amp; Söhne &lt;Bau&gt; GmbH"),
+			wantFields: "Firma=filled",
+		},
+		{
+			name:       "label split across runs",
+			xml:        paragraph("Steuer", "nummer: ", "________"),
+			want:       paragraph("Steuer", "nummer: ", "DE123456789"),
+			wantFields: "Steuernummer=filled",
+		},
+		{
+			name:       "several blanks in one paragraph",
+			xml:        paragraph("Ort: ______ Telefon: ______ Unterschrift: ______"),
+			want:       paragraph("Ort: Rosenheim Telefon: ______ Unterschrift: ______"),
+			wantFields: "Ort=filled, Telefon=missing_data",
+		},
+		{
+			name:       "label with escaped ampersand",
+			xml:        paragraph("Liefer- &amp; Rechnungsnummer: ______"),
+			want:       paragraph("Liefer- &amp; Rechnungsnummer: ______"),
+			wantFields: "Liefer- & Rechnungsnummer=unknown",
+		},
+		{
+			name:       "short underscores are no blanks",
+			xml:        paragraph("Firma: ___"),
+			want:       paragraph("Firma: ___"),
+			wantFields: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []domain.FilledFormField
+			got := fillDocxUnderscoreBlanks(tt.xml, testFormValues(), &fields)
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			if statuses := fieldStatuses(fields); statuses != tt.wantFields {
+				t.Errorf("fields = %q, want %q", statuses, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestFillDocxForm(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?><w:document><w:body>` +
+		`<w:p><w:r><w:t>Eigenerklärung</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t xml:space="preserve">Bieter: ________</w:t></w:r></w:p>` +
+		`</w:body></w:document>`
+	styles := `<w:styles/>`
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"word/document.xml": document, "word/styles.xml": styles} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	filled, fields, err := fillDocxForm(buf.Bytes(), testFormValues())
+	if err != nil {
+		t.Fatalf("fillDocxForm: %v", err)
+	}
+	if statuses := fieldStatuses(fields); statuses != "Bieter=filled" {
+		t.Errorf("fields = %q", statuses)
+	}
+
+	text, err := docxDocumentText(filled)
+	if err != nil {
+		t.Fatalf("docxDocumentText: %v", err)
+	}
+	if text != "Eigenerklärung\nBieter: Müller & Söhne <Bau> GmbH" {
+		t.Errorf("filled text = %q", text)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(filled), int64(len(filled)))
+	if err != nil {
+		t.Fatalf("filled docx is no zip: %v", err)
+	}
+	if len(reader.File) != 2 {
+		t.Errorf("expected all parts to be copied, got %d", len(reader.File))
+	}
+
+	if _, _, err := fillDocxForm(buf.Bytes(), formValues{}); err != nil {
+		t.Errorf("form without company data should still report fields: %v", err)
+	}
+
+	var empty bytes.Buffer
+	zw = zip.NewWriter(&empty)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte(`<w:document><w:body><w:p><w:r><w:t>Leistungsbeschreibung</w:t></w:r></w:p></w:body></w:document>`))
+	zw.Close()
+	if _, _, err := fillDocxForm(empty.Bytes(), testFormValues()); err != errNoFormFields {
+		t.Errorf("expected errNoFormFields, got %v", err)
+	}
+}
@@ -0,0 +1,619 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var (
+	ErrNoFormsDetected        = errors.New("no known form templates among the tender attachments")
+	ErrInvalidFormAttachment  = errors.New("attachment is not a fillable PDF or DOCX of this tender")
+	ErrFormFillingUnavailable = errors.New("form filling requires file storage")
+	ErrFilledFormNotFound     = errors.New("filled form not found")
+	errNoFormFields           = errors.New("document contains no form fields")
+)
+
+// tenderAttachmentsBucket ist der Storage-Bucket für Anhänge (siehe 002_tender_attachments.sql)
+const tenderAttachmentsBucket = "tender-attachments"
+
+// formDetectionHeadChars begrenzt, wie viel OCR-Text für die Erkennung gelesen wird (Formulartitel stehen oben)
+const formDetectionHeadChars = 3_000
+
+// formTemplate ist ein bekanntes Standardformular der deutschen Vergabepraxis
+type formTemplate struct {
+	Key      string
+	Label    string
+	Keywords []string // bereits normalisiert (klein, Umlaute aufgelöst)
+}
+
+var formTemplates = []formTemplate{
+	{
+		Key:      "eigenerklaerung_eignung",
+		Label:    "Eigenerklärung zur Eignung",
+		Keywords: []string{"eigenerklaerung zur eignung", "eignungserklaerung", "eigenerklaerung eignung", "erklaerung zur eignung", "eignungsnachweis"},
+	},
+	{
+		Key:      "ausschlussgruende",
+		Label:    "Eigenerklärung zu Ausschlussgründen (§§ 123, 124 GWB)",
+		Keywords: []string{"ausschlussgruend", "ausschlussgrund", "123 gwb", "124 gwb", "§ 123", "§123", "§§ 123"},
+	},
+	{
+		Key:      "tariftreue",
+		Label:    "Tariftreue- und Mindestlohnerklärung",
+		Keywords: []string{"tariftreue", "mindestlohn", "mindestentgelt"},
+	},
+	{
+		Key:      "russland_sanktionen",
+		Label:    "Eigenerklärung Russland-Sanktionen",
+		Keywords: []string{"russland", "russisch", "833/2014", "art. 5k", "artikel 5k", "sanktion"},
+	},
+}
+
+// formSignals kennzeichnen im Dokumenttext ein Formular (statt z. B. einer Leistungsbeschreibung,
+// die die Ausschlussgründe nur erwähnt)
+var formSignals = []string{"eigenerklaerung", "erklaerung", "formblatt", "vordruck"}
+
+// Firmenfelder, die in Formulare eingesetzt werden
+const (
+	formKeyCompanyName = "company_name"
+	formKeyLegalForm   = "legal_form"
+	formKeyTaxID       = "tax_id"
+	formKeyStreet      = "street"
+	formKeyZip         = "zip"
+	formKeyCity        = "city"
+	formKeyZipCity     = "zip_city"
+	formKeyAddress     = "address"
+	formKeyCountry     = "country"
+	formKeyContactName = "contact_name"
+	formKeyEmail       = "contact_email"
+	formKeyPhone       = "contact_phone"
+	formKeyDate        = "date"
+	formKeyPlaceDate   = "place_date"
+)
+
+// formFieldRule ordnet Feldbezeichnungen einem Firmenfeld zu. Die Regeln werden der Reihe nach
+// geprüft, spezifischere zuerst ("Ort, Datum" vor "Ort", "PLZ/Ort" vor "PLZ").
+type formFieldRule struct {
+	Key      string
+	Synonyms []string
+}
+
+var formFieldRules = []formFieldRule{
+	{formKeyPlaceDate, []string{"ortdatum", "ortunddatum", "datumort"}},
+	{formKeyDate, []string{"datum", "date"}},
+	{formKeyTaxID, []string{"steuernummer", "steuernr", "ustid", "ustidnr", "umsatzsteuer", "steuerid", "vatid", "taxid"}},
+	{formKeyEmail, []string{"email", "mail"}},
+	{formKeyPhone, []string{"telefon", "tel", "phone", "fon", "rufnummer"}},
+	{formKeyContactName, []string{"ansprechpartner", "ansprechperson", "kontaktperson", "kontakt"}},
+	{formKeyZipCity, []string{"plzort", "postleitzahlort"}},
+	{formKeyZip, []string{"plz", "postleitzahl", "zip"}},
+	{formKeyStreet, []string{"strasse", "str", "hausnummer", "street"}},
+	{formKeyCity, []string{"ort", "stadt", "city", "sitz"}},
+	{formKeyAddress, []string{"anschrift", "adresse", "address"}},
+	{formKeyCountry, []string{"land", "staat", "country"}},
+	{formKeyLegalForm, []string{"rechtsform"}},
+	{formKeyCompanyName, []string{"firmenname", "firmenbezeichnung", "firma", "unternehmen", "bieter", "bewerber", "name", "company"}},
+}
+
+// Unterschrift und Stempel bleiben grundsätzlich Handarbeit und werden nicht gemeldet
+var formFieldSkip = []string{"unterschrift", "unterzeichn", "signatur", "signature", "stempel"}
+
+// Längere Beschriftungen sind Fragen oder Erklärungen, keine Stammdatenfelder
+const maxFormLabelWords = 6
+
+// formValues sind die Firmendaten je Firmenfeld
+type formValues map[string]string
+
+// companyFormValues stellt die Formularwerte aus dem Firmenprofil zusammen
+func companyFormValues(company *domain.Company, now time.Time) formValues {
+	name := strings.TrimSpace(company.Name)
+	legalForm := strings.TrimSpace(company.LegalForm)
+	if legalForm != "" && name != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(legalForm)) {
+		name += " " + legalForm
+	}
+
+	zipCity := strings.TrimSpace(company.AddressZip + " " + company.AddressCity)
+	address := strings.TrimSpace(company.AddressStreet)
+	if zipCity != "" {
+		if address != "" {
+			address += ", "
+		}
+		address += zipCity
+	}
+
+	country := strings.TrimSpace(company.AddressCountry)
+	if strings.EqualFold(country, "DE") {
+		country = "Deutschland"
+	}
+
+	date := now.Format("02.01.2006")
+	placeDate := ""
+	if city := strings.TrimSpace(company.AddressCity); city != "" {
+		placeDate = city + ", " + date
+	}
+
+	return formValues{
+		formKeyCompanyName: name,
+		formKeyLegalForm:   legalForm,
+		formKeyTaxID:       strings.TrimSpace(company.TaxID),
+		formKeyStreet:      strings.TrimSpace(company.AddressStreet),
+		formKeyZip:         strings.TrimSpace(company.AddressZip),
+		formKeyCity:        strings.TrimSpace(company.AddressCity),
+		formKeyZipCity:     zipCity,
+		formKeyAddress:     address,
+		formKeyCountry:     country,
+		formKeyContactName: strings.TrimSpace(company.ContactName),
+		formKeyEmail:       strings.TrimSpace(company.ContactEmail),
+		formKeyPhone:       strings.TrimSpace(company.ContactPhone),
+		formKeyDate:        date,
+		formKeyPlaceDate:   placeDate,
+	}
+}
+
+// field ordnet ein Formularfeld zu. labels sind Feldname, Tooltip oder Beschriftung in
+// absteigender Aussagekraft; name ist die Bezeichnung für die Prüfliste. ok ist false für
+// Felder, die nie automatisch befüllt werden (Unterschrift, Stempel), und für Bezeichnungen
+// ohne Wörter (z. B. laufende Nummern in Tabellen).
+func (v formValues) field(name string, labels ...string) (domain.FilledFormField, bool) {
+	result := domain.FilledFormField{Name: name, Status: domain.FormFieldUnknown}
+	hasWords := false
+	for _, label := range labels {
+		tokens := formLabelTokens(label)
+		if len(tokens) == 0 {
+			continue
+		}
+		hasWords = true
+		compact := strings.Join(tokens, "")
+		for _, skip := range formFieldSkip {
+			if strings.Contains(compact, skip) {
+				return result, false
+			}
+		}
+		if len(tokens) > maxFormLabelWords {
+			continue
+		}
+		key := matchFormFieldRule(tokens, compact)
+		if key == "" {
+			continue
+		}
+		result.Key = key
+		if value := v[key]; value != "" {
+			result.Value, result.Status = value, domain.FormFieldFilled
+		} else {
+			result.Status = domain.FormFieldMissingData
+		}
+		return result, true
+	}
+	return result, hasWords
+}
+
+// matchFormFieldRule liefert das Firmenfeld zur Bezeichnung. Kurze Synonyme ("ort", "datum")
+// müssen ein ganzes Wort sein, damit z. B. "Antwort" nicht als Ort und "Gründungsdatum"
+// nicht als heutiges Datum erkannt wird.
+func matchFormFieldRule(tokens []string, compact string) string {
+	for _, rule := range formFieldRules {
+		for _, synonym := range rule.Synonyms {
+			if len(synonym) > 5 && strings.Contains(compact, synonym) {
+				return rule.Key
+			}
+			for _, token := range tokens {
+				if token == synonym {
+					return rule.Key
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// formLabelTokens zerlegt eine Feldbezeichnung in normalisierte Wörter. CamelCase und
+// angehängte Nummern ("Strasse1", "ustIdNr") werden getrennt bzw. entfernt.
+func formLabelTokens(label string) []string {
+	var tokens []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, strings.TrimRightFunc(foldUmlauts(strings.ToLower(string(current))), unicode.IsDigit))
+			current = nil
+		}
+	}
+	runes := []rune(label)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	result := tokens[:0]
+	for _, token := range tokens {
+		if token != "" {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+var umlautReplacer = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss")
+
+// foldUmlauts löst Umlaute auf, damit "Straße" und "Strasse" gleich behandelt werden
+func foldUmlauts(text string) string {
+	return umlautReplacer.Replace(text)
+}
+
+// detectFormTemplate erkennt ein Standardformular an Titel, Dateiname oder Textanfang.
+// Treffer im Titel zählen doppelt; ein Treffer nur im Text reicht, wenn der Text nach
+// Formular aussieht.
+func detectFormTemplate(attachment *domain.TenderAttachment) *formTemplate {
+	name := foldUmlauts(strings.ToLower(attachment.Title + " " + attachment.Filename))
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+	head := attachment.ContentOCR
+	if len(head) > formDetectionHeadChars {
+		head = head[:formDetectionHeadChars]
+	}
+	head = strings.Join(strings.Fields(foldUmlauts(strings.ToLower(head))), " ")
+
+	headIsForm := false
+	for _, signal := range formSignals {
+		if strings.Contains(head, signal) {
+			headIsForm = true
+			break
+		}
+	}
+
+	var best *formTemplate
+	bestScore := 0
+	for i := range formTemplates {
+		score := 0
+		for _, keyword := range formTemplates[i].Keywords {
+			if strings.Contains(name, keyword) {
+				score += 2
+			}
+			if headIsForm && strings.Contains(head, keyword) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = &formTemplates[i], score
+		}
+	}
+	return best
+}
+
+type FormFillingService struct {
+	db      *gorm.DB
+	storage *SupabaseStorageService
+}
+
+func NewFormFillingService(db *gorm.DB, storage *SupabaseStorageService) *FormFillingService {
+	return &FormFillingService{db: db, storage: storage}
+}
+
+// DetectedForm ist ein als Standardformular erkannter Anhang
+type DetectedForm struct {
+	AttachmentID  uuid.UUID `json:"attachment_id"`
+	Filename      string    `json:"filename"`
+	Title         string    `json:"title"`
+	FileType      string    `json:"file_type"`
+	Template      string    `json:"template"`
+	TemplateLabel string    `json:"template_label"`
+}
+
+// FilledForm ist ein vorausgefülltes Formular mit der Feldliste zur Prüfung
+type FilledForm struct {
+	SourceAttachmentID uuid.UUID                `json:"source_attachment_id"`
+	Template           string                   `json:"template,omitempty"`
+	TemplateLabel      string                   `json:"template_label,omitempty"`
+	Attachment         *domain.TenderAttachment `json:"attachment"`
+	Fields             []domain.FilledFormField `json:"fields"`
+	NeedsReview        []domain.FilledFormField `json:"needs_review"`
+}
+
+// FailedForm ist ein Anhang, der nicht befüllt werden konnte
+type FailedForm struct {
+	AttachmentID uuid.UUID `json:"attachment_id"`
+	Filename     string    `json:"filename"`
+	Error        string    `json:"error"`
+}
+
+type FormFillResult struct {
+	Forms       []FilledForm `json:"forms"`
+	FailedForms []FailedForm `json:"failed_forms,omitempty"`
+}
+
+// DetectForms listet die Anhänge einer Ausschreibung, die als Standardformular erkannt werden
+func (s *FormFillingService) DetectForms(ctx context.Context, tenderID uuid.UUID) ([]DetectedForm, error) {
+	attachments, err := s.loadSourceAttachments(ctx, tenderID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	detected := []DetectedForm{}
+	for i := range attachments {
+		if template := detectFormTemplate(&attachments[i]); template != nil {
+			detected = append(detected, newDetectedForm(&attachments[i], template))
+		}
+	}
+	return detected, nil
+}
+
+// FillForms befüllt die erkannten Formulare (oder die angegebenen Anhänge) mit den Firmendaten
+// und legt sie als neue, nur für die Firma sichtbare Anhänge ab. Eine frühere vorausgefüllte
+// Fassung desselben Formulars wird ersetzt. Schlägt ein Formular fehl, werden die übrigen
+// trotzdem gespeichert; ein Fehler kommt nur zurück, wenn keines gelingt.
+func (s *FormFillingService) FillForms(ctx context.Context, authUserID, tenderID uuid.UUID, attachmentIDs []uuid.UUID) (*FormFillResult, error) {
+	if s.storage == nil {
+		return nil, ErrFormFillingUnavailable
+	}
+
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.loadSourceAttachments(ctx, tenderID, attachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var forms []DetectedForm
+	if len(attachmentIDs) > 0 {
+		// Explizit gewählte Anhänge werden auch ohne erkannte Vorlage befüllt
+		if len(attachments) != len(uniqueUUIDs(attachmentIDs)) {
+			return nil, ErrInvalidFormAttachment
+		}
+		for i := range attachments {
+			forms = append(forms, newDetectedForm(&attachments[i], detectFormTemplate(&attachments[i])))
+		}
+	} else {
+		for i := range attachments {
+			if template := detectFormTemplate(&attachments[i]); template != nil {
+				forms = append(forms, newDetectedForm(&attachments[i], template))
+			}
+		}
+	}
+	if len(forms) == 0 {
+		return nil, ErrNoFormsDetected
+	}
+
+	values := companyFormValues(company, time.Now())
+	byID := make(map[uuid.UUID]*domain.TenderAttachment, len(attachments))
+	for i := range attachments {
+		byID[attachments[i].ID] = &attachments[i]
+	}
+
+	result := &FormFillResult{Forms: []FilledForm{}}
+	var firstErr error
+	for _, form := range forms {
+		filled, err := s.fillForm(ctx, company.ID, byID[form.AttachmentID], form, values)
+		if err != nil {
+			log.Printf("Form filling failed for attachment %s: %v", form.AttachmentID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			result.FailedForms = append(result.FailedForms, FailedForm{
+				AttachmentID: form.AttachmentID,
+				Filename:     form.Filename,
+				Error:        err.Error(),
+			})
+			continue
+		}
+		result.Forms = append(result.Forms, *filled)
+	}
+
+	if len(result.Forms) == 0 {
+		return nil, fmt.Errorf("all %d forms failed: %w", len(forms), firstErr)
+	}
+	return result, nil
+}
+
+// ListFilledForms liefert die vorausgefüllten Formulare der Firma zu einer Ausschreibung
+func (s *FormFillingService) ListFilledForms(ctx context.Context, authUserID, tenderID uuid.UUID) ([]domain.TenderAttachment, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := []domain.TenderAttachment{}
+	if err := s.db.WithContext(ctx).
+		Where("tender_id = ? AND company_id = ?", tenderID, company.ID).
+		Order("created_at DESC").
+		Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("load filled forms failed: %w", err)
+	}
+	return attachments, nil
+}
+
+// DeleteFilledForm entfernt ein vorausgefülltes Formular der Firma samt Datei
+func (s *FormFillingService) DeleteFilledForm(ctx context.Context, authUserID, attachmentID uuid.UUID) error {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return err
+	}
+
+	var attachment domain.TenderAttachment
+	if err := s.db.WithContext(ctx).
+		Select("id", "storage_path").
+		Where("id = ? AND company_id = ?", attachmentID, company.ID).
+		First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrFilledFormNotFound
+		}
+		return fmt.Errorf("load filled form failed: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&domain.TenderAttachment{}, "id = ?", attachment.ID).Error; err != nil {
+		return fmt.Errorf("delete filled form failed: %w", err)
+	}
+	if s.storage != nil {
+		if err := s.storage.DeleteFile(tenderAttachmentsBucket, attachment.StoragePath); err != nil {
+			log.Printf("Storage delete failed for filled form %s: %v", attachment.ID, err)
+		}
+	}
+	return nil
+}
+
+// fillForm lädt das Original, befüllt es und speichert das Ergebnis als neuen Anhang
+func (s *FormFillingService) fillForm(ctx context.Context, companyID uuid.UUID, source *domain.TenderAttachment, form DetectedForm, values formValues) (*FilledForm, error) {
+	original, err := s.storage.DownloadFile(tenderAttachmentsBucket, source.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("download form: %w", err)
+	}
+
+	var content []byte
+	var fields []domain.FilledFormField
+	var contentType string
+	switch source.FileType {
+	case "pdf":
+		content, fields, err = fillPDFForm(original, values)
+		contentType = "application/pdf"
+	case "docx":
+		content, fields, err = fillDocxForm(original, values)
+		contentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	default:
+		err = ErrInvalidFormAttachment
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("marshal form fields: %w", err)
+	}
+
+	needsReview := []domain.FilledFormField{}
+	for _, field := range fields {
+		if field.Status == domain.FormFieldMissingData || field.Status == domain.FormFieldUnknown {
+			needsReview = append(needsReview, field)
+		}
+	}
+
+	ext := filepath.Ext(source.Filename)
+	attachmentID := uuid.New()
+	storagePath := source.TenderID.String() + "/" + attachmentID.String() + "." + source.FileType
+	if err := s.storage.UploadFile(tenderAttachmentsBucket, storagePath, content, contentType); err != nil {
+		return nil, fmt.Errorf("upload filled form: %w", err)
+	}
+
+	title := source.Title
+	if title == "" {
+		title = source.Filename
+	}
+	description := "Aus dem Firmenprofil vorausgefüllt – bitte prüfen und unterschreiben."
+	if len(needsReview) > 0 {
+		description = fmt.Sprintf("Aus dem Firmenprofil vorausgefüllt – %d Felder bitte von Hand ergänzen.", len(needsReview))
+	}
+
+	sourceID := source.ID
+	attachment := &domain.TenderAttachment{
+		ID:                 attachmentID,
+		TenderID:           source.TenderID,
+		Filename:           strings.TrimSuffix(source.Filename, ext) + "_ausgefuellt." + source.FileType,
+		FileType:           source.FileType,
+		DocumentType:       domain.DocumentTypeFilledForm,
+		Title:              title + " (vorausgefüllt)",
+		Description:        description,
+		StoragePath:        storagePath,
+		FileSize:           len(content),
+		CreatedAt:          time.Now(),
+		CompanyID:          &companyID,
+		SourceAttachmentID: &sourceID,
+		FormTemplate:       form.Template,
+		FormFields:         fieldsJSON,
+	}
+
+	var previous []domain.TenderAttachment
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "storage_path").
+			Where("company_id = ? AND source_attachment_id = ?", companyID, source.ID).
+			Find(&previous).Error; err != nil {
+			return err
+		}
+		if len(previous) > 0 {
+			if err := tx.Delete(&previous).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(attachment).Error
+	})
+	if err != nil {
+		s.storage.DeleteFile(tenderAttachmentsBucket, storagePath)
+		return nil, fmt.Errorf("save filled form: %w", err)
+	}
+
+	for _, old := range previous {
+		if err := s.storage.DeleteFile(tenderAttachmentsBucket, old.StoragePath); err != nil {
+			log.Printf("Storage delete failed for replaced form %s: %v", old.ID, err)
+		}
+	}
+
+	return &FilledForm{
+		SourceAttachmentID: source.ID,
+		Template:           form.Template,
+		TemplateLabel:      form.TemplateLabel,
+		Attachment:         attachment,
+		Fields:             fields,
+		NeedsReview:        needsReview,
+	}, nil
+}
+
+// loadSourceAttachments lädt die befüllbaren Originalanhänge (PDF/DOCX, keine vorausgefüllten
+// Formulare), optional eingeschränkt auf ids
+func (s *FormFillingService) loadSourceAttachments(ctx context.Context, tenderID uuid.UUID, ids []uuid.UUID) ([]domain.TenderAttachment, error) {
+	query := s.db.WithContext(ctx).
+		Select("id", "tender_id", "filename", "file_type", "title", "storage_path", "content_ocr").
+		Where("tender_id = ? AND company_id IS NULL AND file_type IN ?", tenderID, []string{"pdf", "docx"})
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var attachments []domain.TenderAttachment
+	if err := query.Order("created_at ASC").Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("load attachments failed: %w", err)
+	}
+	return attachments, nil
+}
+
+func newDetectedForm(attachment *domain.TenderAttachment, template *formTemplate) DetectedForm {
+	form := DetectedForm{
+		AttachmentID: attachment.ID,
+		Filename:     attachment.Filename,
+		Title:        attachment.Title,
+		FileType:     attachment.FileType,
+	}
+	if template != nil {
+		form.Template, form.TemplateLabel = template.Key, template.Label
+	}
+	return form
+}
+
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	result := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/klippa-app/go-pdfium/enums"
+	"github.com/klippa-app/go-pdfium/references"
+	"github.com/klippa-app/go-pdfium/requests"
+	"github.com/klippa-app/go-pdfium/structs"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+// fillPDFForm befüllt die Textfelder eines PDF-AcroForms mit den Firmendaten. Die Werte
+// werden wie bei einer Eingabe gesetzt (Fokus, Text ersetzen, Fokus lösen), damit pdfium
+// auch die Darstellung der Felder neu erzeugt. Kontrollkästchen und Auswahlfelder sind
+// Erklärungen des Bieters und werden nur zur Prüfung gemeldet.
+func fillPDFForm(pdfBytes []byte, values formValues) ([]byte, []domain.FilledFormField, error) {
+	if pdfiumInstance == nil {
+		return nil, nil, fmt.Errorf("pdfium not initialized")
+	}
+
+	doc, err := pdfiumInstance.OpenDocument(&requests.OpenDocument{File: &pdfBytes})
+	if err != nil {
+		return nil, nil, fmt.Errorf("open PDF: %w", err)
+	}
+	defer pdfiumInstance.FPDF_CloseDocument(&requests.FPDF_CloseDocument{Document: doc.Document})
+
+	formEnv, err := pdfiumInstance.FPDFDOC_InitFormFillEnvironment(&requests.FPDFDOC_InitFormFillEnvironment{
+		Document:     doc.Document,
+		FormFillInfo: headlessFormFillInfo(),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("init form environment: %w", err)
+	}
+	defer pdfiumInstance.FPDFDOC_ExitFormFillEnvironment(&requests.FPDFDOC_ExitFormFillEnvironment{FormHandle: formEnv.FormHandle})
+
+	pageCount, err := pdfiumInstance.FPDF_GetPageCount(&requests.FPDF_GetPageCount{Document: doc.Document})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get page count: %w", err)
+	}
+
+	fields := []domain.FilledFormField{}
+	seen := map[string]bool{}
+	for i := 0; i < pageCount.PageCount; i++ {
+		pageFields, err := fillPDFPage(doc.Document, formEnv.FormHandle, i, values, seen)
+		if err != nil {
+			return nil, nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		fields = append(fields, pageFields...)
+	}
+	if len(seen) == 0 {
+		return nil, nil, errNoFormFields
+	}
+
+	var buf bytes.Buffer
+	if _, err := pdfiumInstance.FPDF_SaveAsCopy(&requests.FPDF_SaveAsCopy{
+		Document:   doc.Document,
+		Flags:      requests.SaveFlagNoIncremental,
+		FileWriter: &buf,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("save PDF: %w", err)
+	}
+	return buf.Bytes(), fields, nil
+}
+
+// headlessFormFillInfo liefert die Callbacks der Formularumgebung ohne Anzeige. pdfium ruft
+// sie beim Setzen des Fokus und beim Bearbeiten auf, daher darf keiner fehlen.
+func headlessFormFillInfo() structs.FPDF_FORMFILLINFO {
+	return structs.FPDF_FORMFILLINFO{
+		Release:                func() {},
+		FFI_Invalidate:         func(page references.FPDF_PAGE, left, top, right, bottom float64) {},
+		FFI_OutputSelectedRect: func(page references.FPDF_PAGE, left, top, right, bottom float64) {},
+		FFI_SetCursor:          func(cursorType enums.FXCT) {},
+		FFI_SetTimer:           func(elapse int, timerFunc func(idEvent int)) int { return 0 },
+		FFI_KillTimer:          func(timerID int) {},
+		FFI_GetLocalTime:       func() structs.FPDF_SYSTEMTIME { return structs.FPDF_SYSTEMTIME{} },
+		FFI_OnChange:           func() {},
+		FFI_GetPage:            func(document references.FPDF_DOCUMENT, index int) *references.FPDF_PAGE { return nil },
+		FFI_GetCurrentPage:     func(document references.FPDF_DOCUMENT) *references.FPDF_PAGE { return nil },
+		FFI_GetRotation:        func(page references.FPDF_PAGE) enums.FPDF_PAGE_ROTATION { return enums.FPDF_PAGE_ROTATION_NONE },
+		FFI_ExecuteNamedAction: func(namedAction string) {},
+		FFI_SetTextFieldFocus:  func(value string, isFocus bool) {},
+		FFI_DoURIAction:        func(bsURI string) {},
+		FFI_DoGoToAction:       func(pageIndex int, zoomMode enums.FPDF_ZOOM_MODE, pos []float32) {},
+	}
+}
+
+// fillPDFPage befüllt die Formularfelder einer Seite. seen verhindert, dass ein Feld mit
+// mehreren Widgets (z. B. Firmenname in jeder Kopfzeile) mehrfach gemeldet wird.
+func fillPDFPage(document references.FPDF_DOCUMENT, form references.FPDF_FORMHANDLE, index int, values formValues, seen map[string]bool) ([]domain.FilledFormField, error) {
+	page, err := pdfiumInstance.FPDF_LoadPage(&requests.FPDF_LoadPage{Document: document, Index: index})
+	if err != nil {
+		return nil, fmt.Errorf("load page: %w", err)
+	}
+	defer pdfiumInstance.FPDF_ClosePage(&requests.FPDF_ClosePage{Page: page.Page})
+
+	pageRef := requests.Page{ByReference: &page.Page}
+	if _, err := pdfiumInstance.FORM_OnAfterLoadPage(&requests.FORM_OnAfterLoadPage{Page: pageRef, FormHandle: form}); err != nil {
+		return nil, fmt.Errorf("load page form: %w", err)
+	}
+	defer pdfiumInstance.FORM_OnBeforeClosePage(&requests.FORM_OnBeforeClosePage{Page: pageRef, FormHandle: form})
+
+	count, err := pdfiumInstance.FPDFPage_GetAnnotCount(&requests.FPDFPage_GetAnnotCount{Page: pageRef})
+	if err != nil {
+		return nil, fmt.Errorf("count annotations: %w", err)
+	}
+
+	var fields []domain.FilledFormField
+	for i := 0; i < count.Count; i++ {
+		annot, err := pdfiumInstance.FPDFPage_GetAnnot(&requests.FPDFPage_GetAnnot{Page: pageRef, Index: i})
+		if err != nil {
+			return nil, fmt.Errorf("get annotation %d: %w", i, err)
+		}
+		field, ok, err := fillPDFWidget(pageRef, form, annot.Annotation, values, seen)
+		pdfiumInstance.FPDFPage_CloseAnnot(&requests.FPDFPage_CloseAnnot{Annotation: annot.Annotation})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// fillPDFWidget befüllt ein Formularfeld; ok ist false für alles, was nicht in die Prüfliste gehört
+func fillPDFWidget(pageRef requests.Page, form references.FPDF_FORMHANDLE, annotation references.FPDF_ANNOTATION, values formValues, seen map[string]bool) (domain.FilledFormField, bool, error) {
+	subtype, err := pdfiumInstance.FPDFAnnot_GetSubtype(&requests.FPDFAnnot_GetSubtype{Annotation: annotation})
+	if err != nil || subtype.Subtype != enums.FPDF_ANNOT_SUBTYPE_WIDGET {
+		return domain.FilledFormField{}, false, nil
+	}
+
+	name, err := pdfiumInstance.FPDFAnnot_GetFormFieldName(&requests.FPDFAnnot_GetFormFieldName{FormHandle: form, Annotation: annotation})
+	if err != nil {
+		return domain.FilledFormField{}, false, fmt.Errorf("get field name: %w", err)
+	}
+	if seen[name.FormFieldName] {
+		return domain.FilledFormField{}, false, nil
+	}
+	seen[name.FormFieldName] = true
+
+	fieldType, err := pdfiumInstance.FPDFAnnot_GetFormFieldType(&requests.FPDFAnnot_GetFormFieldType{FormHandle: form, Annotation: annotation})
+	if err != nil {
+		return domain.FilledFormField{}, false, fmt.Errorf("get field type: %w", err)
+	}
+	flags, err := pdfiumInstance.FPDFAnnot_GetFormFieldFlags(&requests.FPDFAnnot_GetFormFieldFlags{FormHandle: form, Annotation: annotation})
+	if err != nil {
+		return domain.FilledFormField{}, false, fmt.Errorf("get field flags: %w", err)
+	}
+	if flags.Flags&enums.FPDF_FORMFLAG_READONLY != 0 {
+		return domain.FilledFormField{}, false, nil
+	}
+
+	// Der Tooltip ist oft sprechender als der interne Feldname ("Text12")
+	label := name.FormFieldName
+	if alternate, err := pdfiumInstance.FPDFAnnot_GetFormFieldAlternateName(&requests.FPDFAnnot_GetFormFieldAlternateName{FormHandle: form, Annotation: annotation}); err == nil && alternate.FormFieldAlternateName != "" {
+		label = alternate.FormFieldAlternateName
+	}
+
+	switch fieldType.FormFieldType {
+	case enums.FPDF_FORMFIELD_TYPE_TEXTFIELD:
+	case enums.FPDF_FORMFIELD_TYPE_CHECKBOX, enums.FPDF_FORMFIELD_TYPE_RADIOBUTTON,
+		enums.FPDF_FORMFIELD_TYPE_COMBOBOX, enums.FPDF_FORMFIELD_TYPE_LISTBOX:
+		return domain.FilledFormField{Name: label, Status: domain.FormFieldUnknown}, true, nil
+	default:
+		// Schaltflächen und Signaturfelder
+		return domain.FilledFormField{}, false, nil
+	}
+
+	field, ok := values.field(label, label, name.FormFieldName)
+	if !ok {
+		return domain.FilledFormField{}, false, nil
+	}
+
+	current, err := pdfiumInstance.FPDFAnnot_GetFormFieldValue(&requests.FPDFAnnot_GetFormFieldValue{FormHandle: form, Annotation: annotation})
+	if err == nil && current.FormFieldValue != "" {
+		field.Value, field.Status = current.FormFieldValue, domain.FormFieldPrefilled
+		return field, true, nil
+	}
+	if field.Status != domain.FormFieldFilled {
+		return field, true, nil
+	}
+
+	if _, err := pdfiumInstance.FORM_SetFocusedAnnot(&requests.FORM_SetFocusedAnnot{FormHandle: form, Annotation: annotation}); err != nil {
+		return domain.FilledFormField{}, false, fmt.Errorf("focus field %q: %w", name.FormFieldName, err)
+	}
+	if _, err := pdfiumInstance.FORM_SelectAllText(&requests.FORM_SelectAllText{FormHandle: form, Page: pageRef}); err != nil {
+		return domain.FilledFormField{}, false, fmt.Errorf("select field %q: %w", name.FormFieldName, err)
+	}
+	if _, err := pdfiumInstance.FORM_ReplaceSelection(&requests.FORM_ReplaceSelection{FormHandle: form, Page: pageRef, Text: field.Value}); err != nil {
+		return domain.FilledFormField{}, false, fmt.Errorf("fill field %q: %w", name.FormFieldName, err)
+	}
+	if _, err := pdfiumInstance.FORM_ForceToKillFocus(&requests.FORM_ForceToKillFocus{FormHandle: form}); err != nil {
+		return domain.FilledFormField{}, false, fmt.Errorf("commit field %q: %w", name.FormFieldName, err)
+	}
+	return field, true, nil
+}
@@ -3,11 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"regexp"
 	"strings"
 	"time"
 
-	openaiembed "github.com/cloudwego/eino-ext/components/embedding/openai" // RICHTIGER IMPORT
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/google/uuid"
 	"github.com/pgvector/pgvector-go"
 	"github.com/vergabe-agent/vergabe-backend/internal/domain"
@@ -17,12 +18,14 @@ import (
 type IngestionService struct {
 	db           *gorm.DB
 	xmlParser    *XMLParserService
-	embedder     *openaiembed.Embedder
+	embedder     embedding.Embedder
 	novitaAPIKey string
 	ocrService   *OCRService
+	eligibility  *EligibilityService
+	usage        *UsageService
 }
 
-func NewIngestionService(db *gorm.DB, novitaAPIKey string, embedCfg EmbeddingProviderConfig) (*IngestionService, error) {
+func NewIngestionService(db *gorm.DB, novitaAPIKey string, embedCfg EmbeddingProviderConfig, usage *UsageService) (*IngestionService, error) {
 	ctx := context.Background()
 	emb, err := newEmbeddingClient(ctx, embedCfg)
 	if err != nil {
@@ -34,14 +37,22 @@ func NewIngestionService(db *gorm.DB, novitaAPIKey string, embedCfg EmbeddingPro
 		xmlParser:    NewXMLParserService(db),
 		embedder:     emb,
 		novitaAPIKey: novitaAPIKey,
-		ocrService:   NewOCRService(novitaAPIKey),
+		ocrService:   NewOCRService(novitaAPIKey, usage),
+		eligibility:  NewEligibilityService(db),
+		usage:        usage,
 	}, nil
 }
 
-// ProcessUpload entscheidet anhand des Dateinamens, wie verarbeitet wird
-func (s *IngestionService) ProcessUpload(ctx context.Context, fileContent []byte, filename string) (*domain.Tender, error) {
+// ProcessUpload entscheidet anhand des Dateinamens, wie verarbeitet wird. OCR und Embedding
+// zählen für die Firma des hochladenden Nutzers; ohne Firma ErrCompanyNotFound.
+func (s *IngestionService) ProcessUpload(ctx context.Context, authUserID uuid.UUID, fileContent []byte, filename string) (*domain.Tender, error) {
 	filename = strings.ToLower(filename)
 
+	ctx, err := s.usage.BeginForUser(ctx, authUserID, nil, OperationIngestion)
+	if err != nil {
+		return nil, err
+	}
+
 	switch {
 	case strings.HasSuffix(filename, ".xml"):
 		return s.processXML(ctx, fileContent)
@@ -54,7 +65,7 @@ func (s *IngestionService) ProcessUpload(ctx context.Context, fileContent []byte
 
 func (s *IngestionService) processPDF(ctx context.Context, pdfData []byte) (*domain.Tender, error) {
 	// 1. OCR
-	ocrText, err := s.ocrService.ExtractFromPDF(pdfData)
+	ocrText, err := s.ocrService.ExtractFromPDF(ctx, pdfData)
 	if err != nil {
 		return nil, fmt.Errorf("OCR failed: %w", err)
 	}
@@ -93,6 +104,8 @@ func (s *IngestionService) processPDF(ctx context.Context, pdfData []byte) (*dom
 		return nil, fmt.Errorf("save tender: %w", err)
 	}
 
+	s.refreshRequirements(ctx, tender)
+
 	return tender, nil
 }
 
@@ -134,9 +147,29 @@ func (s *IngestionService) processXML(ctx context.Context, xmlData []byte) (*dom
 		return nil, fmt.Errorf("failed to save embedding: %w", err)
 	}
 
+	s.refreshRequirements(ctx, tender)
+
 	return tender, nil
 }
 
+// refreshRequirements extrahiert die Mindestanforderungen für die Eignungsprüfung.
+// Fehler werden nur geloggt, die Ausschreibung bleibt auch ohne sie nutzbar.
+func (s *IngestionService) refreshRequirements(ctx context.Context, tender *domain.Tender) {
+	req, err := s.eligibility.RefreshTenderRequirements(ctx, tender.ID)
+	if err != nil {
+		log.Printf("Requirement extraction failed for tender %s: %v", tender.ID, err)
+		return
+	}
+
+	if req.MinAnnualRevenue > 0 {
+		tender.MinAnnualRevenue = &req.MinAnnualRevenue
+	}
+	if req.MinEmployeeCount > 0 {
+		tender.MinEmployeeCount = &req.MinEmployeeCount
+	}
+	tender.RequiredCertificates = req.RequiredCertificates
+}
+
 // extractTitleFromText versucht, einen Titel zu finden (nimmt die erste nicht-leere Zeile oder einen Default)
 func extractTitleFromText(text string) string {
 	lines := strings.Split(text, "\n")
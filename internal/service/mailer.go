@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// MailerConfig beschreibt den SMTP-Zugang für ausgehende Mails
+type MailerConfig struct {
+	Host     string
+	Port     string // Standard 587
+	Username string // leer = ohne Anmeldung
+	Password string
+	From     string
+}
+
+// Mailer verschickt einfache Text-Mails per SMTP
+type Mailer struct {
+	cfg MailerConfig
+}
+
+// NewMailer liefert nil, wenn kein SMTP-Host konfiguriert ist; Aufrufer müssen damit umgehen
+func NewMailer(cfg MailerConfig) *Mailer {
+	if strings.TrimSpace(cfg.Host) == "" || strings.TrimSpace(cfg.From) == "" {
+		return nil
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return &Mailer{cfg: cfg}
+}
+
+// Send verschickt eine Text-Mail (UTF-8) an einen Empfänger
+func (m *Mailer) Send(to, subject, body string) error {
+	if m == nil {
+		return fmt.Errorf("mailer not configured")
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	// From darf einen Anzeigenamen enthalten ("Vergabe Agent <noreply@…>"), der Umschlag nicht
+	sender := m.cfg.From
+	if addr, err := mail.ParseAddress(m.cfg.From); err == nil {
+		sender = addr.Address
+	}
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+	if err := smtp.SendMail(addr, auth, sender, []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("send mail failed: %w", err)
+	}
+	return nil
+}
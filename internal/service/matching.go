@@ -5,8 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
 	"gorm.io/gorm"
 
 	"github.com/vergabe-agent/vergabe-backend/internal/domain"
@@ -14,6 +18,20 @@ import (
 
 var ErrCompanyNotFound = errors.New("company not found")
 
+// Kandidaten-Stufe liefert mehr Treffer als angefragt, damit das Re-Ranking Spielraum hat
+const (
+	candidateFactor   = 5
+	minCandidateLimit = 50
+
+	// ANN-Zweig holt annFactor * Kandidatenlimit nächste Nachbarn aus dem HNSW-Index
+	annFactor   = 2
+	maxEFSearch = 1000
+	// Obergrenze je Vorfilter-Zweig (CPV, NUTS, Geo). Die Zweige sortieren vor dem LIMIT
+	// nach Vektor-Distanz (ohne Profil nach Frist) bzw. Geo-Distanz, damit bei mehr Treffern
+	// die relevantesten übrig bleiben statt einer beliebigen Auswahl.
+	prefilterLimit = 500
+)
+
 type MatchingService struct {
 	db *gorm.DB
 }
@@ -22,16 +40,50 @@ func NewMatchingService(db *gorm.DB) *MatchingService {
 	return &MatchingService{db: db}
 }
 
-func (s *MatchingService) FindMatchesHybrid(ctx context.Context, authUserID uuid.UUID, limit int) ([]domain.Match, error) {
-	if limit <= 0 {
-		limit = 20
+// MatchOptions steuert einen Matching-Lauf
+type MatchOptions struct {
+	Limit int
+	// UseFeedback aktiviert das Re-Ranking anhand des Nutzer-Feedbacks
+	UseFeedback bool
+	// FeedbackBefore berücksichtigt nur Feedback vor diesem Zeitpunkt (Zero = alles).
+	// Wird von der Offline-Evaluation für den zeitlichen Split genutzt.
+	FeedbackBefore time.Time
+	// TenderIDs schränkt die Kandidaten auf diese Ausschreibungen ein (leer = alle)
+	TenderIDs []uuid.UUID
+	// IncludeExpired nimmt auch abgelaufene Ausschreibungen auf
+	IncludeExpired bool
+	// ExactScan bewertet alle Ausschreibungen exakt statt über ANN + Vorfilter
+	// (Referenz für Benchmark und Recall-Messung)
+	ExactScan bool
+	// IncludeIneligible liefert auch Ausschreibungen, deren Mindestanforderungen die Firma nicht erfüllt
+	IncludeIneligible bool
+}
+
+func (s *MatchingService) FindMatchesHybrid(ctx context.Context, authUserID uuid.UUID, limit int, includeIneligible bool) ([]domain.Match, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.FindMatchesForCompany(ctx, company.ID, MatchOptions{
+		Limit:             limit,
+		UseFeedback:       true,
+		IncludeIneligible: includeIneligible,
+	})
+}
+
+// FindMatchesForCompany führt Kandidatensuche (SQL) und Re-Ranking (Feedback) für eine Firma aus
+func (s *MatchingService) FindMatchesForCompany(ctx context.Context, companyID uuid.UUID, opts MatchOptions) ([]domain.Match, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 20
 	}
 
 	// Company mit ALLEN benötigten Feldern laden
 	var company domain.Company
 	if err := s.db.WithContext(ctx).
-		Select("id", "profile_embedding", "industry_tags", "location_geom", "service_radius_km").
-		Where("auth_user_id = ?", authUserID).
+		Select("id", "profile_embedding", "industry_tags", "location_geom", "service_radius_km", "latitude", "longitude", "settings",
+			"annual_revenue", "employee_count", "certifications", "is_avpq").
+		Where("id = ?", companyID).
 		First(&company).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrCompanyNotFound
@@ -39,98 +91,87 @@ func (s *MatchingService) FindMatchesHybrid(ctx context.Context, authUserID uuid
 		return nil, fmt.Errorf("load company failed: %w", err)
 	}
 
-	// Hybrid Query mit korrekter PostGIS-Distanzberechnung
-	rows := make([]matchRowHybrid, 0, limit)
+	// Strukturierte Nachweise mit Gültigkeit für die Eignungsprüfung
+	now := time.Now()
+	certificates, err := loadCompanyCertificates(ctx, s.db, company.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	held := companyCertificates(&company, certificates)
+	today := germanToday(now)
+
+	signals := &feedbackSignals{}
+	if opts.UseFeedback {
+		var err error
+		signals, err = s.loadFeedbackSignals(ctx, company.ID, opts.FeedbackBefore)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// WICHTIG: Raw-Query mit Named Parameters
-	err := s.db.WithContext(ctx).Raw(`
-		WITH company_data AS (
-			SELECT 
-				id, 
-				profile_embedding, 
-				industry_tags, 
-				location_geom, 
-				service_radius_km
-			FROM companies 
-			WHERE id = @company_id
-		),
-		tender_candidates AS (
-			SELECT 
-				t.id,
-				t.title,
-				t.description_full,
-				t.deadline,
-				t.region_zip,
-				t.cpv_codes,
-				t.requirement_embedding,
-				t.location_geom AS tender_location,
-				-- 1. Vektor-Ähnlichkeit
-				1 - (t.requirement_embedding <=> c.profile_embedding) AS vector_score,
-				-- 2. CPV-Überlappung
-				COALESCE(
-					(SELECT COUNT(*)::float / NULLIF(array_length(t.cpv_codes, 1), 0)
-					FROM unnest(t.cpv_codes) AS t_cpv
-					WHERE t_cpv = ANY(c.industry_tags)
-					), 0
-				) AS cpv_score,
-				-- 3. Geo-Distanz
-				CASE 
-					WHEN c.location_geom IS NOT NULL AND t.location_geom IS NOT NULL 
-					THEN ST_Distance(c.location_geom::geography, t.location_geom::geography) / 1000
-					ELSE NULL
-				END AS distance_km,
-				-- 4. Ist innerhalb des Radius?
-				CASE 
-					WHEN c.location_geom IS NOT NULL AND t.location_geom IS NOT NULL 
-					THEN ST_DWithin(c.location_geom::geography, t.location_geom::geography, c.service_radius_km * 1000)
-					ELSE false
-				END AS is_within_radius
-			FROM tenders t
-			CROSS JOIN company_data c
-			WHERE t.deadline > NOW()
-		)
-		SELECT 
-			id AS tender_id,
-			title,
-			description_full AS description,
-			deadline,
-			region_zip,
-			cpv_codes,
-			vector_score,
-			cpv_score,
-			distance_km,
-			is_within_radius,
-			-- Geo-Score berechnen (höher = besser)
-			CASE 
-				WHEN distance_km IS NULL THEN 0.3
-				WHEN is_within_radius = true THEN 1.0
-				WHEN distance_km <= 50 THEN 0.8
-				WHEN distance_km <= 100 THEN 0.6
-				WHEN distance_km <= 200 THEN 0.4
-				ELSE 0.2
-			END AS geo_score
-		FROM tender_candidates
-		WHERE (vector_score > 0.3 OR cpv_score > 0.2 OR geo_score > 0.5)
-		ORDER BY 
-			CASE WHEN is_within_radius = true THEN 0 ELSE 1 END,
-			(vector_score * 0.5 + cpv_score * 0.3 + geo_score * 0.2) DESC
-		LIMIT @limit
-	`, sql.Named("company_id", company.ID), sql.Named("limit", limit)).Scan(&rows).Error
+	// Effektiver Profilvektor: Richtung gemochter Ausschreibungen verschoben
+	profileVector := signals.adjustProfileVector(company.ProfileEmbedding.Slice())
 
+	candidateLimit := opts.Limit * candidateFactor
+	if candidateLimit < minCandidateLimit {
+		candidateLimit = minCandidateLimit
+	}
+
+	// Wunschregionen aus dem Onboarding als NUTS-Codes
+	preferredRegions := preferredRegionsFromSettings(company.Settings)
+
+	rows, err := s.findCandidates(ctx, &company, profileVector, preferredRegions, candidateLimit, opts)
 	if err != nil {
-		return nil, fmt.Errorf("hybrid query failed: %w", err)
+		return nil, err
+	}
+
+	// Re-Ranking
+	scored := make([]scoredCandidate, 0, len(rows))
+	for _, row := range rows {
+		if signals.excluded[row.TenderID] {
+			continue
+		}
+		eligibility, issues := evaluateEligibility(&company, held, today, row.Deadline.Time, row.MinAnnualRevenue, row.MinEmployeeCount, row.RequiredCertificates)
+		if eligibility == domain.EligibilityIneligible && !opts.IncludeIneligible {
+			continue
+		}
+		regionName := applyRegionSignals(&row, preferredRegions)
+		base := calculateWeightedScore(row.VectorScore, row.CPVScore, row.GeoScore)
+		scored = append(scored, scoredCandidate{
+			row:         row,
+			score:       clampScore(base - signals.penalty(row)),
+			regionName:  regionName,
+			eligibility: eligibility,
+			issues:      issues,
+		})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		wi, wj := scored[i].row.IsWithinRadius.Bool, scored[j].row.IsWithinRadius.Bool
+		if wi != wj {
+			return wi
+		}
+		return scored[i].score > scored[j].score
+	})
+	if len(scored) > opts.Limit {
+		scored = scored[:opts.Limit]
 	}
 
 	// Matches erstellen
-	matches := make([]domain.Match, len(rows))
-	for i, row := range rows {
+	matches := make([]domain.Match, len(scored))
+	for i, sc := range scored {
+		row := sc.row
 		matches[i] = domain.Match{
 			ID:        uuid.New(),
 			CompanyID: company.ID,
 			TenderID:  row.TenderID,
-			Score:     calculateWeightedScore(row.VectorScore, row.CPVScore, row.GeoScore),
-			Reason:    generateReason(row.VectorScore, row.CPVScore, row.DistanceKM, row.IsWithinRadius),
+			Score:     sc.score,
+			Reason:    generateReason(row.VectorScore, row.CPVScore, row.DistanceKM, row.IsWithinRadius, sc.regionName),
 			Status:    "new",
+
+			Eligibility:       sc.eligibility,
+			EligibilityIssues: sc.issues,
+
 			Tender: domain.Tender{
 				ID:              row.TenderID,
 				Title:           row.Title,
@@ -138,6 +179,10 @@ func (s *MatchingService) FindMatchesHybrid(ctx context.Context, authUserID uuid
 				Deadline:        row.Deadline.Time,
 				RegionZIP:       row.RegionZIP,
 				CPVCodes:        row.CPVCodes,
+				NutsCodes:       row.NutsCodes,
+				EstimatedValue:  row.EstimatedValue.Float64,
+
+				RequiredCertificates: row.RequiredCertificates,
 			},
 		}
 	}
@@ -145,6 +190,240 @@ func (s *MatchingService) FindMatchesHybrid(ctx context.Context, authUserID uuid
 	return matches, nil
 }
 
+// findCandidates ist die Kandidaten-Stufe. Statt alle Ausschreibungen zu scannen, werden
+// Kandidaten aus dem HNSW-Index (ANN) und aus CPV-, NUTS- und Geo-Vorfiltern vereinigt und
+// anschließend exakt mit Vektor-, CPV- und Geo-Score bewertet.
+func (s *MatchingService) findCandidates(ctx context.Context, company *domain.Company, profileVector []float32, preferredRegions []string, limit int, opts MatchOptions) ([]matchRowHybrid, error) {
+	var profileParam any
+	if len(profileVector) > 0 {
+		profileParam = pgvector.NewVector(profileVector)
+	}
+
+	var tenderFilter any
+	if len(opts.TenderIDs) > 0 {
+		ids := make([]string, len(opts.TenderIDs))
+		for i, id := range opts.TenderIDs {
+			ids[i] = id.String()
+		}
+		tenderFilter = pq.StringArray(ids)
+	}
+
+	var industryTags any
+	if len(company.IndustryTags) > 0 {
+		industryTags = company.IndustryTags
+	}
+
+	var regionCodes any
+	if len(preferredRegions) > 0 {
+		regionCodes = pq.StringArray(preferredRegions)
+	}
+
+	annLimit := limit * annFactor
+	efSearch := min(max(annLimit, 100), maxEFSearch)
+
+	// Hybrid Query mit korrekter PostGIS-Distanzberechnung
+	rows := make([]matchRowHybrid, 0, limit)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// ef_search >= LIMIT für ausreichenden Recall; iterative_scan (pgvector >= 0.8)
+		// sucht weiter, wenn der Deadline-Filter Treffer aus dem Index verwirft
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", efSearch)).Error; err != nil {
+			return fmt.Errorf("set ef_search: %w", err)
+		}
+		if err := tx.Exec("SET LOCAL hnsw.iterative_scan = relaxed_order").Error; err != nil {
+			return fmt.Errorf("set iterative_scan: %w", err)
+		}
+
+		// WICHTIG: Raw-Query mit Named Parameters
+		return tx.Raw(`
+			WITH company_data AS (
+				SELECT
+					id,
+					industry_tags,
+					location_geom,
+					service_radius_km
+				FROM companies
+				WHERE id = @company_id
+			),
+			-- Stufe 1a: nächste Nachbarn über den HNSW-Index
+			ann_hits AS (
+				SELECT t.id
+				FROM tenders t
+				WHERE NOT @exact_scan
+				  AND CAST(@tender_ids AS uuid[]) IS NULL
+				  AND CAST(@profile_vector AS vector) IS NOT NULL
+				  AND (@include_expired OR t.deadline > NOW())
+				ORDER BY t.requirement_embedding <=> CAST(@profile_vector AS vector)
+				LIMIT @ann_limit
+			),
+			-- Stufe 1b: CPV-Vorfilter (GIN auf cpv_codes)
+			cpv_hits AS (
+				SELECT t.id
+				FROM tenders t
+				WHERE NOT @exact_scan
+				  AND CAST(@tender_ids AS uuid[]) IS NULL
+				  AND t.cpv_codes && CAST(@industry_tags AS text[])
+				  AND (@include_expired OR t.deadline > NOW())
+				ORDER BY t.requirement_embedding <=> CAST(@profile_vector AS vector) NULLS LAST, t.deadline ASC
+				LIMIT @prefilter_limit
+			),
+			-- Stufe 1c: NUTS-Vorfilter auf die Wunschregionen (GIN auf nuts_prefixes)
+			region_hits AS (
+				SELECT t.id
+				FROM tenders t
+				WHERE NOT @exact_scan
+				  AND CAST(@tender_ids AS uuid[]) IS NULL
+				  AND public.nuts_prefixes(t.nutscodes) && CAST(@region_codes AS text[])
+				  AND (@include_expired OR t.deadline > NOW())
+				ORDER BY t.requirement_embedding <=> CAST(@profile_vector AS vector) NULLS LAST, t.deadline ASC
+				LIMIT @prefilter_limit
+			),
+			-- Stufe 1d: Geo-Vorfilter im Service-Radius (mind. 100 km, GIST auf geography)
+			geo_hits AS (
+				SELECT t.id
+				FROM tenders t
+				CROSS JOIN company_data c
+				WHERE NOT @exact_scan
+				  AND CAST(@tender_ids AS uuid[]) IS NULL
+				  AND c.location_geom IS NOT NULL
+				  AND ST_DWithin(t.location_geom::geography, c.location_geom::geography, GREATEST(c.service_radius_km, 100) * 1000)
+				  AND (@include_expired OR t.deadline > NOW())
+				ORDER BY ST_Distance(t.location_geom::geography, c.location_geom::geography)
+				LIMIT @prefilter_limit
+			),
+			-- Stufe 1e: Ausschreibungen ohne location_geom, deren NUTS-3-Schwerpunkt im Radius liegt
+			nuts_near AS (
+				SELECT array_agg(n.code) AS codes
+				FROM public.nuts_centroids n
+				CROSS JOIN company_data c
+				WHERE n.level = 3
+				  AND c.location_geom IS NOT NULL
+				  AND ST_DWithin(ST_SetSRID(ST_MakePoint(n.longitude, n.latitude), 4326)::geography, c.location_geom::geography, GREATEST(c.service_radius_km, 100) * 1000)
+			),
+			nuts_geo_hits AS (
+				SELECT t.id
+				FROM tenders t
+				CROSS JOIN nuts_near nn
+				WHERE NOT @exact_scan
+				  AND CAST(@tender_ids AS uuid[]) IS NULL
+				  AND t.location_geom IS NULL
+				  AND public.nuts_prefixes(t.nutscodes) && nn.codes
+				  AND (@include_expired OR t.deadline > NOW())
+				ORDER BY t.requirement_embedding <=> CAST(@profile_vector AS vector) NULLS LAST, t.deadline ASC
+				LIMIT @prefilter_limit
+			),
+			candidate_ids AS (
+				SELECT id FROM ann_hits
+				UNION SELECT id FROM cpv_hits
+				UNION SELECT id FROM region_hits
+				UNION SELECT id FROM geo_hits
+				UNION SELECT id FROM nuts_geo_hits
+				-- Explizite Auswahl (Offline-Evaluation)
+				UNION SELECT unnest(CAST(@tender_ids AS uuid[]))
+				-- Exakter Vollscan (Benchmark / Recall-Messung)
+				UNION SELECT t.id FROM tenders t
+				WHERE @exact_scan
+				  AND CAST(@tender_ids AS uuid[]) IS NULL
+				  AND (@include_expired OR t.deadline > NOW())
+			),
+			-- Stufe 2: exaktes Re-Scoring der Kandidaten
+			tender_candidates AS (
+				SELECT
+					t.id,
+					t.title,
+					t.description_full,
+					t.deadline,
+					t.region_zip,
+					t.cpv_codes,
+					t.nutscodes,
+					t.estimated_value,
+					t.min_annual_revenue,
+					t.min_employee_count,
+					t.required_certificates,
+					t.location_geom AS tender_location,
+					-- 1. Vektor-Ähnlichkeit (gegen den feedback-angepassten Profilvektor)
+					COALESCE(1 - (t.requirement_embedding <=> CAST(@profile_vector AS vector)), 0) AS vector_score,
+					-- 2. CPV-Überlappung
+					COALESCE(
+						(SELECT COUNT(*)::float / NULLIF(array_length(t.cpv_codes, 1), 0)
+						FROM unnest(t.cpv_codes) AS t_cpv
+						WHERE t_cpv = ANY(c.industry_tags)
+						), 0
+					) AS cpv_score,
+					-- 3. Geo-Distanz (ohne location_geom zum NUTS-Schwerpunkt)
+					CASE
+						WHEN c.location_geom IS NOT NULL AND tg.geog IS NOT NULL
+						THEN ST_Distance(c.location_geom::geography, tg.geog) / 1000
+						ELSE NULL
+					END AS distance_km,
+					-- 4. Ist innerhalb des Radius?
+					CASE
+						WHEN c.location_geom IS NOT NULL AND tg.geog IS NOT NULL
+						THEN ST_DWithin(c.location_geom::geography, tg.geog, c.service_radius_km * 1000)
+						ELSE false
+					END AS is_within_radius,
+					-- 5. Liegt in einer Wunschregion (NUTS-Präfix)?
+					COALESCE(public.nuts_prefixes(t.nutscodes) && CAST(@region_codes AS text[]), false) AS in_preferred_region
+				FROM candidate_ids ci
+				JOIN tenders t ON t.id = ci.id
+				CROSS JOIN company_data c
+				CROSS JOIN LATERAL (
+					SELECT COALESCE(t.location_geom::geography, public.nuts_centroid(t.nutscodes)) AS geog
+				) tg
+				WHERE (@include_expired OR t.deadline > NOW())
+			),
+			-- Geo-Score berechnen (höher = besser); eigene Stufe, damit Filter und Sortierung ihn sehen
+			scored AS (
+				SELECT
+					tc.*,
+					`+geoScoreSQL+` AS geo_score
+				FROM tender_candidates tc
+			)
+			SELECT
+				id AS tender_id,
+				title,
+				description_full AS description,
+				deadline,
+				region_zip,
+				cpv_codes,
+				nutscodes,
+				estimated_value,
+				min_annual_revenue,
+				min_employee_count,
+				required_certificates,
+				vector_score,
+				cpv_score,
+				distance_km,
+				is_within_radius,
+				geo_score
+			FROM scored
+			WHERE (vector_score > 0.3 OR cpv_score > 0.2 OR geo_score > 0.5 OR in_preferred_region)
+			ORDER BY
+				CASE WHEN is_within_radius = true THEN 0 ELSE 1 END,
+				-- Wunschregion zählt wie im Re-Ranking als voller Geo-Score
+				(vector_score * 0.5 + cpv_score * 0.3 + GREATEST(geo_score, CASE WHEN in_preferred_region THEN 1.0 ELSE 0 END) * 0.2) DESC
+			LIMIT @limit
+		`,
+			sql.Named("company_id", company.ID),
+			sql.Named("profile_vector", profileParam),
+			sql.Named("include_expired", opts.IncludeExpired),
+			sql.Named("exact_scan", opts.ExactScan),
+			sql.Named("tender_ids", tenderFilter),
+			sql.Named("industry_tags", industryTags),
+			sql.Named("region_codes", regionCodes),
+			sql.Named("ann_limit", annLimit),
+			sql.Named("prefilter_limit", prefilterLimit),
+			sql.Named("limit", limit),
+		).Scan(&rows).Error
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("hybrid query failed: %w", err)
+	}
+
+	return rows, nil
+}
+
 // Hilfs-Struct muss ALLE Felder aus der Query enthalten
 type matchRowHybrid struct {
 	TenderID       uuid.UUID       `gorm:"column:tender_id"`
@@ -152,20 +431,69 @@ type matchRowHybrid struct {
 	Description    string          `gorm:"column:description"`
 	Deadline       sql.NullTime    `gorm:"column:deadline"`
 	RegionZIP      string          `gorm:"column:region_zip"`
-	CPVCodes       []string        `gorm:"column:cpv_codes;type:text[]"`
-	VectorScore    float64         `gorm:"column:vector_score"`
-	CPVScore       float64         `gorm:"column:cpv_score"`
-	DistanceKM     sql.NullFloat64 `gorm:"column:distance_km"`
-	IsWithinRadius sql.NullBool    `gorm:"column:is_within_radius"`
-	GeoScore       float64         `gorm:"column:geo_score"`
+	CPVCodes       pq.StringArray  `gorm:"column:cpv_codes;type:text[]"`
+	NutsCodes      pq.StringArray  `gorm:"column:nutscodes;type:text[]"`
+	EstimatedValue sql.NullFloat64 `gorm:"column:estimated_value"`
+	// Mindestanforderungen für die Eignungsprüfung
+	MinAnnualRevenue     sql.NullFloat64 `gorm:"column:min_annual_revenue"`
+	MinEmployeeCount     sql.NullInt64   `gorm:"column:min_employee_count"`
+	RequiredCertificates pq.StringArray  `gorm:"column:required_certificates;type:text[]"`
+	VectorScore          float64         `gorm:"column:vector_score"`
+	CPVScore             float64         `gorm:"column:cpv_score"`
+	DistanceKM           sql.NullFloat64 `gorm:"column:distance_km"`
+	IsWithinRadius       sql.NullBool    `gorm:"column:is_within_radius"`
+	GeoScore             float64         `gorm:"column:geo_score"`
+}
+
+type scoredCandidate struct {
+	row         matchRowHybrid
+	score       float64
+	regionName  string
+	eligibility string
+	issues      []domain.EligibilityIssue
+}
+
+// applyRegionSignals ergänzt den Geo-Score um Wunschregionen: Treffer heben den
+// Geo-Score an. Die Distanz zum NUTS-Schwerpunkt (ohne location_geom) liefert
+// bereits die Hybrid-Query. Gibt den Namen der getroffenen Wunschregion zurück
+// (leer, wenn keine).
+func applyRegionSignals(row *matchRowHybrid, preferredRegions []string) string {
+	regionScore, matched := nutsRegionScore(row.NutsCodes, preferredRegions)
+	if regionScore > row.GeoScore {
+		row.GeoScore = regionScore
+	}
+	if regionScore < 1 {
+		return ""
+	}
+	return nutsRegionName(matched)
 }
 
+// geoScoreSQL bewertet distance_km und is_within_radius (höher = besser); gilt für Feed und Partnersuche
+const geoScoreSQL = `CASE
+	WHEN distance_km IS NULL THEN 0.3
+	WHEN is_within_radius = true THEN 1.0
+	WHEN distance_km <= 50 THEN 0.8
+	WHEN distance_km <= 100 THEN 0.6
+	WHEN distance_km <= 200 THEN 0.4
+	ELSE 0.2
+END`
+
 // Gewichtung: 50% Vektor, 30% CPV, 20% Geo
 func calculateWeightedScore(v, c, g float64) float64 {
 	return (v * 0.5) + (c * 0.3) + (g * 0.2)
 }
 
-func generateReason(v, c float64, distance sql.NullFloat64, withinRadius sql.NullBool) string {
+func clampScore(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+func generateReason(v, c float64, distance sql.NullFloat64, withinRadius sql.NullBool, regionName string) string {
 	if withinRadius.Bool && v > 0.7 {
 		return "Perfekte Übereinstimmung in Ihrer Nähe"
 	}
@@ -181,5 +509,8 @@ func generateReason(v, c float64, distance sql.NullFloat64, withinRadius sql.Nul
 	if distance.Valid && distance.Float64 < 50 {
 		return fmt.Sprintf("Gute geografische Nähe (%.1f km)", distance.Float64)
 	}
+	if regionName != "" {
+		return fmt.Sprintf("In Ihrer Wunschregion (%s)", regionName)
+	}
 	return "Potenzielle Übereinstimmung"
 }
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+// Gewichte für das Feedback-Re-Ranking (Rocchio-artige Verschiebung des Profilvektors)
+const (
+	feedbackLikeWeight    = 0.30
+	feedbackDislikeWeight = 0.15
+
+	// Abzug je verworfener, Bonus je gemochter Ausschreibung pro CPV-Gruppe
+	cpvPenaltyWrongTrade = 0.15
+	cpvPenaltyOther      = 0.05
+	cpvBonusLike         = 0.10
+	// Obergrenze für Abzug und Bonus aus CPV-Gruppen
+	maxCPVPenalty = 0.40
+
+	tooFarPenalty = 0.10
+	tooBigPenalty = 0.10
+)
+
+// feedbackSignals bündelt alles, was das Re-Ranking aus dem Feedback einer Firma ableitet
+type feedbackSignals struct {
+	liked    [][]float32
+	disliked [][]float32
+
+	// excluded enthält verworfene Ausschreibungen, die nicht erneut vorgeschlagen werden
+	excluded map[uuid.UUID]bool
+	// cpvPenalty bildet eine CPV-Gruppe (erste 3 Ziffern) auf einen Score-Abzug ab (negativ = Bonus)
+	cpvPenalty map[string]float64
+	// tooFarKM ist die kleinste Distanz, die als "zu weit" markiert wurde (0 = kein Signal)
+	tooFarKM float64
+	// tooBigValue ist der kleinste Auftragswert, der als "zu groß" markiert wurde (0 = kein Signal)
+	tooBigValue float64
+}
+
+type feedbackRow struct {
+	TenderID       uuid.UUID        `gorm:"column:tender_id"`
+	Vote           string           `gorm:"column:vote"`
+	Reason         string           `gorm:"column:reason"`
+	CPVCodes       pq.StringArray   `gorm:"column:cpv_codes;type:text[]"`
+	EstimatedValue sql.NullFloat64  `gorm:"column:estimated_value"`
+	DistanceKM     sql.NullFloat64  `gorm:"column:distance_km"`
+	Embedding      *pgvector.Vector `gorm:"column:requirement_embedding"`
+}
+
+// loadFeedbackSignals lädt das Feedback einer Firma samt Ausschreibungsdaten.
+// before begrenzt auf Feedback vor diesem Zeitpunkt (Zero = alles).
+func (s *MatchingService) loadFeedbackSignals(ctx context.Context, companyID uuid.UUID, before time.Time) (*feedbackSignals, error) {
+	var beforeParam sql.NullTime
+	if !before.IsZero() {
+		beforeParam = sql.NullTime{Time: before, Valid: true}
+	}
+
+	var rows []feedbackRow
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT
+			f.tender_id,
+			f.vote,
+			COALESCE(f.reason, '') AS reason,
+			t.cpv_codes,
+			t.estimated_value,
+			t.requirement_embedding,
+			CASE
+				WHEN c.location_geom IS NOT NULL AND t.location_geom IS NOT NULL
+				THEN ST_Distance(c.location_geom::geography, t.location_geom::geography) / 1000
+				ELSE NULL
+			END AS distance_km
+		FROM match_feedbacks f
+		JOIN tenders t ON t.id = f.tender_id
+		JOIN companies c ON c.id = f.company_id
+		WHERE f.company_id = @company_id
+		  AND (CAST(@before AS timestamptz) IS NULL OR f.created_at < CAST(@before AS timestamptz))
+	`, sql.Named("company_id", companyID), sql.Named("before", beforeParam)).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("load feedback failed: %w", err)
+	}
+
+	return buildFeedbackSignals(rows), nil
+}
+
+func buildFeedbackSignals(rows []feedbackRow) *feedbackSignals {
+	signals := &feedbackSignals{
+		excluded:   make(map[uuid.UUID]bool),
+		cpvPenalty: make(map[string]float64),
+	}
+
+	for _, row := range rows {
+		var embedding []float32
+		if row.Embedding != nil {
+			embedding = row.Embedding.Slice()
+		}
+
+		if row.Vote == domain.FeedbackVoteUp {
+			if len(embedding) > 0 {
+				signals.liked = append(signals.liked, embedding)
+			}
+			for _, group := range cpvGroups(row.CPVCodes) {
+				signals.cpvPenalty[group] -= cpvBonusLike
+			}
+			continue
+		}
+
+		signals.excluded[row.TenderID] = true
+		if len(embedding) > 0 {
+			signals.disliked = append(signals.disliked, embedding)
+		}
+
+		penalty := cpvPenaltyOther
+		if row.Reason == domain.FeedbackReasonWrongTrade {
+			penalty = cpvPenaltyWrongTrade
+		}
+		for _, group := range cpvGroups(row.CPVCodes) {
+			signals.cpvPenalty[group] += penalty
+		}
+
+		switch row.Reason {
+		case domain.FeedbackReasonTooFar:
+			if row.DistanceKM.Valid && (signals.tooFarKM == 0 || row.DistanceKM.Float64 < signals.tooFarKM) {
+				signals.tooFarKM = row.DistanceKM.Float64
+			}
+		case domain.FeedbackReasonTooBig:
+			if row.EstimatedValue.Valid && row.EstimatedValue.Float64 > 0 &&
+				(signals.tooBigValue == 0 || row.EstimatedValue.Float64 < signals.tooBigValue) {
+				signals.tooBigValue = row.EstimatedValue.Float64
+			}
+		}
+	}
+
+	return signals
+}
+
+// adjustProfileVector verschiebt den Profilvektor Richtung gemochter und weg von verworfenen Ausschreibungen
+func (f *feedbackSignals) adjustProfileVector(profile []float32) []float32 {
+	if len(profile) == 0 || (len(f.liked) == 0 && len(f.disliked) == 0) {
+		return profile
+	}
+
+	adjusted := make([]float64, len(profile))
+	for i, v := range profile {
+		adjusted[i] = float64(v)
+	}
+	addCentroid(adjusted, f.liked, feedbackLikeWeight)
+	addCentroid(adjusted, f.disliked, -feedbackDislikeWeight)
+
+	var norm float64
+	for _, v := range adjusted {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+
+	result := make([]float32, len(adjusted))
+	for i, v := range adjusted {
+		if norm > 0 {
+			v /= norm
+		}
+		result[i] = float32(v)
+	}
+	return result
+}
+
+func addCentroid(target []float64, vectors [][]float32, weight float64) {
+	if len(vectors) == 0 {
+		return
+	}
+	scale := weight / float64(len(vectors))
+	for _, vec := range vectors {
+		if len(vec) != len(target) {
+			continue
+		}
+		for i, v := range vec {
+			target[i] += float64(v) * scale
+		}
+	}
+}
+
+// penalty berechnet den Score-Abzug für einen Kandidaten. Negativ, wenn der Bonus gemochter
+// CPV-Gruppen überwiegt: stärkster Abzug minus stärkster Bonus, beide gedeckelt.
+func (f *feedbackSignals) penalty(row matchRowHybrid) float64 {
+	var cpvPenalty, cpvBonus float64
+	for _, group := range cpvGroups(row.CPVCodes) {
+		p := f.cpvPenalty[group]
+		if p > cpvPenalty {
+			cpvPenalty = p
+		}
+		if -p > cpvBonus {
+			cpvBonus = -p
+		}
+	}
+	total := math.Min(cpvPenalty, maxCPVPenalty) - math.Min(cpvBonus, maxCPVPenalty)
+
+	if f.tooFarKM > 0 && row.DistanceKM.Valid && row.DistanceKM.Float64 >= f.tooFarKM {
+		total += tooFarPenalty
+	}
+	// Schon ab 80% des als zu groß markierten Volumens abwerten
+	if f.tooBigValue > 0 && row.EstimatedValue.Valid && row.EstimatedValue.Float64 >= f.tooBigValue*0.8 {
+		total += tooBigPenalty
+	}
+
+	return total
+}
+
+// cpvGroups reduziert CPV-Codes auf ihre Gruppe (erste 3 Ziffern, z.B. "452" für Bauarbeiten)
+func cpvGroups(codes []string) []string {
+	groups := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if len(code) < 3 {
+			continue
+		}
+		group := code[:3]
+		if !contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+func voteRow(vote, reason string, cpv ...string) feedbackRow {
+	return feedbackRow{TenderID: uuid.New(), Vote: vote, Reason: reason, CPVCodes: pq.StringArray(cpv)}
+}
+
+func TestFeedbackPenalty(t *testing.T) {
+	tooFar := voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonTooFar)
+	tooFar.DistanceKM = sql.NullFloat64{Float64: 80, Valid: true}
+	tooBig := voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonTooBig)
+	tooBig.EstimatedValue = sql.NullFloat64{Float64: 1_000_000, Valid: true}
+
+	tests := []struct {
+		name      string
+		feedback  []feedbackRow
+		candidate matchRowHybrid
+		want      float64
+	}{
+		{
+			name:      "no feedback",
+			candidate: matchRowHybrid{CPVCodes: pq.StringArray{"45210000"}},
+			want:      0,
+		},
+		{
+			name:      "liked group gets bonus",
+			feedback:  []feedbackRow{voteRow(domain.FeedbackVoteUp, "", "45200000")},
+			candidate: matchRowHybrid{CPVCodes: pq.StringArray{"45210000"}},
+			want:      -cpvBonusLike,
+		},
+		{
+			name:      "wrong trade penalty",
+			feedback:  []feedbackRow{voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonWrongTrade, "71300000")},
+			candidate: matchRowHybrid{CPVCodes: pq.StringArray{"71310000"}},
+			want:      cpvPenaltyWrongTrade,
+		},
+		{
+			name: "strongest penalty minus strongest bonus",
+			feedback: []feedbackRow{
+				voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonWrongTrade, "71300000"),
+				voteRow(domain.FeedbackVoteUp, "", "45200000"),
+			},
+			candidate: matchRowHybrid{CPVCodes: pq.StringArray{"71310000", "45210000"}},
+			want:      cpvPenaltyWrongTrade - cpvBonusLike,
+		},
+		{
+			name: "like and dislike in same group offset",
+			feedback: []feedbackRow{
+				voteRow(domain.FeedbackVoteUp, "", "45200000"),
+				voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonOther, "45200000"),
+			},
+			candidate: matchRowHybrid{CPVCodes: pq.StringArray{"45210000"}},
+			want:      cpvPenaltyOther - cpvBonusLike,
+		},
+		{
+			name: "penalty capped",
+			feedback: []feedbackRow{
+				voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonWrongTrade, "71300000"),
+				voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonWrongTrade, "71300000"),
+				voteRow(domain.FeedbackVoteDown, domain.FeedbackReasonWrongTrade, "71300000"),
+			},
+			candidate: matchRowHybrid{CPVCodes: pq.StringArray{"71300000"}},
+			want:      maxCPVPenalty,
+		},
+		{
+			name: "bonus capped",
+			feedback: []feedbackRow{
+				voteRow(domain.FeedbackVoteUp, "", "45200000"),
+				voteRow(domain.FeedbackVoteUp, "", "45200000"),
+				voteRow(domain.FeedbackVoteUp, "", "45200000"),
+				voteRow(domain.FeedbackVoteUp, "", "45200000"),
+				voteRow(domain.FeedbackVoteUp, "", "45200000"),
+			},
+			candidate: matchRowHybrid{CPVCodes: pq.StringArray{"45200000"}},
+			want:      -maxCPVPenalty,
+		},
+		{
+			name:      "too far",
+			feedback:  []feedbackRow{tooFar},
+			candidate: matchRowHybrid{DistanceKM: sql.NullFloat64{Float64: 100, Valid: true}},
+			want:      tooFarPenalty,
+		},
+		{
+			name:      "closer than too far",
+			feedback:  []feedbackRow{tooFar},
+			candidate: matchRowHybrid{DistanceKM: sql.NullFloat64{Float64: 50, Valid: true}},
+			want:      0,
+		},
+		{
+			name:      "too big from 80 percent",
+			feedback:  []feedbackRow{tooBig},
+			candidate: matchRowHybrid{EstimatedValue: sql.NullFloat64{Float64: 800_000, Valid: true}},
+			want:      tooBigPenalty,
+		},
+		{
+			name:      "below too big threshold",
+			feedback:  []feedbackRow{tooBig},
+			candidate: matchRowHybrid{EstimatedValue: sql.NullFloat64{Float64: 700_000, Valid: true}},
+			want:      0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildFeedbackSignals(tt.feedback).penalty(tt.candidate)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("penalty = %.4f, want %.4f", got, tt.want)
+			}
+		})
+	}
+}
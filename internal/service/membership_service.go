@@ -0,0 +1,493 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientRole   = errors.New("insufficient role for this action")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrInvalidMembership  = errors.New("invalid membership change")
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	ErrAlreadyMember      = errors.New("user already belongs to a company")
+)
+
+// invitationTTL ist die Gültigkeit eines Einladungslinks
+const invitationTTL = 7 * 24 * time.Hour
+
+// roleRank ordnet die Rollen; eine höhere Rolle umfasst alle Rechte der niedrigeren
+var roleRank = map[string]int{
+	domain.RoleViewer:     1,
+	domain.RoleBidManager: 2,
+	domain.RoleAdmin:      3,
+	domain.RoleOwner:      4,
+}
+
+// RoleAtLeast prüft, ob role mindestens die Rechte von min hat
+func RoleAtLeast(role, min string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[min]
+}
+
+type MembershipService struct {
+	db     *gorm.DB
+	mailer *Mailer
+	appURL string
+}
+
+// NewMembershipService: ohne Mailer werden Einladungen nicht verschickt, der Link steht
+// aber in der Antwort. appURL ist die Adresse des Frontends für den Einladungslink.
+func NewMembershipService(db *gorm.DB, mailer *Mailer, appURL string) *MembershipService {
+	return &MembershipService{db: db, mailer: mailer, appURL: strings.TrimRight(appURL, "/")}
+}
+
+// Authorize lädt die Mitgliedschaft des Nutzers und prüft die Mindestrolle.
+// Ohne Mitgliedschaft: ErrCompanyNotFound.
+func (s *MembershipService) Authorize(ctx context.Context, authUserID uuid.UUID, minRole string) (*domain.CompanyMember, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRole(member, minRole); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// ListMembers listet alle Mitglieder der eigenen Firma (owner zuerst)
+func (s *MembershipService) ListMembers(ctx context.Context, authUserID uuid.UUID) ([]domain.CompanyMember, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []domain.CompanyMember
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ?", member.CompanyID).
+		Order("case role when 'owner' then 0 when 'admin' then 1 when 'bid_manager' then 2 else 3 end, created_at").
+		Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("load members failed: %w", err)
+	}
+	return members, nil
+}
+
+// UpdateMemberRole ändert die Rolle eines Mitglieds. Vergeben und ändern lassen sich nur
+// Rollen unterhalb der eigenen; owner wird man nur per Übertragung.
+func (s *MembershipService) UpdateMemberRole(ctx context.Context, authUserID, memberUserID uuid.UUID, role string) (*domain.CompanyMember, error) {
+	actor, err := s.Authorize(ctx, authUserID, domain.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.findMember(ctx, actor.CompanyID, memberUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	role = strings.ToLower(strings.TrimSpace(role))
+	if err := checkAssignableRole(actor.Role, role); err != nil {
+		return nil, err
+	}
+	if roleRank[target.Role] >= roleRank[actor.Role] {
+		return nil, fmt.Errorf("%w: cannot change the role of a %s", ErrInsufficientRole, target.Role)
+	}
+
+	target.Role = role
+	target.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).
+		Model(target).
+		Updates(map[string]any{"role": target.Role, "updated_at": target.UpdatedAt}).Error; err != nil {
+		return nil, fmt.Errorf("update member failed: %w", err)
+	}
+	return target, nil
+}
+
+// RemoveMember entfernt ein Mitglied. Jeder kann die Firma selbst verlassen (außer dem owner),
+// andere entfernen nur admin und owner, und zwar nur Mitglieder mit niedrigerer Rolle.
+func (s *MembershipService) RemoveMember(ctx context.Context, authUserID, memberUserID uuid.UUID) error {
+	actor, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return err
+	}
+
+	target := actor
+	if memberUserID != authUserID {
+		// Rolle vor dem Laden prüfen, sonst erfährt jeder, wer Mitglied ist
+		if err := checkRole(actor, domain.RoleAdmin); err != nil {
+			return err
+		}
+		if target, err = s.findMember(ctx, actor.CompanyID, memberUserID); err != nil {
+			return err
+		}
+	}
+	if err := checkMemberRemoval(actor, target); err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&domain.CompanyMember{}, "id = ?", target.ID).Error; err != nil {
+		return fmt.Errorf("remove member failed: %w", err)
+	}
+	return nil
+}
+
+// TransferOwnership macht ein Mitglied zum owner; der bisherige owner wird admin
+func (s *MembershipService) TransferOwnership(ctx context.Context, authUserID, newOwnerUserID uuid.UUID) ([]domain.CompanyMember, error) {
+	actor, err := s.Authorize(ctx, authUserID, domain.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.findMember(ctx, actor.CompanyID, newOwnerUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOwnershipTransfer(actor, target); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Erst abstufen: der Index erlaubt nur einen owner je Firma
+		if err := tx.Model(&domain.CompanyMember{}).Where("id = ?", actor.ID).
+			Updates(map[string]any{"role": domain.RoleAdmin, "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("demote owner failed: %w", err)
+		}
+		if err := tx.Model(&domain.CompanyMember{}).Where("id = ?", target.ID).
+			Updates(map[string]any{"role": domain.RoleOwner, "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("promote owner failed: %w", err)
+		}
+		if err := tx.Model(&domain.Company{}).Where("id = ?", actor.CompanyID).
+			Updates(map[string]any{"auth_user_id": target.AuthUserID, "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("update company owner failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ListMembers(ctx, authUserID)
+}
+
+// InvitationInput ist der Request-Body für eine Einladung
+type InvitationInput struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// InvitationResult enthält den Einladungslink; das Token ist nur hier im Klartext sichtbar
+type InvitationResult struct {
+	Invitation *domain.CompanyInvitation `json:"invitation"`
+	Token      string                    `json:"token"`
+	InviteURL  string                    `json:"invite_url,omitempty"`
+	EmailSent  bool                      `json:"email_sent"`
+}
+
+// ListInvitations listet die offenen Einladungen der eigenen Firma
+func (s *MembershipService) ListInvitations(ctx context.Context, authUserID uuid.UUID) ([]domain.CompanyInvitation, error) {
+	actor, err := s.Authorize(ctx, authUserID, domain.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitations []domain.CompanyInvitation
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND accepted_at IS NULL", actor.CompanyID).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("load invitations failed: %w", err)
+	}
+	return invitations, nil
+}
+
+// CreateInvitation lädt eine E-Mail-Adresse ein. Eine offene Einladung an dieselbe Adresse
+// wird ersetzt. Die Mail wird nur verschickt, wenn SMTP konfiguriert ist.
+func (s *MembershipService) CreateInvitation(ctx context.Context, authUserID uuid.UUID, input InvitationInput) (*InvitationResult, error) {
+	actor, err := s.Authorize(ctx, authUserID, domain.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, fmt.Errorf("%w: email must be a valid e-mail address", ErrInvalidMembership)
+	}
+	role := strings.ToLower(strings.TrimSpace(input.Role))
+	if err := checkAssignableRole(actor.Role, role); err != nil {
+		return nil, err
+	}
+
+	var memberCount int64
+	if err := s.db.WithContext(ctx).
+		Model(&domain.CompanyMember{}).
+		Where("company_id = ? AND lower(email) = ?", actor.CompanyID, email).
+		Count(&memberCount).Error; err != nil {
+		return nil, fmt.Errorf("check members failed: %w", err)
+	}
+	if memberCount > 0 {
+		return nil, fmt.Errorf("%w: %s is already a member", ErrInvalidMembership, email)
+	}
+
+	token, tokenHash, err := newInvitationToken()
+	if err != nil {
+		return nil, err
+	}
+	invitation := &domain.CompanyInvitation{
+		ID:        uuid.New(),
+		CompanyID: actor.CompanyID,
+		Email:     email,
+		Role:      role,
+		TokenHash: tokenHash,
+		InvitedBy: authUserID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+		CreatedAt: time.Now(),
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("company_id = ? AND lower(email) = ? AND accepted_at IS NULL", actor.CompanyID, email).
+			Delete(&domain.CompanyInvitation{}).Error; err != nil {
+			return fmt.Errorf("replace invitation failed: %w", err)
+		}
+		if err := tx.Create(invitation).Error; err != nil {
+			return fmt.Errorf("save invitation failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InvitationResult{Invitation: invitation, Token: token}
+	if s.appURL != "" {
+		result.InviteURL = s.appURL + "/invite?token=" + url.QueryEscape(token)
+	}
+	if s.mailer != nil && result.InviteURL != "" {
+		if err := s.sendInvitation(ctx, invitation, result.InviteURL); err != nil {
+			log.Printf("Invitation mail to %s failed: %v", email, err)
+		} else {
+			result.EmailSent = true
+		}
+	}
+	return result, nil
+}
+
+// RevokeInvitation zieht eine offene Einladung zurück
+func (s *MembershipService) RevokeInvitation(ctx context.Context, authUserID, invitationID uuid.UUID) error {
+	actor, err := s.Authorize(ctx, authUserID, domain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND company_id = ? AND accepted_at IS NULL", invitationID, actor.CompanyID).
+		Delete(&domain.CompanyInvitation{})
+	if result.Error != nil {
+		return fmt.Errorf("revoke invitation failed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation nimmt eine Einladung an. Die Adresse der Einladung muss zur E-Mail des
+// Logins passen, und der Nutzer darf noch keiner Firma angehören.
+func (s *MembershipService) AcceptInvitation(ctx context.Context, authUserID uuid.UUID, authEmail, token string) (*domain.CompanyMember, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("%w: token is required", ErrInvalidMembership)
+	}
+
+	var member *domain.CompanyMember
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var invitation domain.CompanyInvitation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashInvitationToken(token)).
+			First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return fmt.Errorf("load invitation failed: %w", err)
+		}
+		if err := checkInvitation(&invitation, authEmail, time.Now()); err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&domain.CompanyMember{}).Where("auth_user_id = ?", authUserID).Count(&existing).Error; err != nil {
+			return fmt.Errorf("check membership failed: %w", err)
+		}
+		if existing > 0 {
+			return ErrAlreadyMember
+		}
+
+		now := time.Now()
+		member = &domain.CompanyMember{
+			ID:         uuid.New(),
+			CompanyID:  invitation.CompanyID,
+			AuthUserID: authUserID,
+			Email:      invitation.Email,
+			Role:       invitation.Role,
+			InvitedBy:  &invitation.InvitedBy,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return fmt.Errorf("save member failed: %w", err)
+		}
+		if err := tx.Model(&invitation).
+			Updates(map[string]any{"accepted_at": now, "accepted_by": authUserID}).Error; err != nil {
+			return fmt.Errorf("update invitation failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *MembershipService) findMember(ctx context.Context, companyID, authUserID uuid.UUID) (*domain.CompanyMember, error) {
+	var member domain.CompanyMember
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND auth_user_id = ?", companyID, authUserID).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("load member failed: %w", err)
+	}
+	return &member, nil
+}
+
+func (s *MembershipService) sendInvitation(ctx context.Context, invitation *domain.CompanyInvitation, inviteURL string) error {
+	company, err := findCompanyByID(ctx, s.db, invitation.CompanyID, "id", "name")
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Einladung zu %s", company.Name)
+	body := fmt.Sprintf(`Guten Tag,
+
+Sie wurden eingeladen, im Vergabe-Agent bei %s als %s mitzuarbeiten.
+
+Einladung annehmen:
+%s
+
+Der Link ist bis %s gültig. Melden Sie sich dazu mit dieser E-Mail-Adresse an.
+`, company.Name, roleLabel(invitation.Role), inviteURL, formatGermanDateTime(invitation.ExpiresAt))
+	return s.mailer.Send(invitation.Email, subject, body)
+}
+
+// checkRole: der Nutzer hat mindestens die Rolle minRole
+func checkRole(member *domain.CompanyMember, minRole string) error {
+	if !RoleAtLeast(member.Role, minRole) {
+		return fmt.Errorf("%w: requires %s, you are %s", ErrInsufficientRole, minRole, member.Role)
+	}
+	return nil
+}
+
+// checkMemberRemoval: jeder darf selbst gehen, andere entfernt nur ein admin mit höherer Rolle.
+// Der owner bleibt, bis er die Inhaberschaft übertragen hat (die Firma hat immer genau einen).
+func checkMemberRemoval(actor, target *domain.CompanyMember) error {
+	if target.ID != actor.ID {
+		if err := checkRole(actor, domain.RoleAdmin); err != nil {
+			return err
+		}
+		if roleRank[target.Role] >= roleRank[actor.Role] {
+			return fmt.Errorf("%w: cannot remove a %s", ErrInsufficientRole, target.Role)
+		}
+	}
+	if target.Role == domain.RoleOwner {
+		return fmt.Errorf("%w: the owner must transfer ownership before leaving", ErrInvalidMembership)
+	}
+	return nil
+}
+
+// checkOwnershipTransfer: nur der owner überträgt, und zwar an ein anderes Mitglied der Firma
+func checkOwnershipTransfer(actor, target *domain.CompanyMember) error {
+	if err := checkRole(actor, domain.RoleOwner); err != nil {
+		return err
+	}
+	if target.ID == actor.ID {
+		return fmt.Errorf("%w: you already own the company", ErrInvalidMembership)
+	}
+	if target.CompanyID != actor.CompanyID {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// checkInvitation: offen, nicht abgelaufen und an die E-Mail des Logins gerichtet
+func checkInvitation(invitation *domain.CompanyInvitation, authEmail string, now time.Time) error {
+	if invitation.AcceptedAt != nil || !invitation.ExpiresAt.After(now) {
+		return ErrInvitationNotFound
+	}
+	if !strings.EqualFold(strings.TrimSpace(authEmail), invitation.Email) {
+		return fmt.Errorf("%w: invitation was sent to a different e-mail address", ErrInsufficientRole)
+	}
+	return nil
+}
+
+// checkAssignableRole: gültige Rolle unterhalb der eigenen, niemals owner
+func checkAssignableRole(actorRole, role string) error {
+	if _, ok := roleRank[role]; !ok || role == domain.RoleOwner {
+		return fmt.Errorf("%w: role must be one of admin, bid_manager, viewer", ErrInvalidMembership)
+	}
+	if roleRank[role] >= roleRank[actorRole] {
+		return fmt.Errorf("%w: a %s cannot assign the role %s", ErrInsufficientRole, actorRole, role)
+	}
+	return nil
+}
+
+func roleLabel(role string) string {
+	switch role {
+	case domain.RoleAdmin:
+		return "Administrator"
+	case domain.RoleBidManager:
+		return "Angebotsmanager"
+	case domain.RoleViewer:
+		return "Leser"
+	default:
+		return role
+	}
+}
+
+// newInvitationToken erzeugt ein zufälliges Token und dessen Hash für die Datenbank
+func newInvitationToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("generate invitation token failed: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashInvitationToken(token), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// findMembership lädt die Mitgliedschaft des Nutzers; ohne Mitgliedschaft ErrCompanyNotFound
+func findMembership(ctx context.Context, db *gorm.DB, authUserID uuid.UUID) (*domain.CompanyMember, error) {
+	var member domain.CompanyMember
+	if err := db.WithContext(ctx).Where("auth_user_id = ?", authUserID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCompanyNotFound
+		}
+		return nil, fmt.Errorf("load membership failed: %w", err)
+	}
+	return &member, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+func testMember(companyID uuid.UUID, role string) *domain.CompanyMember {
+	return &domain.CompanyMember{ID: uuid.New(), CompanyID: companyID, AuthUserID: uuid.New(), Role: role}
+}
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		role, min string
+		want      bool
+	}{
+		{domain.RoleOwner, domain.RoleOwner, true},
+		{domain.RoleOwner, domain.RoleViewer, true},
+		{domain.RoleAdmin, domain.RoleBidManager, true},
+		{domain.RoleAdmin, domain.RoleOwner, false},
+		{domain.RoleBidManager, domain.RoleBidManager, true},
+		{domain.RoleBidManager, domain.RoleAdmin, false},
+		{domain.RoleViewer, domain.RoleViewer, true},
+		{domain.RoleViewer, domain.RoleBidManager, false},
+		{"", domain.RoleViewer, false},
+		{"superuser", domain.RoleViewer, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+">="+tt.min, func(t *testing.T) {
+			if got := RoleAtLeast(tt.role, tt.min); got != tt.want {
+				t.Errorf("RoleAtLeast(%q, %q) = %v, want %v", tt.role, tt.min, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRole(t *testing.T) {
+	company := uuid.New()
+	tests := []struct {
+		name    string
+		role    string
+		minRole string
+		wantErr error
+	}{
+		{"viewer reads", domain.RoleViewer, domain.RoleViewer, nil},
+		{"viewer cannot manage bids", domain.RoleViewer, domain.RoleBidManager, ErrInsufficientRole},
+		{"bid manager manages bids", domain.RoleBidManager, domain.RoleBidManager, nil},
+		{"bid manager cannot edit profile", domain.RoleBidManager, domain.RoleAdmin, ErrInsufficientRole},
+		{"admin cannot delete company", domain.RoleAdmin, domain.RoleOwner, ErrInsufficientRole},
+		{"owner may do everything", domain.RoleOwner, domain.RoleOwner, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkRole(testMember(company, tt.role), tt.minRole); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkRole(%s, %s) = %v, want %v", tt.role, tt.minRole, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckMemberRemoval(t *testing.T) {
+	company := uuid.New()
+	owner := testMember(company, domain.RoleOwner)
+	admin := testMember(company, domain.RoleAdmin)
+	otherAdmin := testMember(company, domain.RoleAdmin)
+	bidManager := testMember(company, domain.RoleBidManager)
+	viewer := testMember(company, domain.RoleViewer)
+
+	tests := []struct {
+		name          string
+		actor, target *domain.CompanyMember
+		wantErr       error
+	}{
+		{"viewer leaves", viewer, viewer, nil},
+		{"bid manager leaves", bidManager, bidManager, nil},
+		{"admin leaves", admin, admin, nil},
+		{"owner cannot leave", owner, owner, ErrInvalidMembership},
+		{"owner removes admin", owner, admin, nil},
+		{"admin removes viewer", admin, viewer, nil},
+		{"admin cannot remove admin", admin, otherAdmin, ErrInsufficientRole},
+		{"admin cannot remove owner", admin, owner, ErrInsufficientRole},
+		{"bid manager cannot remove viewer", bidManager, viewer, ErrInsufficientRole},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkMemberRemoval(tt.actor, tt.target); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkMemberRemoval = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckOwnershipTransfer(t *testing.T) {
+	company := uuid.New()
+	owner := testMember(company, domain.RoleOwner)
+	admin := testMember(company, domain.RoleAdmin)
+	viewer := testMember(company, domain.RoleViewer)
+	stranger := testMember(uuid.New(), domain.RoleViewer)
+
+	tests := []struct {
+		name          string
+		actor, target *domain.CompanyMember
+		wantErr       error
+	}{
+		{"owner to admin", owner, admin, nil},
+		{"owner to viewer", owner, viewer, nil},
+		{"owner to self", owner, owner, ErrInvalidMembership},
+		{"admin cannot transfer", admin, viewer, ErrInsufficientRole},
+		{"member of another company", owner, stranger, ErrMemberNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkOwnershipTransfer(tt.actor, tt.target); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkOwnershipTransfer = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInvitationToken(t *testing.T) {
+	token, hash, err := newInvitationToken()
+	if err != nil {
+		t.Fatalf("newInvitationToken: %v", err)
+	}
+	if hash != hashInvitationToken(token) {
+		t.Errorf("hash %q does not match hashInvitationToken(token)", hash)
+	}
+	if len(hash) != 64 || hash == token {
+		t.Errorf("expected hex sha256 instead of the token, got %q", hash)
+	}
+	if hashInvitationToken(token+"x") == hash {
+		t.Error("different tokens must not share a hash")
+	}
+
+	other, _, err := newInvitationToken()
+	if err != nil {
+		t.Fatalf("newInvitationToken: %v", err)
+	}
+	if other == token {
+		t.Error("tokens must be random")
+	}
+}
+
+func TestCheckInvitation(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	accepted := now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		invitation domain.CompanyInvitation
+		authEmail  string
+		wantErr    error
+	}{
+		{
+			name:       "open invitation",
+			invitation: domain.CompanyInvitation{Email: "ben@example.com", ExpiresAt: now.Add(time.Hour)},
+			authEmail:  "ben@example.com",
+		},
+		{
+			name:       "email compared case-insensitively",
+			invitation: domain.CompanyInvitation{Email: "ben@example.com", ExpiresAt: now.Add(time.Hour)},
+			authEmail:  " Ben@Example.com ",
+		},
+		{
+			name:       "expired",
+			invitation: domain.CompanyInvitation{Email: "ben@example.com", ExpiresAt: now.Add(-time.Minute)},
+			authEmail:  "ben@example.com",
+			wantErr:    ErrInvitationNotFound,
+		},
+		{
+			name:       "expires right now",
+			invitation: domain.CompanyInvitation{Email: "ben@example.com", ExpiresAt: now},
+			authEmail:  "ben@example.com",
+			wantErr:    ErrInvitationNotFound,
+		},
+		{
+			name:       "already used",
+			invitation: domain.CompanyInvitation{Email: "ben@example.com", ExpiresAt: now.Add(time.Hour), AcceptedAt: &accepted},
+			authEmail:  "ben@example.com",
+			wantErr:    ErrInvitationNotFound,
+		},
+		{
+			name:       "email mismatch",
+			invitation: domain.CompanyInvitation{Email: "ben@example.com", ExpiresAt: now.Add(time.Hour)},
+			authEmail:  "eva@example.com",
+			wantErr:    ErrInsufficientRole,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkInvitation(&tt.invitation, tt.authEmail, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkInvitation = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+//go:embed data/nuts2021_de_at.csv
+var nutsCSV string
+
+// nutsRegion ist ein Eintrag der NUTS-2021-Hierarchie (DE/AT)
+type nutsRegion struct {
+	Code      string
+	Name      string
+	Level     int
+	Latitude  float64
+	Longitude float64
+}
+
+var nutsRegions = mustParseNutsRegions(nutsCSV)
+
+func mustParseNutsRegions(data string) map[string]nutsRegion {
+	regions := make(map[string]nutsRegion)
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, ";")
+		if len(parts) != 4 {
+			panic(fmt.Sprintf("nuts data line %d: expected 4 fields, got %d", i+1, len(parts)))
+		}
+		lat, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			panic(fmt.Sprintf("nuts data line %d: invalid latitude: %v", i+1, err))
+		}
+		lng, err := strconv.ParseFloat(parts[3], 64)
+		if err != nil {
+			panic(fmt.Sprintf("nuts data line %d: invalid longitude: %v", i+1, err))
+		}
+
+		code := parts[0]
+		regions[code] = nutsRegion{
+			Code:      code,
+			Name:      parts[1],
+			Level:     len(code) - 2,
+			Latitude:  lat,
+			Longitude: lng,
+		}
+	}
+	return regions
+}
+
+// normalizeNutsCode bringt Codes wie "de 212" oder "DE212" in die kanonische Form
+func normalizeNutsCode(code string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(code), ""))
+}
+
+// SyncNUTSCentroids schreibt die Schwerpunkte aus data/nuts2021_de_at.csv nach
+// public.nuts_centroids (Migration 023). Die Hybrid-Query verortet damit Ausschreibungen
+// ohne location_geom über public.nuts_centroid(nutscodes). Idempotent, läuft beim Start.
+func SyncNUTSCentroids(ctx context.Context, db *gorm.DB) error {
+	codes := make([]string, 0, len(nutsRegions))
+	for code := range nutsRegions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO public.nuts_centroids (code, name, level, latitude, longitude) VALUES ")
+	args := make([]any, 0, len(codes)*5)
+	for i, code := range codes {
+		r := nutsRegions[code]
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?, ?)")
+		args = append(args, r.Code, r.Name, r.Level, r.Latitude, r.Longitude)
+	}
+	query.WriteString(` ON CONFLICT (code) DO UPDATE SET
+		name = EXCLUDED.name, level = EXCLUDED.level,
+		latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude`)
+
+	if err := db.WithContext(ctx).Exec(query.String(), args...).Error; err != nil {
+		return fmt.Errorf("sync nuts centroids failed: %w", err)
+	}
+	return nil
+}
+
+// nutsRegionName gibt den Namen zum Code zurück (leer, wenn unbekannt)
+func nutsRegionName(code string) string {
+	return nutsRegions[normalizeNutsCode(code)].Name
+}
+
+// resolveNutsRegions übersetzt die Wunschregionen aus dem Onboarding in NUTS-Codes.
+// Akzeptiert werden Codes ("DE21") und Namen ("Oberbayern", "München").
+// Ein Name wie "München" trifft sowohl die Stadt als auch den Landkreis. Sortiert nach Code.
+func resolveNutsRegions(regions []string) []string {
+	var codes []string
+	for _, region := range regions {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			continue
+		}
+
+		if code := normalizeNutsCode(region); nutsRegions[code].Code != "" {
+			if !contains(codes, code) {
+				codes = append(codes, code)
+			}
+			continue
+		}
+
+		needle := strings.ToLower(region)
+		for code, r := range nutsRegions {
+			name := strings.ToLower(r.Name)
+			baseName, _, _ := strings.Cut(name, ",")
+			if name == needle || strings.TrimSpace(baseName) == needle {
+				if !contains(codes, code) {
+					codes = append(codes, code)
+				}
+			}
+		}
+	}
+	// Namen werden über eine Map aufgelöst -> feste Reihenfolge für Ausgabe und Tests
+	sort.Strings(codes)
+	return codes
+}
+
+// nutsRegionScore bewertet, wie gut die NUTS-Codes einer Ausschreibung zu den
+// Wunschregionen passen (Präfix-Vergleich entlang der Hierarchie).
+func nutsRegionScore(tenderCodes, preferred []string) (score float64, matched string) {
+	for _, raw := range tenderCodes {
+		tc := normalizeNutsCode(raw)
+		if len(tc) < 2 {
+			continue
+		}
+		for _, pc := range preferred {
+			var s float64
+			switch {
+			case strings.HasPrefix(tc, pc):
+				// Ausschreibung liegt innerhalb der Wunschregion
+				s = 1.0
+			case strings.HasPrefix(pc, tc) && len(tc) > 2:
+				// Ausschreibung ist gröber angegeben (z.B. ganzes Bundesland)
+				s = 0.7
+			case len(tc) >= 4 && len(pc) >= 4 && tc[:4] == pc[:4]:
+				// gleiche NUTS-2-Region (Regierungsbezirk)
+				s = 0.6
+			case len(tc) >= 3 && len(pc) >= 3 && tc[:3] == pc[:3]:
+				// gleiche NUTS-1-Region (Bundesland)
+				s = 0.4
+			}
+			if s > score {
+				score, matched = s, tc
+			}
+		}
+	}
+	return score, matched
+}
+
+// preferredRegionsFromSettings liest die Wunschregionen aus Company.Settings
+func preferredRegionsFromSettings(settings json.RawMessage) []string {
+	if len(settings) == 0 {
+		return nil
+	}
+
+	var prefs struct {
+		Regions []string `json:"regions"`
+	}
+	if err := json.Unmarshal(settings, &prefs); err != nil {
+		return nil
+	}
+	return resolveNutsRegions(prefs.Regions)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestNutsRegionScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		tender      []string
+		preferred   []string
+		wantScore   float64
+		wantMatched string
+	}{
+		{"inside preferred region", []string{"DE212"}, []string{"DE21"}, 1.0, "DE212"},
+		{"exact match", []string{"DE21"}, []string{"DE21"}, 1.0, "DE21"},
+		{"normalizes tender code", []string{"de 212"}, []string{"DE21"}, 1.0, "DE212"},
+		{"tender coarser than preference", []string{"DE2"}, []string{"DE21"}, 0.7, "DE2"},
+		{"country only", []string{"DE"}, []string{"DE21"}, 0, ""},
+		{"same NUTS-2 region", []string{"DE21H"}, []string{"DE212"}, 0.6, "DE21H"},
+		{"same NUTS-1 region", []string{"DE221"}, []string{"DE212"}, 0.4, "DE221"},
+		{"other state", []string{"DE111"}, []string{"DE212"}, 0, ""},
+		{"best of several codes", []string{"DE221", "DE212"}, []string{"DE21"}, 1.0, "DE212"},
+		{"best of several preferences", []string{"AT130"}, []string{"DE21", "AT13"}, 1.0, "AT130"},
+		{"no preferences", []string{"DE212"}, nil, 0, ""},
+		{"no tender codes", nil, []string{"DE21"}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, matched := nutsRegionScore(tt.tender, tt.preferred)
+			if score != tt.wantScore || matched != tt.wantMatched {
+				t.Errorf("nutsRegionScore(%v, %v) = %v, %q; want %v, %q", tt.tender, tt.preferred, score, matched, tt.wantScore, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func TestPreferredRegionsFromSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     []string
+	}{
+		{"empty settings", "", nil},
+		{"invalid json", `{"regions": `, nil},
+		{"no regions", `{"discoverableAsSubcontractor": true}`, nil},
+		{"codes sorted", `{"regions": ["DE212", " de 21 "]}`, []string{"DE21", "DE212"}},
+		{"name", `{"regions": ["Oberbayern"]}`, []string{"DE21"}},
+		{"name case-insensitive", `{"regions": ["oberbayern"]}`, []string{"DE21"}},
+		{"city and district", `{"regions": ["München"]}`, []string{"DE212", "DE21H"}},
+		{"austria", `{"regions": ["Wien"]}`, []string{"AT13", "AT130"}},
+		{"deduplicates code and name", `{"regions": ["DE21", "Oberbayern"]}`, []string{"DE21"}},
+		{"unknown region", `{"regions": ["Atlantis", " "]}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := preferredRegionsFromSettings(json.RawMessage(tt.settings))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("preferredRegionsFromSettings(%s) = %v, want %v", tt.settings, got, tt.want)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/klippa-app/go-pdfium"
 	"github.com/klippa-app/go-pdfium/requests"
 	"github.com/klippa-app/go-pdfium/webassembly"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/usage"
 )
 
 // Global pdfium instance (initialized once)
@@ -42,20 +44,30 @@ func init() {
 	pdfiumInstance = instance
 }
 
+// ocrModel ist das OCR-Modell bei Novita.ai
+const ocrModel = "deepseek/deepseek-ocr"
+
 type OCRService struct {
 	novitaAPIKey string
 	client       *http.Client
+	usage        usage.Recorder
 }
 
-func NewOCRService(novitaAPIKey string) *OCRService {
+// NewOCRService erstellt den OCR-Client; recorder erfasst Seiten, Tokens und Kosten (nil = keine Erfassung)
+func NewOCRService(novitaAPIKey string, recorder usage.Recorder) *OCRService {
 	return &OCRService{
 		novitaAPIKey: novitaAPIKey,
 		client:       &http.Client{Timeout: 120 * time.Second},
+		usage:        recorder,
 	}
 }
 
-// ExtractFromPDF converts PDF pages to images and sends them to DeepSeek-OCR via Novita.ai
-func (s *OCRService) ExtractFromPDF(pdfBytes []byte) (string, error) {
+// ocrPageSeparator trennt die Seiten im OCR-Ergebnis
+const ocrPageSeparator = "\n\n---\n\n"
+
+// ExtractFromPDF converts PDF pages to images and sends them to DeepSeek-OCR via Novita.ai.
+// Der Verbrauch wird als ein Event je Dokument der Zuordnung aus ctx angerechnet.
+func (s *OCRService) ExtractFromPDF(ctx context.Context, pdfBytes []byte) (string, error) {
 	if pdfiumInstance == nil {
 		return "", fmt.Errorf("pdfium not initialized")
 	}
@@ -71,18 +83,36 @@ func (s *OCRService) ExtractFromPDF(pdfBytes []byte) (string, error) {
 	}
 
 	// 2. Process each page with DeepSeek-OCR
+	start := time.Now()
+	event := usage.Event{Kind: usage.KindOCR, Provider: usage.ProviderNovita, Model: ocrModel}
+	defer func() {
+		event.Latency = time.Since(start)
+		usage.Record(ctx, s.usage, event)
+	}()
+
 	var allText strings.Builder
+	failed := 0
 	for i, imgBase64 := range images {
-		text, err := s.callDeepSeekOCR(imgBase64)
+		if err := ctx.Err(); err != nil {
+			event.Failed = true
+			return "", err
+		}
+		// Trenner auch bei fehlgeschlagenen Seiten, damit die Seitenzählung stimmt
+		if i > 0 {
+			allText.WriteString(ocrPageSeparator)
+		}
+		event.Pages++
+		text, tokens, err := s.callDeepSeekOCR(ctx, imgBase64)
+		event.PromptTokens += tokens.PromptTokens
+		event.CompletionTokens += tokens.CompletionTokens
 		if err != nil {
 			log.Printf("OCR failed for page %d: %v", i+1, err)
+			failed++
 			continue
 		}
-		if i > 0 {
-			allText.WriteString("\n\n---\n\n")
-		}
 		allText.WriteString(text)
 	}
+	event.Failed = failed == len(images)
 
 	return allText.String(), nil
 }
@@ -145,13 +175,49 @@ func (s *OCRService) pdfToImages(pdfBytes []byte) ([]string, error) {
 	return images, nil
 }
 
+// pdfTextLayer liest die eingebettete Textebene aller Seiten (ohne OCR). Gescannte PDFs
+// liefern hier nur wenig oder keinen Text.
+func pdfTextLayer(pdfBytes []byte) (string, error) {
+	if pdfiumInstance == nil {
+		return "", fmt.Errorf("pdfium is not initialized")
+	}
+	doc, err := pdfiumInstance.OpenDocument(&requests.OpenDocument{File: &pdfBytes})
+	if err != nil {
+		return "", fmt.Errorf("open PDF: %w", err)
+	}
+	defer pdfiumInstance.FPDF_CloseDocument(&requests.FPDF_CloseDocument{Document: doc.Document})
+
+	pageCount, err := pdfiumInstance.FPDF_GetPageCount(&requests.FPDF_GetPageCount{Document: doc.Document})
+	if err != nil {
+		return "", fmt.Errorf("get page count: %w", err)
+	}
+
+	pages := make([]string, 0, pageCount.PageCount)
+	for i := 0; i < pageCount.PageCount; i++ {
+		text, err := pdfiumInstance.GetPageText(&requests.GetPageText{
+			Page: requests.Page{ByIndex: &requests.PageByIndex{Document: doc.Document, Index: i}},
+		})
+		if err != nil {
+			return "", fmt.Errorf("read text of page %d: %w", i+1, err)
+		}
+		pages = append(pages, strings.TrimSpace(text.Text))
+	}
+	return strings.Join(pages, ocrPageSeparator), nil
+}
+
+// ocrTokens ist die Token-Nutzung einer Seite laut Antwort
+type ocrTokens struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
 // callDeepSeekOCR sends an image to Novita.ai's DeepSeek-OCR endpoint
-func (s *OCRService) callDeepSeekOCR(imageBase64 string) (string, error) {
+func (s *OCRService) callDeepSeekOCR(ctx context.Context, imageBase64 string) (string, ocrTokens, error) {
 	url := "https://api.novita.ai/v3/openai/chat/completions"
 
 	// OpenAI-compatible request format
 	reqBody := map[string]interface{}{
-		"model": "deepseek/deepseek-ocr",
+		"model": ocrModel,
 		"messages": []map[string]interface{}{
 			{
 				"role": "user",
@@ -174,12 +240,12 @@ func (s *OCRService) callDeepSeekOCR(imageBase64 string) (string, error) {
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
+		return "", ocrTokens{}, fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return "", ocrTokens{}, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+s.novitaAPIKey)
@@ -187,14 +253,14 @@ func (s *OCRService) callDeepSeekOCR(imageBase64 string) (string, error) {
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("API call: %w", err)
+		return "", ocrTokens{}, fmt.Errorf("API call: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return "", ocrTokens{}, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Parse OpenAI-compatible response
@@ -204,22 +270,23 @@ func (s *OCRService) callDeepSeekOCR(imageBase64 string) (string, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage ocrTokens `json:"usage"`
 		Error struct {
 			Message string `json:"message"`
 		} `json:"error"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("parse response: %w", err)
+		return "", ocrTokens{}, fmt.Errorf("parse response: %w", err)
 	}
 
 	if result.Error.Message != "" {
-		return "", fmt.Errorf("OCR error: %s", result.Error.Message)
+		return "", result.Usage, fmt.Errorf("OCR error: %s", result.Error.Message)
 	}
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no OCR result returned")
+		return "", result.Usage, fmt.Errorf("no OCR result returned")
 	}
 
-	return result.Choices[0].Message.Content, nil
+	return result.Choices[0].Message.Content, result.Usage, nil
 }
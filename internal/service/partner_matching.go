@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+// Rollen, für die sich eine Firma in Settings auffindbar machen kann
+const (
+	PartnerRoleConsortium    = "bietergemeinschaft"
+	PartnerRoleSubcontractor = "subunternehmer"
+)
+
+// PartnerSearchOptions steuert die Partnersuche zu einer Ausschreibung
+type PartnerSearchOptions struct {
+	Limit int
+	// Role schränkt auf eine Rolle ein (leer = beide)
+	Role string
+}
+
+// PartnerMatch ist der datenschutzfreundliche Auszug einer Firma für die Partnersuche.
+// Kontaktdaten, Steuer-ID, Umsatz und Adresse werden bewusst nicht herausgegeben.
+type PartnerMatch struct {
+	CompanyID     uuid.UUID `json:"company_id"`
+	Name          string    `json:"name"`
+	City          string    `json:"city"`
+	IndustryTags  []string  `json:"industry_tags"`
+	EmployeeRange string    `json:"employee_range"`
+	IsAVPQ        bool      `json:"is_avpq"`
+	Roles         []string  `json:"roles"`
+	Score         float64   `json:"score"`
+	DistanceKM    *float64  `json:"distance_km,omitempty"`
+	Reason        string    `json:"reason_text"`
+}
+
+type partnerRow struct {
+	CompanyID       uuid.UUID       `gorm:"column:company_id"`
+	Name            string          `gorm:"column:name"`
+	City            string          `gorm:"column:address_city"`
+	IndustryTags    pq.StringArray  `gorm:"column:industry_tags;type:text[]"`
+	EmployeeCount   int             `gorm:"column:employee_count"`
+	IsAVPQ          bool            `gorm:"column:is_avpq"`
+	Latitude        float64         `gorm:"column:latitude"`
+	Longitude       float64         `gorm:"column:longitude"`
+	ServiceRadiusKM int             `gorm:"column:service_radius_km"`
+	Settings        json.RawMessage `gorm:"column:settings"`
+	VectorScore     float64         `gorm:"column:vector_score"`
+	CPVScore        float64         `gorm:"column:cpv_score"`
+	DistanceKM      sql.NullFloat64 `gorm:"column:distance_km"`
+	IsWithinRadius  sql.NullBool    `gorm:"column:is_within_radius"`
+	GeoScore        float64         `gorm:"column:geo_score"`
+}
+
+// FindCompaniesForTender ist das umgekehrte Matching: registrierte Firmen werden mit
+// demselben Hybrid-Score (Vektor, CPV, Geo) für eine Ausschreibung gerankt. Es werden
+// nur Firmen geliefert, die sich in Settings als Partner auffindbar gemacht haben;
+// die Firma des anfragenden Nutzers ist ausgeschlossen.
+func (s *MatchingService) FindCompaniesForTender(ctx context.Context, authUserID, tenderID uuid.UUID, opts PartnerSearchOptions) ([]PartnerMatch, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+
+	requester, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).
+		Select("id", "nutscodes").
+		First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	wantConsortium, wantSubcontractor := partnerRoleFilter(opts.Role)
+
+	candidateLimit := opts.Limit * candidateFactor
+	if candidateLimit < minCandidateLimit {
+		candidateLimit = minCandidateLimit
+	}
+
+	var rows []partnerRow
+	err = s.db.WithContext(ctx).Raw(`
+		WITH tender_data AS (
+			-- Ohne location_geom zählt der NUTS-Schwerpunkt
+			SELECT id, cpv_codes, requirement_embedding,
+				COALESCE(location_geom::geography, public.nuts_centroid(nutscodes)) AS geog
+			FROM tenders
+			WHERE id = @tender_id
+		),
+		company_candidates AS (
+			SELECT
+				c.id,
+				c.name,
+				c.address_city,
+				c.industry_tags,
+				c.employee_count,
+				c.is_avpq,
+				c.latitude,
+				c.longitude,
+				c.service_radius_km,
+				c.settings,
+				-- 1. Vektor-Ähnlichkeit
+				COALESCE(1 - (c.profile_embedding <=> t.requirement_embedding), 0) AS vector_score,
+				-- 2. CPV-Überlappung (Anteil der Tender-CPVs im Firmenprofil)
+				COALESCE(
+					(SELECT COUNT(*)::float / NULLIF(array_length(t.cpv_codes, 1), 0)
+					FROM unnest(t.cpv_codes) AS t_cpv
+					WHERE t_cpv = ANY(c.industry_tags)
+					), 0
+				) AS cpv_score,
+				-- 3. Geo-Distanz
+				CASE
+					WHEN c.location_geom IS NOT NULL AND t.geog IS NOT NULL
+					THEN ST_Distance(c.location_geom::geography, t.geog) / 1000
+					ELSE NULL
+				END AS distance_km,
+				-- 4. Ausschreibung im Service-Radius der Firma?
+				CASE
+					WHEN c.location_geom IS NOT NULL AND t.geog IS NOT NULL
+					THEN ST_DWithin(c.location_geom::geography, t.geog, c.service_radius_km * 1000)
+					ELSE false
+				END AS is_within_radius
+			FROM companies c
+			CROSS JOIN tender_data t
+			WHERE c.onboarding_completed = true
+			  AND c.id <> @requester_id
+			  AND (
+				(@want_consortium AND c.settings->>'discoverableAsConsortiumPartner' = 'true')
+				OR (@want_subcontractor AND c.settings->>'discoverableAsSubcontractor' = 'true')
+			  )
+		)
+		SELECT
+			id AS company_id,
+			name,
+			address_city,
+			industry_tags,
+			employee_count,
+			is_avpq,
+			latitude,
+			longitude,
+			service_radius_km,
+			settings,
+			vector_score,
+			cpv_score,
+			distance_km,
+			is_within_radius,
+			`+geoScoreSQL+` AS geo_score
+		FROM company_candidates
+		WHERE (vector_score > 0.3 OR cpv_score > 0.2 OR is_within_radius)
+		ORDER BY (vector_score * 0.5 + cpv_score * 0.3) DESC
+		LIMIT @limit
+	`,
+		sql.Named("tender_id", tender.ID),
+		sql.Named("requester_id", requester.ID),
+		sql.Named("want_consortium", wantConsortium),
+		sql.Named("want_subcontractor", wantSubcontractor),
+		sql.Named("limit", candidateLimit),
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("partner query failed: %w", err)
+	}
+
+	// Re-Ranking mit derselben Geo-/NUTS-Logik wie im Feed
+	type scoredPartner struct {
+		row        partnerRow
+		match      matchRowHybrid
+		score      float64
+		regionName string
+	}
+	scored := make([]scoredPartner, 0, len(rows))
+	for _, row := range rows {
+		match := matchRowHybrid{
+			TenderID:       tender.ID,
+			NutsCodes:      tender.NutsCodes,
+			VectorScore:    row.VectorScore,
+			CPVScore:       row.CPVScore,
+			DistanceKM:     row.DistanceKM,
+			IsWithinRadius: row.IsWithinRadius,
+			GeoScore:       row.GeoScore,
+		}
+		regionName := applyRegionSignals(&match, preferredRegionsFromSettings(row.Settings))
+
+		scored = append(scored, scoredPartner{
+			row:        row,
+			match:      match,
+			score:      clampScore(calculateWeightedScore(match.VectorScore, match.CPVScore, match.GeoScore)),
+			regionName: regionName,
+		})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+	if len(scored) > opts.Limit {
+		scored = scored[:opts.Limit]
+	}
+
+	partners := make([]PartnerMatch, len(scored))
+	for i, sp := range scored {
+		partners[i] = newPartnerMatch(sp.row, sp.match, sp.score, sp.regionName)
+	}
+
+	return partners, nil
+}
+
+// partnerRoleFilter: leere Rolle sucht beide, unbekannte Rollen finden niemanden
+func partnerRoleFilter(role string) (consortium, subcontractor bool) {
+	return role == "" || role == PartnerRoleConsortium, role == "" || role == PartnerRoleSubcontractor
+}
+
+// newPartnerMatch gibt nur die freigegebenen Felder der Firma heraus
+func newPartnerMatch(row partnerRow, match matchRowHybrid, score float64, regionName string) PartnerMatch {
+	partner := PartnerMatch{
+		CompanyID:     row.CompanyID,
+		Name:          row.Name,
+		City:          row.City,
+		IndustryTags:  row.IndustryTags,
+		EmployeeRange: employeeRange(row.EmployeeCount),
+		IsAVPQ:        row.IsAVPQ,
+		Roles:         partnerRolesFromSettings(row.Settings),
+		Score:         score,
+		Reason:        generateReason(match.VectorScore, match.CPVScore, match.DistanceKM, match.IsWithinRadius, regionName),
+	}
+	if match.DistanceKM.Valid {
+		// Auf ganze Kilometer gerundet, damit kein exakter Standort ableitbar ist
+		distance := math.Round(match.DistanceKM.Float64)
+		partner.DistanceKM = &distance
+	}
+	return partner
+}
+
+// partnerRolesFromSettings liest die freigegebenen Partnerrollen aus Company.Settings
+func partnerRolesFromSettings(settings json.RawMessage) []string {
+	var prefs struct {
+		Consortium    bool `json:"discoverableAsConsortiumPartner"`
+		Subcontractor bool `json:"discoverableAsSubcontractor"`
+	}
+	if len(settings) > 0 {
+		_ = json.Unmarshal(settings, &prefs)
+	}
+
+	roles := []string{}
+	if prefs.Consortium {
+		roles = append(roles, PartnerRoleConsortium)
+	}
+	if prefs.Subcontractor {
+		roles = append(roles, PartnerRoleSubcontractor)
+	}
+	return roles
+}
+
+// employeeRange gibt die Onboarding-Spanne statt der genauen Mitarbeiterzahl zurück
+func employeeRange(count int) string {
+	switch {
+	case count <= 10:
+		return "1-10"
+	case count <= 50:
+		return "11-50"
+	case count <= 200:
+		return "51-200"
+	case count <= 500:
+		return "201-500"
+	default:
+		return "500+"
+	}
+}
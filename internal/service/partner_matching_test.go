@@ -0,0 +1,136 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestPartnerRolesFromSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     []string
+	}{
+		{"no settings", "", []string{}},
+		{"invalid json", `{"discoverableAsSubcontractor": `, []string{}},
+		{"not discoverable", `{"discoverableAsConsortiumPartner": false, "regions": ["DE21"]}`, []string{}},
+		{"consortium only", `{"discoverableAsConsortiumPartner": true}`, []string{PartnerRoleConsortium}},
+		{"subcontractor only", `{"discoverableAsSubcontractor": true}`, []string{PartnerRoleSubcontractor}},
+		{"both roles", `{"discoverableAsSubcontractor": true, "discoverableAsConsortiumPartner": true}`, []string{PartnerRoleConsortium, PartnerRoleSubcontractor}},
+		{"string flags are no opt-in", `{"discoverableAsSubcontractor": "true"}`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := partnerRolesFromSettings(json.RawMessage(tt.settings))
+			if got == nil || !slices.Equal(got, tt.want) {
+				t.Errorf("partnerRolesFromSettings(%s) = %#v, want %#v", tt.settings, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartnerRoleFilter(t *testing.T) {
+	tests := []struct {
+		role                      string
+		consortium, subcontractor bool
+	}{
+		{"", true, true},
+		{PartnerRoleConsortium, true, false},
+		{PartnerRoleSubcontractor, false, true},
+		{"lieferant", false, false},
+	}
+	for _, tt := range tests {
+		consortium, subcontractor := partnerRoleFilter(tt.role)
+		if consortium != tt.consortium || subcontractor != tt.subcontractor {
+			t.Errorf("partnerRoleFilter(%q) = %v, %v; want %v, %v", tt.role, consortium, subcontractor, tt.consortium, tt.subcontractor)
+		}
+	}
+}
+
+func TestEmployeeRange(t *testing.T) {
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{0, "1-10"},
+		{1, "1-10"},
+		{10, "1-10"},
+		{11, "11-50"},
+		{50, "11-50"},
+		{51, "51-200"},
+		{200, "51-200"},
+		{201, "201-500"},
+		{500, "201-500"},
+		{501, "500+"},
+		{12000, "500+"},
+	}
+	for _, tt := range tests {
+		if got := employeeRange(tt.count); got != tt.want {
+			t.Errorf("employeeRange(%d) = %q, want %q", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestNewPartnerMatch(t *testing.T) {
+	row := partnerRow{
+		CompanyID:     uuid.New(),
+		Name:          "Elektro Huber GmbH",
+		City:          "Rosenheim",
+		IndustryTags:  pq.StringArray{"45310000"},
+		EmployeeCount: 37,
+		IsAVPQ:        true,
+		Latitude:      47.8561,
+		Longitude:     12.1289,
+		Settings:      json.RawMessage(`{"discoverableAsSubcontractor": true, "regions": ["DE21"]}`),
+	}
+
+	km := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name         string
+		distance     sql.NullFloat64
+		wantDistance *float64
+	}{
+		{"distance rounded to whole km", sql.NullFloat64{Float64: 12.4, Valid: true}, km(12)},
+		{"distance rounded up", sql.NullFloat64{Float64: 12.5, Valid: true}, km(13)},
+		{"no distance without location", sql.NullFloat64{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partner := newPartnerMatch(row, matchRowHybrid{VectorScore: 0.8, DistanceKM: tt.distance}, 0.72, "")
+			switch {
+			case tt.wantDistance == nil && partner.DistanceKM != nil:
+				t.Errorf("DistanceKM = %v, want nil", *partner.DistanceKM)
+			case tt.wantDistance != nil && (partner.DistanceKM == nil || *partner.DistanceKM != *tt.wantDistance):
+				t.Errorf("DistanceKM = %v, want %v", partner.DistanceKM, *tt.wantDistance)
+			}
+			if partner.EmployeeRange != "11-50" || !slices.Equal(partner.Roles, []string{PartnerRoleSubcontractor}) {
+				t.Errorf("unexpected partner %+v", partner)
+			}
+		})
+	}
+}
+
+func TestPartnerMatchOmitsPrivateFields(t *testing.T) {
+	distance := 12.0
+	payload, err := json.Marshal(PartnerMatch{CompanyID: uuid.New(), Name: "Elektro Huber GmbH", DistanceKM: &distance})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	// Nur diese Felder dürfen andere Firmen sehen (keine Kontaktdaten, Adresse, Koordinaten, Umsatz)
+	allowed := []string{"company_id", "name", "city", "industry_tags", "employee_range", "is_avpq", "roles", "score", "distance_km", "reason_text"}
+	for field := range fields {
+		if !slices.Contains(allowed, field) {
+			t.Errorf("partner match exposes %q", field)
+		}
+	}
+}
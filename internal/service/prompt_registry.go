@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+const (
+	defaultPromptEnvironment = "development"
+	defaultPromptRegistryTTL = time.Minute
+)
+
+// PromptRegistryConfig beschreibt Umgebung und Ablage der Prompt-Versionen
+type PromptRegistryConfig struct {
+	// Environment wählt die Zuordnungen aus prompt_assignments (Standard: development)
+	Environment string
+	// Dir enthält optional Prompt-Dateien <agent>/<version>/<name>.txt
+	Dir string
+	// TTL bestimmt, wie lange geladene Zuordnungen gelten (Standard: 1 Minute)
+	TTL time.Duration
+}
+
+// PromptRegistry liefert den Agents Prompt-Version, Modell und Temperatur je Umgebung.
+// Zuordnungen kommen aus prompt_assignments, die Texte aus prompt_templates, aus Dateien
+// oder aus den mitgelieferten Prompts. Änderungen greifen nach Ablauf der TTL ohne Neustart.
+type PromptRegistry struct {
+	db  *gorm.DB
+	cfg PromptRegistryConfig
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	configs  map[string]*agent.PromptConfig
+}
+
+func NewPromptRegistry(db *gorm.DB, cfg PromptRegistryConfig) *PromptRegistry {
+	cfg.Environment = strings.TrimSpace(cfg.Environment)
+	if cfg.Environment == "" {
+		cfg.Environment = defaultPromptEnvironment
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultPromptRegistryTTL
+	}
+	return &PromptRegistry{db: db, cfg: cfg}
+}
+
+// PromptConfig liefert die aktive Konfiguration des Agents (nil = mitgelieferte Prompts).
+// Schlägt das Neuladen fehl, bleibt die zuletzt geladene Konfiguration aktiv.
+func (r *PromptRegistry) PromptConfig(ctx context.Context, agentName string) (*agent.PromptConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.loadedAt) >= r.cfg.TTL {
+		configs, err := r.load(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Loading prompt registry for %s failed, keeping previous config: %v", r.cfg.Environment, err)
+		} else {
+			r.configs = configs
+		}
+		r.loadedAt = time.Now()
+	}
+	return r.configs[agentName], nil
+}
+
+// load liest die Zuordnungen der Umgebung und löst die Prompt-Texte auf
+func (r *PromptRegistry) load(ctx context.Context) (map[string]*agent.PromptConfig, error) {
+	var assignments []domain.PromptAssignment
+	if err := r.db.WithContext(ctx).Where("environment = ?", r.cfg.Environment).Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("load prompt assignments failed: %w", err)
+	}
+
+	configs := make(map[string]*agent.PromptConfig, len(assignments))
+	for _, a := range assignments {
+		version := strings.TrimSpace(a.PromptVersion)
+		if version == "" {
+			version = agent.DefaultPromptVersion
+		}
+
+		prompts, err := r.prompts(ctx, a.Agent, version)
+		if err != nil {
+			// Eine unvollständige Version darf die übrigen Agents nicht blockieren
+			log.Printf("Prompt version %s/%s unusable, using built-in prompts: %v", a.Agent, version, err)
+			continue
+		}
+
+		cfg := &agent.PromptConfig{Version: version, Prompts: prompts, Temperature: a.Temperature}
+		if a.Model != nil {
+			cfg.Model = strings.TrimSpace(*a.Model)
+		}
+		configs[a.Agent] = cfg
+	}
+	return configs, nil
+}
+
+// prompts sucht die Version in prompt_templates, dann in Dir, dann unter den mitgelieferten
+func (r *PromptRegistry) prompts(ctx context.Context, agentName, version string) (map[string]string, error) {
+	var templates []domain.PromptTemplate
+	if err := r.db.WithContext(ctx).
+		Where("agent = ? AND version = ?", agentName, version).
+		Limit(1).
+		Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("load prompt template failed: %w", err)
+	}
+
+	var prompts map[string]string
+	switch {
+	case len(templates) > 0:
+		if err := json.Unmarshal(templates[0].Prompts, &prompts); err != nil {
+			return nil, fmt.Errorf("parse prompt template: %w", err)
+		}
+	case r.cfg.Dir != "":
+		loaded, err := readPromptDir(filepath.Join(r.cfg.Dir, agentName, version))
+		if err != nil {
+			return nil, err
+		}
+		prompts = loaded
+	}
+	if prompts == nil {
+		builtin, err := agent.BuiltinPrompts(agentName, version)
+		if err != nil {
+			return nil, err
+		}
+		prompts = builtin
+	}
+
+	var missing []string
+	for _, name := range agent.PromptNames(agentName) {
+		if strings.TrimSpace(prompts[name]) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing prompts: %s", strings.Join(missing, ", "))
+	}
+	return prompts, nil
+}
+
+// readPromptDir liest <name>.txt aus dem Verzeichnis einer Version; fehlt es, ist das Ergebnis nil
+func readPromptDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read prompt dir: %w", err)
+	}
+
+	prompts := make(map[string]string, len(entries))
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".txt")
+		if e.IsDir() || !ok {
+			continue
+		}
+		text, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read prompt file: %w", err)
+		}
+		prompts[name] = strings.TrimSpace(string(text))
+	}
+	return prompts, nil
+}
@@ -0,0 +1,648 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"github.com/vergabe-agent/vergabe-backend/internal/usage"
+)
+
+var (
+	ErrInvalidReference  = errors.New("invalid project reference")
+	ErrReferenceNotFound = errors.New("project reference not found")
+)
+
+// Vergabestellen fordern meist Referenzen der letzten 3, teils der letzten 5 Jahre
+const (
+	referenceRecentYears  = 3
+	referenceMaxAgeYears  = 5
+	referenceDefaultLimit = 5
+	referenceMaxLimit     = 20
+)
+
+// Gewichtung der Referenzauswahl: Inhalt, CPV, Aktualität, Auftragswert, Auftraggeberart
+const (
+	referenceWeightSimilarity = 0.45
+	referenceWeightCPV        = 0.20
+	referenceWeightRecency    = 0.15
+	referenceWeightValue      = 0.15
+	referenceWeightClientType = 0.05
+)
+
+// ReferenceInput legt ein Referenzprojekt an; Datumsangaben als YYYY-MM-DD
+type ReferenceInput struct {
+	Title                 string   `json:"title"`
+	Description           string   `json:"description"`
+	Client                string   `json:"client"`
+	ClientType            string   `json:"client_type"`
+	ContractValue         *float64 `json:"contract_value"`
+	StartDate             string   `json:"start_date"`
+	EndDate               string   `json:"end_date"` // leer = laufend
+	CPVCodes              []string `json:"cpv_codes"`
+	LocationCity          string   `json:"location_city"`
+	LocationZip           string   `json:"location_zip"`
+	ContactName           string   `json:"contact_name"`
+	ContactEmail          string   `json:"contact_email"`
+	ContactPhone          string   `json:"contact_phone"`
+	ReleasedForDisclosure bool     `json:"released_for_disclosure"`
+}
+
+// ReferencePatch ändert nur gesetzte Felder; "" löscht ein Datum, contract_value 0 den Wert
+type ReferencePatch struct {
+	Title                 *string   `json:"title"`
+	Description           *string   `json:"description"`
+	Client                *string   `json:"client"`
+	ClientType            *string   `json:"client_type"`
+	ContractValue         *float64  `json:"contract_value"`
+	StartDate             *string   `json:"start_date"`
+	EndDate               *string   `json:"end_date"`
+	CPVCodes              *[]string `json:"cpv_codes"`
+	LocationCity          *string   `json:"location_city"`
+	LocationZip           *string   `json:"location_zip"`
+	ContactName           *string   `json:"contact_name"`
+	ContactEmail          *string   `json:"contact_email"`
+	ContactPhone          *string   `json:"contact_phone"`
+	ReleasedForDisclosure *bool     `json:"released_for_disclosure"`
+}
+
+// ReferenceScores sind die Teilbewertungen einer Referenz (jeweils 0..1)
+type ReferenceScores struct {
+	Similarity float64 `json:"similarity"`
+	CPV        float64 `json:"cpv"`
+	Recency    float64 `json:"recency"`
+	Value      float64 `json:"value"`
+	ClientType float64 `json:"client_type"`
+}
+
+// ReferenceMatch ist eine zur Ausschreibung passende Referenz mit Begründung
+type ReferenceMatch struct {
+	Reference   domain.ProjectReference `json:"reference"`
+	Score       float64                 `json:"score"`
+	Scores      ReferenceScores         `json:"scores"`
+	Explanation []string                `json:"explanation"`
+	Warnings    []string                `json:"warnings,omitempty"`
+}
+
+type ReferenceService struct {
+	db       *gorm.DB
+	embedder embedding.Embedder
+	usage    *UsageService
+}
+
+func NewReferenceService(db *gorm.DB, embedCfg EmbeddingProviderConfig, usageSvc *UsageService) (*ReferenceService, error) {
+	emb, err := newEmbeddingClient(context.Background(), embedCfg)
+	if err != nil {
+		return nil, fmt.Errorf("init embedder failed: %w", err)
+	}
+	return &ReferenceService{db: db, embedder: emb, usage: usageSvc}, nil
+}
+
+// ListReferences listet die Referenzen der eigenen Firma, neueste zuerst
+func (s *ReferenceService) ListReferences(ctx context.Context, authUserID uuid.UUID) ([]domain.ProjectReference, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+	return loadProjectReferences(ctx, s.db, member.CompanyID)
+}
+
+// CreateReference legt ein Referenzprojekt an und berechnet sein Embedding
+func (s *ReferenceService) CreateReference(ctx context.Context, authUserID uuid.UUID, input ReferenceInput) (*domain.ProjectReference, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ref := &domain.ProjectReference{
+		ID:                    uuid.New(),
+		CompanyID:             member.CompanyID,
+		Title:                 strings.TrimSpace(input.Title),
+		Description:           strings.TrimSpace(input.Description),
+		Client:                strings.TrimSpace(input.Client),
+		ClientType:            strings.TrimSpace(input.ClientType),
+		ContractValue:         input.ContractValue,
+		CPVCodes:              normalizeCPVCodes(input.CPVCodes),
+		LocationCity:          strings.TrimSpace(input.LocationCity),
+		LocationZip:           strings.TrimSpace(input.LocationZip),
+		ContactName:           strings.TrimSpace(input.ContactName),
+		ContactEmail:          strings.TrimSpace(input.ContactEmail),
+		ContactPhone:          strings.TrimSpace(input.ContactPhone),
+		ReleasedForDisclosure: input.ReleasedForDisclosure,
+		CreatedBy:             authUserID,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+
+	var problems []string
+	if ref.StartDate, err = parseDateInput(input.StartDate); err != nil {
+		problems = append(problems, "start_date: "+err.Error())
+	}
+	if ref.EndDate, err = parseDateInput(input.EndDate); err != nil {
+		problems = append(problems, "end_date: "+err.Error())
+	}
+	if err := validateReference(ref, problems); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Create(ref).Error; err != nil {
+		return nil, fmt.Errorf("create project reference failed: %w", err)
+	}
+	s.markChecksStale(ctx, ref.CompanyID)
+	s.embedReferences(ctx, ref.CompanyID, []domain.ProjectReference{*ref})
+	return ref, nil
+}
+
+// UpdateReference ändert ein Referenzprojekt; bei geändertem Text wird neu eingebettet
+func (s *ReferenceService) UpdateReference(ctx context.Context, authUserID, referenceID uuid.UUID, patch ReferencePatch) (*domain.ProjectReference, error) {
+	ref, err := s.findReference(ctx, authUserID, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	before := referenceEmbeddingText(ref)
+
+	updates := map[string]any{}
+	setText := func(column string, value *string, field *string) {
+		if value != nil {
+			*field = strings.TrimSpace(*value)
+			updates[column] = *field
+		}
+	}
+	setText("title", patch.Title, &ref.Title)
+	setText("description", patch.Description, &ref.Description)
+	setText("client", patch.Client, &ref.Client)
+	setText("client_type", patch.ClientType, &ref.ClientType)
+	setText("location_city", patch.LocationCity, &ref.LocationCity)
+	setText("location_zip", patch.LocationZip, &ref.LocationZip)
+	setText("contact_name", patch.ContactName, &ref.ContactName)
+	setText("contact_email", patch.ContactEmail, &ref.ContactEmail)
+	setText("contact_phone", patch.ContactPhone, &ref.ContactPhone)
+	if patch.ContractValue != nil {
+		ref.ContractValue = patch.ContractValue
+		if *patch.ContractValue == 0 {
+			ref.ContractValue = nil
+		}
+		updates["contract_value"] = ref.ContractValue
+	}
+	if patch.CPVCodes != nil {
+		ref.CPVCodes = normalizeCPVCodes(*patch.CPVCodes)
+		updates["cpv_codes"] = ref.CPVCodes
+	}
+	if patch.ReleasedForDisclosure != nil {
+		ref.ReleasedForDisclosure = *patch.ReleasedForDisclosure
+		updates["released_for_disclosure"] = ref.ReleasedForDisclosure
+	}
+
+	var problems []string
+	if patch.StartDate != nil {
+		if ref.StartDate, err = parseDateInput(*patch.StartDate); err != nil {
+			problems = append(problems, "start_date: "+err.Error())
+		}
+		updates["start_date"] = ref.StartDate
+	}
+	if patch.EndDate != nil {
+		if ref.EndDate, err = parseDateInput(*patch.EndDate); err != nil {
+			problems = append(problems, "end_date: "+err.Error())
+		}
+		updates["end_date"] = ref.EndDate
+	}
+	if err := validateReference(ref, problems); err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return ref, nil
+	}
+
+	reembed := referenceEmbeddingText(ref) != before
+	if reembed {
+		updates["embedding"] = nil
+	}
+	ref.UpdatedAt = time.Now()
+	updates["updated_at"] = ref.UpdatedAt
+	if err := s.db.WithContext(ctx).
+		Model(&domain.ProjectReference{}).
+		Where("id = ?", ref.ID).
+		Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("update project reference failed: %w", err)
+	}
+	s.markChecksStale(ctx, ref.CompanyID)
+	if reembed {
+		s.embedReferences(ctx, ref.CompanyID, []domain.ProjectReference{*ref})
+	}
+	return ref, nil
+}
+
+// DeleteReference löscht ein Referenzprojekt
+func (s *ReferenceService) DeleteReference(ctx context.Context, authUserID, referenceID uuid.UUID) error {
+	ref, err := s.findReference(ctx, authUserID, referenceID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(&domain.ProjectReference{}, "id = ?", ref.ID).Error; err != nil {
+		return fmt.Errorf("delete project reference failed: %w", err)
+	}
+	s.markChecksStale(ctx, ref.CompanyID)
+	return nil
+}
+
+type referenceSimilarityRow struct {
+	ID          uuid.UUID `gorm:"column:id"`
+	VectorScore float64   `gorm:"column:vector_score"`
+	HasVector   bool      `gorm:"column:has_vector"`
+}
+
+// BestReferencesForTender wählt die am besten passenden Referenzen der Firma für eine
+// Ausschreibung: inhaltliche Ähnlichkeit (Embedding), CPV-Nähe, Aktualität (3/5 Jahre),
+// vergleichbarer Auftragswert und öffentlicher Auftraggeber. Fehlende Embeddings werden
+// vorher berechnet.
+func (s *ReferenceService) BestReferencesForTender(ctx context.Context, authUserID, tenderID uuid.UUID, limit int) ([]ReferenceMatch, error) {
+	if limit <= 0 {
+		limit = referenceDefaultLimit
+	}
+	if limit > referenceMaxLimit {
+		limit = referenceMaxLimit
+	}
+
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "subscription_tier")
+	if err != nil {
+		return nil, err
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).
+		Select("id", "title", "cpv_codes", "estimated_value").
+		First(&tender, "id = ?", tenderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenderNotFound
+		}
+		return nil, fmt.Errorf("load tender failed: %w", err)
+	}
+
+	references, err := loadProjectReferences(ctx, s.db, company.ID)
+	if err != nil || len(references) == 0 {
+		return []ReferenceMatch{}, err
+	}
+
+	var missing []domain.ProjectReference
+	if err := s.db.WithContext(ctx).
+		Where("company_id = ? AND embedding IS NULL", company.ID).
+		Find(&missing).Error; err != nil {
+		return nil, fmt.Errorf("load project references failed: %w", err)
+	}
+	if len(missing) > 0 {
+		embedCtx, err := s.usage.Begin(ctx, company, &tender.ID, OperationReferences)
+		if err != nil {
+			return nil, err
+		}
+		s.embedReferences(embedCtx, company.ID, missing)
+	}
+
+	var rows []referenceSimilarityRow
+	if err := s.db.WithContext(ctx).Raw(`
+		SELECT
+			r.id,
+			COALESCE(1 - (r.embedding <=> t.requirement_embedding), 0) AS vector_score,
+			(r.embedding IS NOT NULL AND t.requirement_embedding IS NOT NULL) AS has_vector
+		FROM project_references r
+		CROSS JOIN (SELECT requirement_embedding FROM tenders WHERE id = @tender_id) t
+		WHERE r.company_id = @company_id
+	`,
+		sql.Named("tender_id", tender.ID),
+		sql.Named("company_id", company.ID),
+	).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("reference similarity query failed: %w", err)
+	}
+	similarity := make(map[uuid.UUID]referenceSimilarityRow, len(rows))
+	for _, row := range rows {
+		similarity[row.ID] = row
+	}
+
+	today := germanToday(time.Now())
+	matches := make([]ReferenceMatch, 0, len(references))
+	for _, ref := range references {
+		matches = append(matches, scoreReference(ref, &tender, similarity[ref.ID], today))
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// scoreReference bewertet eine Referenz und begründet die Teilbewertungen
+func scoreReference(ref domain.ProjectReference, tender *domain.Tender, similarity referenceSimilarityRow, today time.Time) ReferenceMatch {
+	match := ReferenceMatch{Reference: ref, Explanation: []string{}}
+
+	// 1. Inhalt
+	if similarity.HasVector {
+		match.Scores.Similarity = clampScore(similarity.VectorScore)
+		percent := math.Round(match.Scores.Similarity * 100)
+		switch {
+		case match.Scores.Similarity >= 0.75:
+			match.Explanation = append(match.Explanation, fmt.Sprintf("Inhaltlich sehr ähnlich (%.0f %%)", percent))
+		case match.Scores.Similarity >= 0.6:
+			match.Explanation = append(match.Explanation, fmt.Sprintf("Inhaltlich ähnlich (%.0f %%)", percent))
+		default:
+			match.Explanation = append(match.Explanation, fmt.Sprintf("Inhaltlich nur entfernt verwandt (%.0f %%)", percent))
+		}
+	} else {
+		match.Warnings = append(match.Warnings, "Inhaltliche Ähnlichkeit nicht bewertbar (Embedding fehlt)")
+	}
+
+	// 2. CPV
+	var cpvReason string
+	match.Scores.CPV, cpvReason = referenceCPVScore(tender.CPVCodes, ref.CPVCodes)
+	if cpvReason != "" {
+		match.Explanation = append(match.Explanation, cpvReason)
+	}
+
+	// 3. Aktualität
+	var recencyReason string
+	match.Scores.Recency, recencyReason = referenceRecencyScore(ref, today)
+	match.Explanation = append(match.Explanation, recencyReason)
+
+	// 4. Auftragswert
+	var valueReason string
+	match.Scores.Value, valueReason = referenceValueScore(ref.ContractValue, tender.EstimatedValue)
+	if valueReason != "" {
+		match.Explanation = append(match.Explanation, valueReason)
+	}
+
+	// 5. Auftraggeber
+	switch ref.ClientType {
+	case domain.ClientTypePublic:
+		match.Scores.ClientType = 1
+		match.Explanation = append(match.Explanation, "Öffentlicher Auftraggeber")
+	case domain.ClientTypePrivate:
+		match.Scores.ClientType = 0.6
+		match.Explanation = append(match.Explanation, "Privater Auftraggeber")
+	default:
+		match.Scores.ClientType = 0.8
+	}
+
+	if !ref.ReleasedForDisclosure {
+		match.Warnings = append(match.Warnings, "Keine Freigabe des Auftraggebers zur Nennung als Referenz")
+	}
+	if ref.ContactName == "" && ref.ContactEmail == "" && ref.ContactPhone == "" {
+		match.Warnings = append(match.Warnings, "Keine Ansprechperson beim Auftraggeber hinterlegt")
+	}
+
+	match.Score = clampScore(match.Scores.Similarity*referenceWeightSimilarity +
+		match.Scores.CPV*referenceWeightCPV +
+		match.Scores.Recency*referenceWeightRecency +
+		match.Scores.Value*referenceWeightValue +
+		match.Scores.ClientType*referenceWeightClientType)
+	match.Score = math.Round(match.Score*1000) / 1000
+	return match
+}
+
+// referenceCPVScore: Anteil der Tender-CPVs, die die Referenz abdeckt (gleicher Code 1,
+// gleiche Klasse 0.7, gleiche Abteilung 0.3)
+func referenceCPVScore(tenderCodes, referenceCodes []string) (float64, string) {
+	if len(tenderCodes) == 0 || len(referenceCodes) == 0 {
+		return 0, ""
+	}
+
+	var total float64
+	var exact, class []string
+	for _, tenderCode := range tenderCodes {
+		t := cpvDigits(tenderCode)
+		best := 0.0
+		for _, referenceCode := range referenceCodes {
+			r := cpvDigits(referenceCode)
+			switch {
+			case t == r:
+				best = 1
+			case len(t) >= 4 && len(r) >= 4 && t[:4] == r[:4]:
+				best = math.Max(best, 0.7)
+			case len(t) >= 2 && len(r) >= 2 && t[:2] == r[:2]:
+				best = math.Max(best, 0.3)
+			}
+		}
+		switch {
+		case best == 1:
+			exact = append(exact, tenderCode)
+		case best >= 0.7:
+			class = append(class, tenderCode)
+		}
+		total += best
+	}
+
+	score := total / float64(len(tenderCodes))
+	switch {
+	case len(exact) > 0:
+		return score, "Gleiche CPV-Codes: " + strings.Join(exact, ", ")
+	case len(class) > 0:
+		return score, "Gleiche CPV-Klasse wie " + strings.Join(class, ", ")
+	case score > 0:
+		return score, "Gleicher CPV-Bereich"
+	}
+	return 0, "Keine gemeinsamen CPV-Codes"
+}
+
+// referenceRecencyScore bewertet das Projektende: bis 3 Jahre voll, bis 5 Jahre eingeschränkt
+func referenceRecencyScore(ref domain.ProjectReference, today time.Time) (float64, string) {
+	switch {
+	case ref.EndDate == nil && ref.StartDate != nil && !ref.StartDate.After(today):
+		return 1, fmt.Sprintf("Laufendes Projekt seit %d", ref.StartDate.Year())
+	case ref.EndDate == nil:
+		return 0.5, "Projektzeitraum nicht angegeben"
+	case ref.EndDate.After(today):
+		return 1, fmt.Sprintf("Laufendes Projekt bis %s", ref.EndDate.Format("01/2006"))
+	}
+
+	years := today.Sub(*ref.EndDate).Hours() / 24 / 365.25
+	finished := ref.EndDate.Format("01/2006")
+	switch {
+	case years <= referenceRecentYears:
+		return 1, fmt.Sprintf("Abgeschlossen %s, innerhalb der üblichen %d Jahre", finished, referenceRecentYears)
+	case years <= referenceMaxAgeYears:
+		return 0.6, fmt.Sprintf("Abgeschlossen %s, nur zulässig, wenn %d Jahre gefordert sind", finished, referenceMaxAgeYears)
+	default:
+		return 0.1, fmt.Sprintf("Abgeschlossen %s, älter als %d Jahre", finished, referenceMaxAgeYears)
+	}
+}
+
+// referenceValueScore vergleicht den Auftragswert mit dem geschätzten Wert der Ausschreibung
+func referenceValueScore(contractValue *float64, estimatedValue float64) (float64, string) {
+	if contractValue == nil || *contractValue <= 0 {
+		return 0.5, ""
+	}
+	if estimatedValue <= 0 {
+		return 0.5, fmt.Sprintf("Auftragswert %s", formatEuro(*contractValue))
+	}
+
+	ratio := *contractValue / estimatedValue
+	reason := fmt.Sprintf("Auftragswert %s (%.0f %% des geschätzten Auftragswerts)", formatEuro(*contractValue), math.Round(ratio*100))
+	switch {
+	case ratio >= 0.5:
+		return 1, reason
+	case ratio >= 0.25:
+		return 0.6, reason
+	default:
+		return 0.2, reason
+	}
+}
+
+func cpvDigits(code string) string {
+	code, _, _ = strings.Cut(strings.TrimSpace(code), "-")
+	return code
+}
+
+// embedReferences berechnet die Embeddings der Referenzen; Fehler werden nur geloggt,
+// die Referenzauswahl versucht es später erneut
+func (s *ReferenceService) embedReferences(ctx context.Context, companyID uuid.UUID, references []domain.ProjectReference) {
+	if len(references) == 0 {
+		return
+	}
+
+	texts := make([]string, len(references))
+	for i := range references {
+		texts[i] = referenceEmbeddingText(&references[i])
+	}
+
+	// Beim Anlegen/Ändern ohne Kontingentprüfung, wie beim Firmenprofil
+	if usage.AttributionFrom(ctx).Operation == "" {
+		ctx = usage.WithAttribution(ctx, usage.Attribution{CompanyID: &companyID, Operation: OperationReferences})
+	}
+	vectors, err := s.embedder.EmbedStrings(ctx, texts)
+	if err != nil || len(vectors) != len(references) {
+		log.Printf("Project reference embedding failed for company %s: %v", companyID, err)
+		return
+	}
+
+	for i, ref := range references {
+		vector32 := make([]float32, len(vectors[i]))
+		for j, v := range vectors[i] {
+			vector32[j] = float32(v)
+		}
+		if err := s.db.WithContext(ctx).
+			Model(&domain.ProjectReference{}).
+			Where("id = ?", ref.ID).
+			Update("embedding", pgvector.NewVector(vector32)).Error; err != nil {
+			log.Printf("Saving project reference embedding failed for %s: %v", ref.ID, err)
+		}
+	}
+}
+
+func (s *ReferenceService) findReference(ctx context.Context, authUserID, referenceID uuid.UUID) (*domain.ProjectReference, error) {
+	member, err := findMembership(ctx, s.db, authUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref domain.ProjectReference
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND company_id = ?", referenceID, member.CompanyID).
+		First(&ref).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReferenceNotFound
+		}
+		return nil, fmt.Errorf("load project reference failed: %w", err)
+	}
+	return &ref, nil
+}
+
+// markChecksStale: Referenzen sind Teil des Firmenprofils für die Compliance-Prüfung
+func (s *ReferenceService) markChecksStale(ctx context.Context, companyID uuid.UUID) {
+	if err := markComplianceChecksStale(ctx, s.db, "company_id", companyID, StaleReasonReferences); err != nil {
+		log.Printf("Compliance stale warning: %v", err)
+	}
+}
+
+// loadProjectReferences lädt alle Referenzen einer Firma, laufende und zuletzt beendete zuerst
+func loadProjectReferences(ctx context.Context, db *gorm.DB, companyID uuid.UUID) ([]domain.ProjectReference, error) {
+	references := []domain.ProjectReference{}
+	if err := db.WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Order("end_date DESC NULLS FIRST, title ASC").
+		Find(&references).Error; err != nil {
+		return nil, fmt.Errorf("load project references failed: %w", err)
+	}
+	return references, nil
+}
+
+// referenceEmbeddingText ist die Grundlage des Referenz-Embeddings
+func referenceEmbeddingText(ref *domain.ProjectReference) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s",
+		ref.Title,
+		ref.Description,
+		ref.Client,
+		strings.Join(ref.CPVCodes, " "),
+		ref.LocationCity,
+	)
+}
+
+// validateReference ergänzt die Datumsfehler um die übrigen Prüfungen und meldet alle gemeinsam
+func validateReference(ref *domain.ProjectReference, problems []string) error {
+	for _, check := range []struct {
+		field, value string
+		min, max     int
+	}{
+		{"title", ref.Title, 2, 200},
+		{"description", ref.Description, 0, 5000},
+		{"client", ref.Client, 0, 200},
+		{"location_city", ref.LocationCity, 0, 100},
+		{"location_zip", ref.LocationZip, 0, 10},
+		{"contact_name", ref.ContactName, 0, 200},
+		{"contact_phone", ref.ContactPhone, 0, 50},
+	} {
+		if problem := checkLength(check.value, check.min, check.max); problem != "" {
+			problems = append(problems, check.field+": "+problem)
+		}
+	}
+
+	switch ref.ClientType {
+	case "", domain.ClientTypePublic, domain.ClientTypePrivate:
+	default:
+		problems = append(problems, "client_type: must be public or private")
+	}
+	if ref.ContractValue != nil && (*ref.ContractValue < 0 || *ref.ContractValue >= 1e18) {
+		problems = append(problems, "contract_value: must be a positive amount in EUR")
+	}
+	for _, code := range ref.CPVCodes {
+		if !cpvCodePattern.MatchString(code) {
+			problems = append(problems, fmt.Sprintf("cpv_codes: %q is not a CPV code (e.g. 45000000-7)", code))
+			break
+		}
+	}
+	if ref.ContactEmail != "" {
+		if _, err := mail.ParseAddress(ref.ContactEmail); err != nil {
+			problems = append(problems, "contact_email: is not a valid email address")
+		}
+	}
+	if ref.StartDate != nil && ref.EndDate != nil && ref.EndDate.Before(*ref.StartDate) {
+		problems = append(problems, "end_date: must not be before start_date")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidReference, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func normalizeCPVCodes(codes []string) pq.StringArray {
+	normalized := pq.StringArray{}
+	for _, code := range codes {
+		if code = strings.TrimSpace(code); code != "" {
+			normalized = append(normalized, code)
+		}
+	}
+	return normalized
+}
@@ -53,6 +53,35 @@ func (s *SupabaseStorageService) UploadFile(bucket, path string, data []byte, co
 	return nil
 }
 
+// DownloadFile loads a file from Supabase Storage
+func (s *SupabaseStorageService) DownloadFile(bucket, path string) ([]byte, error) {
+	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.projectURL, bucket, path)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read download failed: %w", err)
+	}
+	return data, nil
+}
+
 // DeleteFile deletes a file from Supabase Storage
 func (s *SupabaseStorageService) DeleteFile(bucket, path string) error {
 	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.projectURL, bucket, path)
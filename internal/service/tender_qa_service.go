@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/agent"
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+)
+
+var ErrInvalidQuestion = errors.New("invalid question")
+
+// Retrieval für Fragen: kleinere Abschnitte als beim Compliance-Check, damit die Fundstellen
+// präzise bleiben; die besten qaMaxPassages gehen an das Modell.
+const (
+	qaChunkChars       = 1_200
+	qaMaxPassages      = 8
+	qaMaxQuestionChars = 2_000
+	qaHistoryMessages  = 10
+)
+
+type TenderQAService struct {
+	qaAgent *agent.TenderQAAgent
+	db      *gorm.DB
+	usage   *UsageService
+}
+
+func NewTenderQAService(qaAgent *agent.TenderQAAgent, db *gorm.DB, usage *UsageService) *TenderQAService {
+	return &TenderQAService{qaAgent: qaAgent, db: db, usage: usage}
+}
+
+// Ask beantwortet eine Frage zur Ausschreibung anhand der relevantesten Textstellen aus
+// Beschreibung und Anhängen. Frage und Antwort werden im Verlauf des Nutzers gespeichert;
+// zurückgegeben wird die Antwort-Nachricht.
+func (s *TenderQAService) Ask(ctx context.Context, authUserID, tenderID uuid.UUID, question string) (*domain.TenderChatMessage, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("%w: question is required", ErrInvalidQuestion)
+	}
+	if utf8.RuneCountInString(question) > qaMaxQuestionChars {
+		return nil, fmt.Errorf("%w: question is longer than %d characters", ErrInvalidQuestion, qaMaxQuestionChars)
+	}
+
+	var tender domain.Tender
+	if err := s.db.WithContext(ctx).First(&tender, "id = ?", tenderID).Error; err != nil {
+		return nil, fmt.Errorf("tender not found: %w", err)
+	}
+
+	docs, _, err := loadTenderDocuments(ctx, s.db, &tender)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.recentHistory(ctx, authUserID, tenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Der Chat gehört dem Nutzer; Verbrauch und Kontingent zählen für seine Firma, sofern er eine hat
+	ctx, err = s.usage.BeginForUser(ctx, authUserID, &tenderID, OperationTenderChat)
+	if err != nil {
+		return nil, err
+	}
+
+	input := agent.TenderQAInput{
+		Question: question,
+		Passages: retrievePassages(docs, retrievalQuery(question, history), qaChunkChars, qaMaxPassages),
+	}
+	for _, msg := range history {
+		input.History = append(input.History, agent.ChatTurn{Role: msg.Role, Content: msg.Content})
+	}
+
+	answer, err := s.qaAgent.Answer(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	citations, err := json.Marshal(answer.Citations)
+	if err != nil {
+		return nil, fmt.Errorf("marshal citations failed: %w", err)
+	}
+
+	now := time.Now()
+	userMessage := domain.TenderChatMessage{
+		ID:         uuid.New(),
+		AuthUserID: authUserID,
+		TenderID:   tenderID,
+		Role:       agent.ChatRoleUser,
+		Content:    question,
+		Citations:  json.RawMessage("[]"),
+		CreatedAt:  now,
+	}
+	reply := domain.TenderChatMessage{
+		ID:            uuid.New(),
+		AuthUserID:    authUserID,
+		TenderID:      tenderID,
+		Role:          agent.ChatRoleAssistant,
+		Content:       answer.Answer,
+		Citations:     citations,
+		Found:         answer.Found,
+		PromptVersion: answer.Run.PromptVersion,
+		ModelID:       answer.Run.ModelID,
+		// Gleiche Zeitstempel würden die Reihenfolge im Verlauf offenlassen
+		CreatedAt: now.Add(time.Millisecond),
+	}
+	if err := s.db.WithContext(ctx).Create([]*domain.TenderChatMessage{&userMessage, &reply}).Error; err != nil {
+		return nil, fmt.Errorf("save chat messages failed: %w", err)
+	}
+
+	return &reply, nil
+}
+
+// History liefert den Verlauf des Nutzers zu einer Ausschreibung (älteste zuerst)
+func (s *TenderQAService) History(ctx context.Context, authUserID, tenderID uuid.UUID) ([]domain.TenderChatMessage, error) {
+	messages := []domain.TenderChatMessage{}
+	if err := s.db.WithContext(ctx).
+		Where("auth_user_id = ? AND tender_id = ?", authUserID, tenderID).
+		Order("created_at ASC").
+		Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("load chat history failed: %w", err)
+	}
+	return messages, nil
+}
+
+// ClearHistory löscht den Verlauf des Nutzers zu einer Ausschreibung
+func (s *TenderQAService) ClearHistory(ctx context.Context, authUserID, tenderID uuid.UUID) error {
+	if err := s.db.WithContext(ctx).
+		Where("auth_user_id = ? AND tender_id = ?", authUserID, tenderID).
+		Delete(&domain.TenderChatMessage{}).Error; err != nil {
+		return fmt.Errorf("delete chat history failed: %w", err)
+	}
+	return nil
+}
+
+// recentHistory lädt die letzten Nachrichten für den Prompt (älteste zuerst)
+func (s *TenderQAService) recentHistory(ctx context.Context, authUserID, tenderID uuid.UUID) ([]domain.TenderChatMessage, error) {
+	var messages []domain.TenderChatMessage
+	if err := s.db.WithContext(ctx).
+		Select("role", "content", "created_at").
+		Where("auth_user_id = ? AND tender_id = ?", authUserID, tenderID).
+		Order("created_at DESC").
+		Limit(qaHistoryMessages).
+		Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("load chat history failed: %w", err)
+	}
+
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// retrievalQuery ergänzt die Frage um die vorige Nutzerfrage, damit Rückfragen
+// ("Und bis wann?") dieselben Textstellen finden
+func retrievalQuery(question string, history []domain.TenderChatMessage) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == agent.ChatRoleUser {
+			return question + "\n" + history[i].Content
+		}
+	}
+	return question
+}
+
+// retrievePassages wählt die maxPassages relevantesten Abschnitte per BM25 (in Dokument-/Seitenreihenfolge)
+func retrievePassages(docs []sourceDocument, query string, chunkChars, maxPassages int) []agent.Passage {
+	chunks := chunkDocuments(docs, chunkChars)
+	ranked := rankChunksBM25(query, chunks)
+	if len(ranked) > maxPassages {
+		ranked = ranked[:maxPassages]
+	}
+
+	selected := make([]bool, len(chunks))
+	for _, idx := range ranked {
+		selected[idx] = true
+	}
+
+	var passages []agent.Passage
+	for i, chunk := range chunks {
+		if !selected[i] {
+			continue
+		}
+		passages = append(passages, agent.Passage{
+			Document: chunk.Document,
+			Page:     chunk.Page,
+			Text:     chunk.Text,
+		})
+	}
+	return passages
+}
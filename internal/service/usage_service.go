@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"github.com/vergabe-agent/vergabe-backend/internal/usage"
+)
+
+// Funktionen, denen der Verbrauch zugeordnet wird
+const (
+	OperationComplianceCheck = "compliance_check"
+	OperationChecklist       = "checklist"
+	OperationTenderChat      = "tender_chat"
+	OperationBidderQuestions = "bidder_questions"
+	OperationBidDraft        = "bid_draft"
+	OperationAttachmentOCR   = "attachment_ocr"
+	OperationIngestion       = "ingestion"
+	OperationCompanyProfile  = "company_profile"
+	OperationReferences      = "project_references"
+	OperationCVExtraction    = "cv_extraction"
+	OperationTeamProposal    = "team_proposal"
+)
+
+// Abo-Stufen (companies.subscription_tier)
+const (
+	SubscriptionTierFree       = "free"
+	SubscriptionTierPro        = "pro"
+	SubscriptionTierEnterprise = "enterprise"
+)
+
+// UsageQuota begrenzt den Verbrauch einer Abo-Stufe (0 = unbegrenzt). Monatswerte gelten je
+// Kalendermonat (Europe/Berlin), RequestsPerMinute zählt KI-Funktionen, nicht einzelne Modellaufrufe.
+type UsageQuota struct {
+	MonthlyCostUSD    float64 `json:"monthly_cost_usd"`
+	MonthlyOCRPages   int     `json:"monthly_ocr_pages"`
+	RequestsPerMinute int     `json:"requests_per_minute"`
+}
+
+var usageQuotas = map[string]UsageQuota{
+	SubscriptionTierFree:       {MonthlyCostUSD: 5, MonthlyOCRPages: 200, RequestsPerMinute: 10},
+	SubscriptionTierPro:        {MonthlyCostUSD: 100, MonthlyOCRPages: 5000, RequestsPerMinute: 60},
+	SubscriptionTierEnterprise: {RequestsPerMinute: 300},
+}
+
+// quotaForTier liefert das Kontingent der Stufe; unbekannte Stufen gelten als free
+func quotaForTier(tier string) (string, UsageQuota) {
+	tier = strings.ToLower(strings.TrimSpace(tier))
+	if quota, ok := usageQuotas[tier]; ok {
+		return tier, quota
+	}
+	return SubscriptionTierFree, usageQuotas[SubscriptionTierFree]
+}
+
+var (
+	// ErrQuotaExceeded: Monatskontingent der Abo-Stufe verbraucht (402)
+	ErrQuotaExceeded = errors.New("usage quota exceeded")
+	// ErrRateLimited: zu viele KI-Anfragen in kurzer Zeit (429)
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// Kontingente
+const (
+	QuotaLimitMonthlyCost     = "monthly_cost_usd"
+	QuotaLimitMonthlyOCRPages = "monthly_ocr_pages"
+	QuotaLimitRequestsPerMin  = "requests_per_minute"
+)
+
+// QuotaError beschreibt das überschrittene Kontingent; errors.Is trifft ErrQuotaExceeded
+// bzw. ErrRateLimited
+type QuotaError struct {
+	Tier  string
+	Limit string
+	Used  float64
+	Max   float64
+	// RetryAfter ist bei ErrRateLimited die Wartezeit bis zur nächsten freien Anfrage
+	RetryAfter time.Duration
+	err        error
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %s of tier %s (%.2f of %.2f)", e.err, e.Limit, e.Tier, e.Used, e.Max)
+}
+
+func (e *QuotaError) Unwrap() error { return e.err }
+
+// UsageService speichert den Verbrauch (usage.Recorder), prüft Kontingente und wertet je
+// Firma und Monat aus
+type UsageService struct {
+	db       *gorm.DB
+	location *time.Location
+
+	mu     sync.Mutex
+	recent map[uuid.UUID][]time.Time
+}
+
+func NewUsageService(db *gorm.DB) *UsageService {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		location = time.UTC
+	}
+	return &UsageService{db: db, location: location, recent: make(map[uuid.UUID][]time.Time)}
+}
+
+// Record speichert einen Aufruf; Fehler werden nur geloggt
+func (s *UsageService) Record(ctx context.Context, e usage.Event) {
+	if s == nil || s.db == nil {
+		return
+	}
+	row := domain.LLMUsage{
+		ID:               uuid.New(),
+		CompanyID:        e.CompanyID,
+		TenderID:         e.TenderID,
+		Operation:        e.Operation,
+		Kind:             e.Kind,
+		Provider:         e.Provider,
+		Model:            e.Model,
+		PromptTokens:     e.PromptTokens,
+		CompletionTokens: e.CompletionTokens,
+		Pages:            e.Pages,
+		LatencyMS:        e.Latency.Milliseconds(),
+		CostUSD:          e.CostUSD,
+		Failed:           e.Failed,
+		CreatedAt:        time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		log.Printf("Recording %s usage (%s) failed: %v", e.Kind, e.Model, err)
+	}
+}
+
+// Begin prüft die Kontingente der Firma und ordnet alle Aufrufe mit dem zurückgegebenen
+// Kontext Firma, Ausschreibung und Funktion zu. company und tenderID dürfen nil sein.
+func (s *UsageService) Begin(ctx context.Context, company *domain.Company, tenderID *uuid.UUID, operation string) (context.Context, error) {
+	attribution := usage.Attribution{TenderID: tenderID, Operation: operation}
+	if company != nil {
+		companyID := company.ID
+		attribution.CompanyID = &companyID
+	}
+	ctx = usage.WithAttribution(ctx, attribution)
+	if s == nil || company == nil {
+		return ctx, nil
+	}
+
+	tier, quota := quotaForTier(company.SubscriptionTier)
+
+	if quota.MonthlyCostUSD > 0 || quota.MonthlyOCRPages > 0 {
+		totals, err := s.monthTotals(ctx, company.ID, s.monthStart(time.Now()))
+		if err != nil {
+			return ctx, err
+		}
+		if err := checkMonthlyQuota(tier, quota, totals, operation); err != nil {
+			return ctx, err
+		}
+	}
+
+	if quota.RequestsPerMinute > 0 {
+		if retryAfter, ok := s.allow(company.ID, quota.RequestsPerMinute, time.Now()); !ok {
+			return ctx, &QuotaError{
+				Tier: tier, Limit: QuotaLimitRequestsPerMin,
+				Used: float64(quota.RequestsPerMinute), Max: float64(quota.RequestsPerMinute),
+				RetryAfter: retryAfter, err: ErrRateLimited,
+			}
+		}
+	}
+	return ctx, nil
+}
+
+// BeginForUser wie Begin für die Firma des Nutzers. Ohne Firma gibt es keinen Verbrauch
+// (ErrCompanyNotFound); Systemverbrauch ohne Kontingent bleibt Jobs ohne Nutzer vorbehalten.
+func (s *UsageService) BeginForUser(ctx context.Context, authUserID uuid.UUID, tenderID *uuid.UUID, operation string) (context.Context, error) {
+	if s == nil {
+		return s.Begin(ctx, nil, tenderID, operation)
+	}
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "subscription_tier")
+	if err != nil {
+		return ctx, err
+	}
+	return s.Begin(ctx, company, tenderID, operation)
+}
+
+// checkMonthlyQuota prüft den Monatsverbrauch gegen das Kontingent der Stufe
+func checkMonthlyQuota(tier string, quota UsageQuota, totals UsageTotals, operation string) error {
+	if quota.MonthlyCostUSD > 0 && totals.CostUSD >= quota.MonthlyCostUSD {
+		return &QuotaError{Tier: tier, Limit: QuotaLimitMonthlyCost, Used: totals.CostUSD, Max: quota.MonthlyCostUSD, err: ErrQuotaExceeded}
+	}
+	// OCR-Seiten zählen nur bei Funktionen, die OCR auslösen
+	if quota.MonthlyOCRPages > 0 && isOCROperation(operation) && totals.OCRPages >= quota.MonthlyOCRPages {
+		return &QuotaError{Tier: tier, Limit: QuotaLimitMonthlyOCRPages, Used: float64(totals.OCRPages), Max: float64(quota.MonthlyOCRPages), err: ErrQuotaExceeded}
+	}
+	return nil
+}
+
+func isOCROperation(operation string) bool {
+	return operation == OperationAttachmentOCR || operation == OperationIngestion || operation == OperationCVExtraction
+}
+
+// allow zählt KI-Anfragen je Firma in einem gleitenden Fenster von einer Minute. Der Zähler
+// liegt im Speicher und gilt je Instanz.
+func (s *UsageService) allow(companyID uuid.UUID, limit int, now time.Time) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window := now.Add(-time.Minute)
+	recent := s.recent[companyID]
+	kept := recent[:0]
+	for _, t := range recent {
+		if t.After(window) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) >= limit {
+		s.recent[companyID] = kept
+		return kept[0].Sub(window), false
+	}
+	s.recent[companyID] = append(kept, now)
+	return 0, true
+}
+
+func (s *UsageService) monthStart(now time.Time) time.Time {
+	local := now.In(s.location)
+	return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, s.location)
+}
+
+// UsageTotals ist der Verbrauch seit Monatsbeginn
+type UsageTotals struct {
+	CostUSD  float64 `json:"cost_usd"`
+	OCRPages int     `json:"ocr_pages"`
+}
+
+func (s *UsageService) monthTotals(ctx context.Context, companyID uuid.UUID, since time.Time) (UsageTotals, error) {
+	var totals UsageTotals
+	err := s.db.WithContext(ctx).Model(&domain.LLMUsage{}).
+		Select("coalesce(sum(cost_usd), 0) as cost_usd, coalesce(sum(pages) filter (where kind = ?), 0) as ocr_pages", usage.KindOCR).
+		Where("company_id = ? AND created_at >= ?", companyID, since).
+		Scan(&totals).Error
+	if err != nil {
+		return totals, fmt.Errorf("load usage totals failed: %w", err)
+	}
+	return totals, nil
+}
+
+// UsageAggregate fasst die Aufrufe eines Monats je Funktion, Art und Modell zusammen
+type UsageAggregate struct {
+	Operation        string  `json:"operation"`
+	Kind             string  `json:"kind"`
+	Model            string  `json:"model"`
+	Requests         int     `json:"requests"`
+	FailedRequests   int     `json:"failed_requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Pages            int     `json:"pages"`
+	CostUSD          float64 `json:"cost_usd"`
+	AvgLatencyMS     float64 `json:"avg_latency_ms"`
+}
+
+// UsageMonth ist der Verbrauch eines Kalendermonats
+type UsageMonth struct {
+	Month            string           `json:"month"` // YYYY-MM
+	Requests         int              `json:"requests"`
+	PromptTokens     int              `json:"prompt_tokens"`
+	CompletionTokens int              `json:"completion_tokens"`
+	OCRPages         int              `json:"ocr_pages"`
+	CostUSD          float64          `json:"cost_usd"`
+	Items            []UsageAggregate `json:"items"`
+}
+
+// UsageSummary ist die Verbrauchsübersicht der eigenen Firma
+type UsageSummary struct {
+	SubscriptionTier string       `json:"subscription_tier"`
+	Quota            UsageQuota   `json:"quota"`
+	CurrentMonth     UsageTotals  `json:"current_month"`
+	Months           []UsageMonth `json:"months"`
+}
+
+// Summary liefert den Verbrauch der letzten months Kalendermonate (neueste zuerst)
+func (s *UsageService) Summary(ctx context.Context, authUserID uuid.UUID, months int) (*UsageSummary, error) {
+	company, err := findCompanyByAuthUser(ctx, s.db, authUserID, "id", "subscription_tier")
+	if err != nil {
+		return nil, err
+	}
+	if months <= 0 {
+		months = 6
+	}
+
+	now := time.Now()
+	currentStart := s.monthStart(now)
+	since := currentStart.AddDate(0, -(months - 1), 0)
+
+	type row struct {
+		Month string
+		UsageAggregate
+	}
+	var rows []row
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT to_char(created_at AT TIME ZONE ?, 'YYYY-MM') AS month,
+		       coalesce(operation, '') AS operation, kind, model,
+		       count(*) AS requests,
+		       count(*) FILTER (WHERE failed) AS failed_requests,
+		       coalesce(sum(prompt_tokens), 0) AS prompt_tokens,
+		       coalesce(sum(completion_tokens), 0) AS completion_tokens,
+		       coalesce(sum(pages), 0) AS pages,
+		       coalesce(sum(cost_usd), 0) AS cost_usd,
+		       coalesce(avg(latency_ms), 0) AS avg_latency_ms
+		FROM llm_usages
+		WHERE company_id = ? AND created_at >= ?
+		GROUP BY 1, 2, 3, 4`,
+		s.location.String(), company.ID, since).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("load usage failed: %w", err)
+	}
+
+	byMonth := make(map[string]*UsageMonth)
+	for _, r := range rows {
+		m := byMonth[r.Month]
+		if m == nil {
+			m = &UsageMonth{Month: r.Month}
+			byMonth[r.Month] = m
+		}
+		m.Requests += r.Requests
+		m.PromptTokens += r.PromptTokens
+		m.CompletionTokens += r.CompletionTokens
+		m.CostUSD += r.CostUSD
+		if r.Kind == usage.KindOCR {
+			m.OCRPages += r.Pages
+		}
+		m.Items = append(m.Items, r.UsageAggregate)
+	}
+
+	tier, quota := quotaForTier(company.SubscriptionTier)
+	summary := &UsageSummary{SubscriptionTier: tier, Quota: quota, Months: []UsageMonth{}}
+	for _, m := range byMonth {
+		sort.Slice(m.Items, func(i, j int) bool { return m.Items[i].CostUSD > m.Items[j].CostUSD })
+		summary.Months = append(summary.Months, *m)
+	}
+	sort.Slice(summary.Months, func(i, j int) bool { return summary.Months[i].Month > summary.Months[j].Month })
+
+	if current, ok := byMonth[currentStart.Format("2006-01")]; ok {
+		summary.CurrentMonth = UsageTotals{CostUSD: current.CostUSD, OCRPages: current.OCRPages}
+	}
+	return summary, nil
+}
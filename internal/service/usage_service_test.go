@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vergabe-agent/vergabe-backend/internal/domain"
+	"github.com/vergabe-agent/vergabe-backend/internal/usage"
+)
+
+func TestCheckMonthlyQuota(t *testing.T) {
+	_, free := quotaForTier(SubscriptionTierFree)
+	_, enterprise := quotaForTier(SubscriptionTierEnterprise)
+
+	tests := []struct {
+		name      string
+		quota     UsageQuota
+		totals    UsageTotals
+		operation string
+		wantLimit string
+	}{
+		{"below cost limit", free, UsageTotals{CostUSD: 4.99}, OperationComplianceCheck, ""},
+		{"cost limit reached", free, UsageTotals{CostUSD: 5}, OperationComplianceCheck, QuotaLimitMonthlyCost},
+		{"cost limit blocks ocr too", free, UsageTotals{CostUSD: 6}, OperationAttachmentOCR, QuotaLimitMonthlyCost},
+		{"ocr pages reached for ocr", free, UsageTotals{OCRPages: 200}, OperationAttachmentOCR, QuotaLimitMonthlyOCRPages},
+		{"ocr pages reached for cv extraction", free, UsageTotals{OCRPages: 250}, OperationCVExtraction, QuotaLimitMonthlyOCRPages},
+		{"ocr pages ignored without ocr", free, UsageTotals{OCRPages: 250}, OperationTenderChat, ""},
+		{"enterprise is unlimited", enterprise, UsageTotals{CostUSD: 10_000, OCRPages: 100_000}, OperationAttachmentOCR, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMonthlyQuota(SubscriptionTierFree, tt.quota, tt.totals, tt.operation)
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var quotaErr *QuotaError
+			if !errors.As(err, &quotaErr) || quotaErr.Limit != tt.wantLimit || !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("expected %s quota error, got %v", tt.wantLimit, err)
+			}
+		})
+	}
+}
+
+func TestQuotaForTier(t *testing.T) {
+	tests := []struct {
+		tier     string
+		wantTier string
+	}{
+		{"pro", SubscriptionTierPro},
+		{" Enterprise ", SubscriptionTierEnterprise},
+		{"", SubscriptionTierFree},
+		{"gold", SubscriptionTierFree},
+	}
+	for _, tt := range tests {
+		if tier, quota := quotaForTier(tt.tier); tier != tt.wantTier || quota != usageQuotas[tt.wantTier] {
+			t.Errorf("quotaForTier(%q) = %s %+v, want %s", tt.tier, tier, quota, tt.wantTier)
+		}
+	}
+}
+
+func TestAllowSlidingWindow(t *testing.T) {
+	s := NewUsageService(nil)
+	company, other := uuid.New(), uuid.New()
+	start := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if _, ok := s.allow(company, 3, start.Add(time.Duration(i)*10*time.Second)); !ok {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+
+	retryAfter, ok := s.allow(company, 3, start.Add(30*time.Second))
+	if ok {
+		t.Fatal("fourth request within a minute should be rate limited")
+	}
+	// Die erste Anfrage fällt nach 60 s aus dem Fenster
+	if retryAfter != 30*time.Second {
+		t.Errorf("retryAfter = %v, want 30s", retryAfter)
+	}
+
+	if _, ok := s.allow(other, 3, start.Add(30*time.Second)); !ok {
+		t.Error("limits are per company")
+	}
+	if _, ok := s.allow(company, 3, start.Add(61*time.Second)); !ok {
+		t.Error("request after the first one left the window should be allowed")
+	}
+	if _, ok := s.allow(company, 3, start.Add(62*time.Second)); ok {
+		t.Error("window is full again")
+	}
+}
+
+func TestBeginRateLimit(t *testing.T) {
+	s := NewUsageService(nil)
+	// enterprise hat keine Monatsgrenzen, Begin braucht dann keine Datenbank
+	company := &domain.Company{ID: uuid.New(), SubscriptionTier: SubscriptionTierEnterprise}
+	tenderID := uuid.New()
+
+	limit := usageQuotas[SubscriptionTierEnterprise].RequestsPerMinute
+	for i := 0; i < limit; i++ {
+		ctx, err := s.Begin(context.Background(), company, &tenderID, OperationTenderChat)
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if i == 0 {
+			a := usage.AttributionFrom(ctx)
+			if a.CompanyID == nil || *a.CompanyID != company.ID || a.TenderID != &tenderID || a.Operation != OperationTenderChat {
+				t.Errorf("unexpected attribution %+v", a)
+			}
+		}
+	}
+
+	_, err := s.Begin(context.Background(), company, &tenderID, OperationTenderChat)
+	var quotaErr *QuotaError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrRateLimited) || quotaErr.Limit != QuotaLimitRequestsPerMin || quotaErr.RetryAfter <= 0 {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+}
+
+func TestBeginWithoutCompany(t *testing.T) {
+	tenderID := uuid.New()
+	for _, s := range []*UsageService{nil, NewUsageService(nil)} {
+		ctx, err := s.Begin(context.Background(), nil, &tenderID, OperationIngestion)
+		if err != nil {
+			t.Fatalf("system usage must not be limited: %v", err)
+		}
+		if a := usage.AttributionFrom(ctx); a.CompanyID != nil || a.Operation != OperationIngestion {
+			t.Errorf("unexpected attribution %+v", a)
+		}
+	}
+}
+
+func TestMonthStart(t *testing.T) {
+	s := NewUsageService(nil)
+	// 31.03. 23:30 UTC ist in Berlin bereits der 1. April
+	got := s.monthStart(time.Date(2026, 3, 31, 23, 30, 0, 0, time.UTC))
+	if got.Month() != time.April || got.Day() != 1 || got.Hour() != 0 {
+		t.Errorf("monthStart = %v, want 1 April 00:00 Berlin", got)
+	}
+}
@@ -0,0 +1,148 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// chatModel misst jeden Aufruf des eingebetteten ChatModels
+type chatModel struct {
+	inner        model.ToolCallingChatModel
+	rec          Recorder
+	defaultModel string
+}
+
+// WrapChatModel erfasst Tokens und Latenz aller Aufrufe; ohne Recorder bleibt das Modell unverändert
+func WrapChatModel(inner model.ToolCallingChatModel, rec Recorder, defaultModel string) model.ToolCallingChatModel {
+	if rec == nil {
+		return inner
+	}
+	return &chatModel{inner: inner, rec: rec, defaultModel: defaultModel}
+}
+
+func (m *chatModel) Generate(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	start := time.Now()
+	msg, err := m.inner.Generate(ctx, in, opts...)
+	m.record(ctx, start, msg, err, opts)
+	return msg, err
+}
+
+// Stream liest eine Kopie des Streams mit; die Nutzung steht erst im letzten Chunk
+func (m *chatModel) Stream(ctx context.Context, in []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	start := time.Now()
+	sr, err := m.inner.Stream(ctx, in, opts...)
+	if err != nil {
+		m.record(ctx, start, nil, err, opts)
+		return nil, err
+	}
+
+	copies := sr.Copy(2)
+	go func() {
+		defer copies[1].Close()
+		var last *schema.Message
+		for {
+			chunk, err := copies[1].Recv()
+			if errors.Is(err, io.EOF) {
+				m.record(ctx, start, last, nil, opts)
+				return
+			}
+			if err != nil {
+				m.record(ctx, start, last, err, opts)
+				return
+			}
+			if chunk != nil && chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+				last = chunk
+			}
+		}
+	}()
+	return copies[0], nil
+}
+
+func (m *chatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.inner.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &chatModel{inner: inner, rec: m.rec, defaultModel: m.defaultModel}, nil
+}
+
+// record erfasst einen Aufruf; das Modell kann je Lauf über die Prompt-Registry wechseln
+func (m *chatModel) record(ctx context.Context, start time.Time, msg *schema.Message, err error, opts []model.Option) {
+	e := Event{
+		Kind:     KindChat,
+		Provider: ProviderOpenRouter,
+		Model:    m.defaultModel,
+		Latency:  time.Since(start),
+		Failed:   err != nil,
+	}
+	if o := model.GetCommonOptions(&model.Options{}, opts...); o.Model != nil && *o.Model != "" {
+		e.Model = *o.Model
+	}
+	if msg != nil && msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+		e.PromptTokens = msg.ResponseMeta.Usage.PromptTokens
+		e.CompletionTokens = msg.ResponseMeta.Usage.CompletionTokens
+	}
+	Record(ctx, m.rec, e)
+}
+
+// embedder misst jeden Aufruf des eingebetteten Embedders
+type embedder struct {
+	inner embedding.Embedder
+	rec   Recorder
+	model string
+}
+
+// WrapEmbedder erfasst Tokens und Latenz aller Embedding-Aufrufe; ohne Recorder bleibt der
+// Embedder unverändert
+func WrapEmbedder(inner embedding.Embedder, rec Recorder, model string) embedding.Embedder {
+	if rec == nil {
+		return inner
+	}
+	return &embedder{inner: inner, rec: rec, model: model}
+}
+
+func (e *embedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	// Die Token-Zahl meldet der Embedder nur über Callbacks
+	var tokens int
+	handler := callbacks.NewHandlerBuilder().
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if out := embedding.ConvCallbackOutput(output); out != nil && out.TokenUsage != nil {
+				tokens = out.TokenUsage.PromptTokens
+			}
+			return ctx
+		}).
+		Build()
+	callCtx := callbacks.InitCallbacks(ctx, &callbacks.RunInfo{Component: components.ComponentOfEmbedding}, handler)
+
+	start := time.Now()
+	vectors, err := e.inner.EmbedStrings(callCtx, texts, opts...)
+	if tokens == 0 {
+		tokens = estimateTokens(texts)
+	}
+	Record(ctx, e.rec, Event{
+		Kind:         KindEmbedding,
+		Provider:     ProviderOpenRouter,
+		Model:        e.model,
+		PromptTokens: tokens,
+		Latency:      time.Since(start),
+		Failed:       err != nil,
+	})
+	return vectors, err
+}
+
+// estimateTokens schätzt Tokens grob mit vier Zeichen je Token
+func estimateTokens(texts []string) int {
+	n := 0
+	for _, t := range texts {
+		n += len([]rune(t))
+	}
+	return (n + 3) / 4
+}
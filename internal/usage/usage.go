@@ -0,0 +1,119 @@
+// Package usage erfasst kostenpflichtige Aufrufe (OCR, Embeddings, Chat) mit Tokens, Seiten,
+// Modell, Latenz und geschätzten Kosten und ordnet sie Firma und Ausschreibung zu.
+package usage
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Arten kostenpflichtiger Aufrufe
+const (
+	KindOCR       = "ocr"
+	KindEmbedding = "embedding"
+	KindChat      = "chat"
+)
+
+// Anbieter
+const (
+	ProviderNovita     = "novita"
+	ProviderOpenRouter = "openrouter"
+)
+
+// Event ist ein einzelner Aufruf eines Anbieters
+type Event struct {
+	Kind             string
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Pages            int
+	Latency          time.Duration
+	CostUSD          float64
+	Failed           bool
+	// Zuordnung aus dem Kontext (WithAttribution)
+	Attribution
+}
+
+// Recorder speichert Events; Fehler beim Speichern dürfen den Aufruf nicht abbrechen
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+// Attribution ordnet Aufrufe einer Firma, Ausschreibung und Funktion zu. Ohne Firma gelten
+// sie als Systemverbrauch (z. B. Import von Ausschreibungen).
+type Attribution struct {
+	CompanyID *uuid.UUID
+	TenderID  *uuid.UUID
+	// Operation ist die auslösende Funktion, z. B. compliance_check oder tender_chat
+	Operation string
+}
+
+type attributionKey struct{}
+
+// WithAttribution hängt die Zuordnung an den Kontext; alle folgenden Aufrufe zählen dafür
+func WithAttribution(ctx context.Context, a Attribution) context.Context {
+	return context.WithValue(ctx, attributionKey{}, a)
+}
+
+// AttributionFrom liefert die Zuordnung aus dem Kontext (leer, wenn keine gesetzt ist)
+func AttributionFrom(ctx context.Context) Attribution {
+	a, _ := ctx.Value(attributionKey{}).(Attribution)
+	return a
+}
+
+// Price sind Listenpreise in USD; Kosten sind Schätzungen, abgerechnet wird beim Anbieter
+type Price struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+	PerPage       float64
+}
+
+// prices nach Modell-ID. Unbekannte Modelle werden mit fallbackPrice geschätzt.
+var prices = map[string]Price{
+	"openai/gpt-4o":                 {InputPerMTok: 2.5, OutputPerMTok: 10},
+	"openai/gpt-4o-mini":            {InputPerMTok: 0.15, OutputPerMTok: 0.6},
+	"openai/gpt-4.1":                {InputPerMTok: 2, OutputPerMTok: 8},
+	"openai/gpt-4.1-mini":           {InputPerMTok: 0.4, OutputPerMTok: 1.6},
+	"anthropic/claude-sonnet-4":     {InputPerMTok: 3, OutputPerMTok: 15},
+	"text-embedding-3-small":        {InputPerMTok: 0.02},
+	"openai/text-embedding-3-small": {InputPerMTok: 0.02},
+	"deepseek/deepseek-ocr":         {InputPerMTok: 0.03, OutputPerMTok: 0.1},
+}
+
+// fallbackPrice für Modelle ohne Listenpreis: der teuerste Chat-Preis, damit Kosten und
+// Kontingente eher zu hoch als gar nicht zählen
+var fallbackPrice = Price{InputPerMTok: 3, OutputPerMTok: 15}
+
+// unknownModels merkt sich bereits gemeldete Modelle ohne Preis (einmal loggen je Modell)
+var unknownModels sync.Map
+
+// EstimateCost schätzt die Kosten eines Aufrufs in USD
+func EstimateCost(model string, promptTokens, completionTokens, pages int) float64 {
+	p, ok := prices[model]
+	if !ok {
+		p = fallbackPrice
+		if _, logged := unknownModels.LoadOrStore(model, true); !logged {
+			log.Printf("⚠️ No price for model %q, estimating costs with the fallback price", model)
+		}
+	}
+	return float64(promptTokens)/1e6*p.InputPerMTok +
+		float64(completionTokens)/1e6*p.OutputPerMTok +
+		float64(pages)*p.PerPage
+}
+
+// Record ergänzt Zuordnung aus dem Kontext und Kosten und gibt das Event an den Recorder;
+// rec darf nil sein
+func Record(ctx context.Context, rec Recorder, e Event) {
+	if rec == nil {
+		return
+	}
+	e.Attribution = AttributionFrom(ctx)
+	e.CostUSD = EstimateCost(e.Model, e.PromptTokens, e.CompletionTokens, e.Pages)
+	// Auch abgebrochene Anfragen werden noch gespeichert
+	rec.Record(context.WithoutCancel(ctx), e)
+}
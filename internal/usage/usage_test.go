@@ -0,0 +1,30 @@
+package usage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		name                     string
+		model                    string
+		prompt, completion, page int
+		want                     float64
+	}{
+		{"chat model", "openai/gpt-4o", 1_000_000, 100_000, 0, 2.5 + 1.0},
+		{"cheap chat model", "openai/gpt-4o-mini", 200_000, 0, 0, 0.03},
+		{"embedding without output price", "text-embedding-3-small", 500_000, 0, 0, 0.01},
+		{"no tokens", "openai/gpt-4.1", 0, 0, 0, 0},
+		{"unknown model uses fallback price", "vendor/new-model", 1_000_000, 1_000_000, 0, fallbackPrice.InputPerMTok + fallbackPrice.OutputPerMTok},
+		{"empty model uses fallback price", "", 100_000, 0, 0, fallbackPrice.InputPerMTok / 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateCost(tt.model, tt.prompt, tt.completion, tt.page)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EstimateCost(%q) = %.6f, want %.6f", tt.model, got, tt.want)
+			}
+		})
+	}
+}
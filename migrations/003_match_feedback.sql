@@ -0,0 +1,28 @@
+-- Migration: Create match_feedbacks table (Nutzer-Feedback für das Re-Ranking)
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. CREATE TABLE
+-- ============================================
+create table if not exists public.match_feedbacks (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  tender_id uuid not null references tenders(id) on delete cascade,
+  auth_user_id uuid,
+
+  -- 'up' oder 'down'
+  vote text not null check (vote in ('up', 'down')),
+  -- too_far | wrong_trade | too_big | other
+  reason text,
+  comment text,
+
+  -- Timestamps
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint match_feedbacks_pkey primary key (id)
+);
+
+-- Ein Feedback pro Firma und Ausschreibung (erneutes Bewerten überschreibt)
+create unique index if not exists idx_match_feedback_company_tender on match_feedbacks(company_id, tender_id);
+create index if not exists idx_match_feedbacks_company_created on match_feedbacks(company_id, created_at);
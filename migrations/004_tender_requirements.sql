@@ -0,0 +1,16 @@
+-- Migration: Strukturierte Mindestanforderungen für Ausschreibungen + AVPQ-Status für Firmen
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. TENDERS: extrahierte Mindestanforderungen
+-- ============================================
+alter table public.tenders
+  add column if not exists min_annual_revenue numeric(20,2),
+  add column if not exists min_employee_count integer,
+  add column if not exists required_certificates text[] default '{}';
+
+-- ============================================
+-- 2. COMPANIES: Präqualifikation (AVPQ)
+-- ============================================
+alter table public.companies
+  add column if not exists is_avpq boolean default false;
@@ -0,0 +1,50 @@
+-- Migration: Indizes für die ANN-Kandidatensuche im Matching
+-- Run this in Supabase SQL Editor
+--
+-- Voraussetzung: pgvector >= 0.8.0 (hnsw.iterative_scan, damit der Deadline-Filter
+-- die HNSW-Ergebnisse nicht ausdünnt) und PostGIS.
+-- Der HNSW-Build braucht Speicher, vorher ggf.: set maintenance_work_mem = '1GB';
+
+-- ============================================
+-- 1. HNSW-Index auf dem Ausschreibungs-Embedding (Kosinus-Distanz)
+-- ============================================
+create index if not exists idx_tenders_requirement_embedding_hnsw
+  on public.tenders
+  using hnsw (requirement_embedding vector_cosine_ops)
+  with (m = 16, ef_construction = 64);
+
+-- ============================================
+-- 2. CPV-Vorfilter (Array-Overlap &&)
+-- ============================================
+create index if not exists idx_tenders_cpv_codes_gin
+  on public.tenders using gin (cpv_codes);
+
+-- ============================================
+-- 3. NUTS-Vorfilter: alle Präfixe eines Codes (DE212 -> DE, DE2, DE21, DE212),
+--    damit "liegt in Wunschregion" per GIN-Index statt LIKE-Scan geprüft wird
+-- ============================================
+create or replace function public.nuts_prefixes(codes text[])
+returns text[]
+language sql
+immutable
+parallel safe
+as $$
+  select coalesce(array_agg(distinct left(c.code, n)), '{}')
+  from (select upper(replace(raw, ' ', '')) as code from unnest(codes) as raw) c,
+       generate_series(2, length(c.code)) as n
+$$;
+
+create index if not exists idx_tenders_nuts_prefixes_gin
+  on public.tenders using gin (public.nuts_prefixes(nutscodes));
+
+-- ============================================
+-- 4. Geo-Vorfilter (ST_DWithin auf geography)
+-- ============================================
+create index if not exists idx_tenders_location_geog
+  on public.tenders using gist ((location_geom::geography));
+
+-- ============================================
+-- 5. Deadline-Filter
+-- ============================================
+create index if not exists idx_tenders_deadline
+  on public.tenders (deadline);
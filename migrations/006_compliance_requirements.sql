@@ -0,0 +1,70 @@
+-- Migration: Einzelne Anforderungen und Blocker eines Compliance-Checks als Zeilen
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. COMPLIANCE_REQUIREMENTS
+-- ============================================
+create table if not exists public.compliance_requirements (
+  id uuid not null default extensions.uuid_generate_v4(),
+  compliance_check_id uuid not null references compliance_checks(id) on delete cascade,
+  position integer not null default 0,
+
+  title text,
+  -- eignung | ausschluss | nachweis | technical
+  category text not null check (category in ('eignung', 'ausschluss', 'nachweis', 'technical')),
+
+  -- Quelle: wörtliches Zitat mit Dokument und Seite
+  source_quote text,
+  source_document text,
+  source_page integer,
+
+  -- fulfilled | not_fulfilled | unknown
+  status text not null default 'unknown' check (status in ('fulfilled', 'not_fulfilled', 'unknown')),
+  -- Verwendete Angaben aus dem Firmenprofil
+  evidence text,
+  is_knockout boolean default false,
+
+  created_at timestamptz default now(),
+
+  constraint compliance_requirements_pkey primary key (id)
+);
+
+create index if not exists idx_compliance_requirements_check on compliance_requirements(compliance_check_id, position);
+
+-- ============================================
+-- 2. COMPLIANCE_BLOCKERS
+-- ============================================
+create table if not exists public.compliance_blockers (
+  id uuid not null default extensions.uuid_generate_v4(),
+  compliance_check_id uuid not null references compliance_checks(id) on delete cascade,
+  position integer not null default 0,
+
+  -- missing_document | critical_issue
+  kind text not null check (kind in ('missing_document', 'critical_issue')),
+  description text not null,
+
+  created_at timestamptz default now(),
+
+  constraint compliance_blockers_pkey primary key (id)
+);
+
+create index if not exists idx_compliance_blockers_check on compliance_blockers(compliance_check_id, position);
+
+-- ============================================
+-- 3. BESTANDSDATEN übernehmen und alte Array-Spalten entfernen
+-- ============================================
+insert into public.compliance_blockers (compliance_check_id, position, kind, description, created_at)
+select c.id, d.ord - 1, 'missing_document', d.description, c.created_at
+from public.compliance_checks c,
+     unnest(c.missing_docs) with ordinality as d(description, ord)
+where c.missing_docs is not null;
+
+insert into public.compliance_blockers (compliance_check_id, position, kind, description, created_at)
+select c.id, coalesce(array_length(c.missing_docs, 1), 0) + i.ord - 1, 'critical_issue', i.description, c.created_at
+from public.compliance_checks c,
+     unnest(c.critical_issues) with ordinality as i(description, ord)
+where c.critical_issues is not null;
+
+alter table public.compliance_checks
+  drop column if exists missing_docs,
+  drop column if exists critical_issues;
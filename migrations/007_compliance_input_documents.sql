@@ -0,0 +1,6 @@
+-- Migration: Protokoll der Dokumente, die in einen Compliance-Check eingeflossen sind
+-- Run this in Supabase SQL Editor
+
+-- [{ "name", "attachment_id", "status": included|partial|skipped, "reason", "pages", "total_chars", "included_chars" }]
+alter table public.compliance_checks
+  add column if not exists input_documents jsonb default '[]';
@@ -0,0 +1,32 @@
+-- Migration: Cache-Schlüssel und Veraltet-Markierung für Compliance-Checks
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. Cache-Schlüssel
+-- ============================================
+-- input_hash: SHA-256 über alle Dokumenttexte (Bekanntmachung, OCR-Extrakt, Anhänge)
+-- profile_hash: SHA-256 über das Firmendossier
+-- prompt_version / model_id: Version der Prompts/Tool-Schemas und verwendetes Modell
+alter table public.compliance_checks
+  add column if not exists input_hash text,
+  add column if not exists profile_hash text,
+  add column if not exists prompt_version text,
+  add column if not exists model_id text;
+
+-- ============================================
+-- 2. Veraltet-Markierung
+-- ============================================
+alter table public.compliance_checks
+  add column if not exists is_stale boolean default false,
+  add column if not exists stale_reason text,
+  add column if not exists stale_at timestamptz;
+
+-- ============================================
+-- 3. Indizes für Cache-Lookup und Verlauf
+-- ============================================
+create index if not exists idx_compliance_checks_history
+  on public.compliance_checks (company_id, tender_id, created_at desc);
+
+create index if not exists idx_compliance_checks_cache
+  on public.compliance_checks (company_id, tender_id, input_hash, profile_hash, prompt_version, model_id)
+  where is_stale = false;
@@ -0,0 +1,41 @@
+-- Migration: Abgabe-Checkliste (einzureichende Unterlagen je Firma und Ausschreibung)
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. SUBMISSION_CHECKLIST_ITEMS
+-- ============================================
+create table if not exists public.submission_checklist_items (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  tender_id uuid not null references tenders(id) on delete cascade,
+  position integer not null default 0,
+
+  title text not null,
+  -- formular | eigenerklaerung | nachweis | preisblatt | konzept | sonstiges
+  category text not null check (category in ('formular', 'eigenerklaerung', 'nachweis', 'preisblatt', 'konzept', 'sonstiges')),
+  -- Geforderte Form (unterschrieben, Kopie, über Vergabeplattform, ...)
+  due_form text,
+  -- mit_angebot | auf_verlangen
+  submission_timing text not null default 'mit_angebot' check (submission_timing in ('mit_angebot', 'auf_verlangen')),
+  page_limit integer,
+
+  -- Quelle: wörtliches Zitat mit Dokument und Seite
+  source_quote text,
+  source_document text,
+  source_page integer,
+
+  -- Bearbeitung durch den Nutzer: open | in_progress | done | not_applicable
+  status text not null default 'open' check (status in ('open', 'in_progress', 'done', 'not_applicable')),
+  note text,
+  -- Vom Nutzer angelegt (bleibt beim Neu-Generieren erhalten)
+  is_custom boolean default false,
+
+  -- Timestamps
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint submission_checklist_items_pkey primary key (id)
+);
+
+create index if not exists idx_checklist_company_tender
+  on public.submission_checklist_items (company_id, tender_id, position);
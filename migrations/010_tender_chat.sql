@@ -0,0 +1,26 @@
+-- Migration: Frage-Antwort-Verlauf je Nutzer und Ausschreibung
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. TENDER_CHAT_MESSAGES
+-- ============================================
+create table if not exists public.tender_chat_messages (
+  id uuid not null default extensions.uuid_generate_v4(),
+  auth_user_id uuid not null,
+  tender_id uuid not null references tenders(id) on delete cascade,
+
+  -- user | assistant
+  role text not null check (role in ('user', 'assistant')),
+  content text not null,
+
+  -- Nur Antworten: Fundstellen [{document, page, quote}] und ob die Unterlagen die Frage beantworten
+  citations jsonb default '[]'::jsonb,
+  found boolean default false,
+
+  created_at timestamptz default now(),
+
+  constraint tender_chat_messages_pkey primary key (id)
+);
+
+create index if not exists idx_tender_chat_user_tender
+  on public.tender_chat_messages (auth_user_id, tender_id, created_at);
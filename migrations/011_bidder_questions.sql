@@ -0,0 +1,47 @@
+-- Migration: Entwürfe für Bieterfragen und Frist für Bieterfragen
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. Frist für Bieterfragen an der Ausschreibung
+-- ============================================
+alter table public.tenders
+  add column if not exists question_deadline_at timestamptz,
+  add column if not exists question_deadline_quote text;
+
+-- ============================================
+-- 2. BIDDER_QUESTIONS
+-- ============================================
+create table if not exists public.bidder_questions (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  tender_id uuid not null references tenders(id) on delete cascade,
+  position integer not null default 0,
+
+  subject text,
+  question text not null,
+  -- Interner Hinweis, warum die Frage nötig ist
+  reason text,
+  -- widerspruch | unklarheit | luecke
+  kind text not null check (kind in ('widerspruch', 'unklarheit', 'luecke')),
+
+  -- Quelle: wörtliches Zitat mit Dokument und Seite
+  source_quote text,
+  source_document text,
+  source_page integer,
+
+  -- draft | approved | submitted | discarded
+  status text not null default 'draft' check (status in ('draft', 'approved', 'submitted', 'discarded')),
+  -- Antwort der Vergabestelle
+  answer text,
+  -- Vom Nutzer angelegt (bleibt beim Neu-Generieren erhalten)
+  is_custom boolean default false,
+
+  -- Timestamps
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint bidder_questions_pkey primary key (id)
+);
+
+create index if not exists idx_bidder_questions_company_tender
+  on public.bidder_questions (company_id, tender_id, position);
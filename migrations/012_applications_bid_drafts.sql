@@ -0,0 +1,54 @@
+-- Migration: Bewerbungen und versionierte Angebotskonzepte
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. APPLICATIONS (eine Bewerbung je Firma und Ausschreibung)
+-- ============================================
+create table if not exists public.applications (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  tender_id uuid not null references tenders(id) on delete cascade,
+  -- in_progress | submitted | withdrawn
+  status text not null default 'in_progress' check (status in ('in_progress', 'submitted', 'withdrawn')),
+
+  -- Entwurfserstellung: running | done | failed
+  generation_status text,
+  generation_error text,
+  generation_started_at timestamptz,
+
+  -- Timestamps
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint applications_pkey primary key (id)
+);
+
+create unique index if not exists idx_applications_company_tender
+  on public.applications (company_id, tender_id);
+
+-- ============================================
+-- 2. BID_DRAFTS (Versionen des Angebotskonzepts)
+-- ============================================
+create table if not exists public.bid_drafts (
+  id uuid not null default extensions.uuid_generate_v4(),
+  application_id uuid not null references applications(id) on delete cascade,
+  version integer not null,
+  -- generated | edited
+  source text not null check (source in ('generated', 'edited')),
+  based_on_version integer,
+
+  -- Abschnitte: [{heading, requirements, award_criterion, page_limit, content, word_count, ...}]
+  sections jsonb not null default '[]'::jsonb,
+  outline_derived boolean default false,
+  failed_sections jsonb default '[]'::jsonb,
+  input_documents jsonb default '[]'::jsonb,
+  model_id text,
+  created_by uuid,
+
+  created_at timestamptz default now(),
+
+  constraint bid_drafts_pkey primary key (id)
+);
+
+create unique index if not exists idx_bid_drafts_application_version
+  on public.bid_drafts (application_id, version);
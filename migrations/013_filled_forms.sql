@@ -0,0 +1,18 @@
+-- Migration: Vorausgefüllte Formulare (Eigenerklärungen) als Anhänge
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. TENDER_ATTACHMENTS erweitern
+-- ============================================
+-- Vorausgefüllte Formulare enthalten Firmendaten und gehören genau einer Firma
+alter table public.tender_attachments
+  add column if not exists company_id uuid references companies(id) on delete cascade,
+  add column if not exists source_attachment_id uuid references tender_attachments(id) on delete set null,
+  -- eigenerklaerung_eignung | ausschlussgruende | tariftreue | russland_sanktionen
+  add column if not exists form_template text,
+  -- Felder: [{name, key, value, status}], status: filled | prefilled | missing_data | unknown
+  add column if not exists form_fields jsonb;
+
+create index if not exists idx_tender_attachments_company
+  on public.tender_attachments (company_id)
+  where company_id is not null;
@@ -0,0 +1,63 @@
+-- Migration: Prompt-Registry (versionierte Prompts und Modell je Agent und Umgebung)
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. PROMPT_TEMPLATES
+-- ============================================
+-- Prompt-Versionen je Agent; ohne Eintrag gelten die mitgelieferten Prompts (v1)
+-- bzw. Dateien unter PROMPT_DIR/<agent>/<version>/<name>.txt
+create table if not exists public.prompt_templates (
+  id uuid not null default extensions.uuid_generate_v4(),
+  -- compliance | checklist | tender_qa | bidder_questions | bid_draft
+  agent text not null,
+  version text not null,
+  -- {name: text}, z. B. {"system": "..."}; compliance: system, extraction, verdict; bid_draft: outline, section
+  prompts jsonb not null,
+  description text,
+
+  created_at timestamptz default now(),
+
+  constraint prompt_templates_pkey primary key (id)
+);
+
+create unique index if not exists idx_prompt_templates_agent_version
+  on public.prompt_templates (agent, version);
+
+-- ============================================
+-- 2. PROMPT_ASSIGNMENTS
+-- ============================================
+-- Aktive Version und Modell je Umgebung (APP_ENV) und Agent. Änderungen greifen ohne
+-- Neustart nach spätestens einer Minute; ohne Eintrag gelten v1 und OPENROUTER_MODEL.
+create table if not exists public.prompt_assignments (
+  environment text not null,
+  agent text not null,
+  prompt_version text not null default 'v1',
+  -- leer = OPENROUTER_MODEL
+  model text,
+  -- leer = Standardtemperatur des Agents
+  temperature real check (temperature is null or (temperature >= 0 and temperature <= 2)),
+
+  updated_at timestamptz default now(),
+
+  constraint prompt_assignments_pkey primary key (environment, agent)
+);
+
+-- ============================================
+-- 3. PROMPT-VERSION UND MODELL AN ALLEN AGENT-ERGEBNISSEN
+-- ============================================
+-- prompt_version: "<version>@<hash>" (Hash über Prompts und Tool-Schemas)
+-- compliance_checks hat beide Spalten seit 008, bid_drafts das Modell seit 012
+alter table public.submission_checklist_items
+  add column if not exists prompt_version text,
+  add column if not exists model_id text;
+
+alter table public.tender_chat_messages
+  add column if not exists prompt_version text,
+  add column if not exists model_id text;
+
+alter table public.bidder_questions
+  add column if not exists prompt_version text,
+  add column if not exists model_id text;
+
+alter table public.bid_drafts
+  add column if not exists prompt_version text;
@@ -0,0 +1,34 @@
+-- Migration: Verbrauch von OCR, Embeddings und Chat-Modellen je Firma
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. LLM_USAGES
+-- ============================================
+-- Ein Eintrag je Aufruf (OCR: je Dokument). Kosten sind Schätzungen nach Listenpreis in USD.
+-- company_id ist leer bei Systemverbrauch (z. B. Import von Ausschreibungen).
+create table if not exists public.llm_usages (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid references companies(id) on delete cascade,
+  tender_id uuid references tenders(id) on delete set null,
+  -- auslösende Funktion: compliance_check | checklist | tender_chat | bidder_questions | bid_draft | attachment_ocr | ingestion | company_profile
+  operation text,
+  -- ocr | embedding | chat
+  kind text not null,
+  -- novita | openrouter
+  provider text not null,
+  model text not null,
+  prompt_tokens integer not null default 0,
+  completion_tokens integer not null default 0,
+  pages integer not null default 0,
+  latency_ms bigint not null default 0,
+  cost_usd numeric(12,6) not null default 0,
+  failed boolean not null default false,
+
+  created_at timestamptz default now(),
+
+  constraint llm_usages_pkey primary key (id)
+);
+
+-- Monatsauswertung und Kontingentprüfung je Firma
+create index if not exists idx_llm_usages_company_created
+  on public.llm_usages (company_id, created_at);
@@ -0,0 +1,30 @@
+-- Migration: Branche im Firmenprofil, Löschung der Firma mit allen Daten
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. COMPANIES
+-- ============================================
+-- Branche aus dem Onboarding (fließt ins Profil-Embedding ein)
+alter table public.companies
+  add column if not exists industry text;
+
+-- ============================================
+-- 2. KASKADEN AUF COMPANIES
+-- ============================================
+-- matches und compliance_checks stammen aus dem Ausgangsschema. Damit beim Löschen einer
+-- Firma (DSGVO) nichts zurückbleibt, werden ihre Fremdschlüssel mit Kaskade neu angelegt.
+delete from public.matches m
+  where not exists (select 1 from public.companies c where c.id = m.company_id);
+alter table public.matches
+  drop constraint if exists matches_company_id_fkey;
+alter table public.matches
+  add constraint matches_company_id_fkey
+  foreign key (company_id) references companies(id) on delete cascade;
+
+delete from public.compliance_checks cc
+  where not exists (select 1 from public.companies c where c.id = cc.company_id);
+alter table public.compliance_checks
+  drop constraint if exists compliance_checks_company_id_fkey;
+alter table public.compliance_checks
+  add constraint compliance_checks_company_id_fkey
+  foreign key (company_id) references companies(id) on delete cascade;
@@ -0,0 +1,64 @@
+-- Migration: Mehrere Nutzer je Firma mit Rollen und Einladungen
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. COMPANY_MEMBERS
+-- ============================================
+-- Jeder Nutzer gehört zu höchstens einer Firma. companies.auth_user_id bleibt als owner erhalten,
+-- der Zugriff läuft aber ausschließlich über die Mitgliedschaft.
+create table if not exists public.company_members (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  auth_user_id uuid not null,
+  email text,
+  -- owner | admin | bid_manager | viewer
+  role text not null check (role in ('owner', 'admin', 'bid_manager', 'viewer')),
+  invited_by uuid,
+
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint company_members_pkey primary key (id)
+);
+
+create unique index if not exists idx_company_members_auth_user
+  on public.company_members (auth_user_id);
+create index if not exists idx_company_members_company
+  on public.company_members (company_id);
+-- Genau ein owner je Firma
+create unique index if not exists idx_company_members_owner
+  on public.company_members (company_id) where role = 'owner';
+
+-- Bestehende Firmen: bisheriger Login wird owner
+insert into public.company_members (company_id, auth_user_id, email, role)
+select c.id, c.auth_user_id, u.email, 'owner'
+from public.companies c
+left join auth.users u on u.id = c.auth_user_id
+where c.auth_user_id is not null
+on conflict do nothing;
+
+-- ============================================
+-- 2. COMPANY_INVITATIONS
+-- ============================================
+create table if not exists public.company_invitations (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  email text not null,
+  role text not null check (role in ('admin', 'bid_manager', 'viewer')),
+  -- SHA-256 des Tokens aus dem Einladungslink
+  token_hash text not null,
+  invited_by uuid not null,
+  expires_at timestamptz not null,
+  accepted_at timestamptz,
+  accepted_by uuid,
+
+  created_at timestamptz default now(),
+
+  constraint company_invitations_pkey primary key (id)
+);
+
+create unique index if not exists idx_company_invitations_token
+  on public.company_invitations (token_hash);
+-- Höchstens eine offene Einladung je Adresse und Firma
+create unique index if not exists idx_company_invitations_open
+  on public.company_invitations (company_id, lower(email)) where accepted_at is null;
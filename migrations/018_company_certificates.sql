@@ -0,0 +1,75 @@
+-- Migration: Strukturierte Nachweise (Zertifikate, Präqualifikation, Versicherungen) mit Ablaufdatum
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. COMPANY_CERTIFICATES
+-- ============================================
+create table if not exists public.company_certificates (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  -- iso_9001 | iso_14001 | iso_27001 | iso_45001 | iso_50001 | scc | avpq | pq_vob
+  -- | meisterbrief | entsorgungsfachbetrieb | insurance | other
+  type text not null,
+  name text not null,
+  issuer text,
+  scope text,
+  certificate_number text,
+  valid_from date,
+  -- null = unbefristet (z. B. Meisterbrief)
+  valid_until date,
+
+  -- Datei im Bucket company-assets (certificates/<company_id>/<id>/<datei>)
+  storage_path text,
+  filename text,
+  content_type text,
+  file_size integer,
+
+  -- Zuletzt verschickte Ablauf-Erinnerung in Tagen vor Ablauf (90, 30, 7, 0 = abgelaufen);
+  -- wird bei geändertem valid_until zurückgesetzt
+  last_reminder_days integer,
+
+  created_by uuid,
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint company_certificates_pkey primary key (id)
+);
+
+create index if not exists idx_company_certificates_company
+  on public.company_certificates (company_id);
+-- Erinnerungs-Job: nur befristete Nachweise
+create index if not exists idx_company_certificates_valid_until
+  on public.company_certificates (valid_until) where valid_until is not null;
+
+-- ============================================
+-- 2. BACKFILL AUS COMPANIES.CERTIFICATIONS
+-- ============================================
+-- Im Onboarding ausgewählte Zertifikate (Strings im JSON-Array) werden zu Nachweisen ohne
+-- Gültigkeitsangaben; die Firma kann Aussteller und Ablaufdatum nachtragen.
+insert into public.company_certificates (company_id, type, name)
+select c.id,
+  case
+    when cert ~* 'iso\s*9001' then 'iso_9001'
+    when cert ~* 'iso\s*14001' then 'iso_14001'
+    when cert ~* 'iso\s*(/\s*iec\s*)?27001' then 'iso_27001'
+    when cert ~* 'iso\s*45001' then 'iso_45001'
+    when cert ~* 'iso\s*50001' then 'iso_50001'
+    when cert ~* 'pq-vob' then 'pq_vob'
+    when cert ~* 'avpq|präqualifi' then 'avpq'
+    when cert ~* '\mscc\M' then 'scc'
+    when cert ~* 'meisterbrief|meisterprüfung' then 'meisterbrief'
+    when cert ~* 'entsorgungsfachbetrieb' then 'entsorgungsfachbetrieb'
+    when cert ~* 'haftpflicht|versicherung' then 'insurance'
+    else 'other'
+  end,
+  cert
+from public.companies c
+cross join lateral (
+  select trim(e #>> '{}') as cert
+  from jsonb_array_elements(
+    case when jsonb_typeof(c.certifications::jsonb) = 'array' then c.certifications::jsonb else '[]'::jsonb end
+  ) e
+  where jsonb_typeof(e) = 'string'
+) certs
+where cert <> ''
+  and not exists (select 1 from public.company_certificates cc where cc.company_id = c.id);
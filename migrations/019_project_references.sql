@@ -0,0 +1,62 @@
+-- Migration: Strukturierte Referenzprojekte mit eigenem Embedding
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. PROJECT_REFERENCES
+-- ============================================
+create table if not exists public.project_references (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  title text not null,
+  description text,
+  client text,
+  -- public | private (leer = unbekannt)
+  client_type text check (client_type in ('public', 'private', '')),
+  contract_value numeric(20,2),
+  start_date date,
+  -- null = laufend oder unbekannt
+  end_date date,
+  cpv_codes text[] default '{}',
+  location_city text,
+  location_zip text,
+  contact_name text,
+  contact_email text,
+  contact_phone text,
+  -- Auftraggeber hat der Nennung als Referenz zugestimmt
+  released_for_disclosure boolean not null default false,
+
+  -- Titel, Beschreibung, Auftraggeber, CPV und Ort; null = noch nicht berechnet
+  embedding vector(1536),
+
+  created_by uuid,
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint project_references_pkey primary key (id)
+);
+
+create index if not exists idx_project_references_company
+  on public.project_references (company_id);
+
+-- ============================================
+-- 2. BACKFILL AUS COMPANIES.PROJECT_REFERENCES
+-- ============================================
+-- Onboarding-Einträge {name, client, year, budget, keywords}: das Jahr wird zum Projektende
+-- (31.12.), Stichworte landen in der Beschreibung. Embeddings berechnet das Backend bei der
+-- ersten Referenzauswahl.
+insert into public.project_references (company_id, title, description, client, contract_value, end_date)
+select c.id,
+  coalesce(nullif(trim(r->>'name'), ''), trim(r->>'client')),
+  case when jsonb_typeof(r->'keywords') = 'array'
+    then (select string_agg(k, ', ') from jsonb_array_elements_text(r->'keywords') k)
+  end,
+  nullif(trim(r->>'client'), ''),
+  case when (r->>'budget') ~ '^\d+(\.\d+)?$' then (r->>'budget')::numeric end,
+  case when (r->>'year') ~ '^\d{4}$' then make_date((r->>'year')::int, 12, 31) end
+from public.companies c
+cross join lateral jsonb_array_elements(
+  case when jsonb_typeof(c.project_references::jsonb) = 'array' then c.project_references::jsonb else '[]'::jsonb end
+) r
+where jsonb_typeof(r) = 'object'
+  and coalesce(nullif(trim(r->>'name'), ''), nullif(trim(r->>'client'), '')) is not null
+  and not exists (select 1 from public.project_references pr where pr.company_id = c.id);
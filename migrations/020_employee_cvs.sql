@@ -0,0 +1,43 @@
+-- Migration: Lebensläufe mit extrahierten Abschlüssen, Qualifikationen und Projektrollen
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. EMPLOYEE_CVS
+-- ============================================
+create table if not exists public.employee_cvs (
+  id uuid not null default extensions.uuid_generate_v4(),
+  company_id uuid not null references companies(id) on delete cascade,
+  full_name text,
+  position text,
+  years_of_experience integer not null default 0,
+  -- [{degree, field, institution, year}]
+  degrees jsonb not null default '[]',
+  certifications text[] default '{}',
+  skills text[] default '{}',
+  languages text[] default '{}',
+  -- [{project, role, client, from_year, to_year, description}]
+  project_roles jsonb not null default '[]',
+  summary text,
+
+  -- Datei im Bucket company-assets (cvs/<company_id>/<id>/<datei>)
+  storage_path text,
+  filename text,
+  content_type text,
+  file_size integer,
+
+  extraction_status text not null default 'processing'
+    check (extraction_status in ('processing', 'extracted', 'failed')),
+  extraction_error text,
+  extracted_at timestamptz,
+  prompt_version text,
+  model_id text,
+
+  created_by uuid,
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  constraint employee_cvs_pkey primary key (id)
+);
+
+create index if not exists idx_employee_cvs_company
+  on public.employee_cvs (company_id);
@@ -0,0 +1,18 @@
+-- Migration: Teilergebnisse von Compliance-Checks nicht aus dem Cache liefern
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. Teilergebnis-Markierung
+-- ============================================
+-- is_partial: mindestens ein Abschnitt der Unterlagen ist fehlgeschlagen (siehe input_documents)
+alter table public.compliance_checks
+  add column if not exists is_partial boolean not null default false;
+
+-- ============================================
+-- 2. Cache-Index nur über vollständige Checks
+-- ============================================
+drop index if exists public.idx_compliance_checks_cache;
+
+create index if not exists idx_compliance_checks_cache
+  on public.compliance_checks (company_id, tender_id, input_hash, profile_hash, prompt_version, model_id)
+  where is_stale = false and is_partial = false;
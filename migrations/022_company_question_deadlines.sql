@@ -0,0 +1,27 @@
+-- Migration: Von Hand gesetzte Frist für Bieterfragen je Firma
+-- Run this in Supabase SQL Editor
+
+-- ============================================
+-- 1. TENDER_QUESTION_DEADLINES
+-- ============================================
+-- tenders.question_deadline_at enthält nur noch die Frist aus den Unterlagen (für alle Firmen gleich).
+-- Eine Korrektur gilt nur für die eigene Firma; question_deadline_at null = Frist gelöscht.
+create table if not exists public.tender_question_deadlines (
+  company_id uuid not null references companies(id) on delete cascade,
+  tender_id uuid not null references tenders(id) on delete cascade,
+  question_deadline_at timestamptz,
+  updated_by uuid,
+  updated_at timestamptz not null default now(),
+
+  constraint tender_question_deadlines_pkey primary key (company_id, tender_id)
+);
+
+-- ============================================
+-- 2. Bisherige Korrekturen entfernen
+-- ============================================
+-- Von Hand gesetzte Fristen (ohne Zitat) lassen sich keiner Firma zuordnen und galten
+-- bisher für alle; sie werden verworfen und beim nächsten Generieren neu gelesen.
+update public.tenders
+set question_deadline_at = null
+where question_deadline_at is not null
+  and coalesce(question_deadline_quote, '') = '';
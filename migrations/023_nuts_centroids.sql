@@ -0,0 +1,38 @@
+-- Migration: NUTS-Schwerpunkte für Ausschreibungen ohne location_geom
+-- Run this in Supabase SQL Editor
+--
+-- Die Zeilen schreibt die API beim Start aus internal/service/data/nuts2021_de_at.csv
+-- (service.SyncNUTSCentroids); die Migration legt nur Tabelle und Funktion an.
+
+-- ============================================
+-- 1. NUTS_CENTROIDS (DE/AT, Ebenen 0-3)
+-- ============================================
+create table if not exists public.nuts_centroids (
+  code text not null,
+  name text not null,
+  level integer not null,
+  latitude double precision not null,
+  longitude double precision not null,
+
+  constraint nuts_centroids_pkey primary key (code)
+);
+
+-- ============================================
+-- 2. Schwerpunkt zu den NUTS-Codes einer Ausschreibung
+-- ============================================
+-- Erster Code mit bekanntem Schwerpunkt; unbekannte Codes fallen auf die nächsthöhere
+-- bekannte Ebene zurück (DE21Z -> DE21). Null, wenn kein Code bekannt ist.
+create or replace function public.nuts_centroid(codes text[])
+returns geography
+language sql
+stable
+parallel safe
+as $$
+  select st_setsrid(st_makepoint(n.longitude, n.latitude), 4326)::geography
+  from unnest(codes) with ordinality as raw(code, ord)
+  cross join lateral (select upper(replace(raw.code, ' ', '')) as code) c
+  cross join lateral generate_series(length(c.code), 2, -1) as len
+  join public.nuts_centroids n on n.code = left(c.code, len)
+  order by raw.ord, len desc
+  limit 1
+$$;